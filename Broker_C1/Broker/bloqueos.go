@@ -0,0 +1,46 @@
+package main
+
+import (
+	"sync"
+)
+
+// ========== Bloqueos por clave ==========
+
+// bloqueosPorClave serializa operaciones sobre una misma clave (una oferta,
+// un producto). Cada bloqueo cuenta cuántos lo usan o esperan y se elimina al
+// quedar libre, así el mapa no crece con cada clave vista.
+type bloqueosPorClave struct {
+	mu       sync.Mutex
+	bloqueos map[string]*bloqueoClave
+}
+
+type bloqueoClave struct {
+	sync.Mutex
+	usuarios int
+}
+
+// bloquear toma el bloqueo de la clave y devuelve la función que lo suelta.
+func (b *bloqueosPorClave) bloquear(clave string) (desbloquear func()) {
+	b.mu.Lock()
+	if b.bloqueos == nil {
+		b.bloqueos = make(map[string]*bloqueoClave)
+	}
+	bloqueo, ok := b.bloqueos[clave]
+	if !ok {
+		bloqueo = &bloqueoClave{}
+		b.bloqueos[clave] = bloqueo
+	}
+	bloqueo.usuarios++
+	b.mu.Unlock()
+
+	bloqueo.Lock()
+	return func() {
+		bloqueo.Unlock()
+
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if bloqueo.usuarios--; bloqueo.usuarios == 0 {
+			delete(b.bloqueos, clave)
+		}
+	}
+}
@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"log"
 	"net"
+	"net/http"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -15,284 +17,504 @@ import (
 	pb "broker_c1/proto"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 )
 
 const (
 	address_broker = ":50051"
 )
 
-var validCategorias = map[string]bool{
-	"Electrónica":        true,
-	"Moda":               true,
-	"Hogar":              true,
-	"Deportes":           true,
-	"Belleza":            true,
-	"Infantil":           true,
-	"Computación":        true,
-	"Electrodomésticos":  true,
-	"Herramientas":       true,
-	"Juguetes":           true,
-	"Automotriz":         true,
-	"Mascotas":           true,
-}
-
 type ConsumidorInfo struct {
-	ID            string
-	Categorias    []string
-	Tiendas       []string
-	PrecioMax     int32
-	DireccionGRPC string
-	Cliente       pb.NotificacionesConsumidorClient
-	Activo        bool
+	ID                 string
+	Categorias         []string
+	Tiendas            []string
+	PrecioMax          int32
+	DireccionGRPC      string
+	WebhookURL         string
+	WebhookSecreto     string
+	Grupo              string // vacío = recibe todas las ofertas que cumplen sus filtros
+	EntregaOrdenada    bool
+	SecuenciaEntregada int64         // último secuencia_consumidor usado (entrega ordenada)
+	Seguimientos       []seguimiento // con alguno, solo recibe bajas de precio de esos productos
+	ResumenSegundos    int32         // modo resumen: plazo máximo de acumulación
+	ResumenMaxOfertas  int32         // modo resumen: ofertas por resumen
+	Cliente            pb.NotificacionesConsumidorClient
+	Conexion           *grpc.ClientConn // nil para consumidores webhook
+	Activo             bool
 }
 
 type EstadisticasProductor struct {
-	OfertasEnviadas  int
-	OfertasAceptadas int
+	OfertasEnviadas   int
+	OfertasAceptadas  int
 	OfertasRechazadas int
 }
 
 type EstadisticasNodo struct {
-	NodoID            string
-	Activo            bool
+	NodoID             string
+	Activo             bool
 	EscriturasExitosas int
 	EscriturasFallidas int
 }
 
 type EstadisticasConsumidor struct {
-	ConsumidorID      string
-	OfertasRecibidas  int
-	Activo            bool
+	ConsumidorID     string
+	OfertasRecibidas int
+	Activo           bool
 }
 
 type server struct {
 	pb.UnimplementedOfertasServer
 	pb.UnimplementedConsumidorServer
-	
+	pb.UnimplementedTaxonomiaServer
+	pb.UnimplementedCartasMuertasServer
+	pb.UnimplementedComprasServer
+
+	// Taxonomía autoritativa de categorías
+	taxonomia *taxonomia
+
+	// Pipeline de reglas de validación de ofertas
+	validador *motorValidacion
+
 	// Productores registrados
 	productores      []string
 	productoresMutex sync.Mutex
-	
+
 	// Consumidores registrados
 	consumidores      map[string]*ConsumidorInfo
 	consumidoresMutex sync.RWMutex
-	
+
 	// Nodos DB
 	dbClients []pb.DynamoDBClient
 	dbActivos []bool
+	dbNodos   []*nodoDB
 	dbMutex   sync.RWMutex
-	
-	// Control de duplicados (idempotencia)
-	ofertasProcesadas      map[string]bool
+
+	// Control de duplicados (idempotencia): ofertas ya aceptadas y ofertas
+	// que se están procesando ahora en esta réplica
+	ofertasProcesadas       map[string]bool
+	ofertasEnCurso          map[string]bool
 	ofertasProcesakdasMutex sync.Mutex
-	
-	// Estadísticas
-	statsProductores   map[string]*EstadisticasProductor
-	statsNodos         []*EstadisticasNodo
-	statsConsumidores  map[string]*EstadisticasConsumidor
-	statsMutex         sync.Mutex
+
+	// Reparto de ofertas en grupos de consumidores (turnos solo para round robin)
+	repartoGrupos    string
+	turnosGrupo      map[string]uint64
+	turnosGrupoMutex sync.Mutex
+
+	// Entrega ordenada: secuencia global replicada, orden de liberación y un
+	// remitente por consumidor
+	ultimaSecuencia      int64
+	ultimaSecuenciaMutex sync.Mutex
+	reservaMutex         sync.Mutex
+	secuenciador         *secuenciador
+	colasOrdenadas       map[string]*colaOrdenada
+	colasOrdenadasMutex  sync.Mutex
+
+	// Ofertas acumuladas de los consumidores en modo resumen
+	resumenes      map[string]*colaResumen
+	resumenesMutex sync.Mutex
+
+	// Cliente HTTP para las entregas por webhook y si pueden ir a redes privadas
+	clienteHTTPWebhook   *http.Client
+	webhookRedesPrivadas bool
+
+	// Ofertas rechazadas o no entregadas, por id de carta
+	cartasMuertas          map[string]*CartaMuerta
+	cartasMuertasMutex     sync.Mutex
+	maxCartasMuertas       int
+	retencionCartasMuertas time.Duration
+
+	// Reservas de stock vigentes y un bloqueo por oferta para las escrituras de stock
+	reservas         map[string]*Reserva
+	reversionesStock map[string]*ReversionStock
+	reservasMutex    sync.Mutex
+	bloqueosStock    bloqueosPorClave
+	duracionReserva  time.Duration
+
+	// Menor precio aceptado por producto, para los seguimientos de precio, y
+	// un bloqueo por producto entre la lectura del mínimo y su actualización
+	preciosMinimos      map[string]int32
+	preciosMinimosMutex sync.Mutex
+	bloqueosProductos   bloqueosPorClave
+
+	// Eventos en vivo para el dashboard y resultado de la última escritura por nodo
+	eventos           *difusorEventos
+	ultimaEscrituraOK []bool
+	saludNodosMutex   sync.Mutex
+
+	// Estadísticas (las pendientes son incrementos que el líder aún no replica)
+	statsProductores       map[string]*EstadisticasProductor
+	statsNodos             []*EstadisticasNodo
+	statsConsumidores      map[string]*EstadisticasConsumidor
+	statsMutex             sync.Mutex
+	estadisticasPendientes *deltaEstadisticas
+	estadisticasMutex      sync.Mutex
+
+	// Replicación del estado de control (nil si el broker corre solo)
+	raft                 *nodoRaft
+	conexionesLider      map[string]*grpc.ClientConn
+	conexionesLiderMutex sync.Mutex
+
+	// Persistencia del estado de control (nil si no se habilitó)
+	persistencia          *persistenciaBroker
+	persistenciaMutex     sync.Mutex
+	indiceAplicado        int64
+	terminoAplicado       int64
+	comandosDesdeSnapshot int
 }
 
 func (s *server) EnviarOferta(ctx context.Context, in *pb.OfertaRequest) (*pb.OfertaResponse, error) {
+	if s.debeReenviar() {
+		return s.reenviarOferta(ctx, in)
+	}
+
 	clienteID := in.GetClienteId()
 	ofertaID := in.GetOfertaId()
-	
+
 	log.Printf("[BROKER] Recibida oferta %s de %s", ofertaID, clienteID)
-	
+
 	// 1. Validar productor registrado
 	if !s.esProductorRegistrado(clienteID) {
-		s.registrarProductor(clienteID)
+		if err := s.registrarProductor(clienteID); err != nil {
+			return &pb.OfertaResponse{Exito: false, Mensaje: "No se pudo registrar el productor"}, nil
+		}
 	}
-	
+
 	s.incrementarOfertasEnviadas(clienteID)
-	
+
+	return s.procesarOferta(ctx, in, "")
+}
+
+// procesarOferta valida, almacena y distribuye una oferta. Lo que falla queda
+// como carta muerta; cartaID indica la carta que se está reprocesando, si hay.
+func (s *server) procesarOferta(ctx context.Context, in *pb.OfertaRequest, cartaID string) (*pb.OfertaResponse, error) {
+	clienteID := in.GetClienteId()
+	ofertaID := in.GetOfertaId()
+
 	// 2. Validar oferta
-	if err := s.validarOferta(in); err != nil {
-		log.Printf("[BROKER] Oferta %s rechazada: %v", ofertaID, err)
+	if violaciones := s.validador.validar(in); len(violaciones) > 0 {
+		motivos := make([]string, len(violaciones))
+		codigosRechazo := make([]string, len(violaciones))
+		for i, v := range violaciones {
+			log.Printf("[BROKER] Oferta %s rechazada por %s (%s): %s", ofertaID, v.Regla, v.Codigo, v.Mensaje)
+			motivos[i] = v.Mensaje
+			codigosRechazo[i] = v.Codigo
+		}
 		s.incrementarOfertasRechazadas(clienteID)
-		return &pb.OfertaResponse{Exito: false, Mensaje: err.Error()}, nil
+		s.publicarOferta(in, ofertaRechazada, strings.Join(codigosRechazo, ","))
+		s.registrarCartaMuerta(cartaID, in, etapaValidacion, strings.Join(motivos, "; "), codigosRechazo, "")
+		return nil, errorRechazo(in, violaciones)
 	}
-	
-	// 3. Verificar idempotencia
-	if s.esOfertaDuplicada(ofertaID) {
+
+	// 3. Verificar idempotencia: el oferta_id queda reservado hasta terminar,
+	// así un reintento concurrente no la almacena dos veces
+	if procesada, reservada := s.reservarOferta(ofertaID); !reservada {
+		if !procesada {
+			log.Printf("[BROKER] Oferta %s ya está en proceso, descartando reintento", ofertaID)
+			return &pb.OfertaResponse{Exito: false, Mensaje: "Oferta en proceso"}, nil
+		}
 		log.Printf("[BROKER] Oferta %s duplicada, descartando", ofertaID)
+		s.publicarOferta(in, ofertaDuplicada, "")
 		return &pb.OfertaResponse{Exito: true, Mensaje: "Oferta ya procesada"}, nil
 	}
-	
-	// 4. Almacenar en base de datos distribuida (W=2)
+	defer s.liberarOferta(ofertaID)
+
+	// 4. Asignar secuencia global y anotar el precio mínimo previo del
+	// producto (se guardan junto con la oferta). El bloqueo del producto se
+	// mantiene hasta replicar el nuevo mínimo en el paso 6: dos ofertas del
+	// mismo producto no pueden leer el mismo mínimo anterior
+	liberarProducto := sync.OnceFunc(s.bloqueosProductos.bloquear(in.GetProductoId()))
+	defer liberarProducto()
+
+	secuencia, err := s.reservarSecuencia()
+	if err != nil {
+		return &pb.OfertaResponse{Exito: false, Mensaje: "No se pudo asignar secuencia"}, nil
+	}
+	in.Secuencia = secuencia
+	in.PrecioMinimoAnterior = s.precioMinimo(in.GetProductoId())
+	// El stock solo cambia por compare-and-set, y los avisos de agotado solo
+	// los genera el broker
+	in.VersionStock = 0
+	in.OperacionStock = ""
+	in.Evento = ""
+	// Las lápidas las marcan y confirman los nodos DB: una oferta nueva no
+	// llega eliminada ni con confirmaciones que adelanten su recolección
+	in.Eliminada = false
+	in.ConfirmadaPor = nil
+	// El reloj vectorial, las hermanas y la hora de escritura los asigna cada
+	// nodo DB: un reloj inventado por el cliente dominaría a versiones que
+	// nunca vio
+	in.RelojVectorial = nil
+	in.Hermanas = nil
+	in.EscritaNs = 0
+
+	// 5. Almacenar en base de datos distribuida (W = mayoría de los nodos)
+	w := s.quorum()
 	confirmaciones := s.almacenarEnDB(ctx, in)
-	if confirmaciones < 2 {
-		log.Printf("[BROKER] ERROR: Solo %d confirmaciones, se requieren W=2", confirmaciones)
-		return &pb.OfertaResponse{Exito: false, Mensaje: "No se alcanzó W=2"}, nil
-	}
-	
-	log.Printf("[BROKER] Oferta %s almacenada con %d confirmaciones (W=2 cumplido)", ofertaID, confirmaciones)
-	
-	// 5. Marcar como procesada
-	s.marcarOfertaProcesada(ofertaID)
+	if confirmaciones < w {
+		log.Printf("[BROKER] ERROR: Solo %d confirmaciones, se requieren W=%d", confirmaciones, w)
+		s.liberarSecuencia(secuencia, nil)
+		s.publicarOferta(in, ofertaSinQuorum, fmt.Sprintf("%d/%d confirmaciones", confirmaciones, w))
+		s.registrarCartaMuerta(cartaID, in, etapaQuorum,
+			fmt.Sprintf("solo %d confirmaciones, se requieren W=%d", confirmaciones, w), nil, "")
+		return &pb.OfertaResponse{Exito: false, Mensaje: fmt.Sprintf("No se alcanzó W=%d", w)}, nil
+	}
+
+	log.Printf("[BROKER] Oferta %s almacenada con %d confirmaciones (W=%d cumplido)", ofertaID, confirmaciones, w)
+
+	// 6. Marcar como procesada (en el mismo comando se registra el precio
+	// mínimo del producto). Si no se replica, la oferta no se acepta
+	if err := s.marcarOfertaProcesada(in); err != nil {
+		s.liberarSecuencia(secuencia, nil)
+		s.registrarCartaMuerta(cartaID, in, etapaQuorum, "no se pudo registrar como procesada: "+err.Error(), nil, "")
+		return &pb.OfertaResponse{Exito: false, Mensaje: "No se pudo registrar la oferta"}, nil
+	}
+	if anterior := in.GetPrecioMinimoAnterior(); in.GetProductoId() != "" && in.GetPrecioDescuento() > 0 &&
+		(anterior == 0 || in.GetPrecioDescuento() < anterior) {
+		log.Printf("[BROKER] Nuevo precio mínimo de %s: $%d", in.GetProductoId(), in.GetPrecioDescuento())
+	}
+	liberarProducto()
 	s.incrementarOfertasAceptadas(clienteID)
-	
-	// 6. Distribuir a consumidores interesados
+	s.publicarOferta(in, ofertaAceptada, "")
+
+	// 7. Distribuir a consumidores interesados (los de entrega ordenada la
+	// reciben cuando se liberan todas las secuencias anteriores)
 	s.distribuirAConsumidores(ctx, in)
-	
+	s.liberarSecuencia(secuencia, in)
+
 	return &pb.OfertaResponse{Exito: true, Mensaje: "Oferta registrada y distribuida"}, nil
 }
 
 func (s *server) RegistrarConsumidor(ctx context.Context, in *pb.RegistroConsumidorRequest) (*pb.RegistroConsumidorResponse, error) {
-	s.consumidoresMutex.Lock()
-	defer s.consumidoresMutex.Unlock()
-	
+	if s.debeReenviar() {
+		return s.reenviarRegistro(ctx, in)
+	}
+
 	consumidorID := in.GetConsumidorId()
 	log.Printf("[BROKER] Registrando consumidor %s", consumidorID)
-	
-	// Conectar al servicio gRPC del consumidor
-	conn, err := grpc.Dial(in.GetDireccionGrpc(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+
+	if in.GetWebhookUrl() != "" {
+		if err := validarWebhook(in.GetWebhookUrl(), in.GetWebhookSecreto(), s.webhookRedesPrivadas); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		log.Printf("[BROKER] Consumidor %s recibirá ofertas por webhook %s", consumidorID, in.GetWebhookUrl())
+	} else {
+		if in.GetDireccionGrpc() == "" {
+			return nil, status.Error(codes.InvalidArgument, "se requiere direccion_grpc o webhook_url")
+		}
+		// La dirección anunciada debe llegar a este mismo consumidor
+		if err := verificarConsumidor(ctx, consumidorID, in.GetDireccionGrpc()); err != nil {
+			log.Printf("[BROKER] Handshake con consumidor %s falló: %v", consumidorID, err)
+			return nil, status.Errorf(codes.FailedPrecondition, "dirección no verificada: %v", err)
+		}
+		log.Printf("[BROKER] Consumidor %s verificado en %s", consumidorID, in.GetDireccionGrpc())
+	}
+
+	seguimientos, err := seguimientosDesdeProto(in.GetSeguimientos())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := validarResumen(in); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := validarEntregaOrdenada(in); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	err = s.replicar(comando{
+		Tipo:              cmdRegistrarConsumidor,
+		ConsumidorID:      consumidorID,
+		Categorias:        s.taxonomia.normalizarPreferencias(in.GetCategorias()),
+		Tiendas:           in.GetTiendas(),
+		PrecioMax:         in.GetPrecioMax(),
+		DireccionGRPC:     in.GetDireccionGrpc(),
+		WebhookURL:        in.GetWebhookUrl(),
+		WebhookSecreto:    in.GetWebhookSecreto(),
+		Grupo:             in.GetGrupo(),
+		EntregaOrdenada:   in.GetEntregaOrdenada(),
+		Seguimientos:      seguimientos,
+		ResumenSegundos:   in.GetResumenSegundos(),
+		ResumenMaxOfertas: in.GetResumenMaxOfertas(),
+	})
 	if err != nil {
-		log.Printf("[BROKER] Error conectando a consumidor %s: %v", consumidorID, err)
 		return &pb.RegistroConsumidorResponse{Exito: false, Mensaje: err.Error()}, nil
 	}
-	
-	cliente := pb.NewNotificacionesConsumidorClient(conn)
-	
-	s.consumidores[consumidorID] = &ConsumidorInfo{
-		ID:            consumidorID,
-		Categorias:    in.GetCategorias(),
-		Tiendas:       in.GetTiendas(),
-		PrecioMax:     in.GetPrecioMax(),
-		DireccionGRPC: in.GetDireccionGrpc(),
-		Cliente:       cliente,
-		Activo:        true,
-	}
-	
-	s.statsMutex.Lock()
-	s.statsConsumidores[consumidorID] = &EstadisticasConsumidor{
-		ConsumidorID:     consumidorID,
-		OfertasRecibidas: 0,
-		Activo:           true,
-	}
-	s.statsMutex.Unlock()
-	
-	log.Printf("[BROKER] Consumidor %s registrado exitosamente", consumidorID)
+
 	return &pb.RegistroConsumidorResponse{Exito: true, Mensaje: "Registrado"}, nil
 }
 
 func (s *server) SolicitarHistorico(ctx context.Context, in *pb.SolicitarHistoricoRequest) (*pb.HistoricoConsumidorResponse, error) {
 	consumidorID := in.GetConsumidorId()
-	log.Printf("[BROKER] Consumidor %s solicita histórico", consumidorID)
-	
-	// Leer de al menos 2 nodos (R=2)
-	historicos := s.leerHistoricoDistribuido(ctx)
-	
-	if len(historicos) < 2 {
-		log.Printf("[BROKER] ERROR: Solo %d nodos respondieron, se requieren R=2", len(historicos))
+	if in.GetDesdeTimestamp() > 0 {
+		log.Printf("[BROKER] Consumidor %s solicita histórico desde %d", consumidorID, in.GetDesdeTimestamp())
+	} else {
+		log.Printf("[BROKER] Consumidor %s solicita histórico", consumidorID)
+	}
+
+	// Leer de la mayoría de los nodos (R)
+	r := s.quorum()
+	historicos := s.leerHistoricoDistribuido(ctx, in.GetDesdeTimestamp())
+
+	if len(historicos) < r {
+		log.Printf("[BROKER] ERROR: Solo %d nodos respondieron, se requieren R=%d", len(historicos), r)
 		return &pb.HistoricoConsumidorResponse{Ofertas: nil}, nil
 	}
-	
-	// Combinar resultados (consenso simple: unión de ofertas)
+
+	// Combinar resultados (consenso simple: unión de ofertas, con el stock
+	// de la copia más reciente)
 	ofertasMap := make(map[string]*pb.OfertaRequest)
 	for _, hist := range historicos {
 		for _, oferta := range hist.Ofertas {
-			ofertasMap[oferta.OfertaId] = oferta
+			if actual, ok := ofertasMap[oferta.OfertaId]; !ok || oferta.GetVersionStock() > actual.GetVersionStock() {
+				ofertasMap[oferta.OfertaId] = oferta
+			}
 		}
 	}
-	
+
+	// Las eliminadas llegan como lápidas: ganan a las copias atrasadas y se descartan
 	ofertas := make([]*pb.OfertaRequest, 0, len(ofertasMap))
 	for _, oferta := range ofertasMap {
-		ofertas = append(ofertas, oferta)
+		if !oferta.GetEliminada() {
+			ofertas = append(ofertas, oferta)
+		}
 	}
-	
+
+	// En orden de aceptación (las ofertas sin secuencia son anteriores)
+	sort.Slice(ofertas, func(i, j int) bool {
+		if ofertas[i].GetSecuencia() != ofertas[j].GetSecuencia() {
+			return ofertas[i].GetSecuencia() < ofertas[j].GetSecuencia()
+		}
+		return ofertas[i].GetTimestamp() < ofertas[j].GetTimestamp()
+	})
+
 	// Filtrar por preferencias del consumidor
 	s.consumidoresMutex.RLock()
 	consumidor, existe := s.consumidores[consumidorID]
 	s.consumidoresMutex.RUnlock()
-	
+
 	if existe {
 		ofertas = s.filtrarOfertas(ofertas, consumidor)
+		if consumidor.Grupo != "" {
+			ofertas = s.repartirHistorico(ofertas, consumidor)
+		}
 	}
-	
+
 	log.Printf("[BROKER] Enviando %d ofertas históricas a %s", len(ofertas), consumidorID)
 	return &pb.HistoricoConsumidorResponse{Ofertas: ofertas}, nil
 }
 
-func (s *server) validarOferta(oferta *pb.OfertaRequest) error {
-	if oferta.GetOfertaId() == "" {
-		return fmt.Errorf("oferta_id vacío")
-	}
-	if oferta.GetStock() <= 0 {
-		return fmt.Errorf("stock debe ser mayor a 0")
+// reservarOferta anota la oferta como en curso si no está procesada ni en
+// curso. Devuelve si ya estaba procesada y si quedó reservada.
+func (s *server) reservarOferta(ofertaID string) (procesada, reservada bool) {
+	s.ofertasProcesakdasMutex.Lock()
+	defer s.ofertasProcesakdasMutex.Unlock()
+	if s.ofertasProcesadas[ofertaID] {
+		return true, false
 	}
-	if !validCategorias[oferta.GetCategoria()] {
-		return fmt.Errorf("categoría %s no válida", oferta.GetCategoria())
+	if s.ofertasEnCurso[ofertaID] {
+		return false, false
 	}
-	return nil
+	s.ofertasEnCurso[ofertaID] = true
+	return false, true
 }
 
-func (s *server) esOfertaDuplicada(ofertaID string) bool {
+func (s *server) liberarOferta(ofertaID string) {
 	s.ofertasProcesakdasMutex.Lock()
 	defer s.ofertasProcesakdasMutex.Unlock()
-	return s.ofertasProcesadas[ofertaID]
+	delete(s.ofertasEnCurso, ofertaID)
 }
 
-func (s *server) marcarOfertaProcesada(ofertaID string) {
-	s.ofertasProcesakdasMutex.Lock()
-	defer s.ofertasProcesakdasMutex.Unlock()
-	s.ofertasProcesadas[ofertaID] = true
+// marcarOfertaProcesada replica en un solo comando que la oferta fue aceptada
+// y su precio, que baja el mínimo del producto si corresponde.
+func (s *server) marcarOfertaProcesada(oferta *pb.OfertaRequest) error {
+	return s.replicar(comando{
+		Tipo:       cmdOfertaProcesada,
+		OfertaID:   oferta.GetOfertaId(),
+		ProductoID: oferta.GetProductoId(),
+		Precio:     oferta.GetPrecioDescuento(),
+	})
 }
 
 func (s *server) almacenarEnDB(ctx context.Context, oferta *pb.OfertaRequest) int {
 	confirmaciones := 0
 	var wg sync.WaitGroup
 	var mu sync.Mutex
-	
+
 	s.dbMutex.RLock()
 	defer s.dbMutex.RUnlock()
-	
+
 	for i, dbClient := range s.dbClients {
 		if !s.dbActivos[i] {
 			continue
 		}
-		
+
 		wg.Add(1)
 		go func(idx int, client pb.DynamoDBClient) {
 			defer wg.Done()
-			
+
 			ctxTimeout, cancel := context.WithTimeout(ctx, 2*time.Second)
 			defer cancel()
-			
+
 			resp, err := client.GuardarOferta(ctxTimeout, oferta)
 			if err != nil {
 				log.Printf("[BROKER] Error guardando en DB%d: %v", idx+1, err)
 				s.incrementarEscriturasFallidas(idx)
+				s.publicarEscritura(idx, false)
 				return
 			}
-			
+
 			if resp.GetExito() {
 				mu.Lock()
 				confirmaciones++
 				mu.Unlock()
 				s.incrementarEscriturasExitosas(idx)
+				s.publicarEscritura(idx, true)
 				log.Printf("[BROKER] DB%d confirmó almacenamiento", idx+1)
 			}
 		}(i, dbClient)
 	}
-	
+
 	wg.Wait()
 	return confirmaciones
 }
 
 func (s *server) distribuirAConsumidores(ctx context.Context, oferta *pb.OfertaRequest) {
 	s.consumidoresMutex.RLock()
-	defer s.consumidoresMutex.RUnlock()
-	
+	grupos := make(map[string]bool)
 	for _, consumidor := range s.consumidores {
 		if !consumidor.Activo {
 			continue
 		}
-		
-		if s.ofertaCumpleFiltros(oferta, consumidor) {
-			go s.enviarAConsumidor(ctx, consumidor, oferta)
+
+		// Estos reciben la oferta por su remitente ordenado
+		if consumidor.EntregaOrdenada && consumidor.Grupo == "" {
+			continue
+		}
+
+		// Los grupos reciben una sola copia, que se reparte entre sus miembros
+		if consumidor.Grupo != "" {
+			if s.ofertaCumpleFiltros(oferta, consumidor) {
+				grupos[consumidor.Grupo] = true
+			}
+			continue
+		}
+
+		if !s.ofertaCumpleFiltros(oferta, consumidor) {
+			continue
+		}
+		if consumidor.modoResumen() {
+			s.agregarAResumen(consumidor, oferta)
+			continue
 		}
+		// El envío continúa después de responder al productor, así que no
+		// puede depender del contexto de su llamada
+		go s.enviarAConsumidor(context.Background(), consumidor, oferta)
+	}
+	s.consumidoresMutex.RUnlock()
+
+	for grupo := range grupos {
+		go s.enviarAGrupo(context.Background(), grupo, oferta)
 	}
 }
 
@@ -301,7 +523,7 @@ func (s *server) ofertaCumpleFiltros(oferta *pb.OfertaRequest, consumidor *Consu
 	if len(consumidor.Categorias) > 0 && consumidor.Categorias[0] != "null" {
 		categoriaMatch := false
 		for _, cat := range consumidor.Categorias {
-			if cat == oferta.GetCategoria() {
+			if s.taxonomia.incluye(cat, oferta.GetCategoria()) {
 				categoriaMatch = true
 				break
 			}
@@ -310,7 +532,7 @@ func (s *server) ofertaCumpleFiltros(oferta *pb.OfertaRequest, consumidor *Consu
 			return false
 		}
 	}
-	
+
 	// Filtro de tienda
 	if len(consumidor.Tiendas) > 0 && consumidor.Tiendas[0] != "null" {
 		tiendaMatch := false
@@ -324,65 +546,80 @@ func (s *server) ofertaCumpleFiltros(oferta *pb.OfertaRequest, consumidor *Consu
 			return false
 		}
 	}
-	
+
 	// Filtro de precio
 	if consumidor.PrecioMax > 0 && oferta.GetPrecioDescuento() > consumidor.PrecioMax {
 		return false
 	}
-	
-	return true
+
+	// Productos seguidos: solo las bajas de precio
+	return cumpleSeguimientos(oferta, consumidor)
 }
 
 func (s *server) enviarAConsumidor(ctx context.Context, consumidor *ConsumidorInfo, oferta *pb.OfertaRequest) {
-	ctxTimeout, cancel := context.WithTimeout(ctx, 2*time.Second)
+	if err := s.entregarAConsumidor(ctx, consumidor, oferta); err != nil {
+		s.registrarCartaMuerta("", oferta, etapaEntrega, err.Error(), nil, consumidor.ID)
+	}
+}
+
+func (s *server) entregarAConsumidor(ctx context.Context, consumidor *ConsumidorInfo, oferta *pb.OfertaRequest) error {
+	timeout := 2 * time.Second
+	if consumidor.WebhookURL != "" {
+		timeout = timeoutEntregaWebhook
+	}
+	ctxTimeout, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
-	
+
 	_, err := consumidor.Cliente.RecibirOferta(ctxTimeout, oferta)
 	if err != nil {
 		log.Printf("[BROKER] Error enviando a consumidor %s: %v", consumidor.ID, err)
 		s.marcarConsumidorInactivo(consumidor.ID)
-		return
+		s.publicarEntrega(oferta.GetOfertaId(), consumidor.ID, false)
+		return err
 	}
-	
+
 	s.incrementarOfertasRecibidas(consumidor.ID)
+	s.registrarSecuenciaEntregada(consumidor.ID, oferta.GetSecuenciaConsumidor())
+	s.publicarEntrega(oferta.GetOfertaId(), consumidor.ID, true)
 	log.Printf("[BROKER] Oferta %s enviada a consumidor %s", oferta.GetOfertaId(), consumidor.ID)
+	return nil
 }
 
-func (s *server) leerHistoricoDistribuido(ctx context.Context) []*pb.HistoricoResponse {
+func (s *server) leerHistoricoDistribuido(ctx context.Context, desdeTimestamp int64) []*pb.HistoricoResponse {
 	var historicos []*pb.HistoricoResponse
 	var wg sync.WaitGroup
 	var mu sync.Mutex
-	
+
 	s.dbMutex.RLock()
 	defer s.dbMutex.RUnlock()
-	
+
 	for i, dbClient := range s.dbClients {
 		if !s.dbActivos[i] {
 			continue
 		}
-		
+
 		wg.Add(1)
 		go func(idx int, client pb.DynamoDBClient) {
 			defer wg.Done()
-			
+
 			ctxTimeout, cancel := context.WithTimeout(ctx, 3*time.Second)
 			defer cancel()
-			
+
 			resp, err := client.LeerHistorico(ctxTimeout, &pb.LeerHistoricoRequest{
 				NodoId:         fmt.Sprintf("DB%d", idx+1),
-				DesdeTimestamp: 0,
+				DesdeTimestamp: desdeTimestamp,
 			})
 			if err != nil {
 				log.Printf("[BROKER] Error leyendo de DB%d: %v", idx+1, err)
 				return
 			}
-			
+
 			mu.Lock()
 			historicos = append(historicos, resp)
 			mu.Unlock()
 		}(i, dbClient)
 	}
-	
+
 	wg.Wait()
 	return historicos
 }
@@ -397,6 +634,17 @@ func (s *server) filtrarOfertas(ofertas []*pb.OfertaRequest, consumidor *Consumi
 	return filtradas
 }
 
+// repartirHistorico deja solo las ofertas que le tocan a este miembro del grupo
+func (s *server) repartirHistorico(ofertas []*pb.OfertaRequest, consumidor *ConsumidorInfo) []*pb.OfertaRequest {
+	var propias []*pb.OfertaRequest
+	for _, oferta := range ofertas {
+		if s.perteneceAMiembro(consumidor, oferta) {
+			propias = append(propias, oferta)
+		}
+	}
+	return propias
+}
+
 func (s *server) esProductorRegistrado(clienteID string) bool {
 	s.productoresMutex.Lock()
 	defer s.productoresMutex.Unlock()
@@ -408,94 +656,28 @@ func (s *server) esProductorRegistrado(clienteID string) bool {
 	return false
 }
 
-func (s *server) registrarProductor(clienteID string) {
-	s.productoresMutex.Lock()
-	defer s.productoresMutex.Unlock()
-	s.productores = append(s.productores, clienteID)
-	
-	s.statsMutex.Lock()
-	s.statsProductores[clienteID] = &EstadisticasProductor{}
-	s.statsMutex.Unlock()
-	
-	log.Printf("[BROKER] Productor %s registrado", clienteID)
-}
-
-func (s *server) incrementarOfertasEnviadas(clienteID string) {
-	s.statsMutex.Lock()
-	defer s.statsMutex.Unlock()
-	if stats, ok := s.statsProductores[clienteID]; ok {
-		stats.OfertasEnviadas++
-	}
-}
-
-func (s *server) incrementarOfertasAceptadas(clienteID string) {
-	s.statsMutex.Lock()
-	defer s.statsMutex.Unlock()
-	if stats, ok := s.statsProductores[clienteID]; ok {
-		stats.OfertasAceptadas++
-	}
-}
-
-func (s *server) incrementarOfertasRechazadas(clienteID string) {
-	s.statsMutex.Lock()
-	defer s.statsMutex.Unlock()
-	if stats, ok := s.statsProductores[clienteID]; ok {
-		stats.OfertasRechazadas++
-	}
-}
-
-func (s *server) incrementarEscriturasExitosas(idx int) {
-	s.statsMutex.Lock()
-	defer s.statsMutex.Unlock()
-	if idx < len(s.statsNodos) {
-		s.statsNodos[idx].EscriturasExitosas++
-	}
-}
-
-func (s *server) incrementarEscriturasFallidas(idx int) {
-	s.statsMutex.Lock()
-	defer s.statsMutex.Unlock()
-	if idx < len(s.statsNodos) {
-		s.statsNodos[idx].EscriturasFallidas++
-	}
-}
-
-func (s *server) incrementarOfertasRecibidas(consumidorID string) {
-	s.statsMutex.Lock()
-	defer s.statsMutex.Unlock()
-	if stats, ok := s.statsConsumidores[consumidorID]; ok {
-		stats.OfertasRecibidas++
-	}
+func (s *server) registrarProductor(clienteID string) error {
+	return s.replicar(comando{Tipo: cmdRegistrarProductor, ClienteID: clienteID})
 }
 
 func (s *server) marcarConsumidorInactivo(consumidorID string) {
-	s.consumidoresMutex.Lock()
-	defer s.consumidoresMutex.Unlock()
-	if consumidor, ok := s.consumidores[consumidorID]; ok {
-		consumidor.Activo = false
-	}
-	
-	s.statsMutex.Lock()
-	if stats, ok := s.statsConsumidores[consumidorID]; ok {
-		stats.Activo = false
-	}
-	s.statsMutex.Unlock()
+	s.replicar(comando{Tipo: cmdConsumidorInactivo, ConsumidorID: consumidorID})
 }
 
 func (s *server) generarReporte() {
 	s.statsMutex.Lock()
 	defer s.statsMutex.Unlock()
-	
+
 	file, err := os.Create("Reporte.txt")
 	if err != nil {
 		log.Printf("Error creando reporte: %v", err)
 		return
 	}
 	defer file.Close()
-	
+
 	fmt.Fprintf(file, "=== REPORTE CYBERDAY DISTRIBUIDO ===\n")
 	fmt.Fprintf(file, "Fecha: %s\n\n", time.Now().Format("2006-01-02 15:04:05"))
-	
+
 	// Resumen de productores
 	fmt.Fprintf(file, "--- RESUMEN DE PRODUCTORES ---\n")
 	for id, stats := range s.statsProductores {
@@ -505,7 +687,7 @@ func (s *server) generarReporte() {
 		fmt.Fprintf(file, "  Ofertas rechazadas: %d\n", stats.OfertasRechazadas)
 		fmt.Fprintf(file, "\n")
 	}
-	
+
 	// Estado de nodos
 	fmt.Fprintf(file, "--- ESTADO DE NODOS DE BASE DE DATOS ---\n")
 	for _, stats := range s.statsNodos {
@@ -518,7 +700,7 @@ func (s *server) generarReporte() {
 		fmt.Fprintf(file, "  Escrituras fallidas: %d\n", stats.EscriturasFallidas)
 		fmt.Fprintf(file, "\n")
 	}
-	
+
 	// Notificaciones a consumidores
 	fmt.Fprintf(file, "--- NOTIFICACIONES A CONSUMIDORES ---\n")
 	for id, stats := range s.statsConsumidores {
@@ -530,12 +712,13 @@ func (s *server) generarReporte() {
 		fmt.Fprintf(file, "  Ofertas recibidas: %d\n", stats.OfertasRecibidas)
 		fmt.Fprintf(file, "\n")
 	}
-	
+
 	// Conclusión
 	fmt.Fprintf(file, "--- CONCLUSIÓN ---\n")
-	fmt.Fprintf(file, "El sistema mantuvo disponibilidad y consistencia bajo las reglas N=3, W=2, R=2.\n")
+	n, quorum := len(s.statsNodos), len(s.statsNodos)/2+1
+	fmt.Fprintf(file, "El sistema mantuvo disponibilidad y consistencia bajo las reglas N=%d, W=%d, R=%d.\n", n, quorum, quorum)
 	fmt.Fprintf(file, "Total ofertas procesadas: %d\n", len(s.ofertasProcesadas))
-	
+
 	log.Println("[BROKER] Reporte generado: Reporte.txt")
 }
 
@@ -545,39 +728,39 @@ func cargarConsumidoresDesdeCSV(rutaCSV string) ([]*pb.RegistroConsumidorRequest
 		return nil, err
 	}
 	defer file.Close()
-	
+
 	reader := csv.NewReader(file)
 	records, err := reader.ReadAll()
 	if err != nil {
 		return nil, err
 	}
-	
+
 	var consumidores []*pb.RegistroConsumidorRequest
 	for i, record := range records {
 		if i == 0 {
 			continue // Skip header
 		}
-		
+
 		categorias := []string{}
 		if record[1] != "null" {
 			categorias = strings.Split(record[1], ";")
 		} else {
 			categorias = []string{"null"}
 		}
-		
+
 		tiendas := []string{}
 		if record[2] != "null" {
 			tiendas = strings.Split(record[2], ";")
 		} else {
 			tiendas = []string{"null"}
 		}
-		
+
 		precioMax := int32(0)
 		if record[3] != "null" {
 			precio, _ := strconv.Atoi(record[3])
 			precioMax = int32(precio)
 		}
-		
+
 		consumidores = append(consumidores, &pb.RegistroConsumidorRequest{
 			ConsumidorId: record[0],
 			Categorias:   categorias,
@@ -585,7 +768,7 @@ func cargarConsumidoresDesdeCSV(rutaCSV string) ([]*pb.RegistroConsumidorRequest
 			PrecioMax:    precioMax,
 		})
 	}
-	
+
 	return consumidores, nil
 }
 
@@ -597,73 +780,238 @@ func newDBClient(address string) (pb.DynamoDBClient, *grpc.ClientConn, error) {
 	return pb.NewDynamoDBClient(conn), conn, nil
 }
 
-func main() {
-	log.Println("[BROKER] Iniciando...")
-	
-	// Conectar a nodos DB
-	db1Addr := os.Getenv("DB1_ADDR")
-    if db1Addr == "" { db1Addr = "db1:50052" } // Default
-
-    db2Addr := os.Getenv("DB2_ADDR")
-    if db2Addr == "" { db2Addr = "db2:50053" } // Default
-
-    db3Addr := os.Getenv("DB3_ADDR")
-    if db3Addr == "" { db3Addr = "db3:50054" } // Default
-	dbAddresses := []string{db1Addr, db2Addr, db3Addr} // <-- Use the variables read from env
+// nuevoServer conecta con los nodos DB y crea un broker con estado vacío.
+func nuevoServer(dbAddresses []string, tax *taxonomia, validador *motorValidacion) (*server, []*grpc.ClientConn) {
 	var dbClients []pb.DynamoDBClient
 	var connections []*grpc.ClientConn
-	dbActivos := []bool{true, true, true}
-	
+	dbActivos := make([]bool, len(dbAddresses))
+	dbNodos := make([]*nodoDB, len(dbAddresses))
+	statsNodos := make([]*EstadisticasNodo, len(dbAddresses))
+
 	for i, addr := range dbAddresses {
 		client, conn, err := newDBClient(addr)
 		if err != nil {
 			log.Printf("[BROKER] ADVERTENCIA: No se pudo conectar a DB%d: %v", i+1, err)
-			dbActivos[i] = false
 			dbClients = append(dbClients, nil)
 		} else {
+			dbActivos[i] = true
 			dbClients = append(dbClients, client)
 			connections = append(connections, conn)
 			log.Printf("[BROKER] Conectado a DB%d", i+1)
 		}
+		dbNodos[i] = &nodoDB{id: fmt.Sprintf("DB%d", i+1), direccion: addr, conexion: conn}
+		statsNodos[i] = &EstadisticasNodo{NodoID: fmt.Sprintf("DB%d", i+1), Activo: dbActivos[i]}
 	}
-	
-	// Crear servidor
+
 	srv := &server{
-		productores:          make([]string, 0),
-		consumidores:         make(map[string]*ConsumidorInfo),
-		dbClients:            dbClients,
-		dbActivos:            dbActivos,
-		ofertasProcesadas:    make(map[string]bool),
-		statsProductores:     make(map[string]*EstadisticasProductor),
-		statsConsumidores:    make(map[string]*EstadisticasConsumidor),
-		statsNodos: []*EstadisticasNodo{
-			{NodoID: "DB1", Activo: dbActivos[0]},
-			{NodoID: "DB2", Activo: dbActivos[1]},
-			{NodoID: "DB3", Activo: dbActivos[2]},
-		},
-	}
-	
+		taxonomia:              tax,
+		validador:              validador,
+		productores:            make([]string, 0),
+		consumidores:           make(map[string]*ConsumidorInfo),
+		dbClients:              dbClients,
+		dbActivos:              dbActivos,
+		dbNodos:                dbNodos,
+		ofertasProcesadas:      make(map[string]bool),
+		ofertasEnCurso:         make(map[string]bool),
+		cartasMuertas:          make(map[string]*CartaMuerta),
+		maxCartasMuertas:       maxCartasMuertasDefecto,
+		retencionCartasMuertas: retencionCartasMuertasDefecto,
+		reservas:               make(map[string]*Reserva),
+		reversionesStock:       make(map[string]*ReversionStock),
+		duracionReserva:        duracionReservaDefecto,
+		preciosMinimos:         make(map[string]int32),
+		eventos:                nuevoDifusorEventos(),
+		clienteHTTPWebhook:     nuevoClienteHTTPWebhook(false),
+		repartoGrupos:          repartoHashProducto,
+		turnosGrupo:            make(map[string]uint64),
+		secuenciador:           nuevoSecuenciador(),
+		colasOrdenadas:         make(map[string]*colaOrdenada),
+		resumenes:              make(map[string]*colaResumen),
+		ultimaEscrituraOK:      append([]bool{}, dbActivos...),
+		statsProductores:       make(map[string]*EstadisticasProductor),
+		statsConsumidores:      make(map[string]*EstadisticasConsumidor),
+		statsNodos:             statsNodos,
+
+		estadisticasPendientes: nuevoDeltaEstadisticas(),
+	}
+
+	return srv, connections
+}
+
+// registrarServicios publica los servicios del broker en el servidor gRPC.
+func (s *server) registrarServicios(grpcServer *grpc.Server) {
+	pb.RegisterOfertasServer(grpcServer, s)
+	pb.RegisterConsumidorServer(grpcServer, s)
+	pb.RegisterTaxonomiaServer(grpcServer, s)
+	pb.RegisterCartasMuertasServer(grpcServer, s)
+	pb.RegisterComprasServer(grpcServer, s)
+	if s.raft != nil {
+		pb.RegisterRaftServer(grpcServer, s.raft)
+	}
+}
+
+func main() {
+	log.Println("[BROKER] Iniciando...")
+
+	// Conectar a nodos DB
+	db1Addr := os.Getenv("DB1_ADDR")
+	if db1Addr == "" {
+		db1Addr = "db1:50052"
+	} // Default
+
+	db2Addr := os.Getenv("DB2_ADDR")
+	if db2Addr == "" {
+		db2Addr = "db2:50053"
+	} // Default
+
+	db3Addr := os.Getenv("DB3_ADDR")
+	if db3Addr == "" {
+		db3Addr = "db3:50054"
+	} // Default
+	dbAddresses := []string{db1Addr, db2Addr, db3Addr} // <-- Use the variables read from env
+
+	// Taxonomía de categorías
+	archivoCategorias := os.Getenv("ARCHIVO_CATEGORIAS")
+	if archivoCategorias == "" {
+		archivoCategorias = "categorias.json"
+	}
+	tax, err := cargarTaxonomia(archivoCategorias)
+	if err != nil {
+		log.Fatalf("[BROKER] Error cargando taxonomía: %v", err)
+	}
+	log.Printf("[BROKER] Taxonomía versión %d con %d categorías", tax.version, len(tax.categorias))
+
+	// Reglas de validación de ofertas
+	archivoReglas := os.Getenv("ARCHIVO_REGLAS")
+	if archivoReglas == "" {
+		archivoReglas = "reglas.json"
+	}
+	cfgReglas, err := cargarReglas(archivoReglas)
+	if err != nil {
+		log.Fatalf("[BROKER] Error cargando reglas: %v", err)
+	}
+	validador, err := nuevoMotorValidacion(cfgReglas, tax)
+	if err != nil {
+		log.Fatalf("[BROKER] Error en configuración de reglas: %v", err)
+	}
+	log.Printf("[BROKER] %d reglas de validación, %d tiendas con configuración propia",
+		len(validador.reglas), len(cfgReglas.Tiendas))
+
+	// Crear servidor
+	srv, connections := nuevoServer(dbAddresses, tax, validador)
+
+	// Reparto dentro de grupos de consumidores
+	if reparto := os.Getenv("REPARTO_GRUPOS"); reparto != "" {
+		if err := validarReparto(reparto); err != nil {
+			log.Fatalf("[BROKER] %v", err)
+		}
+		srv.repartoGrupos = reparto
+	}
+
+	// Tiempo que una reserva retiene el stock sin confirmarse
+	if ttl := os.Getenv("RESERVA_TTL"); ttl != "" {
+		duracion, err := time.ParseDuration(ttl)
+		if err != nil || duracion <= 0 {
+			log.Fatalf("[BROKER] RESERVA_TTL inválido: %q", ttl)
+		}
+		srv.duracionReserva = duracion
+	}
+
+	// Retención de las cartas muertas
+	if maximo := os.Getenv("CARTAS_MUERTAS_MAX"); maximo != "" {
+		n, err := strconv.Atoi(maximo)
+		if err != nil || n <= 0 {
+			log.Fatalf("[BROKER] CARTAS_MUERTAS_MAX inválido: %q", maximo)
+		}
+		srv.maxCartasMuertas = n
+	}
+	if ttl := os.Getenv("CARTAS_MUERTAS_TTL"); ttl != "" {
+		duracion, err := time.ParseDuration(ttl)
+		if err != nil || duracion <= 0 {
+			log.Fatalf("[BROKER] CARTAS_MUERTAS_TTL inválido: %q", ttl)
+		}
+		srv.retencionCartasMuertas = duracion
+	}
+
+	// Webhooks a direcciones privadas o loopback: solo si los consumidores
+	// están en la misma red interna
+	if os.Getenv("WEBHOOK_REDES_PRIVADAS") == "1" {
+		srv.webhookRedesPrivadas = true
+		srv.clienteHTTPWebhook = nuevoClienteHTTPWebhook(true)
+		log.Printf("[BROKER] ADVERTENCIA: se permiten webhooks a redes privadas y loopback")
+	}
+
+	// Réplicas del broker (opcional): BROKER_ID=B1, BROKER_PEERS="B1=broker:50051,B2=broker2:50051,B3=broker3:50051"
+	brokerPeers := os.Getenv("BROKER_PEERS")
+	if brokerPeers != "" {
+		brokerID := os.Getenv("BROKER_ID")
+		replicas, err := parsearReplicas(brokerPeers)
+		if err != nil {
+			log.Fatalf("[BROKER] BROKER_PEERS inválido: %v", err)
+		}
+		if _, ok := replicas[brokerID]; !ok {
+			log.Fatalf("[BROKER] BROKER_ID %q no aparece en BROKER_PEERS", brokerID)
+		}
+		srv.habilitarRaft(brokerID, replicas)
+		log.Printf("[BROKER] Réplica %s de un clúster de %d brokers", brokerID, len(replicas))
+	}
+
+	// Restaurar registro y estadísticas guardados antes de reiniciar
+	dataDir := os.Getenv("DATA_DIR")
+	if dataDir == "" {
+		dataDir = "."
+	}
+	if err := srv.habilitarPersistencia(dataDir); err != nil {
+		log.Fatalf("[BROKER] Error cargando estado persistido: %v", err)
+	}
+
+	puerto := os.Getenv("PUERTO")
+	if puerto == "" {
+		puerto = address_broker
+	}
+
 	// Iniciar servidor gRPC
-	lis, err := net.Listen("tcp", address_broker)
+	lis, err := net.Listen("tcp", puerto)
 	if err != nil {
 		log.Fatalf("[BROKER] Error escuchando: %v", err)
 	}
-	
+
 	grpcServer := grpc.NewServer()
-	pb.RegisterOfertasServer(grpcServer, srv)
-	pb.RegisterConsumidorServer(grpcServer, srv)
-	
+	srv.registrarServicios(grpcServer)
+
+	if srv.raft != nil {
+		srv.raft.iniciar()
+	}
+
+	// Dashboard web con el flujo de ofertas en vivo (SSE)
+	httpPuerto := os.Getenv("HTTP_PUERTO")
+	if httpPuerto == "" {
+		httpPuerto = ":8080"
+	}
+	go srv.iniciarDashboard(httpPuerto)
+
+	// Devolver al stock las reservas vencidas (solo actúa el líder)
+	go srv.bucleReservas()
+	go srv.bucleCartasMuertas()
+
+	// Seguir la membresía de los nodos DB (gossip entre ellos)
+	go srv.bucleMembresia()
+
+	// Replicar las estadísticas acumuladas (solo actúa el líder)
+	go srv.bucleEstadisticas()
+
 	log.Printf("[BROKER] Escuchando en %v", lis.Addr())
-	
+
 	// Generar reporte al finalizar
 	defer func() {
+		srv.replicarEstadisticas()
 		srv.generarReporte()
 		for _, conn := range connections {
 			conn.Close()
 		}
 	}()
-	
+
 	if err := grpcServer.Serve(lis); err != nil {
 		log.Fatalf("[BROKER] Error sirviendo: %v", err)
 	}
-}
\ No newline at end of file
+}
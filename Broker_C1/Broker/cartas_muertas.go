@@ -0,0 +1,389 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	pb "broker_c1/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// Etapas en las que una oferta puede terminar como carta muerta
+const (
+	etapaValidacion = "validacion" // rechazada por las reglas de validación
+	etapaQuorum     = "quorum"     // no alcanzó el quórum W en los nodos DB
+	etapaEntrega    = "entrega"    // falló la notificación a un consumidor
+	etapaResumen    = "resumen"    // falló el envío de un resumen a un consumidor
+)
+
+// Retención del almacén (CARTAS_MUERTAS_MAX, CARTAS_MUERTAS_TTL): al llegar
+// al máximo se descartan las más antiguas, y el líder purga las vencidas
+const (
+	maxCartasMuertasDefecto       = 10000
+	retencionCartasMuertasDefecto = 7 * 24 * time.Hour
+	intervaloCartasMuertas        = time.Minute
+)
+
+// CartaMuerta es una oferta que no pudo completar una etapa. Forma parte del
+// estado de control replicado, así que sobrevive reinicios y cambios de líder.
+type CartaMuerta struct {
+	ID           string          `json:"id"`
+	Etapa        string          `json:"etapa"`
+	Motivo       string          `json:"motivo"`
+	Codigos      []string        `json:"codigos,omitempty"`
+	ConsumidorID string          `json:"consumidor_id,omitempty"`
+	Oferta       json.RawMessage `json:"oferta"`            // OfertaRequest completo en protojson
+	Resumen      json.RawMessage `json:"resumen,omitempty"` // ResumenOfertas en protojson (etapa resumen)
+	Timestamp    int64           `json:"timestamp"`
+	Intentos     int32           `json:"intentos"`
+}
+
+func (c *CartaMuerta) aResumen() (*pb.ResumenOfertas, error) {
+	resumen := &pb.ResumenOfertas{}
+	if err := protojson.Unmarshal(c.Resumen, resumen); err != nil {
+		return nil, err
+	}
+	return resumen, nil
+}
+
+func (c *CartaMuerta) aOferta() (*pb.OfertaRequest, error) {
+	oferta := &pb.OfertaRequest{}
+	if err := protojson.Unmarshal(c.Oferta, oferta); err != nil {
+		return nil, err
+	}
+	return oferta, nil
+}
+
+func (c *CartaMuerta) aProto() *pb.CartaMuerta {
+	var oferta *pb.OfertaRequest
+	var resumen *pb.ResumenOfertas
+	var err error
+	if c.Etapa == etapaResumen {
+		resumen, err = c.aResumen()
+	} else {
+		oferta, err = c.aOferta()
+	}
+	if err != nil {
+		log.Printf("[BROKER] Carta muerta %s con contenido ilegible: %v", c.ID, err)
+	}
+	return &pb.CartaMuerta{
+		Id:           c.ID,
+		Oferta:       oferta,
+		Resumen:      resumen,
+		Etapa:        c.Etapa,
+		Motivo:       c.Motivo,
+		Codigos:      c.Codigos,
+		ConsumidorId: c.ConsumidorID,
+		Timestamp:    c.Timestamp,
+		Intentos:     c.Intentos,
+	}
+}
+
+// registrarCartaMuerta guarda la oferta en el almacén de cartas muertas. Si
+// cartaID no es vacío se actualiza esa carta (un reintento que volvió a fallar).
+func (s *server) registrarCartaMuerta(cartaID string, oferta *pb.OfertaRequest, etapa, motivo string, codigos []string, consumidorID string) {
+	datos, err := protojson.Marshal(oferta)
+	if err != nil {
+		log.Printf("[BROKER] Error serializando oferta %s para cartas muertas: %v", oferta.GetOfertaId(), err)
+		return
+	}
+
+	if cartaID == "" {
+		cartaID = fmt.Sprintf("%s-%s-%d", etapa, oferta.GetOfertaId(), time.Now().UnixNano())
+		if consumidorID != "" {
+			cartaID = fmt.Sprintf("%s-%s-%s-%d", etapa, oferta.GetOfertaId(), consumidorID, time.Now().UnixNano())
+		}
+	}
+
+	log.Printf("[BROKER] Oferta %s a cartas muertas (%s): %s", oferta.GetOfertaId(), etapa, motivo)
+	s.guardarCartaMuerta(&CartaMuerta{
+		ID:           cartaID,
+		Etapa:        etapa,
+		Motivo:       motivo,
+		Codigos:      codigos,
+		ConsumidorID: consumidorID,
+		Oferta:       datos,
+		Timestamp:    time.Now().Unix(),
+	}, oferta.GetSecuenciaConsumidor())
+}
+
+// registrarResumenMuerto guarda un resumen que no se pudo enviar como una sola
+// carta muerta; al reprocesarla se reenvía completo por RecibirResumen.
+func (s *server) registrarResumenMuerto(cartaID string, resumen *pb.ResumenOfertas, motivo string) {
+	datos, err := protojson.Marshal(resumen)
+	if err != nil {
+		log.Printf("[BROKER] Error serializando resumen %s para cartas muertas: %v", resumen.GetResumenId(), err)
+		return
+	}
+
+	if cartaID == "" {
+		cartaID = fmt.Sprintf("%s-%s", etapaResumen, resumen.GetResumenId())
+	}
+
+	log.Printf("[BROKER] Resumen %s (%d ofertas) a cartas muertas: %s", resumen.GetResumenId(), len(resumen.GetOfertas()), motivo)
+	s.guardarCartaMuerta(&CartaMuerta{
+		ID:           cartaID,
+		Etapa:        etapaResumen,
+		Motivo:       motivo,
+		ConsumidorID: resumen.GetConsumidorId(),
+		Resumen:      datos,
+		Timestamp:    time.Now().Unix(),
+	}, 0)
+}
+
+// guardarCartaMuerta replica la carta. Si es nueva y el almacén está lleno,
+// el mismo comando descarta las más antiguas para no pasar del máximo.
+func (s *server) guardarCartaMuerta(carta *CartaMuerta, secuencia int64) {
+	s.cartasMuertasMutex.Lock()
+	var descartadas []string
+	if _, existe := s.cartasMuertas[carta.ID]; !existe && len(s.cartasMuertas) >= s.maxCartasMuertas {
+		descartadas = s.cartasMasAntiguas(len(s.cartasMuertas) - s.maxCartasMuertas + 1)
+	}
+	s.cartasMuertasMutex.Unlock()
+
+	if len(descartadas) > 0 {
+		log.Printf("[BROKER] Cartas muertas al máximo (%d): se descartan las %d más antiguas", s.maxCartasMuertas, len(descartadas))
+	}
+	s.replicar(comando{
+		Tipo:      cmdCartaMuerta,
+		Secuencia: secuencia,
+		Carta:     carta,
+		CartaIDs:  descartadas,
+	})
+}
+
+// cartasMasAntiguas devuelve los ids de las n cartas más antiguas. Se llama
+// con cartasMuertasMutex tomado.
+func (s *server) cartasMasAntiguas(n int) []string {
+	cartas := make([]*CartaMuerta, 0, len(s.cartasMuertas))
+	for _, carta := range s.cartasMuertas {
+		cartas = append(cartas, carta)
+	}
+	ordenarCartas(cartas)
+
+	ids := make([]string, 0, n)
+	for _, carta := range cartas[:min(n, len(cartas))] {
+		ids = append(ids, carta.ID)
+	}
+	return ids
+}
+
+// ordenarCartas deja primero las más antiguas.
+func ordenarCartas(cartas []*CartaMuerta) {
+	sort.Slice(cartas, func(i, j int) bool {
+		if cartas[i].Timestamp != cartas[j].Timestamp {
+			return cartas[i].Timestamp < cartas[j].Timestamp
+		}
+		return cartas[i].ID < cartas[j].ID
+	})
+}
+
+func (s *server) aplicarCartaMuerta(carta *CartaMuerta) {
+	s.cartasMuertasMutex.Lock()
+	defer s.cartasMuertasMutex.Unlock()
+
+	if existente, ok := s.cartasMuertas[carta.ID]; ok {
+		carta.Intentos = existente.Intentos + 1
+	} else {
+		carta.Intentos = 1
+	}
+	s.cartasMuertas[carta.ID] = carta
+}
+
+func (s *server) obtenerCartaMuerta(id string) (*CartaMuerta, bool) {
+	s.cartasMuertasMutex.Lock()
+	defer s.cartasMuertasMutex.Unlock()
+	carta, ok := s.cartasMuertas[id]
+	return carta, ok
+}
+
+func (s *server) aplicarCartasPurgadas(ids []string) {
+	s.cartasMuertasMutex.Lock()
+	defer s.cartasMuertasMutex.Unlock()
+	for _, id := range ids {
+		delete(s.cartasMuertas, id)
+	}
+}
+
+// purgarCartasMuertas elimina las cartas de la etapa dada (vacía = todas)
+// anteriores a antesDe (0 = todas) en un solo comando, y devuelve cuántas.
+func (s *server) purgarCartasMuertas(etapa string, antesDe int64) (int, error) {
+	s.cartasMuertasMutex.Lock()
+	var ids []string
+	for _, carta := range s.cartasMuertas {
+		if (etapa == "" || carta.Etapa == etapa) && (antesDe == 0 || carta.Timestamp < antesDe) {
+			ids = append(ids, carta.ID)
+		}
+	}
+	s.cartasMuertasMutex.Unlock()
+
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	if err := s.replicar(comando{Tipo: cmdCartasPurgadas, CartaIDs: ids}); err != nil {
+		return 0, err
+	}
+	return len(ids), nil
+}
+
+// bucleCartasMuertas purga las cartas que pasaron la retención. Corre en
+// todas las réplicas, pero solo actúa el líder.
+func (s *server) bucleCartasMuertas() {
+	ticker := time.NewTicker(intervaloCartasMuertas)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if s.debeReenviar() {
+			continue
+		}
+		limite := time.Now().Add(-s.retencionCartasMuertas).Unix()
+		if n, err := s.purgarCartasMuertas("", limite); err == nil && n > 0 {
+			log.Printf("[BROKER] %d carta(s) muerta(s) vencidas purgadas", n)
+		}
+	}
+}
+
+// ========== Servicio CartasMuertas ==========
+
+// ListarCartasMuertas y ObtenerCartaMuerta leen el estado replicado, así que
+// cualquier réplica puede responderlas.
+func (s *server) ListarCartasMuertas(ctx context.Context, in *pb.ListarCartasMuertasRequest) (*pb.ListarCartasMuertasResponse, error) {
+	s.cartasMuertasMutex.Lock()
+	var cartas []*CartaMuerta
+	for _, carta := range s.cartasMuertas {
+		if in.GetEtapa() == "" || carta.Etapa == in.GetEtapa() {
+			cartas = append(cartas, carta)
+		}
+	}
+	s.cartasMuertasMutex.Unlock()
+
+	ordenarCartas(cartas)
+	if in.GetLimite() > 0 && int(in.GetLimite()) < len(cartas) {
+		cartas = cartas[:in.GetLimite()]
+	}
+
+	resp := &pb.ListarCartasMuertasResponse{}
+	for _, carta := range cartas {
+		resp.Cartas = append(resp.Cartas, carta.aProto())
+	}
+	return resp, nil
+}
+
+func (s *server) ObtenerCartaMuerta(ctx context.Context, in *pb.ObtenerCartaMuertaRequest) (*pb.CartaMuerta, error) {
+	carta, ok := s.obtenerCartaMuerta(in.GetId())
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "carta muerta %s no existe", in.GetId())
+	}
+	return carta.aProto(), nil
+}
+
+// ReprocesarCartaMuerta vuelve a pasar la oferta por la etapa que falló. Si
+// ahora funciona la carta se elimina; si no, se le suma un intento.
+func (s *server) ReprocesarCartaMuerta(ctx context.Context, in *pb.ReprocesarCartaMuertaRequest) (*pb.ReprocesarCartaMuertaResponse, error) {
+	if s.debeReenviar() {
+		return s.reenviarReproceso(ctx, in)
+	}
+
+	carta, ok := s.obtenerCartaMuerta(in.GetId())
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "carta muerta %s no existe", in.GetId())
+	}
+	log.Printf("[BROKER] Reprocesando carta muerta %s (etapa %s, intento %d)", carta.ID, carta.Etapa, carta.Intentos+1)
+
+	if carta.Etapa == etapaResumen {
+		if resp, err := s.reprocesarResumen(ctx, carta); err != nil || !resp.GetExito() {
+			return resp, err
+		}
+		return s.resolverCartaMuerta(carta)
+	}
+
+	oferta, err := carta.aOferta()
+	if err != nil {
+		return nil, status.Errorf(codes.DataLoss, "oferta ilegible: %v", err)
+	}
+
+	switch carta.Etapa {
+	case etapaEntrega:
+		s.consumidoresMutex.RLock()
+		consumidor, existe := s.consumidores[carta.ConsumidorID]
+		s.consumidoresMutex.RUnlock()
+		if !existe {
+			return nil, status.Errorf(codes.FailedPrecondition, "consumidor %s no está registrado", carta.ConsumidorID)
+		}
+		// A un grupo se le reentrega por medio de cualquier miembro activo
+		if consumidor.Grupo != "" {
+			if _, err := s.entregarAGrupo(ctx, consumidor.Grupo, oferta); err != nil {
+				s.registrarCartaMuerta(carta.ID, oferta, etapaEntrega, err.Error(), nil, carta.ConsumidorID)
+				return &pb.ReprocesarCartaMuertaResponse{Exito: false, Mensaje: err.Error()}, nil
+			}
+		} else if err := s.entregarAConsumidor(ctx, consumidor, oferta); err != nil {
+			s.registrarCartaMuerta(carta.ID, oferta, etapaEntrega, err.Error(), nil, carta.ConsumidorID)
+			return &pb.ReprocesarCartaMuertaResponse{Exito: false, Mensaje: err.Error()}, nil
+		}
+
+	default:
+		resp, err := s.procesarOferta(ctx, oferta, carta.ID)
+		if err != nil {
+			return &pb.ReprocesarCartaMuertaResponse{Exito: false, Mensaje: status.Convert(err).Message()}, nil
+		}
+		if !resp.GetExito() {
+			return &pb.ReprocesarCartaMuertaResponse{Exito: false, Mensaje: resp.GetMensaje()}, nil
+		}
+	}
+
+	return s.resolverCartaMuerta(carta)
+}
+
+// reprocesarResumen reenvía el resumen completo al consumidor.
+func (s *server) reprocesarResumen(ctx context.Context, carta *CartaMuerta) (*pb.ReprocesarCartaMuertaResponse, error) {
+	resumen, err := carta.aResumen()
+	if err != nil {
+		return nil, status.Errorf(codes.DataLoss, "resumen ilegible: %v", err)
+	}
+
+	s.consumidoresMutex.RLock()
+	consumidor, existe := s.consumidores[carta.ConsumidorID]
+	s.consumidoresMutex.RUnlock()
+	if !existe {
+		return nil, status.Errorf(codes.FailedPrecondition, "consumidor %s no está registrado", carta.ConsumidorID)
+	}
+
+	if _, err := consumidor.Cliente.RecibirResumen(ctx, resumen); err != nil {
+		s.registrarResumenMuerto(carta.ID, resumen, err.Error())
+		return &pb.ReprocesarCartaMuertaResponse{Exito: false, Mensaje: err.Error()}, nil
+	}
+	for _, oferta := range resumen.GetOfertas() {
+		s.incrementarOfertasRecibidas(carta.ConsumidorID)
+		s.publicarEntrega(oferta.GetOfertaId(), carta.ConsumidorID, true)
+	}
+	return &pb.ReprocesarCartaMuertaResponse{Exito: true}, nil
+}
+
+func (s *server) resolverCartaMuerta(carta *CartaMuerta) (*pb.ReprocesarCartaMuertaResponse, error) {
+	if err := s.replicar(comando{Tipo: cmdCartaResuelta, CartaID: carta.ID}); err != nil {
+		return nil, status.Errorf(codes.Unavailable, "no se pudo eliminar la carta: %v", err)
+	}
+	log.Printf("[BROKER] Carta muerta %s reprocesada con éxito", carta.ID)
+	return &pb.ReprocesarCartaMuertaResponse{Exito: true, Mensaje: "Oferta reprocesada"}, nil
+}
+
+// PurgarCartasMuertas elimina las cartas que ya no se van a reprocesar.
+func (s *server) PurgarCartasMuertas(ctx context.Context, in *pb.PurgarCartasMuertasRequest) (*pb.PurgarCartasMuertasResponse, error) {
+	if s.debeReenviar() {
+		return s.reenviarPurga(ctx, in)
+	}
+
+	n, err := s.purgarCartasMuertas(in.GetEtapa(), in.GetAntesDe())
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "no se pudieron purgar las cartas: %v", err)
+	}
+	log.Printf("[BROKER] %d carta(s) muerta(s) purgadas (etapa %q, antes de %d)", n, in.GetEtapa(), in.GetAntesDe())
+	return &pb.PurgarCartasMuertasResponse{Eliminadas: int32(n)}, nil
+}
@@ -0,0 +1,581 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	pb "broker_c1/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Tiempo que una reserva retiene las unidades si no se confirma (RESERVA_TTL)
+const duracionReservaDefecto = 5 * time.Minute
+
+// Cada cuánto el líder busca reservas vencidas
+const intervaloReservas = time.Second
+
+// Tiempo, desde el vencimiento de la reserva, que se conserva una compra
+// confirmada para responder los reintentos de ConfirmarCompra
+const retencionCompras = time.Hour
+
+// Reintentos de una escritura de stock que no alcanzó el quórum W
+const intentosStock = 3
+
+// Evento que reciben los consumidores cuando una oferta se queda sin stock
+const eventoAgotado = "agotado"
+
+// Estados de una reserva (las liberadas se eliminan; las confirmadas, pasada
+// la retención)
+const (
+	reservaPendiente  = "pendiente"
+	reservaConfirmada = "confirmada"
+)
+
+// Reserva retiene unidades de una oferta para un consumidor. Forma parte del
+// estado de control replicado; el stock en sí vive en los nodos DB.
+type Reserva struct {
+	ID           string `json:"id"`
+	OfertaID     string `json:"oferta_id"`
+	ConsumidorID string `json:"consumidor_id"`
+	Cantidad     int32  `json:"cantidad"`
+	Expira       int64  `json:"expira"` // unix
+	Estado       string `json:"estado"`
+}
+
+// ReversionStock deshace una escritura de stock que no alcanzó el quórum W y
+// quedó solo en algunos nodos: reescribe el stock previo con una versión mayor
+// que la de esa escritura, así ninguna lectura posterior la puede elegir. Si
+// la reversión tampoco alcanza W queda pendiente en el estado replicado y la
+// oferta no acepta otras operaciones de stock hasta completarla.
+type ReversionStock struct {
+	OfertaID    string           `json:"oferta_id"`
+	OperacionID string           `json:"operacion_id"` // operación que se deshace
+	Stock       int32            `json:"stock"`        // stock previo a ella
+	Version     int64            `json:"version"`
+	Reloj       map[string]int64 `json:"reloj,omitempty"`
+}
+
+// ========== Servicio Compras ==========
+
+// ReservarOferta descuenta unidades del stock en los nodos DB y deja una
+// reserva que vence si no se confirma a tiempo.
+func (s *server) ReservarOferta(ctx context.Context, in *pb.ReservarOfertaRequest) (*pb.ReservarOfertaResponse, error) {
+	if s.debeReenviar() {
+		return s.reenviarReserva(ctx, in)
+	}
+
+	ofertaID := in.GetOfertaId()
+	consumidorID := in.GetConsumidorId()
+	if ofertaID == "" || consumidorID == "" {
+		return nil, status.Error(codes.InvalidArgument, "se requieren oferta_id y consumidor_id")
+	}
+	cantidad := in.GetCantidad()
+	if cantidad < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "cantidad inválida: %d", cantidad)
+	}
+	if cantidad == 0 {
+		cantidad = 1
+	}
+
+	defer s.bloqueosStock.bloquear(ofertaID)()
+
+	reservaID := fmt.Sprintf("R-%s-%s-%d", ofertaID, consumidorID, time.Now().UnixNano())
+	oferta, err := s.actualizarStockQuorum(ctx, ofertaID, reservaID, func(stock int32) (int32, error) {
+		if stock == 0 {
+			return 0, status.Errorf(codes.FailedPrecondition, "oferta %s agotada", ofertaID)
+		}
+		if stock < cantidad {
+			return 0, status.Errorf(codes.FailedPrecondition, "quedan %d unidades de %s, se pidieron %d", stock, ofertaID, cantidad)
+		}
+		return stock - cantidad, nil
+	})
+	if err != nil {
+		log.Printf("[BROKER] Reserva de %s para %s rechazada: %v", ofertaID, consumidorID, err)
+		return nil, err
+	}
+
+	reserva := &Reserva{
+		ID:           reservaID,
+		OfertaID:     ofertaID,
+		ConsumidorID: consumidorID,
+		Cantidad:     cantidad,
+		Expira:       time.Now().Add(s.duracionReserva).Unix(),
+		Estado:       reservaPendiente,
+	}
+	if err := s.replicar(comando{Tipo: cmdReserva, Reserva: reserva}); err != nil {
+		// Las unidades ya se descontaron; sin reserva nadie las devolvería
+		if _, errStock := s.devolverStock(ctx, reserva); errStock != nil {
+			log.Printf("[BROKER] No se pudieron devolver %d unidades de %s: %v", cantidad, ofertaID, errStock)
+		}
+		return nil, status.Errorf(codes.Unavailable, "no se pudo registrar la reserva de %s: %v", ofertaID, err)
+	}
+
+	log.Printf("[BROKER] Reserva %s: %d unidad(es) de %s para %s, quedan %d", reservaID, cantidad, ofertaID, consumidorID, oferta.GetStock())
+	if oferta.GetStock() == 0 {
+		s.notificarAgotado(oferta)
+	}
+
+	return &pb.ReservarOfertaResponse{
+		Exito:         true,
+		ReservaId:     reservaID,
+		StockRestante: oferta.GetStock(),
+		Expira:        reserva.Expira,
+		Mensaje:       "Reservada",
+	}, nil
+}
+
+// ConfirmarCompra convierte una reserva vigente en compra. Las unidades ya
+// se descontaron al reservar, así que solo cambia el estado replicado.
+func (s *server) ConfirmarCompra(ctx context.Context, in *pb.ConfirmarCompraRequest) (*pb.ConfirmarCompraResponse, error) {
+	if s.debeReenviar() {
+		return s.reenviarConfirmacion(ctx, in)
+	}
+
+	reserva, ok := s.obtenerReserva(in.GetReservaId())
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "reserva %s no existe o ya venció", in.GetReservaId())
+	}
+	if in.GetConsumidorId() != "" && in.GetConsumidorId() != reserva.ConsumidorID {
+		return nil, status.Errorf(codes.PermissionDenied, "la reserva %s es de otro consumidor", reserva.ID)
+	}
+
+	// Mismo bloqueo que la liberación: la reserva no puede vencer a mitad de la confirmación
+	defer s.bloqueosStock.bloquear(reserva.OfertaID)()
+
+	reserva, ok = s.obtenerReserva(reserva.ID)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "reserva %s no existe o ya venció", in.GetReservaId())
+	}
+	if reserva.Estado == reservaConfirmada {
+		return &pb.ConfirmarCompraResponse{Exito: true, Mensaje: "Compra ya confirmada"}, nil
+	}
+	if time.Now().Unix() >= reserva.Expira {
+		return nil, status.Errorf(codes.FailedPrecondition, "reserva %s venció", reserva.ID)
+	}
+
+	if err := s.replicar(comando{Tipo: cmdCompraConfirmada, ReservaID: reserva.ID}); err != nil {
+		return nil, status.Errorf(codes.Unavailable, "no se pudo confirmar la reserva %s: %v", reserva.ID, err)
+	}
+
+	log.Printf("[BROKER] Compra confirmada: reserva %s (%d unidad(es) de %s para %s)", reserva.ID, reserva.Cantidad, reserva.OfertaID, reserva.ConsumidorID)
+	return &pb.ConfirmarCompraResponse{Exito: true, Mensaje: "Compra confirmada"}, nil
+}
+
+// ========== Stock replicado en los nodos DB ==========
+
+// actualizarStockQuorum lee el stock de la oferta con quórum R, calcula el
+// nuevo valor y lo escribe con compare-and-set por versión, confirmado con
+// quórum W. Ambos son la mayoría de los N nodos actuales (ver quorum), así que
+// R+W > N y la lectura siempre ve la última escritura confirmada.
+//
+// operacionID hace idempotente el reintento: si la versión más reciente ya es
+// de esta operación, no se vuelve a calcular, solo se completa la escritura.
+// Si no se alcanza W, lo que quedó escrito en algunos nodos se deshace (ver
+// ReversionStock). Quien llama debe tener el bloqueo de la oferta.
+func (s *server) actualizarStockQuorum(ctx context.Context, ofertaID, operacionID string, calcular func(stock int32) (int32, error)) (resultado *pb.OfertaRequest, err error) {
+	if _, pendiente := s.obtenerReversion(ofertaID); pendiente {
+		return nil, status.Errorf(codes.Unavailable, "el stock de %s tiene una escritura sin quórum por deshacer", ofertaID)
+	}
+
+	var reversion *ReversionStock
+	defer func() {
+		if err != nil && reversion != nil && reversion.Version > 0 {
+			s.deshacerStock(reversion)
+		}
+	}()
+
+	for intento := 1; intento <= intentosStock; intento++ {
+		actual, err := s.leerOfertaQuorum(ctx, ofertaID)
+		if err != nil {
+			return nil, err
+		}
+
+		stock, version := actual.GetStock(), actual.GetVersionStock()
+		if actual.GetOperacionStock() != operacionID {
+			if stock, err = calcular(stock); err != nil {
+				return nil, err
+			}
+			version++
+			if reversion == nil {
+				reversion = &ReversionStock{OfertaID: ofertaID, OperacionID: operacionID, Stock: actual.GetStock()}
+			}
+		}
+		if reversion != nil {
+			// La reversión tiene que superar a todo lo que se intentó escribir
+			reversion.Version = max(reversion.Version, version+1)
+			reversion.Reloj = actual.GetRelojVectorial()
+		}
+
+		confirmaciones := s.escribirStock(ctx, &pb.ActualizarStockRequest{
+			OfertaId:       ofertaID,
+			Stock:          stock,
+			Version:        version,
+			OperacionId:    operacionID,
+			RelojVectorial: actual.GetRelojVectorial(),
+		})
+		if confirmaciones >= s.quorum() {
+			resultado := proto.Clone(actual).(*pb.OfertaRequest)
+			resultado.Stock = stock
+			resultado.VersionStock = version
+			resultado.OperacionStock = operacionID
+			return resultado, nil
+		}
+
+		log.Printf("[BROKER] Stock de %s v%d: solo %d confirmaciones (intento %d de %d)", ofertaID, version, confirmaciones, intento, intentosStock)
+		time.Sleep(time.Duration(intento) * 100 * time.Millisecond)
+	}
+	return nil, status.Errorf(codes.Unavailable, "no se alcanzó W=%d al actualizar el stock de %s", s.quorum(), ofertaID)
+}
+
+// leerOfertaQuorum devuelve la copia de la oferta con la versión de stock más
+// reciente entre los nodos que respondieron (se requiere el quórum R), con los
+// relojes vectoriales de todas las copias leídas fusionados: la escritura que
+// se base en ella reemplaza a todas esas versiones en los nodos.
+func (s *server) leerOfertaQuorum(ctx context.Context, ofertaID string) (*pb.OfertaRequest, error) {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	respuestas := 0
+	var masReciente *pb.OfertaRequest
+	reloj := make(map[string]int64)
+
+	s.dbMutex.RLock()
+	for i, dbClient := range s.dbClients {
+		if !s.dbActivos[i] {
+			continue
+		}
+
+		wg.Add(1)
+		go func(idx int, client pb.DynamoDBClient) {
+			defer wg.Done()
+
+			ctxTimeout, cancel := context.WithTimeout(ctx, 2*time.Second)
+			defer cancel()
+
+			resp, err := client.LeerOferta(ctxTimeout, &pb.LeerOfertaRequest{OfertaId: ofertaID})
+			if err != nil {
+				log.Printf("[BROKER] Error leyendo %s de DB%d: %v", ofertaID, idx+1, err)
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			respuestas++
+			for nodo, n := range resp.GetOferta().GetRelojVectorial() {
+				reloj[nodo] = max(reloj[nodo], n)
+			}
+			// Las lápidas también cuentan: una réplica atrasada no debe
+			// revivir una oferta eliminada
+			if resp.GetOferta() != nil && (masReciente == nil || resp.GetOferta().GetVersionStock() > masReciente.GetVersionStock()) {
+				masReciente = resp.GetOferta()
+			}
+		}(i, dbClient)
+	}
+	s.dbMutex.RUnlock()
+	wg.Wait()
+
+	if r := s.quorum(); respuestas < r {
+		return nil, status.Errorf(codes.Unavailable, "solo %d nodos respondieron, se requieren R=%d", respuestas, r)
+	}
+	if masReciente == nil || masReciente.GetEliminada() {
+		return nil, status.Errorf(codes.NotFound, "oferta %s no existe", ofertaID)
+	}
+	masReciente = proto.Clone(masReciente).(*pb.OfertaRequest)
+	masReciente.RelojVectorial = reloj
+	return masReciente, nil
+}
+
+// escribirStock envía la escritura condicional a los nodos activos y
+// devuelve cuántos la aceptaron.
+func (s *server) escribirStock(ctx context.Context, req *pb.ActualizarStockRequest) int {
+	confirmaciones := 0
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+
+	s.dbMutex.RLock()
+	defer s.dbMutex.RUnlock()
+
+	for i, dbClient := range s.dbClients {
+		if !s.dbActivos[i] {
+			continue
+		}
+
+		wg.Add(1)
+		go func(idx int, client pb.DynamoDBClient) {
+			defer wg.Done()
+
+			ctxTimeout, cancel := context.WithTimeout(ctx, 2*time.Second)
+			defer cancel()
+
+			resp, err := client.ActualizarStock(ctxTimeout, req)
+			if err != nil {
+				log.Printf("[BROKER] Error actualizando stock en DB%d: %v", idx+1, err)
+				return
+			}
+			if !resp.GetExito() {
+				log.Printf("[BROKER] DB%d rechazó stock v%d de %s: %s (tiene v%d)", idx+1, req.GetVersion(), req.GetOfertaId(), resp.GetMensaje(), resp.GetVersion())
+				return
+			}
+
+			mu.Lock()
+			confirmaciones++
+			mu.Unlock()
+		}(i, dbClient)
+	}
+
+	wg.Wait()
+	return confirmaciones
+}
+
+// deshacerStock reescribe el stock previo a una operación que no alcanzó W.
+// Usa su propio plazo: el de la llamada original puede ser el que se agotó.
+// Si tampoco alcanza W, deja la reversión pendiente para bucleReservas.
+// Quien llama debe tener el bloqueo de la oferta.
+func (s *server) deshacerStock(reversion *ReversionStock) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	for intento := 1; intento <= intentosStock; intento++ {
+		confirmaciones := s.escribirStock(ctx, &pb.ActualizarStockRequest{
+			OfertaId:       reversion.OfertaID,
+			Stock:          reversion.Stock,
+			Version:        reversion.Version,
+			OperacionId:    "revertir-" + reversion.OperacionID,
+			RelojVectorial: reversion.Reloj,
+		})
+		if confirmaciones >= s.quorum() {
+			log.Printf("[BROKER] Stock de %s vuelve a %d (v%d): se deshizo %s", reversion.OfertaID, reversion.Stock, reversion.Version, reversion.OperacionID)
+			if _, pendiente := s.obtenerReversion(reversion.OfertaID); pendiente {
+				s.replicar(comando{Tipo: cmdReversionAplicada, OfertaID: reversion.OfertaID})
+			}
+			return true
+		}
+		time.Sleep(time.Duration(intento) * 100 * time.Millisecond)
+	}
+
+	log.Printf("[BROKER] ADVERTENCIA: no se pudo deshacer %s en el stock de %s; queda pendiente", reversion.OperacionID, reversion.OfertaID)
+	if _, pendiente := s.obtenerReversion(reversion.OfertaID); !pendiente {
+		s.replicar(comando{Tipo: cmdReversionStock, Reversion: reversion})
+	}
+	return false
+}
+
+// devolverStock suma al stock las unidades de una reserva que no se compró.
+// Quien llama debe tener el bloqueo de la oferta.
+func (s *server) devolverStock(ctx context.Context, reserva *Reserva) (*pb.OfertaRequest, error) {
+	return s.actualizarStockQuorum(ctx, reserva.OfertaID, "liberar-"+reserva.ID, func(stock int32) (int32, error) {
+		return stock + reserva.Cantidad, nil
+	})
+}
+
+// ========== Vencimiento de reservas ==========
+
+// bucleReservas devuelve al stock las reservas vencidas y archiva las compras
+// confirmadas. Corre en todas las réplicas, pero solo actúa el líder.
+func (s *server) bucleReservas() {
+	ticker := time.NewTicker(intervaloReservas)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if s.debeReenviar() {
+			continue
+		}
+		s.completarReversiones()
+		s.liberarReservasVencidas()
+		s.archivarCompras()
+	}
+}
+
+func (s *server) liberarReservasVencidas() {
+	ahora := time.Now().Unix()
+
+	s.reservasMutex.Lock()
+	var vencidas []Reserva
+	for _, reserva := range s.reservas {
+		if reserva.Estado == reservaPendiente && reserva.Expira <= ahora {
+			vencidas = append(vencidas, *reserva)
+		}
+	}
+	s.reservasMutex.Unlock()
+
+	for i := range vencidas {
+		s.liberarReserva(&vencidas[i])
+	}
+}
+
+// completarReversiones reintenta las reversiones de stock pendientes.
+func (s *server) completarReversiones() {
+	s.reservasMutex.Lock()
+	var pendientes []ReversionStock
+	for _, reversion := range s.reversionesStock {
+		pendientes = append(pendientes, *reversion)
+	}
+	s.reservasMutex.Unlock()
+
+	for i := range pendientes {
+		reversion := &pendientes[i]
+		desbloquear := s.bloqueosStock.bloquear(reversion.OfertaID)
+		if _, pendiente := s.obtenerReversion(reversion.OfertaID); pendiente {
+			s.deshacerStock(reversion)
+		}
+		desbloquear()
+	}
+}
+
+// archivarCompras saca del estado replicado las compras confirmadas que ya
+// pasaron la retención, en un solo comando.
+func (s *server) archivarCompras() {
+	limite := time.Now().Add(-retencionCompras).Unix()
+
+	s.reservasMutex.Lock()
+	var archivadas []string
+	for _, reserva := range s.reservas {
+		if reserva.Estado == reservaConfirmada && reserva.Expira <= limite {
+			archivadas = append(archivadas, reserva.ID)
+		}
+	}
+	s.reservasMutex.Unlock()
+
+	if len(archivadas) == 0 {
+		return
+	}
+	if err := s.replicar(comando{Tipo: cmdComprasArchivadas, ReservaIDs: archivadas}); err != nil {
+		return
+	}
+	log.Printf("[BROKER] %d compra(s) confirmada(s) archivadas", len(archivadas))
+}
+
+// liberarReserva devuelve las unidades de una reserva vencida. Si algo falla
+// la reserva sigue pendiente y se reintenta en la próxima vuelta.
+func (s *server) liberarReserva(reserva *Reserva) {
+	defer s.bloqueosStock.bloquear(reserva.OfertaID)()
+
+	// Pudo confirmarse mientras se esperaba el bloqueo
+	if actual, ok := s.obtenerReserva(reserva.ID); !ok || actual.Estado != reservaPendiente {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	oferta, err := s.devolverStock(ctx, reserva)
+	if err != nil {
+		log.Printf("[BROKER] No se pudo liberar la reserva %s: %v", reserva.ID, err)
+		return
+	}
+	if err := s.replicar(comando{Tipo: cmdReservaLiberada, ReservaID: reserva.ID}); err != nil {
+		return
+	}
+	log.Printf("[BROKER] Reserva %s venció: %d unidad(es) de %s vuelven al stock (%d)", reserva.ID, reserva.Cantidad, reserva.OfertaID, oferta.GetStock())
+}
+
+// ========== Estado replicado ==========
+
+func (s *server) obtenerReserva(id string) (*Reserva, bool) {
+	s.reservasMutex.Lock()
+	defer s.reservasMutex.Unlock()
+	reserva, ok := s.reservas[id]
+	if !ok {
+		return nil, false
+	}
+	copia := *reserva
+	return &copia, true
+}
+
+func (s *server) obtenerReversion(ofertaID string) (*ReversionStock, bool) {
+	s.reservasMutex.Lock()
+	defer s.reservasMutex.Unlock()
+	reversion, ok := s.reversionesStock[ofertaID]
+	if !ok {
+		return nil, false
+	}
+	copia := *reversion
+	return &copia, true
+}
+
+func (s *server) aplicarReversionStock(reversion *ReversionStock) {
+	s.reservasMutex.Lock()
+	defer s.reservasMutex.Unlock()
+	copia := *reversion
+	s.reversionesStock[reversion.OfertaID] = &copia
+}
+
+func (s *server) aplicarReversionAplicada(ofertaID string) {
+	s.reservasMutex.Lock()
+	defer s.reservasMutex.Unlock()
+	delete(s.reversionesStock, ofertaID)
+}
+
+func (s *server) aplicarReserva(reserva *Reserva) {
+	s.reservasMutex.Lock()
+	defer s.reservasMutex.Unlock()
+	copia := *reserva
+	s.reservas[reserva.ID] = &copia
+}
+
+func (s *server) aplicarCompraConfirmada(reservaID string) {
+	s.reservasMutex.Lock()
+	defer s.reservasMutex.Unlock()
+	if reserva, ok := s.reservas[reservaID]; ok {
+		reserva.Estado = reservaConfirmada
+	}
+}
+
+func (s *server) aplicarReservaLiberada(reservaID string) {
+	s.reservasMutex.Lock()
+	defer s.reservasMutex.Unlock()
+	delete(s.reservas, reservaID)
+}
+
+func (s *server) aplicarComprasArchivadas(reservaIDs []string) {
+	s.reservasMutex.Lock()
+	defer s.reservasMutex.Unlock()
+	for _, id := range reservaIDs {
+		if reserva, ok := s.reservas[id]; ok && reserva.Estado == reservaConfirmada {
+			delete(s.reservas, id)
+		}
+	}
+}
+
+// ========== Aviso de agotado ==========
+
+// notificarAgotado avisa a los consumidores interesados (también a todos los
+// miembros de los grupos) que la oferta se quedó sin stock. Es un aviso de
+// mejor esfuerzo: si la entrega falla no queda carta muerta.
+func (s *server) notificarAgotado(oferta *pb.OfertaRequest) {
+	evento := proto.Clone(oferta).(*pb.OfertaRequest)
+	evento.Evento = eventoAgotado
+	evento.SecuenciaConsumidor = 0
+
+	s.consumidoresMutex.RLock()
+	var destinatarios []*ConsumidorInfo
+	for _, consumidor := range s.consumidores {
+		if consumidor.Activo && s.ofertaCumpleFiltros(oferta, consumidor) {
+			destinatarios = append(destinatarios, consumidor)
+		}
+	}
+	s.consumidoresMutex.RUnlock()
+
+	log.Printf("[BROKER] Oferta %s agotada, avisando a %d consumidores", oferta.GetOfertaId(), len(destinatarios))
+	s.publicarOferta(oferta, ofertaAgotada, "")
+
+	for _, consumidor := range destinatarios {
+		go func(consumidor *ConsumidorInfo) {
+			timeout := 2 * time.Second
+			if consumidor.WebhookURL != "" {
+				timeout = timeoutEntregaWebhook
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			if _, err := consumidor.Cliente.RecibirOferta(ctx, evento); err != nil {
+				log.Printf("[BROKER] Error avisando agotado de %s a %s: %v", oferta.GetOfertaId(), consumidor.ID, err)
+			}
+		}(consumidor)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	pb "broker_c1/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ofertaConStock publica una oferta con 10 unidades en todos los nodos.
+func ofertaConStock(t *testing.T, srv *server, id string) {
+	t.Helper()
+	resp, err := srv.EnviarOferta(context.Background(), ofertaProductor(id))
+	if err != nil || !resp.GetExito() {
+		t.Fatalf("se esperaba aceptar la oferta, se obtuvo %v, %v", resp, err)
+	}
+}
+
+func stockEnNodos(t *testing.T, nodos []*nodoDBPrueba, id string, esperado int32) {
+	t.Helper()
+	for i, nodo := range nodos {
+		if stock := nodo.oferta(id).GetStock(); stock != esperado {
+			t.Fatalf("DB%d tiene stock %d, se esperaba %d", i+1, stock, esperado)
+		}
+	}
+}
+
+func TestReservaSinQuorumSeDeshace(t *testing.T) {
+	srv, nodos := servidorConNodosPrueba(t, 3)
+	ofertaConStock(t, srv, "R-cas")
+
+	// La mayoría rechaza todos los intentos de la reserva y se recupera a
+	// tiempo para la reversión
+	nodos[1].rechazarStock(intentosStock)
+	nodos[2].rechazarStock(intentosStock)
+
+	_, err := srv.ReservarOferta(context.Background(), &pb.ReservarOfertaRequest{OfertaId: "R-cas", ConsumidorId: "C1", Cantidad: 3})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("se esperaba Unavailable sin quórum, se obtuvo %v", err)
+	}
+
+	stockEnNodos(t, nodos, "R-cas", 10)
+	if _, pendiente := srv.obtenerReversion("R-cas"); pendiente {
+		t.Fatal("la reversión alcanzó W y no debía quedar pendiente")
+	}
+	if leida, err := srv.leerOfertaQuorum(context.Background(), "R-cas"); err != nil || leida.GetStock() != 10 {
+		t.Fatalf("la lectura con quórum debe ver 10 unidades, se obtuvo %v, %v", leida.GetStock(), err)
+	}
+}
+
+func TestReversionPendienteBloqueaElStock(t *testing.T) {
+	srv, nodos := servidorConNodosPrueba(t, 3)
+	ofertaConStock(t, srv, "R-pendiente")
+
+	nodos[1].rechazarStock(-1)
+	nodos[2].rechazarStock(-1)
+
+	_, err := srv.ReservarOferta(context.Background(), &pb.ReservarOfertaRequest{OfertaId: "R-pendiente", ConsumidorId: "C1", Cantidad: 3})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("se esperaba Unavailable sin quórum, se obtuvo %v", err)
+	}
+	if _, pendiente := srv.obtenerReversion("R-pendiente"); !pendiente {
+		t.Fatal("la reversión sin quórum debía quedar pendiente")
+	}
+
+	// Con los nodos de vuelta, la oferta no acepta reservas hasta deshacer
+	nodos[1].rechazarStock(0)
+	nodos[2].rechazarStock(0)
+	_, err = srv.ReservarOferta(context.Background(), &pb.ReservarOfertaRequest{OfertaId: "R-pendiente", ConsumidorId: "C2"})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("se esperaba Unavailable con la reversión pendiente, se obtuvo %v", err)
+	}
+
+	srv.completarReversiones()
+
+	if _, pendiente := srv.obtenerReversion("R-pendiente"); pendiente {
+		t.Fatal("la reversión debía completarse")
+	}
+	stockEnNodos(t, nodos, "R-pendiente", 10)
+
+	resp, err := srv.ReservarOferta(context.Background(), &pb.ReservarOfertaRequest{OfertaId: "R-pendiente", ConsumidorId: "C2"})
+	if err != nil || !resp.GetExito() {
+		t.Fatalf("se esperaba reservar tras deshacer, se obtuvo %v, %v", resp, err)
+	}
+	stockEnNodos(t, nodos, "R-pendiente", 9)
+}
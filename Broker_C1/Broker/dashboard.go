@@ -0,0 +1,289 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	pb "broker_c1/proto"
+)
+
+// Página del dashboard, embebida en el binario
+//
+//go:embed dashboard.html
+var paginaDashboard []byte
+
+// Cada cuánto se envía el estado agregado a los navegadores conectados
+const intervaloEstadoDashboard = 2 * time.Second
+
+// Tipos de evento que publica el broker
+const (
+	eventoOferta    = "oferta"
+	eventoEscritura = "escritura"
+	eventoEntrega   = "entrega"
+	eventoEstado    = "estado"
+)
+
+// Estados de una oferta en el flujo del dashboard
+const (
+	ofertaAceptada  = "aceptada"
+	ofertaRechazada = "rechazada"
+	ofertaSinQuorum = "sin_quorum"
+	ofertaDuplicada = "duplicada"
+	ofertaAgotada   = "agotada"
+)
+
+type eventoDashboard struct {
+	Tipo      string      `json:"tipo"`
+	Timestamp int64       `json:"timestamp"` // milisegundos
+	Datos     interface{} `json:"datos"`
+}
+
+type eventoOfertaDashboard struct {
+	OfertaID  string `json:"oferta_id"`
+	ClienteID string `json:"cliente_id"`
+	Tienda    string `json:"tienda"`
+	Categoria string `json:"categoria"`
+	Producto  string `json:"producto"`
+	Precio    int32  `json:"precio"`
+	Estado    string `json:"estado"`
+	Motivo    string `json:"motivo,omitempty"`
+}
+
+type eventoEscrituraDashboard struct {
+	Nodo  string `json:"nodo"`
+	Exito bool   `json:"exito"`
+}
+
+type eventoEntregaDashboard struct {
+	OfertaID     string `json:"oferta_id"`
+	ConsumidorID string `json:"consumidor_id"`
+	Exito        bool   `json:"exito"`
+}
+
+// difusorEventos reparte los eventos del broker a los navegadores suscritos.
+// Un suscriptor lento pierde eventos en vez de frenar al broker.
+type difusorEventos struct {
+	mu           sync.Mutex
+	suscriptores map[chan eventoDashboard]struct{}
+}
+
+func nuevoDifusorEventos() *difusorEventos {
+	return &difusorEventos{suscriptores: make(map[chan eventoDashboard]struct{})}
+}
+
+func (d *difusorEventos) suscribir() chan eventoDashboard {
+	ch := make(chan eventoDashboard, 256)
+	d.mu.Lock()
+	d.suscriptores[ch] = struct{}{}
+	d.mu.Unlock()
+	return ch
+}
+
+func (d *difusorEventos) desuscribir(ch chan eventoDashboard) {
+	d.mu.Lock()
+	delete(d.suscriptores, ch)
+	d.mu.Unlock()
+}
+
+func (d *difusorEventos) publicar(tipo string, datos interface{}) {
+	evento := eventoDashboard{Tipo: tipo, Timestamp: time.Now().UnixMilli(), Datos: datos}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for ch := range d.suscriptores {
+		select {
+		case ch <- evento:
+		default:
+		}
+	}
+}
+
+// ========== Eventos del broker ==========
+
+func (s *server) publicarOferta(oferta *pb.OfertaRequest, estado, motivo string) {
+	s.eventos.publicar(eventoOferta, eventoOfertaDashboard{
+		OfertaID:  oferta.GetOfertaId(),
+		ClienteID: oferta.GetClienteId(),
+		Tienda:    oferta.GetTienda(),
+		Categoria: oferta.GetCategoria(),
+		Producto:  oferta.GetProducto(),
+		Precio:    oferta.GetPrecioDescuento(),
+		Estado:    estado,
+		Motivo:    motivo,
+	})
+}
+
+func (s *server) publicarEscritura(idx int, exito bool) {
+	s.saludNodosMutex.Lock()
+	if idx < len(s.ultimaEscrituraOK) {
+		s.ultimaEscrituraOK[idx] = exito
+	}
+	s.saludNodosMutex.Unlock()
+
+	s.eventos.publicar(eventoEscritura, eventoEscrituraDashboard{Nodo: fmt.Sprintf("DB%d", idx+1), Exito: exito})
+}
+
+func (s *server) publicarEntrega(ofertaID, consumidorID string, exito bool) {
+	s.eventos.publicar(eventoEntrega, eventoEntregaDashboard{OfertaID: ofertaID, ConsumidorID: consumidorID, Exito: exito})
+}
+
+// ========== Estado agregado ==========
+
+type estadoDashboard struct {
+	Lider         bool                           `json:"lider"`
+	Productores   map[string]productorDashboard  `json:"productores"`
+	Nodos         []nodoDashboard                `json:"nodos"`
+	Consumidores  map[string]consumidorDashboard `json:"consumidores"`
+	CartasMuertas int                            `json:"cartas_muertas"`
+	Procesadas    int                            `json:"procesadas"`
+}
+
+type productorDashboard struct {
+	Enviadas   int `json:"enviadas"`
+	Aceptadas  int `json:"aceptadas"`
+	Rechazadas int `json:"rechazadas"`
+}
+
+type nodoDashboard struct {
+	ID                 string `json:"id"`
+	Activo             bool   `json:"activo"`
+	EscriturasExitosas int    `json:"escrituras_exitosas"`
+	EscriturasFallidas int    `json:"escrituras_fallidas"`
+}
+
+type consumidorDashboard struct {
+	Recibidas int  `json:"recibidas"`
+	Activo    bool `json:"activo"`
+}
+
+func (s *server) estadoDashboard() estadoDashboard {
+	estado := estadoDashboard{
+		Lider:        s.raft == nil || s.raft.esLider(),
+		Productores:  make(map[string]productorDashboard),
+		Consumidores: make(map[string]consumidorDashboard),
+	}
+
+	s.saludNodosMutex.Lock()
+	ultimaEscrituraOK := append([]bool{}, s.ultimaEscrituraOK...)
+	s.saludNodosMutex.Unlock()
+
+	s.statsMutex.Lock()
+	for id, stats := range s.statsProductores {
+		estado.Productores[id] = productorDashboard{
+			Enviadas:   stats.OfertasEnviadas,
+			Aceptadas:  stats.OfertasAceptadas,
+			Rechazadas: stats.OfertasRechazadas,
+		}
+	}
+	// Un nodo DB recién agregado puede no estar todavía en la copia
+	for i, stats := range s.statsNodos {
+		estado.Nodos = append(estado.Nodos, nodoDashboard{
+			ID:                 stats.NodoID,
+			Activo:             i < len(ultimaEscrituraOK) && ultimaEscrituraOK[i],
+			EscriturasExitosas: stats.EscriturasExitosas,
+			EscriturasFallidas: stats.EscriturasFallidas,
+		})
+	}
+	for id, stats := range s.statsConsumidores {
+		estado.Consumidores[id] = consumidorDashboard{Recibidas: stats.OfertasRecibidas, Activo: stats.Activo}
+	}
+	s.statsMutex.Unlock()
+
+	s.cartasMuertasMutex.Lock()
+	estado.CartasMuertas = len(s.cartasMuertas)
+	s.cartasMuertasMutex.Unlock()
+
+	s.ofertasProcesakdasMutex.Lock()
+	estado.Procesadas = len(s.ofertasProcesadas)
+	s.ofertasProcesakdasMutex.Unlock()
+
+	return estado
+}
+
+// ========== Servidor HTTP ==========
+
+func (s *server) iniciarDashboard(direccion string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.servirPagina)
+	mux.HandleFunc("/api/estado", s.servirEstado)
+	mux.HandleFunc("/eventos", s.servirEventos)
+	s.registrarGateway(mux)
+
+	log.Printf("[BROKER] Dashboard y API REST en http://localhost%s", direccion)
+	if err := http.ListenAndServe(direccion, mux); err != nil {
+		log.Printf("[BROKER] Error en dashboard: %v", err)
+	}
+}
+
+func (s *server) servirPagina(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(paginaDashboard)
+}
+
+func (s *server) servirEstado(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.estadoDashboard())
+}
+
+// servirEventos mantiene abierta una conexión SSE con el flujo de ofertas,
+// escrituras y entregas, más el estado agregado cada intervaloEstadoDashboard.
+func (s *server) servirEventos(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming no soportado", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	ch := s.eventos.suscribir()
+	defer s.eventos.desuscribir(ch)
+
+	ticker := time.NewTicker(intervaloEstadoDashboard)
+	defer ticker.Stop()
+
+	enviar := func(evento eventoDashboard) bool {
+		datos, err := json.Marshal(evento)
+		if err != nil {
+			return true
+		}
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", evento.Tipo, datos); err != nil {
+			return false
+		}
+		flusher.Flush()
+		return true
+	}
+
+	estado := func() eventoDashboard {
+		return eventoDashboard{Tipo: eventoEstado, Timestamp: time.Now().UnixMilli(), Datos: s.estadoDashboard()}
+	}
+
+	if !enviar(estado()) {
+		return
+	}
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case evento := <-ch:
+			if !enviar(evento) {
+				return
+			}
+		case <-ticker.C:
+			if !enviar(estado()) {
+				return
+			}
+		}
+	}
+}
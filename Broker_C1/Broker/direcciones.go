@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"time"
+
+	pb "broker_c1/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// Tiempo máximo del handshake con un consumidor que se registra
+const timeoutVerificacion = 3 * time.Second
+
+// verificarConsumidor hace el handshake con la dirección anunciada por un
+// consumidor: le envía un nonce y espera que responda con su propio id y el
+// mismo nonce. Así se rechazan direcciones inalcanzables o que apuntan a otro
+// proceso antes de replicar el registro.
+func verificarConsumidor(ctx context.Context, consumidorID, direccion string) error {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	conn, err := grpc.Dial(direccion, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, timeoutVerificacion)
+	defer cancel()
+
+	resp, err := pb.NewNotificacionesConsumidorClient(conn).Verificar(ctxTimeout, &pb.VerificacionRequest{
+		ConsumidorId: consumidorID,
+		Nonce:        hex.EncodeToString(nonce),
+	})
+	if err != nil {
+		return fmt.Errorf("%s no respondió al handshake: %v", direccion, err)
+	}
+	if resp.GetConsumidorId() != consumidorID || resp.GetNonce() != hex.EncodeToString(nonce) {
+		return fmt.Errorf("%s respondió como %q, no como %s", direccion, resp.GetConsumidorId(), consumidorID)
+	}
+	return nil
+}
+
+// conectarConsumidor devuelve el cliente de entrega para un registro. Si el
+// consumidor ya estaba registrado en la misma dirección se reutiliza su
+// conexión; si se movió, se cierra la anterior. Debe llamarse con
+// s.consumidoresMutex tomado.
+func (s *server) conectarConsumidor(consumidorID, direccionGRPC, webhookURL, webhookSecreto string, anterior *ConsumidorInfo) (pb.NotificacionesConsumidorClient, *grpc.ClientConn, error) {
+	if anterior != nil && anterior.Cliente != nil &&
+		anterior.DireccionGRPC == direccionGRPC &&
+		anterior.WebhookURL == webhookURL && anterior.WebhookSecreto == webhookSecreto {
+		return anterior.Cliente, anterior.Conexion, nil
+	}
+
+	cliente, conexion, err := s.nuevoClienteConsumidor(consumidorID, direccionGRPC, webhookURL, webhookSecreto)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if anterior != nil {
+		log.Printf("[BROKER] Consumidor %s cambió de dirección: %s -> %s",
+			consumidorID, destinoConsumidor(anterior.DireccionGRPC, anterior.WebhookURL), destinoConsumidor(direccionGRPC, webhookURL))
+		if anterior.Conexion != nil {
+			anterior.Conexion.Close()
+		}
+	}
+	return cliente, conexion, nil
+}
+
+func destinoConsumidor(direccionGRPC, webhookURL string) string {
+	if webhookURL != "" {
+		return webhookURL
+	}
+	return direccionGRPC
+}
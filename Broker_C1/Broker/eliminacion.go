@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	pb "broker_c1/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ========== Eliminación de ofertas ==========
+
+// EliminarOferta borra una oferta de los nodos DB. Cada nodo guarda una
+// lápida que se replica con la sincronización, así la oferta no vuelve desde
+// una réplica atrasada; los nodos la recolectan cuando la tienen todos.
+func (s *server) EliminarOferta(ctx context.Context, in *pb.EliminarOfertaRequest) (*pb.EliminarOfertaResponse, error) {
+	if s.debeReenviar() {
+		return s.reenviarEliminacion(ctx, in)
+	}
+
+	ofertaID := in.GetOfertaId()
+	if ofertaID == "" {
+		return nil, status.Error(codes.InvalidArgument, "se requiere oferta_id")
+	}
+
+	// Con el mismo bloqueo que las compras: la lápida no se cruza con una
+	// escritura de stock a medio hacer
+	defer s.bloqueosStock.bloquear(ofertaID)()
+
+	oferta, err := s.leerOfertaQuorum(ctx, ofertaID)
+	if err != nil {
+		log.Printf("[BROKER] Eliminación de %s rechazada: %v", ofertaID, err)
+		return nil, err
+	}
+
+	req := &pb.EliminarOfertaRequest{
+		OfertaId:       ofertaID,
+		RelojVectorial: oferta.GetRelojVectorial(),
+		VersionStock:   oferta.GetVersionStock(),
+	}
+	confirmaciones := s.escribirLapida(ctx, req)
+	if w := s.quorum(); confirmaciones < w {
+		log.Printf("[BROKER] ERROR: Eliminación de %s con solo %d confirmaciones, se requieren W=%d", ofertaID, confirmaciones, w)
+		return &pb.EliminarOfertaResponse{
+			Exito:          false,
+			Mensaje:        "No se alcanzó el quórum de escritura",
+			Confirmaciones: int32(confirmaciones),
+		}, nil
+	}
+
+	log.Printf("[BROKER] Oferta %s eliminada (%d confirmaciones)", ofertaID, confirmaciones)
+	return &pb.EliminarOfertaResponse{
+		Exito:          true,
+		Mensaje:        "Eliminada",
+		Confirmaciones: int32(confirmaciones),
+	}, nil
+}
+
+// escribirLapida envía la eliminación a los nodos activos y devuelve cuántos
+// guardaron la lápida.
+func (s *server) escribirLapida(ctx context.Context, req *pb.EliminarOfertaRequest) int {
+	confirmaciones := 0
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+
+	s.dbMutex.RLock()
+	defer s.dbMutex.RUnlock()
+
+	for i, dbClient := range s.dbClients {
+		if !s.dbActivos[i] {
+			continue
+		}
+
+		wg.Add(1)
+		go func(idx int, client pb.DynamoDBClient) {
+			defer wg.Done()
+
+			ctxTimeout, cancel := context.WithTimeout(ctx, 2*time.Second)
+			defer cancel()
+
+			resp, err := client.EliminarOferta(ctxTimeout, req)
+			if err != nil {
+				log.Printf("[BROKER] Error eliminando %s en DB%d: %v", req.GetOfertaId(), idx+1, err)
+				return
+			}
+			if !resp.GetExito() {
+				log.Printf("[BROKER] DB%d rechazó la eliminación de %s: %s", idx+1, req.GetOfertaId(), resp.GetMensaje())
+				return
+			}
+
+			mu.Lock()
+			confirmaciones++
+			mu.Unlock()
+		}(i, dbClient)
+	}
+
+	wg.Wait()
+	return confirmaciones
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"time"
+)
+
+// Cada cuánto el líder replica los contadores acumulados
+const intervaloEstadisticas = time.Second
+
+// ========== Estadísticas ==========
+
+// Las estadísticas se replican como el resto del estado de control, pero
+// fuera del camino de cada oferta: el líder acumula los incrementos y los
+// replica juntos en un solo comando por intervalo.
+
+type contadoresNodo struct {
+	Exitosas int `json:"exitosas,omitempty"`
+	Fallidas int `json:"fallidas,omitempty"`
+}
+
+// deltaEstadisticas son los incrementos acumulados desde la última réplica.
+type deltaEstadisticas struct {
+	Productores  map[string]*EstadisticasProductor `json:"productores,omitempty"`
+	Nodos        map[int]*contadoresNodo           `json:"nodos,omitempty"`
+	Consumidores map[string]int                    `json:"consumidores,omitempty"`
+}
+
+func nuevoDeltaEstadisticas() *deltaEstadisticas {
+	return &deltaEstadisticas{
+		Productores:  make(map[string]*EstadisticasProductor),
+		Nodos:        make(map[int]*contadoresNodo),
+		Consumidores: make(map[string]int),
+	}
+}
+
+func (d *deltaEstadisticas) vacio() bool {
+	return len(d.Productores) == 0 && len(d.Nodos) == 0 && len(d.Consumidores) == 0
+}
+
+func (d *deltaEstadisticas) productor(clienteID string) *EstadisticasProductor {
+	stats, ok := d.Productores[clienteID]
+	if !ok {
+		stats = &EstadisticasProductor{}
+		d.Productores[clienteID] = stats
+	}
+	return stats
+}
+
+func (d *deltaEstadisticas) nodo(idx int) *contadoresNodo {
+	contadores, ok := d.Nodos[idx]
+	if !ok {
+		contadores = &contadoresNodo{}
+		d.Nodos[idx] = contadores
+	}
+	return contadores
+}
+
+// sumar agrega otro delta a este (para no perder uno que no se pudo replicar).
+func (d *deltaEstadisticas) sumar(otro *deltaEstadisticas) {
+	for id, stats := range otro.Productores {
+		propio := d.productor(id)
+		propio.OfertasEnviadas += stats.OfertasEnviadas
+		propio.OfertasAceptadas += stats.OfertasAceptadas
+		propio.OfertasRechazadas += stats.OfertasRechazadas
+	}
+	for idx, contadores := range otro.Nodos {
+		propio := d.nodo(idx)
+		propio.Exitosas += contadores.Exitosas
+		propio.Fallidas += contadores.Fallidas
+	}
+	for id, n := range otro.Consumidores {
+		d.Consumidores[id] += n
+	}
+}
+
+func (s *server) acumular(cambio func(d *deltaEstadisticas)) {
+	s.estadisticasMutex.Lock()
+	defer s.estadisticasMutex.Unlock()
+	cambio(s.estadisticasPendientes)
+}
+
+func (s *server) incrementarOfertasEnviadas(clienteID string) {
+	s.acumular(func(d *deltaEstadisticas) { d.productor(clienteID).OfertasEnviadas++ })
+}
+
+func (s *server) incrementarOfertasAceptadas(clienteID string) {
+	s.acumular(func(d *deltaEstadisticas) { d.productor(clienteID).OfertasAceptadas++ })
+}
+
+func (s *server) incrementarOfertasRechazadas(clienteID string) {
+	s.acumular(func(d *deltaEstadisticas) { d.productor(clienteID).OfertasRechazadas++ })
+}
+
+func (s *server) incrementarEscriturasExitosas(idx int) {
+	s.acumular(func(d *deltaEstadisticas) { d.nodo(idx).Exitosas++ })
+}
+
+func (s *server) incrementarEscriturasFallidas(idx int) {
+	s.acumular(func(d *deltaEstadisticas) { d.nodo(idx).Fallidas++ })
+}
+
+func (s *server) incrementarOfertasRecibidas(consumidorID string) {
+	s.acumular(func(d *deltaEstadisticas) { d.Consumidores[consumidorID]++ })
+}
+
+// replicarEstadisticas replica los contadores acumulados. Si falla, vuelven
+// a quedar pendientes para el próximo intento.
+func (s *server) replicarEstadisticas() {
+	s.estadisticasMutex.Lock()
+	delta := s.estadisticasPendientes
+	s.estadisticasPendientes = nuevoDeltaEstadisticas()
+	s.estadisticasMutex.Unlock()
+
+	if delta.vacio() {
+		return
+	}
+	if err := s.replicar(comando{Tipo: cmdEstadisticas, Estadisticas: delta}); err != nil {
+		s.acumular(func(d *deltaEstadisticas) { d.sumar(delta) })
+	}
+}
+
+// bucleEstadisticas corre en todas las réplicas, pero solo el líder acumula.
+func (s *server) bucleEstadisticas() {
+	ticker := time.NewTicker(intervaloEstadisticas)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if s.debeReenviar() {
+			continue
+		}
+		s.replicarEstadisticas()
+	}
+}
+
+func (s *server) aplicarEstadisticas(delta *deltaEstadisticas) {
+	s.statsMutex.Lock()
+	defer s.statsMutex.Unlock()
+
+	for id, incremento := range delta.Productores {
+		if stats, ok := s.statsProductores[id]; ok {
+			stats.OfertasEnviadas += incremento.OfertasEnviadas
+			stats.OfertasAceptadas += incremento.OfertasAceptadas
+			stats.OfertasRechazadas += incremento.OfertasRechazadas
+		}
+	}
+	for idx, incremento := range delta.Nodos {
+		if idx >= 0 && idx < len(s.statsNodos) {
+			s.statsNodos[idx].EscriturasExitosas += incremento.Exitosas
+			s.statsNodos[idx].EscriturasFallidas += incremento.Fallidas
+		}
+	}
+	for id, n := range delta.Consumidores {
+		if stats, ok := s.statsConsumidores[id]; ok {
+			stats.OfertasRecibidas += n
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	_ "embed"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	pb "broker_c1/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Documento OpenAPI del gateway REST, embebido en el binario
+//
+//go:embed openapi.json
+var documentoOpenAPI []byte
+
+// Tamaño máximo del cuerpo JSON aceptado por el gateway
+const maxCuerpoGateway = 1 << 20
+
+// Tiempo máximo de una llamada del gateway (igual al que usan los productores)
+const timeoutGateway = 5 * time.Second
+
+// Las respuestas usan los nombres del .proto (snake_case); al leer se aceptan
+// tanto esos nombres como los camelCase de protojson.
+var marshalGateway = protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}
+
+// registrarGateway publica la API REST/JSON, que llama a los mismos métodos
+// del servidor gRPC (misma validación, reenvío al líder y cartas muertas).
+func (s *server) registrarGateway(mux *http.ServeMux) {
+	mux.HandleFunc("POST /api/v1/ofertas", s.gatewayEnviarOferta)
+	mux.HandleFunc("DELETE /api/v1/ofertas/{id}", s.gatewayEliminarOferta)
+	mux.HandleFunc("POST /api/v1/consumidores", s.gatewayRegistrarConsumidor)
+	mux.HandleFunc("GET /api/v1/consumidores/{id}/historico", s.gatewaySolicitarHistorico)
+	mux.HandleFunc("POST /api/v1/reservas", s.gatewayReservarOferta)
+	mux.HandleFunc("POST /api/v1/reservas/{id}/confirmar", s.gatewayConfirmarCompra)
+	mux.HandleFunc("GET /api/v1/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(documentoOpenAPI)
+	})
+}
+
+func (s *server) gatewayEnviarOferta(w http.ResponseWriter, r *http.Request) {
+	in := &pb.OfertaRequest{}
+	if !leerCuerpo(w, r, in) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeoutGateway)
+	defer cancel()
+
+	resp, err := s.EnviarOferta(ctx, in)
+	if err != nil {
+		escribirError(w, err)
+		return
+	}
+	// Exito=false sin error gRPC significa que no se alcanzó el quórum W
+	codigo := http.StatusCreated
+	if !resp.GetExito() {
+		codigo = http.StatusServiceUnavailable
+	}
+	escribirJSON(w, codigo, resp)
+}
+
+func (s *server) gatewayEliminarOferta(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), timeoutGateway)
+	defer cancel()
+
+	resp, err := s.EliminarOferta(ctx, &pb.EliminarOfertaRequest{OfertaId: r.PathValue("id")})
+	if err != nil {
+		escribirError(w, err)
+		return
+	}
+	// Exito=false significa que no se alcanzó el quórum de escritura
+	codigo := http.StatusOK
+	if !resp.GetExito() {
+		codigo = http.StatusServiceUnavailable
+	}
+	escribirJSON(w, codigo, resp)
+}
+
+func (s *server) gatewayRegistrarConsumidor(w http.ResponseWriter, r *http.Request) {
+	in := &pb.RegistroConsumidorRequest{}
+	if !leerCuerpo(w, r, in) {
+		return
+	}
+	if in.GetConsumidorId() == "" {
+		escribirError(w, status.Error(codes.InvalidArgument, "consumidor_id vacío"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeoutGateway)
+	defer cancel()
+
+	resp, err := s.RegistrarConsumidor(ctx, in)
+	if err != nil {
+		escribirError(w, err)
+		return
+	}
+	// Exito=false significa que el registro no se pudo replicar
+	codigo := http.StatusCreated
+	if !resp.GetExito() {
+		codigo = http.StatusServiceUnavailable
+	}
+	escribirJSON(w, codigo, resp)
+}
+
+func (s *server) gatewaySolicitarHistorico(w http.ResponseWriter, r *http.Request) {
+	var desde int64
+	if valor := r.URL.Query().Get("desde"); valor != "" {
+		var err error
+		if desde, err = strconv.ParseInt(valor, 10, 64); err != nil {
+			escribirError(w, status.Errorf(codes.InvalidArgument, "desde inválido: %v", err))
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeoutGateway)
+	defer cancel()
+
+	resp, err := s.SolicitarHistorico(ctx, &pb.SolicitarHistoricoRequest{
+		ConsumidorId:   r.PathValue("id"),
+		DesdeTimestamp: desde,
+	})
+	if err != nil {
+		escribirError(w, err)
+		return
+	}
+	escribirJSON(w, http.StatusOK, resp)
+}
+
+func (s *server) gatewayReservarOferta(w http.ResponseWriter, r *http.Request) {
+	in := &pb.ReservarOfertaRequest{}
+	if !leerCuerpo(w, r, in) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeoutGateway)
+	defer cancel()
+
+	// Si la reserva no se pudo replicar llega como UNAVAILABLE (503)
+	resp, err := s.ReservarOferta(ctx, in)
+	if err != nil {
+		escribirError(w, err)
+		return
+	}
+	escribirJSON(w, http.StatusCreated, resp)
+}
+
+func (s *server) gatewayConfirmarCompra(w http.ResponseWriter, r *http.Request) {
+	// El cuerpo es opcional: solo puede traer consumidor_id
+	in := &pb.ConfirmarCompraRequest{}
+	if r.ContentLength != 0 && !leerCuerpo(w, r, in) {
+		return
+	}
+	in.ReservaId = r.PathValue("id")
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeoutGateway)
+	defer cancel()
+
+	resp, err := s.ConfirmarCompra(ctx, in)
+	if err != nil {
+		escribirError(w, err)
+		return
+	}
+	escribirJSON(w, http.StatusOK, resp)
+}
+
+// leerCuerpo decodifica el cuerpo JSON en msg. Si falla responde 400 y devuelve false.
+func leerCuerpo(w http.ResponseWriter, r *http.Request, msg proto.Message) bool {
+	datos, err := io.ReadAll(io.LimitReader(r.Body, maxCuerpoGateway))
+	if err != nil {
+		escribirError(w, status.Errorf(codes.InvalidArgument, "no se pudo leer el cuerpo: %v", err))
+		return false
+	}
+	if err := protojson.Unmarshal(datos, msg); err != nil {
+		escribirError(w, status.Errorf(codes.InvalidArgument, "JSON inválido: %v", err))
+		return false
+	}
+	return true
+}
+
+func escribirJSON(w http.ResponseWriter, codigo int, msg proto.Message) {
+	datos, err := marshalGateway.Marshal(msg)
+	if err != nil {
+		log.Printf("[BROKER] Error serializando respuesta del gateway: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(codigo)
+	w.Write(datos)
+}
+
+// escribirError responde con el google.rpc.Status del error (código,
+// mensaje y detalles como ErrorInfo y BadRequest) y el código HTTP equivalente.
+func escribirError(w http.ResponseWriter, err error) {
+	st := status.Convert(err)
+	escribirJSON(w, codigoHTTP(st.Code()), st.Proto())
+}
+
+// codigoHTTP traduce un código gRPC al código HTTP equivalente.
+func codigoHTTP(codigo codes.Code) int {
+	switch codigo {
+	case codes.OK:
+		return http.StatusOK
+	case codes.InvalidArgument, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.FailedPrecondition:
+		return http.StatusPreconditionFailed
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.DeadlineExceeded, codes.Canceled:
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"sort"
+
+	pb "broker_c1/proto"
+)
+
+// Estrategias de reparto de ofertas dentro de un grupo de consumidores (REPARTO_GRUPOS)
+const (
+	// Cada producto va siempre al mismo miembro mientras el grupo no cambie;
+	// si un miembro cae, solo se reasignan sus productos (hashing por rendezvous).
+	repartoHashProducto = "hash_producto"
+	// Los miembros se turnan oferta por oferta.
+	repartoRoundRobin = "round_robin"
+)
+
+func validarReparto(reparto string) error {
+	if reparto != repartoHashProducto && reparto != repartoRoundRobin {
+		return fmt.Errorf("estrategia de reparto %q desconocida (usar %s o %s)", reparto, repartoHashProducto, repartoRoundRobin)
+	}
+	return nil
+}
+
+// puntajeRendezvous es el peso de un miembro para un producto: el producto se
+// asigna al miembro con mayor puntaje.
+func puntajeRendezvous(productoID, consumidorID string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(productoID))
+	h.Write([]byte{0})
+	h.Write([]byte(consumidorID))
+	return h.Sum64()
+}
+
+// candidatosGrupo devuelve los miembros activos del grupo que aceptan la
+// oferta, en el orden en que se les debe intentar entregar.
+func (s *server) candidatosGrupo(grupo string, oferta *pb.OfertaRequest) []*ConsumidorInfo {
+	s.consumidoresMutex.RLock()
+	var candidatos []*ConsumidorInfo
+	for _, consumidor := range s.consumidores {
+		if consumidor.Grupo == grupo && consumidor.Activo && s.ofertaCumpleFiltros(oferta, consumidor) {
+			candidatos = append(candidatos, consumidor)
+		}
+	}
+	s.consumidoresMutex.RUnlock()
+
+	if len(candidatos) == 0 {
+		return nil
+	}
+
+	switch s.repartoGrupos {
+	case repartoRoundRobin:
+		sort.Slice(candidatos, func(i, j int) bool { return candidatos[i].ID < candidatos[j].ID })
+
+		s.turnosGrupoMutex.Lock()
+		turno := s.turnosGrupo[grupo]
+		s.turnosGrupo[grupo] = turno + 1
+		s.turnosGrupoMutex.Unlock()
+
+		inicio := int(turno % uint64(len(candidatos)))
+		candidatos = append(candidatos[inicio:], candidatos[:inicio]...)
+
+	default:
+		productoID := oferta.GetProductoId()
+		sort.Slice(candidatos, func(i, j int) bool {
+			return puntajeRendezvous(productoID, candidatos[i].ID) > puntajeRendezvous(productoID, candidatos[j].ID)
+		})
+	}
+	return candidatos
+}
+
+// entregarAGrupo entrega la oferta a un solo miembro del grupo. Si el elegido
+// no responde queda inactivo y se intenta con el siguiente.
+func (s *server) entregarAGrupo(ctx context.Context, grupo string, oferta *pb.OfertaRequest) (string, error) {
+	candidatos := s.candidatosGrupo(grupo, oferta)
+	if len(candidatos) == 0 {
+		return "", fmt.Errorf("grupo %s sin miembros activos para la oferta", grupo)
+	}
+
+	var err error
+	for _, consumidor := range candidatos {
+		if err = s.entregarAConsumidor(ctx, consumidor, oferta); err == nil {
+			return consumidor.ID, nil
+		}
+		log.Printf("[BROKER] Rebalanceando oferta %s del grupo %s: %s no respondió", oferta.GetOfertaId(), grupo, consumidor.ID)
+	}
+	return candidatos[0].ID, err
+}
+
+func (s *server) enviarAGrupo(ctx context.Context, grupo string, oferta *pb.OfertaRequest) {
+	consumidorID, err := s.entregarAGrupo(ctx, grupo, oferta)
+	if err != nil {
+		s.registrarCartaMuerta("", oferta, etapaEntrega, err.Error(), nil, consumidorID)
+	}
+}
+
+// perteneceAMiembro indica si, con los miembros activos actuales, la oferta
+// le corresponde a este miembro del grupo. Se usa para repartir el histórico
+// (en round robin también se reparte por producto, porque los turnos pasados
+// no se pueden reconstruir).
+func (s *server) perteneceAMiembro(consumidor *ConsumidorInfo, oferta *pb.OfertaRequest) bool {
+	productoID := oferta.GetProductoId()
+	mejor := consumidor.ID
+	mejorPuntaje := puntajeRendezvous(productoID, consumidor.ID)
+
+	s.consumidoresMutex.RLock()
+	defer s.consumidoresMutex.RUnlock()
+	for _, otro := range s.consumidores {
+		if otro.Grupo != consumidor.Grupo || !otro.Activo || otro.ID == consumidor.ID {
+			continue
+		}
+		if !s.ofertaCumpleFiltros(oferta, otro) {
+			continue
+		}
+		if puntaje := puntajeRendezvous(productoID, otro.ID); puntaje > mejorPuntaje {
+			mejor, mejorPuntaje = otro.ID, puntaje
+		}
+	}
+	return mejor == consumidor.ID
+}
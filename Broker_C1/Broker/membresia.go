@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	pb "broker_c1/proto"
+
+	"google.golang.org/grpc"
+)
+
+// Cada cuánto el broker consulta la membresía de los nodos DB
+const intervaloMembresia = 5 * time.Second
+
+// nodoDB es lo que el broker sabe de cada nodo DB además de su cliente. Los
+// nodos iniciales parten con el nombre DB1, DB2, ... y la dirección de
+// DBn_ADDR; su nodo_id real se confirma al verlos en la membresía.
+type nodoDB struct {
+	id         string
+	confirmado bool
+	direccion  string
+	conexion   *grpc.ClientConn
+	retirado   bool // dejó el clúster para siempre: ya no cuenta en N
+}
+
+// ========== Membresía de los nodos DB ==========
+
+// Los nodos DB se descubren y vigilan entre ellos por gossip (SWIM). El broker
+// consulta esa membresía: deja de escribir en los nodos muertos o detenidos,
+// vuelve a usarlos cuando reviven y agrega al final los nodos nuevos. Los
+// retirados dejan además de contar en N.
+// DB1_ADDR, DB2_ADDR y DB3_ADDR quedan como los nodos iniciales.
+func (s *server) bucleMembresia() {
+	ticker := time.NewTicker(intervaloMembresia)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		miembros, err := s.consultarMembresia()
+		if err != nil {
+			log.Printf("[BROKER] Error consultando la membresía de los nodos DB: %v", err)
+			continue
+		}
+		s.aplicarMembresia(miembros)
+	}
+}
+
+// consultarMembresia pregunta a los nodos DB, primero a los activos, hasta
+// que uno responda.
+func (s *server) consultarMembresia() ([]*pb.Miembro, error) {
+	s.dbMutex.RLock()
+	var orden []pb.DynamoDBClient
+	for _, activos := range []bool{true, false} {
+		for i, client := range s.dbClients {
+			if client != nil && s.dbActivos[i] == activos {
+				orden = append(orden, client)
+			}
+		}
+	}
+	s.dbMutex.RUnlock()
+
+	for _, client := range orden {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		resp, err := client.Miembros(ctx, &pb.MiembrosRequest{NodoOrigen: "BROKER"})
+		cancel()
+		if err == nil {
+			return resp.GetMiembros(), nil
+		}
+	}
+	return nil, fmt.Errorf("ningún nodo DB respondió")
+}
+
+func (s *server) aplicarMembresia(miembros []*pb.Miembro) {
+	for _, miembro := range miembros {
+		// Los sospechosos pueden estar vivos: se les sigue escribiendo
+		activo := miembro.GetEstado() == pb.EstadoMiembro_VIVO || miembro.GetEstado() == pb.EstadoMiembro_SOSPECHOSO
+
+		idx := s.indiceNodoDB(miembro)
+		if idx < 0 {
+			if activo {
+				s.agregarNodoDB(miembro)
+			}
+			continue
+		}
+
+		s.dbMutex.Lock()
+		nodo := s.dbNodos[idx]
+		if activo && miembro.GetDireccion() != "" && (s.dbClients[idx] == nil || nodo.direccion != miembro.GetDireccion()) {
+			client, conn, err := newDBClient(miembro.GetDireccion())
+			if err != nil {
+				s.dbMutex.Unlock()
+				log.Printf("[BROKER] Error conectando a %s (%s): %v", miembro.GetNodoId(), miembro.GetDireccion(), err)
+				continue
+			}
+			if nodo.conexion != nil {
+				nodo.conexion.Close()
+			}
+			if nodo.direccion != "" && nodo.direccion != miembro.GetDireccion() {
+				log.Printf("[BROKER] %s cambió de dirección: %s -> %s", miembro.GetNodoId(), nodo.direccion, miembro.GetDireccion())
+			}
+			s.dbClients[idx] = client
+			nodo.direccion = miembro.GetDireccion()
+			nodo.conexion = conn
+		}
+		cambio := s.dbActivos[idx] != activo
+		s.dbActivos[idx] = activo
+		retirado := miembro.GetEstado() == pb.EstadoMiembro_RETIRADO
+		cambioN := s.dbNodos[idx].retirado != retirado
+		s.dbNodos[idx].retirado = retirado
+		s.dbMutex.Unlock()
+
+		if cambioN {
+			log.Printf("[BROKER] %s %s: W=R=%d", miembro.GetNodoId(), miembro.GetEstado(), s.quorum())
+		}
+
+		if !cambio {
+			continue
+		}
+		s.statsMutex.Lock()
+		s.statsNodos[idx].Activo = activo
+		s.statsMutex.Unlock()
+		log.Printf("[BROKER] %s %s según la membresía", miembro.GetNodoId(), miembro.GetEstado())
+	}
+}
+
+// quorum devuelve la mayoría de los N nodos DB conocidos, que se usa tanto
+// para W como para R: así R+W > N también cuando el clúster crece. Los nodos
+// caídos o detenidos siguen contando, porque pueden volver con sus datos; los
+// retirados ya entregaron sus ofertas y no.
+func (s *server) quorum() int {
+	s.dbMutex.RLock()
+	defer s.dbMutex.RUnlock()
+	n := 0
+	for _, nodo := range s.dbNodos {
+		if !nodo.retirado {
+			n++
+		}
+	}
+	return n/2 + 1
+}
+
+// indiceNodoDB busca el nodo del miembro: por su nodo_id si ya se confirmó
+// y, si no, entre los nodos iniciales por dirección o por nombre. Así un
+// nodo inicial con otro NODO_ID no se cuenta dos veces en N.
+func (s *server) indiceNodoDB(miembro *pb.Miembro) int {
+	s.dbMutex.Lock()
+	idx := -1
+	for _, coincide := range []func(*nodoDB) bool{
+		func(nodo *nodoDB) bool { return nodo.confirmado && nodo.id == miembro.GetNodoId() },
+		func(nodo *nodoDB) bool {
+			return !nodo.confirmado && nodo.direccion != "" && nodo.direccion == miembro.GetDireccion()
+		},
+		func(nodo *nodoDB) bool { return !nodo.confirmado && nodo.id == miembro.GetNodoId() },
+	} {
+		for i, nodo := range s.dbNodos {
+			if coincide(nodo) {
+				idx = i
+				break
+			}
+		}
+		if idx >= 0 {
+			break
+		}
+	}
+	if idx < 0 || s.dbNodos[idx].confirmado {
+		s.dbMutex.Unlock()
+		return idx
+	}
+	nombre := s.dbNodos[idx].id
+	s.dbNodos[idx].id = miembro.GetNodoId()
+	s.dbNodos[idx].confirmado = true
+	s.dbMutex.Unlock()
+
+	if nombre != miembro.GetNodoId() {
+		s.statsMutex.Lock()
+		s.statsNodos[idx].NodoID = miembro.GetNodoId()
+		s.statsMutex.Unlock()
+		log.Printf("[BROKER] El nodo inicial %s es %s", nombre, miembro.GetNodoId())
+	}
+	return idx
+}
+
+// agregarNodoDB empieza a escribir en un nodo que se unió al clúster.
+func (s *server) agregarNodoDB(miembro *pb.Miembro) {
+	client, conn, err := newDBClient(miembro.GetDireccion())
+	if err != nil {
+		log.Printf("[BROKER] Error conectando a %s (%s): %v", miembro.GetNodoId(), miembro.GetDireccion(), err)
+		return
+	}
+
+	s.dbMutex.Lock()
+	s.dbClients = append(s.dbClients, client)
+	s.dbActivos = append(s.dbActivos, true)
+	s.dbNodos = append(s.dbNodos, &nodoDB{id: miembro.GetNodoId(), confirmado: true, direccion: miembro.GetDireccion(), conexion: conn})
+	s.dbMutex.Unlock()
+
+	s.saludNodosMutex.Lock()
+	s.ultimaEscrituraOK = append(s.ultimaEscrituraOK, true)
+	s.saludNodosMutex.Unlock()
+
+	s.statsMutex.Lock()
+	s.statsNodos = append(s.statsNodos, &EstadisticasNodo{NodoID: miembro.GetNodoId(), Activo: true})
+	s.statsMutex.Unlock()
+
+	log.Printf("[BROKER] Nuevo nodo DB %s en %s (W=R=%d)", miembro.GetNodoId(), miembro.GetDireccion(), s.quorum())
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"testing"
+
+	pb "broker_c1/proto"
+)
+
+func TestNodoRetiradoDejaDeContarEnN(t *testing.T) {
+	srv, _ := servidorConNodosPrueba(t, 4)
+	if w := srv.quorum(); w != 3 {
+		t.Fatalf("con 4 nodos W debía ser 3, es %d", w)
+	}
+
+	// Un nodo detenido puede volver con sus datos: sigue contando
+	srv.aplicarMembresia([]*pb.Miembro{{NodoId: "DB4", Estado: pb.EstadoMiembro_SALIO}})
+	if w := srv.quorum(); w != 3 {
+		t.Fatalf("un nodo detenido debía seguir contando, W=%d", w)
+	}
+
+	srv.aplicarMembresia([]*pb.Miembro{{NodoId: "DB4", Estado: pb.EstadoMiembro_RETIRADO}})
+	if w := srv.quorum(); w != 2 {
+		t.Fatalf("sin el nodo retirado W debía ser 2, es %d", w)
+	}
+}
+
+func TestNodoInicialSeReconocePorDireccion(t *testing.T) {
+	srv, _ := servidorConNodosPrueba(t, 3)
+	for i, direccion := range []string{"db1:50052", "db2:50053", "db3:50054"} {
+		srv.dbNodos[i].direccion = direccion
+	}
+
+	srv.aplicarMembresia([]*pb.Miembro{{NodoId: "nodo-a", Direccion: "db1:50052"}})
+
+	if n := len(srv.dbClients); n != 3 {
+		t.Fatalf("el nodo inicial con otro NODO_ID no debía sumarse a N: N=%d", n)
+	}
+	if w := srv.quorum(); w != 2 {
+		t.Fatalf("W debía seguir en 2, es %d", w)
+	}
+	if id := srv.statsNodos[0].NodoID; id != "nodo-a" {
+		t.Fatalf("el nodo inicial debía tomar el nodo_id de la membresía, tiene %q", id)
+	}
+
+	// Ya confirmado, el nombre por defecto no vuelve a coincidir con él
+	srv.aplicarMembresia([]*pb.Miembro{{NodoId: "DB1", Direccion: "db9:50052"}})
+	if n := len(srv.dbClients); n != 4 {
+		t.Fatalf("un nodo nuevo llamado DB1 debía agregarse: N=%d", n)
+	}
+}
+
+func TestCambioDeDireccionReconecta(t *testing.T) {
+	srv, nodos := servidorConNodosPrueba(t, 3)
+	for i, direccion := range []string{"db1:50052", "db2:50053", "db3:50054"} {
+		srv.dbNodos[i].direccion = direccion
+	}
+
+	srv.aplicarMembresia([]*pb.Miembro{{NodoId: "DB2", Direccion: "db2:50053"}})
+	if srv.dbClients[1] != pb.DynamoDBClient(nodos[1]) {
+		t.Fatal("con la misma dirección no debía reconectar")
+	}
+
+	srv.aplicarMembresia([]*pb.Miembro{{NodoId: "DB2", Direccion: "10.0.0.7:50053"}})
+	if srv.dbClients[1] == pb.DynamoDBClient(nodos[1]) {
+		t.Fatal("al cambiar la dirección debía reconectar")
+	}
+	if direccion := srv.dbNodos[1].direccion; direccion != "10.0.0.7:50053" {
+		t.Fatalf("la dirección del nodo debía actualizarse, es %q", direccion)
+	}
+	if n := len(srv.dbClients); n != 3 {
+		t.Fatalf("N no debía cambiar: N=%d", n)
+	}
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	pb "broker_c1/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+// nodoDBPrueba es un nodo DB en memoria: guarda las ofertas tal como llegan
+// y aplica el compare-and-set de stock como los nodos reales. Rechaza las
+// próximas rechazosStock escrituras de stock (-1: todas).
+type nodoDBPrueba struct {
+	pb.DynamoDBClient
+
+	mu            sync.Mutex
+	ofertas       map[string]*pb.OfertaRequest
+	rechazosStock int
+}
+
+func nuevoNodoDBPrueba() *nodoDBPrueba {
+	return &nodoDBPrueba{ofertas: make(map[string]*pb.OfertaRequest)}
+}
+
+func (n *nodoDBPrueba) GuardarOferta(ctx context.Context, in *pb.OfertaRequest, opts ...grpc.CallOption) (*pb.AckResponse, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.ofertas[in.GetOfertaId()] = proto.Clone(in).(*pb.OfertaRequest)
+	return &pb.AckResponse{Exito: true}, nil
+}
+
+func (n *nodoDBPrueba) LeerOferta(ctx context.Context, in *pb.LeerOfertaRequest, opts ...grpc.CallOption) (*pb.LeerOfertaResponse, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	oferta, ok := n.ofertas[in.GetOfertaId()]
+	if !ok {
+		return &pb.LeerOfertaResponse{}, nil
+	}
+	return &pb.LeerOfertaResponse{Existe: !oferta.GetEliminada(), Oferta: proto.Clone(oferta).(*pb.OfertaRequest)}, nil
+}
+
+func (n *nodoDBPrueba) ActualizarStock(ctx context.Context, in *pb.ActualizarStockRequest, opts ...grpc.CallOption) (*pb.ActualizarStockResponse, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	oferta, ok := n.ofertas[in.GetOfertaId()]
+	if n.rechazosStock != 0 {
+		if n.rechazosStock > 0 {
+			n.rechazosStock--
+		}
+		return &pb.ActualizarStockResponse{Exito: false, Mensaje: "rechazada"}, nil
+	}
+	if !ok {
+		return &pb.ActualizarStockResponse{Exito: false, Mensaje: "rechazada"}, nil
+	}
+	aplicar := oferta.GetVersionStock() < in.GetVersion()
+	if aplicar {
+		oferta.Stock = in.GetStock()
+		oferta.VersionStock = in.GetVersion()
+		oferta.OperacionStock = in.GetOperacionId()
+	}
+	aceptada := aplicar || (oferta.GetVersionStock() == in.GetVersion() && oferta.GetOperacionStock() == in.GetOperacionId())
+	return &pb.ActualizarStockResponse{Exito: aceptada, Stock: oferta.GetStock(), Version: oferta.GetVersionStock()}, nil
+}
+
+func (n *nodoDBPrueba) rechazarStock(veces int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.rechazosStock = veces
+}
+
+func (n *nodoDBPrueba) oferta(id string) *pb.OfertaRequest {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if oferta, ok := n.ofertas[id]; ok {
+		return proto.Clone(oferta).(*pb.OfertaRequest)
+	}
+	return nil
+}
+
+// servidorConNodosPrueba arma un broker sin Raft que escribe en n nodos DB
+// en memoria.
+func servidorConNodosPrueba(t *testing.T, n int) (*server, []*nodoDBPrueba) {
+	t.Helper()
+
+	tax, err := nuevaTaxonomia(taxonomiaPorDefecto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	validador, err := nuevoMotorValidacion(configReglas{parametrosReglas: parametrosPorDefecto}, tax)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, _ := nuevoServer(nil, tax, validador)
+	t.Cleanup(func() {
+		for _, nodo := range srv.dbNodos {
+			if nodo.conexion != nil {
+				nodo.conexion.Close()
+			}
+		}
+	})
+
+	nodos := make([]*nodoDBPrueba, n)
+	for i := range nodos {
+		nodos[i] = nuevoNodoDBPrueba()
+		srv.dbClients = append(srv.dbClients, nodos[i])
+		srv.dbActivos = append(srv.dbActivos, true)
+		srv.dbNodos = append(srv.dbNodos, &nodoDB{id: fmt.Sprintf("DB%d", i+1)})
+		srv.ultimaEscrituraOK = append(srv.ultimaEscrituraOK, true)
+		srv.statsNodos = append(srv.statsNodos, &EstadisticasNodo{NodoID: fmt.Sprintf("DB%d", i+1), Activo: true})
+	}
+	return srv, nodos
+}
+
+func ofertaProductor(id string) *pb.OfertaRequest {
+	return &pb.OfertaRequest{
+		OfertaId:        id,
+		ProductoId:      "P-1",
+		Tienda:          "Riploy",
+		Categoria:       "Electrónica",
+		Producto:        "Audífonos",
+		PrecioDescuento: 45000,
+		Stock:           10,
+		Fecha:           time.Now().Format("2006-01-02"),
+		ClienteId:       "Riploy",
+		Timestamp:       time.Now().Unix(),
+	}
+}
+
+func TestOfertaNuevaNoSeGuardaComoLapida(t *testing.T) {
+	srv, nodos := servidorConNodosPrueba(t, 3)
+
+	oferta := ofertaProductor("R-lapida")
+	oferta.Eliminada = true
+	oferta.ConfirmadaPor = []string{"DB1", "DB2", "DB3"}
+
+	resp, err := srv.EnviarOferta(context.Background(), oferta)
+	if err != nil || !resp.GetExito() {
+		t.Fatalf("se esperaba aceptar la oferta, se obtuvo %v, %v", resp, err)
+	}
+
+	for i, nodo := range nodos {
+		guardada := nodo.oferta("R-lapida")
+		if guardada == nil {
+			t.Fatalf("DB%d no recibió la oferta", i+1)
+		}
+		if guardada.GetEliminada() || len(guardada.GetConfirmadaPor()) > 0 {
+			t.Fatalf("DB%d guardó la oferta como lápida: eliminada=%v confirmada_por=%v",
+				i+1, guardada.GetEliminada(), guardada.GetConfirmadaPor())
+		}
+	}
+
+	leida, err := srv.leerOfertaQuorum(context.Background(), "R-lapida")
+	if err != nil {
+		t.Fatalf("la oferta aceptada debe poder leerse: %v", err)
+	}
+	if leida.GetEliminada() {
+		t.Fatal("la lectura con quórum devolvió una lápida")
+	}
+}
+
+func TestOfertaNuevaNoTraeStockNiEventoDelCliente(t *testing.T) {
+	srv, nodos := servidorConNodosPrueba(t, 3)
+
+	oferta := ofertaProductor("R-stock")
+	oferta.VersionStock = 1000000
+	oferta.OperacionStock = "R-ajena"
+	oferta.Evento = eventoAgotado
+
+	resp, err := srv.EnviarOferta(context.Background(), oferta)
+	if err != nil || !resp.GetExito() {
+		t.Fatalf("se esperaba aceptar la oferta, se obtuvo %v, %v", resp, err)
+	}
+
+	for i, nodo := range nodos {
+		guardada := nodo.oferta("R-stock")
+		if guardada.GetVersionStock() != 0 || guardada.GetOperacionStock() != "" || guardada.GetEvento() != "" {
+			t.Fatalf("DB%d guardó campos del cliente: version_stock=%d operacion_stock=%q evento=%q",
+				i+1, guardada.GetVersionStock(), guardada.GetOperacionStock(), guardada.GetEvento())
+		}
+	}
+}
+
+func TestOfertaNuevaNoTraeVersionadoDelCliente(t *testing.T) {
+	srv, nodos := servidorConNodosPrueba(t, 3)
+
+	oferta := ofertaProductor("R-reloj")
+	oferta.RelojVectorial = map[string]int64{"DB1": 1000000, "DB2": 1000000}
+	oferta.Hermanas = []*pb.OfertaRequest{ofertaProductor("R-reloj")}
+	oferta.EscritaNs = time.Now().Add(24 * time.Hour).UnixNano()
+
+	resp, err := srv.EnviarOferta(context.Background(), oferta)
+	if err != nil || !resp.GetExito() {
+		t.Fatalf("se esperaba aceptar la oferta, se obtuvo %v, %v", resp, err)
+	}
+
+	for i, nodo := range nodos {
+		guardada := nodo.oferta("R-reloj")
+		if len(guardada.GetRelojVectorial()) > 0 || len(guardada.GetHermanas()) > 0 || guardada.GetEscritaNs() != 0 {
+			t.Fatalf("DB%d recibió el versionado del cliente: reloj=%v hermanas=%d escrita_ns=%d",
+				i+1, guardada.GetRelojVectorial(), len(guardada.GetHermanas()), guardada.GetEscritaNs())
+		}
+	}
+}
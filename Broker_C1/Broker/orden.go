@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+
+	pb "broker_c1/proto"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// La entrega ordenada funciona en dos niveles:
+//
+//  1. Al aceptar una oferta (antes de escribirla en los nodos DB) el líder le
+//     asigna una secuencia global replicada, que queda guardada con la oferta.
+//  2. Como las escrituras con quórum terminan en cualquier orden, el secuenciador
+//     retiene cada oferta hasta que todas las de secuencia menor se resolvieron
+//     y recién entonces la encola para los consumidores con entrega ordenada.
+//     Cada uno de ellos tiene un único remitente que entrega de a una oferta.
+//
+// El remitente numera las ofertas de cada consumidor (secuencia_consumidor)
+// con un correlativo sin saltos: si el consumidor ve un salto, se perdió una
+// oferta (quedó como carta muerta) y puede recuperarla con el histórico.
+
+// validarEntregaOrdenada revisa que entrega_ordenada no venga con un grupo:
+// los miembros se reparten las ofertas por el reparto del grupo, que no pasa
+// por el secuenciador, así que no habría orden que garantizar.
+func validarEntregaOrdenada(in *pb.RegistroConsumidorRequest) error {
+	if in.GetEntregaOrdenada() && in.GetGrupo() != "" {
+		return fmt.Errorf("entrega_ordenada no aplica a miembros de grupos")
+	}
+	return nil
+}
+
+// reservarSecuencia asigna la siguiente secuencia global. La reserva se
+// replica antes de usarse, así un nuevo líder nunca repite una secuencia.
+func (s *server) reservarSecuencia() (int64, error) {
+	s.reservaMutex.Lock()
+	defer s.reservaMutex.Unlock()
+
+	s.ultimaSecuenciaMutex.Lock()
+	secuencia := s.ultimaSecuencia + 1
+	s.ultimaSecuenciaMutex.Unlock()
+
+	if err := s.replicar(comando{Tipo: cmdSecuencia, Secuencia: secuencia}); err != nil {
+		return 0, err
+	}
+	s.secuenciador.reservada(secuencia)
+	return secuencia, nil
+}
+
+func (s *server) aplicarSecuencia(secuencia int64) {
+	s.ultimaSecuenciaMutex.Lock()
+	if secuencia > s.ultimaSecuencia {
+		s.ultimaSecuencia = secuencia
+	}
+	s.ultimaSecuenciaMutex.Unlock()
+}
+
+// secuenciador libera las ofertas en orden de secuencia global.
+type secuenciador struct {
+	mutex      sync.Mutex
+	siguiente  int64                       // próxima secuencia a liberar (0 = aún no se reservó ninguna)
+	ultima     int64                       // última secuencia reservada por esta réplica
+	pendientes map[int64]*pb.OfertaRequest // nil = la oferta no se distribuye (sin quorum)
+}
+
+func nuevoSecuenciador() *secuenciador {
+	return &secuenciador{pendientes: make(map[int64]*pb.OfertaRequest)}
+}
+
+// reservada anota una secuencia reservada por esta réplica. Las secuencias
+// intermedias las reservó otro líder, así que se dan por resueltas.
+func (q *secuenciador) reservada(secuencia int64) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.siguiente == 0 {
+		q.siguiente = secuencia
+	}
+	for i := q.ultima + 1; q.ultima != 0 && i < secuencia; i++ {
+		q.pendientes[i] = nil
+	}
+	q.ultima = secuencia
+}
+
+// liberarSecuencia resuelve una secuencia reservada: oferta es la oferta
+// aceptada o nil si no llegó a distribuirse. Toda secuencia reservada debe
+// liberarse, o las siguientes quedan retenidas.
+func (s *server) liberarSecuencia(secuencia int64, oferta *pb.OfertaRequest) {
+	q := s.secuenciador
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.pendientes[secuencia] = oferta
+	for {
+		aceptada, ok := q.pendientes[q.siguiente]
+		if !ok {
+			return
+		}
+		delete(q.pendientes, q.siguiente)
+		q.siguiente++
+		if aceptada != nil {
+			s.distribuirOrdenada(aceptada)
+		}
+	}
+}
+
+// colaOrdenada es la cola de ofertas pendientes de un consumidor con entrega
+// ordenada. La atiende un solo remitente.
+type colaOrdenada struct {
+	mutex      sync.Mutex
+	pendientes []*pb.OfertaRequest
+	ultima     int64 // último secuencia_consumidor asignado por este líder
+	aviso      chan struct{}
+}
+
+// distribuirOrdenada encola la oferta para cada consumidor con entrega
+// ordenada que la acepta. No bloquea.
+func (s *server) distribuirOrdenada(oferta *pb.OfertaRequest) {
+	s.consumidoresMutex.RLock()
+	var destinos []string
+	for _, consumidor := range s.consumidores {
+		if consumidor.EntregaOrdenada && consumidor.Grupo == "" && consumidor.Activo && s.ofertaCumpleFiltros(oferta, consumidor) {
+			destinos = append(destinos, consumidor.ID)
+		}
+	}
+	s.consumidoresMutex.RUnlock()
+
+	for _, consumidorID := range destinos {
+		cola := s.colaOrdenada(consumidorID)
+		cola.mutex.Lock()
+		cola.pendientes = append(cola.pendientes, oferta)
+		cola.mutex.Unlock()
+
+		select {
+		case cola.aviso <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// colaOrdenada devuelve la cola del consumidor, creándola junto con su
+// remitente la primera vez.
+func (s *server) colaOrdenada(consumidorID string) *colaOrdenada {
+	s.colasOrdenadasMutex.Lock()
+	defer s.colasOrdenadasMutex.Unlock()
+
+	cola, ok := s.colasOrdenadas[consumidorID]
+	if !ok {
+		cola = &colaOrdenada{aviso: make(chan struct{}, 1)}
+		s.colasOrdenadas[consumidorID] = cola
+		go s.remitenteOrdenado(consumidorID, cola)
+	}
+	return cola
+}
+
+// remitenteOrdenado entrega las ofertas de la cola de a una, en orden.
+func (s *server) remitenteOrdenado(consumidorID string, cola *colaOrdenada) {
+	for range cola.aviso {
+		for {
+			cola.mutex.Lock()
+			if len(cola.pendientes) == 0 {
+				cola.mutex.Unlock()
+				break
+			}
+			oferta := cola.pendientes[0]
+			cola.pendientes = cola.pendientes[1:]
+			cola.mutex.Unlock()
+
+			s.entregarOrdenada(consumidorID, cola, oferta)
+		}
+	}
+}
+
+func (s *server) entregarOrdenada(consumidorID string, cola *colaOrdenada, oferta *pb.OfertaRequest) {
+	// El consumidor se busca en cada entrega porque puede haberse vuelto a registrar
+	s.consumidoresMutex.RLock()
+	consumidor, existe := s.consumidores[consumidorID]
+	var entregada int64
+	var activo bool
+	if existe {
+		entregada = consumidor.SecuenciaEntregada
+		activo = consumidor.Activo
+	}
+	s.consumidoresMutex.RUnlock()
+	if !existe {
+		return
+	}
+
+	// El correlativo continúa desde lo último replicado (también tras un
+	// cambio de líder) y avanza aunque la entrega falle, para que el
+	// consumidor note el hueco.
+	if entregada > cola.ultima {
+		cola.ultima = entregada
+	}
+	cola.ultima++
+
+	copia := proto.Clone(oferta).(*pb.OfertaRequest)
+	copia.SecuenciaConsumidor = cola.ultima
+
+	if !activo {
+		s.registrarCartaMuerta("", copia, etapaEntrega,
+			fmt.Sprintf("consumidor %s inactivo", consumidorID), nil, consumidorID)
+		return
+	}
+
+	log.Printf("[BROKER] Entrega ordenada a %s: oferta %s (secuencia %d, #%d)",
+		consumidorID, copia.GetOfertaId(), copia.GetSecuencia(), copia.GetSecuenciaConsumidor())
+	s.enviarAConsumidor(context.Background(), consumidor, copia)
+}
+
+// registrarSecuenciaEntregada replica el correlativo de una entrega ordenada
+// (las entregas comunes no llevan correlativo y no replican nada).
+func (s *server) registrarSecuenciaEntregada(consumidorID string, secuencia int64) {
+	if secuencia == 0 {
+		return
+	}
+	s.replicar(comando{Tipo: cmdSecuenciaEntregada, ConsumidorID: consumidorID, Secuencia: secuencia})
+}
+
+// aplicarSecuenciaEntregada registra el último correlativo usado con un
+// consumidor (entregado o enviado a cartas muertas).
+func (s *server) aplicarSecuenciaEntregada(consumidorID string, secuencia int64) {
+	if secuencia == 0 {
+		return
+	}
+	s.consumidoresMutex.Lock()
+	if consumidor, ok := s.consumidores[consumidorID]; ok && secuencia > consumidor.SecuenciaEntregada {
+		consumidor.SecuenciaEntregada = secuencia
+	}
+	s.consumidoresMutex.Unlock()
+}
@@ -0,0 +1,687 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	pb "broker_c1/proto"
+)
+
+// Cantidad de comandos aplicados entre dos snapshots del estado de control
+const intervaloSnapshot = 1000
+
+// registroJournal es una línea del journal: un comando aplicado junto con su
+// posición en el log (índice y término de Raft, o un contador local sin Raft).
+type registroJournal struct {
+	Indice  int64           `json:"indice"`
+	Termino int64           `json:"termino"`
+	Comando json.RawMessage `json:"comando,omitempty"`
+}
+
+type consumidorPersistido struct {
+	ID                 string        `json:"id"`
+	Categorias         []string      `json:"categorias"`
+	Tiendas            []string      `json:"tiendas"`
+	PrecioMax          int32         `json:"precio_max"`
+	DireccionGRPC      string        `json:"direccion_grpc"`
+	WebhookURL         string        `json:"webhook_url,omitempty"`
+	WebhookSecreto     string        `json:"webhook_secreto,omitempty"`
+	Grupo              string        `json:"grupo,omitempty"`
+	EntregaOrdenada    bool          `json:"entrega_ordenada,omitempty"`
+	SecuenciaEntregada int64         `json:"secuencia_entregada,omitempty"`
+	Seguimientos       []seguimiento `json:"seguimientos,omitempty"`
+	ResumenSegundos    int32         `json:"resumen_segundos,omitempty"`
+	ResumenMaxOfertas  int32         `json:"resumen_max_ofertas,omitempty"`
+	Activo             bool          `json:"activo"`
+}
+
+// estadoPersistido es el snapshot del estado de control del broker.
+type estadoPersistido struct {
+	Indice            int64                              `json:"indice"`
+	Termino           int64                              `json:"termino"`
+	Productores       []string                           `json:"productores"`
+	Consumidores      []consumidorPersistido             `json:"consumidores"`
+	OfertasProcesadas []string                           `json:"ofertas_procesadas"`
+	StatsProductores  map[string]*EstadisticasProductor  `json:"stats_productores"`
+	StatsNodos        []*EstadisticasNodo                `json:"stats_nodos"`
+	StatsConsumidores map[string]*EstadisticasConsumidor `json:"stats_consumidores"`
+	CartasMuertas     []*CartaMuerta                     `json:"cartas_muertas,omitempty"`
+	UltimaSecuencia   int64                              `json:"ultima_secuencia,omitempty"`
+	Reservas          []*Reserva                         `json:"reservas,omitempty"`
+	ReversionesStock  []*ReversionStock                  `json:"reversiones_stock,omitempty"`
+	PreciosMinimos    map[string]int32                   `json:"precios_minimos,omitempty"`
+}
+
+type estadoRaftPersistido struct {
+	Termino  int64  `json:"termino"`
+	VotoPara string `json:"voto_para"`
+}
+
+// persistenciaBroker guarda en disco el estado de control como un snapshot
+// periódico más un journal de los comandos aplicados desde ese snapshot. Con
+// Raft guarda además el término y voto, y el log de entradas agregadas (aún
+// sin aplicar) que la réplica ya confirmó al líder.
+type persistenciaBroker struct {
+	archivoSnapshot string
+	archivoJournal  string
+	archivoRaft     string
+	archivoLogRaft  string
+
+	journal *os.File
+	logRaft *os.File
+}
+
+func nuevaPersistenciaBroker(dir, prefijo string) (*persistenciaBroker, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	p := &persistenciaBroker{
+		archivoSnapshot: filepath.Join(dir, prefijo+"_snapshot.json"),
+		archivoJournal:  filepath.Join(dir, prefijo+"_journal.jsonl"),
+		archivoRaft:     filepath.Join(dir, prefijo+"_raft.json"),
+		archivoLogRaft:  filepath.Join(dir, prefijo+"_log.jsonl"),
+	}
+	return p, nil
+}
+
+func (p *persistenciaBroker) abrirJournal(truncar bool) error {
+	if p.journal != nil {
+		p.journal.Close()
+	}
+
+	flags := os.O_APPEND | os.O_CREATE | os.O_WRONLY
+	if truncar {
+		flags |= os.O_TRUNC
+	}
+	file, err := os.OpenFile(p.archivoJournal, flags, 0644)
+	if err != nil {
+		return err
+	}
+	p.journal = file
+	return nil
+}
+
+func (p *persistenciaBroker) agregarAlJournal(registro registroJournal) error {
+	return agregarRegistros(p.journal, []registroJournal{registro})
+}
+
+// serializarRegistros devuelve una línea JSON por registro.
+func serializarRegistros(registros []registroJournal) ([]byte, error) {
+	var datos []byte
+	for _, registro := range registros {
+		linea, err := json.Marshal(registro)
+		if err != nil {
+			return nil, err
+		}
+		datos = append(append(datos, linea...), '\n')
+	}
+	return datos, nil
+}
+
+// agregarRegistros escribe los registros y espera a que lleguen a disco.
+func agregarRegistros(file *os.File, registros []registroJournal) error {
+	datos, err := serializarRegistros(registros)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(datos); err != nil {
+		return err
+	}
+	return file.Sync()
+}
+
+func registrosDeEntradas(entradas []*pb.EntradaLog) []registroJournal {
+	registros := make([]registroJournal, len(entradas))
+	for i, entrada := range entradas {
+		registros[i] = registroJournal{Indice: entrada.GetIndice(), Termino: entrada.GetTermino(), Comando: entrada.GetComando()}
+	}
+	return registros
+}
+
+func (p *persistenciaBroker) abrirLogRaft() error {
+	file, err := os.OpenFile(p.archivoLogRaft, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	p.logRaft = file
+	return nil
+}
+
+// agregarAlLogRaft guarda entradas del log de Raft. Al cargar, una entrada
+// reemplaza a las anteriores con su mismo índice o uno mayor.
+func (p *persistenciaBroker) agregarAlLogRaft(entradas []*pb.EntradaLog) error {
+	return agregarRegistros(p.logRaft, registrosDeEntradas(entradas))
+}
+
+// reescribirLogRaft deja en el log de Raft solo las entradas dadas.
+func (p *persistenciaBroker) reescribirLogRaft(entradas []*pb.EntradaLog) error {
+	datos, err := serializarRegistros(registrosDeEntradas(entradas))
+	if err != nil {
+		return err
+	}
+	if p.logRaft != nil {
+		p.logRaft.Close()
+	}
+	if err := escribirArchivo(p.archivoLogRaft, datos); err != nil {
+		p.abrirLogRaft()
+		return err
+	}
+	return p.abrirLogRaft()
+}
+
+// escribirArchivo reemplaza el archivo de forma atómica (temporal + rename).
+func escribirArchivo(ruta string, datos []byte) error {
+	tmp := ruta + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(datos); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, ruta)
+}
+
+// guardarSnapshot escribe el snapshot y deja el journal vacío.
+func (p *persistenciaBroker) guardarSnapshot(datos []byte) error {
+	if err := escribirArchivo(p.archivoSnapshot, datos); err != nil {
+		return err
+	}
+	return p.abrirJournal(true)
+}
+
+// cargar lee el snapshot (si existe) y los registros del journal posteriores.
+func (p *persistenciaBroker) cargar() (*estadoPersistido, []registroJournal, error) {
+	var estado *estadoPersistido
+
+	datos, err := os.ReadFile(p.archivoSnapshot)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, nil, err
+	}
+	if err == nil {
+		estado = &estadoPersistido{}
+		if err := json.Unmarshal(datos, estado); err != nil {
+			return nil, nil, fmt.Errorf("snapshot corrupto: %v", err)
+		}
+	}
+
+	file, err := os.Open(p.archivoJournal)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return estado, nil, nil
+		}
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	var registros []registroJournal
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var registro registroJournal
+		if err := json.Unmarshal(scanner.Bytes(), &registro); err != nil {
+			// Línea incompleta por una caída a mitad de escritura: se descarta el resto
+			log.Printf("[BROKER] Journal truncado tras %d registros: %v", len(registros), err)
+			break
+		}
+		if estado != nil && registro.Indice <= estado.Indice {
+			continue
+		}
+		registros = append(registros, registro)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	return estado, registros, nil
+}
+
+func (p *persistenciaBroker) guardarEstadoRaft(estado estadoRaftPersistido) error {
+	datos, err := json.Marshal(estado)
+	if err != nil {
+		return err
+	}
+	return escribirArchivo(p.archivoRaft, datos)
+}
+
+// cargarLogRaft devuelve las entradas guardadas que siguen, sin huecos, a
+// desde (la última entrada aplicada).
+func (p *persistenciaBroker) cargarLogRaft(desde int64) ([]*pb.EntradaLog, error) {
+	file, err := os.Open(p.archivoLogRaft)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	var entradas []*pb.EntradaLog
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var registro registroJournal
+		if err := json.Unmarshal(scanner.Bytes(), &registro); err != nil {
+			log.Printf("[BROKER] Log de Raft truncado tras %d entradas: %v", len(entradas), err)
+			break
+		}
+		// Una entrada reescrita por un líder nuevo descarta las siguientes
+		for len(entradas) > 0 && entradas[len(entradas)-1].GetIndice() >= registro.Indice {
+			entradas = entradas[:len(entradas)-1]
+		}
+		entradas = append(entradas, &pb.EntradaLog{Termino: registro.Termino, Indice: registro.Indice, Comando: registro.Comando})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var pendientes []*pb.EntradaLog
+	for _, entrada := range entradas {
+		if entrada.GetIndice() == desde+1+int64(len(pendientes)) {
+			pendientes = append(pendientes, entrada)
+		} else if entrada.GetIndice() > desde {
+			break
+		}
+	}
+	return pendientes, nil
+}
+
+func (p *persistenciaBroker) cargarEstadoRaft() (estadoRaftPersistido, error) {
+	var estado estadoRaftPersistido
+	datos, err := os.ReadFile(p.archivoRaft)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return estado, nil
+		}
+		return estado, err
+	}
+	err = json.Unmarshal(datos, &estado)
+	return estado, err
+}
+
+// habilitarPersistencia restaura el estado de control guardado en dir y
+// empieza a registrar en disco cada comando aplicado. Si el broker es una
+// réplica Raft, habilitarRaft debe llamarse antes.
+func (s *server) habilitarPersistencia(dir string) error {
+	prefijo := "broker"
+	if s.raft != nil {
+		prefijo = fmt.Sprintf("broker_%s", s.raft.id)
+	}
+
+	p, err := nuevaPersistenciaBroker(dir, prefijo)
+	if err != nil {
+		return err
+	}
+
+	estado, registros, err := p.cargar()
+	if err != nil {
+		return err
+	}
+
+	s.persistenciaMutex.Lock()
+	defer s.persistenciaMutex.Unlock()
+
+	var indiceSnapshot, terminoSnapshot int64
+	if estado != nil {
+		s.restaurarEstado(estado)
+		indiceSnapshot, terminoSnapshot = estado.Indice, estado.Termino
+	}
+
+	entradas := make([]*pb.EntradaLog, 0, len(registros))
+	for _, registro := range registros {
+		if len(registro.Comando) > 0 {
+			s.aplicarComandoSerializado(registro.Comando)
+		}
+		s.indiceAplicado = registro.Indice
+		s.terminoAplicado = registro.Termino
+		entradas = append(entradas, &pb.EntradaLog{
+			Termino: registro.Termino,
+			Indice:  registro.Indice,
+			Comando: registro.Comando,
+		})
+	}
+
+	if estado != nil || len(registros) > 0 {
+		log.Printf("[BROKER] Estado restaurado desde disco: snapshot hasta %d + %d comandos del journal",
+			indiceSnapshot, len(registros))
+	}
+
+	if s.raft != nil {
+		estadoRaft, err := p.cargarEstadoRaft()
+		if err != nil {
+			return err
+		}
+		pendientes, err := p.cargarLogRaft(s.indiceAplicado)
+		if err != nil {
+			return err
+		}
+		s.raft.restaurar(estadoRaft.Termino, estadoRaft.VotoPara, indiceSnapshot, terminoSnapshot, entradas, pendientes)
+		if err := p.abrirLogRaft(); err != nil {
+			return err
+		}
+	}
+
+	if err := p.abrirJournal(false); err != nil {
+		return err
+	}
+	s.persistencia = p
+	return nil
+}
+
+// registrarAplicado anota en el journal un comando recién aplicado y, cada
+// intervaloSnapshot comandos, toma un snapshot. Devuelve el índice del
+// snapshot tomado o 0. Debe llamarse con s.persistenciaMutex tomado.
+func (s *server) registrarAplicado(indice, termino int64, datos []byte) int64 {
+	s.indiceAplicado = indice
+	s.terminoAplicado = termino
+
+	if s.persistencia == nil {
+		return 0
+	}
+
+	err := s.persistencia.agregarAlJournal(registroJournal{Indice: indice, Termino: termino, Comando: datos})
+	if err != nil {
+		log.Printf("[BROKER] Error escribiendo journal: %v", err)
+	}
+
+	s.comandosDesdeSnapshot++
+	if s.comandosDesdeSnapshot < intervaloSnapshot {
+		return 0
+	}
+
+	if err := s.tomarSnapshot(); err != nil {
+		log.Printf("[BROKER] Error guardando snapshot: %v", err)
+		return 0
+	}
+	return indice
+}
+
+// tomarSnapshot debe llamarse con s.persistenciaMutex tomado.
+func (s *server) tomarSnapshot() error {
+	datos, err := json.Marshal(s.capturarEstado())
+	if err != nil {
+		return err
+	}
+	if err := s.persistencia.guardarSnapshot(datos); err != nil {
+		return err
+	}
+	s.comandosDesdeSnapshot = 0
+	log.Printf("[BROKER] Snapshot del estado guardado (índice %d)", s.indiceAplicado)
+	return nil
+}
+
+// aplicarEntrada implementa almacenRaft: aplica y registra una entrada confirmada.
+func (s *server) aplicarEntrada(entrada *pb.EntradaLog) {
+	s.persistenciaMutex.Lock()
+	if entrada.GetIndice() <= s.indiceAplicado {
+		// Ya incluida en un snapshot instalado
+		s.persistenciaMutex.Unlock()
+		return
+	}
+	if len(entrada.GetComando()) > 0 {
+		s.aplicarComandoSerializado(entrada.GetComando())
+	}
+	indiceSnapshot := s.registrarAplicado(entrada.GetIndice(), entrada.GetTermino(), entrada.GetComando())
+	s.persistenciaMutex.Unlock()
+
+	if indiceSnapshot > 0 {
+		s.raft.compactar(indiceSnapshot)
+	}
+}
+
+func (s *server) guardarEstadoRaft(termino int64, votoPara string) error {
+	if s.persistencia == nil {
+		return nil
+	}
+	return s.persistencia.guardarEstadoRaft(estadoRaftPersistido{Termino: termino, VotoPara: votoPara})
+}
+
+// guardarEntradasRaft y reescribirEntradasRaft se llaman con el mutex del
+// nodo Raft tomado, que ordena las escrituras al log.
+func (s *server) guardarEntradasRaft(entradas []*pb.EntradaLog) error {
+	if s.persistencia == nil {
+		return nil
+	}
+	return s.persistencia.agregarAlLogRaft(entradas)
+}
+
+func (s *server) reescribirEntradasRaft(entradas []*pb.EntradaLog) error {
+	if s.persistencia == nil {
+		return nil
+	}
+	return s.persistencia.reescribirLogRaft(entradas)
+}
+
+func (s *server) snapshotRaft() (int64, int64, []byte) {
+	s.persistenciaMutex.Lock()
+	defer s.persistenciaMutex.Unlock()
+
+	estado := s.capturarEstado()
+	datos, err := json.Marshal(estado)
+	if err != nil {
+		log.Printf("[BROKER] Error serializando snapshot: %v", err)
+	}
+	return estado.Indice, estado.Termino, datos
+}
+
+func (s *server) instalarSnapshotRaft(indice, termino int64, datos []byte) error {
+	estado := &estadoPersistido{}
+	if err := json.Unmarshal(datos, estado); err != nil {
+		return err
+	}
+
+	s.persistenciaMutex.Lock()
+	defer s.persistenciaMutex.Unlock()
+
+	s.restaurarEstado(estado)
+	s.indiceAplicado = indice
+	s.terminoAplicado = termino
+
+	if s.persistencia != nil {
+		if err := s.persistencia.guardarSnapshot(datos); err != nil {
+			return err
+		}
+		s.comandosDesdeSnapshot = 0
+	}
+	return nil
+}
+
+// capturarEstado copia el estado de control. Debe llamarse con
+// s.persistenciaMutex tomado para que el índice corresponda al contenido.
+func (s *server) capturarEstado() *estadoPersistido {
+	estado := &estadoPersistido{
+		Indice:            s.indiceAplicado,
+		Termino:           s.terminoAplicado,
+		StatsProductores:  make(map[string]*EstadisticasProductor),
+		StatsConsumidores: make(map[string]*EstadisticasConsumidor),
+	}
+
+	s.productoresMutex.Lock()
+	estado.Productores = append([]string{}, s.productores...)
+	s.productoresMutex.Unlock()
+
+	s.consumidoresMutex.RLock()
+	for _, c := range s.consumidores {
+		estado.Consumidores = append(estado.Consumidores, consumidorPersistido{
+			ID:                 c.ID,
+			Categorias:         c.Categorias,
+			Tiendas:            c.Tiendas,
+			PrecioMax:          c.PrecioMax,
+			DireccionGRPC:      c.DireccionGRPC,
+			WebhookURL:         c.WebhookURL,
+			WebhookSecreto:     c.WebhookSecreto,
+			Grupo:              c.Grupo,
+			EntregaOrdenada:    c.EntregaOrdenada,
+			SecuenciaEntregada: c.SecuenciaEntregada,
+			Seguimientos:       c.Seguimientos,
+			ResumenSegundos:    c.ResumenSegundos,
+			ResumenMaxOfertas:  c.ResumenMaxOfertas,
+			Activo:             c.Activo,
+		})
+	}
+	s.consumidoresMutex.RUnlock()
+
+	s.ofertasProcesakdasMutex.Lock()
+	for ofertaID := range s.ofertasProcesadas {
+		estado.OfertasProcesadas = append(estado.OfertasProcesadas, ofertaID)
+	}
+	s.ofertasProcesakdasMutex.Unlock()
+
+	s.statsMutex.Lock()
+	for id, stats := range s.statsProductores {
+		copia := *stats
+		estado.StatsProductores[id] = &copia
+	}
+	for _, stats := range s.statsNodos {
+		copia := *stats
+		estado.StatsNodos = append(estado.StatsNodos, &copia)
+	}
+	for id, stats := range s.statsConsumidores {
+		copia := *stats
+		estado.StatsConsumidores[id] = &copia
+	}
+	s.statsMutex.Unlock()
+
+	s.cartasMuertasMutex.Lock()
+	for _, carta := range s.cartasMuertas {
+		copia := *carta
+		estado.CartasMuertas = append(estado.CartasMuertas, &copia)
+	}
+	s.cartasMuertasMutex.Unlock()
+
+	s.ultimaSecuenciaMutex.Lock()
+	estado.UltimaSecuencia = s.ultimaSecuencia
+	s.ultimaSecuenciaMutex.Unlock()
+
+	s.reservasMutex.Lock()
+	for _, reserva := range s.reservas {
+		copia := *reserva
+		estado.Reservas = append(estado.Reservas, &copia)
+	}
+	for _, reversion := range s.reversionesStock {
+		copia := *reversion
+		estado.ReversionesStock = append(estado.ReversionesStock, &copia)
+	}
+	s.reservasMutex.Unlock()
+
+	s.preciosMinimosMutex.Lock()
+	estado.PreciosMinimos = make(map[string]int32, len(s.preciosMinimos))
+	for productoID, precio := range s.preciosMinimos {
+		estado.PreciosMinimos[productoID] = precio
+	}
+	s.preciosMinimosMutex.Unlock()
+
+	return estado
+}
+
+// restaurarEstado reemplaza el estado de control por el de un snapshot y
+// reconecta con los consumidores registrados.
+func (s *server) restaurarEstado(estado *estadoPersistido) {
+	s.productoresMutex.Lock()
+	s.productores = append([]string{}, estado.Productores...)
+	s.productoresMutex.Unlock()
+
+	consumidores := make(map[string]*ConsumidorInfo)
+	for _, c := range estado.Consumidores {
+		cliente, conexion, err := s.nuevoClienteConsumidor(c.ID, c.DireccionGRPC, c.WebhookURL, c.WebhookSecreto)
+		if err != nil {
+			log.Printf("[BROKER] Error reconectando a consumidor %s: %v", c.ID, err)
+			continue
+		}
+		consumidores[c.ID] = &ConsumidorInfo{
+			ID:                 c.ID,
+			Categorias:         c.Categorias,
+			Tiendas:            c.Tiendas,
+			PrecioMax:          c.PrecioMax,
+			DireccionGRPC:      c.DireccionGRPC,
+			WebhookURL:         c.WebhookURL,
+			WebhookSecreto:     c.WebhookSecreto,
+			Grupo:              c.Grupo,
+			EntregaOrdenada:    c.EntregaOrdenada,
+			SecuenciaEntregada: c.SecuenciaEntregada,
+			Seguimientos:       prepararSeguimientos(c.Seguimientos),
+			ResumenSegundos:    c.ResumenSegundos,
+			ResumenMaxOfertas:  c.ResumenMaxOfertas,
+			Cliente:            cliente,
+			Conexion:           conexion,
+			Activo:             c.Activo,
+		}
+	}
+	s.consumidoresMutex.Lock()
+	anteriores := s.consumidores
+	s.consumidores = consumidores
+	s.consumidoresMutex.Unlock()
+
+	for _, c := range anteriores {
+		if c.Conexion != nil {
+			c.Conexion.Close()
+		}
+	}
+
+	s.ofertasProcesakdasMutex.Lock()
+	s.ofertasProcesadas = make(map[string]bool, len(estado.OfertasProcesadas))
+	for _, ofertaID := range estado.OfertasProcesadas {
+		s.ofertasProcesadas[ofertaID] = true
+	}
+	s.ofertasProcesakdasMutex.Unlock()
+
+	s.statsMutex.Lock()
+	s.statsProductores = make(map[string]*EstadisticasProductor)
+	for id, stats := range estado.StatsProductores {
+		s.statsProductores[id] = stats
+	}
+	// El estado Activo de los nodos lo determina la conexión actual, no el snapshot
+	for i, stats := range estado.StatsNodos {
+		if i < len(s.statsNodos) {
+			s.statsNodos[i].EscriturasExitosas = stats.EscriturasExitosas
+			s.statsNodos[i].EscriturasFallidas = stats.EscriturasFallidas
+		}
+	}
+	s.statsConsumidores = make(map[string]*EstadisticasConsumidor)
+	for id, stats := range estado.StatsConsumidores {
+		s.statsConsumidores[id] = stats
+	}
+	s.statsMutex.Unlock()
+
+	s.cartasMuertasMutex.Lock()
+	s.cartasMuertas = make(map[string]*CartaMuerta, len(estado.CartasMuertas))
+	for _, carta := range estado.CartasMuertas {
+		s.cartasMuertas[carta.ID] = carta
+	}
+	s.cartasMuertasMutex.Unlock()
+
+	s.ultimaSecuenciaMutex.Lock()
+	s.ultimaSecuencia = estado.UltimaSecuencia
+	s.ultimaSecuenciaMutex.Unlock()
+
+	s.reservasMutex.Lock()
+	s.reservas = make(map[string]*Reserva, len(estado.Reservas))
+	for _, reserva := range estado.Reservas {
+		s.reservas[reserva.ID] = reserva
+	}
+	s.reversionesStock = make(map[string]*ReversionStock, len(estado.ReversionesStock))
+	for _, reversion := range estado.ReversionesStock {
+		s.reversionesStock[reversion.OfertaID] = reversion
+	}
+	s.reservasMutex.Unlock()
+
+	s.preciosMinimosMutex.Lock()
+	s.preciosMinimos = make(map[string]int32, len(estado.PreciosMinimos))
+	for productoID, precio := range estado.PreciosMinimos {
+		s.preciosMinimos[productoID] = precio
+	}
+	s.preciosMinimosMutex.Unlock()
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+
+	pb "broker_c1/proto"
+)
+
+func TestLogRaftReemplazaEntradasDeOtroLider(t *testing.T) {
+	p, err := nuevaPersistenciaBroker(t.TempDir(), "broker_B1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.abrirLogRaft(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Índices 1-4 del término 1; un líder nuevo reescribe desde el 3
+	var entradas []*pb.EntradaLog
+	for i := int64(1); i <= 4; i++ {
+		entradas = append(entradas, &pb.EntradaLog{Termino: 1, Indice: i, Comando: []byte(`{"tipo":"secuencia"}`)})
+	}
+	if err := p.agregarAlLogRaft(entradas); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.agregarAlLogRaft([]*pb.EntradaLog{{Termino: 2, Indice: 3}}); err != nil {
+		t.Fatal(err)
+	}
+
+	pendientes, err := p.cargarLogRaft(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pendientes) != 2 {
+		t.Fatalf("se esperaban 2 entradas pendientes, se obtuvieron %d", len(pendientes))
+	}
+	if pendientes[0].GetIndice() != 2 || pendientes[1].GetIndice() != 3 || pendientes[1].GetTermino() != 2 {
+		t.Fatalf("entradas pendientes inesperadas: %v", pendientes)
+	}
+
+	// Tras un snapshot en el índice 2 solo queda la entrada 3
+	if err := p.reescribirLogRaft(pendientes[1:]); err != nil {
+		t.Fatal(err)
+	}
+	pendientes, err = p.cargarLogRaft(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pendientes) != 1 || pendientes[0].GetIndice() != 3 {
+		t.Fatalf("tras reescribir se esperaba solo la entrada 3: %v", pendientes)
+	}
+}
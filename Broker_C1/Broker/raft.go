@@ -0,0 +1,745 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+
+	pb "broker_c1/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+const (
+	intervaloTickRaft    = 10 * time.Millisecond
+	intervaloLatidoRaft  = 50 * time.Millisecond
+	timeoutEleccionMin   = 300 * time.Millisecond
+	timeoutEleccionDelta = 300 * time.Millisecond
+	timeoutRPCRaft       = 200 * time.Millisecond
+)
+
+var (
+	errNoLider           = errors.New("esta réplica no es el líder")
+	errEntradaDescartada = errors.New("la entrada fue reemplazada por otro líder")
+)
+
+type estadoRaft int
+
+const (
+	seguidor estadoRaft = iota
+	candidato
+	lider
+)
+
+func (e estadoRaft) String() string {
+	switch e {
+	case candidato:
+		return "CANDIDATO"
+	case lider:
+		return "LÍDER"
+	default:
+		return "SEGUIDOR"
+	}
+}
+
+// almacenRaft es lo que la réplica necesita de su máquina de estados para
+// aplicar entradas, persistir su estado y transferir snapshots.
+type almacenRaft interface {
+	// aplicarEntrada recibe cada entrada confirmada, en orden (incluidas las
+	// entradas vacías del cambio de líder).
+	aplicarEntrada(entrada *pb.EntradaLog)
+	// guardarEstadoRaft persiste el término actual y el voto emitido. Hasta
+	// que no devuelve nil la réplica no puede votar ni hacer campaña.
+	guardarEstadoRaft(termino int64, votoPara string) error
+	// guardarEntradasRaft persiste entradas recién agregadas al log, antes de
+	// contarlas para el quórum. Una entrada con un índice ya guardado
+	// reemplaza a esa y a todas las siguientes.
+	guardarEntradasRaft(entradas []*pb.EntradaLog) error
+	// reescribirEntradasRaft reemplaza las entradas guardadas por las que
+	// siguen a un snapshot.
+	reescribirEntradasRaft(entradas []*pb.EntradaLog) error
+	// snapshotRaft devuelve un snapshot del estado aplicado (índice, término y datos).
+	snapshotRaft() (int64, int64, []byte)
+	// instalarSnapshotRaft reemplaza el estado por el de un snapshot del líder.
+	instalarSnapshotRaft(indice, termino int64, datos []byte) error
+}
+
+// nodoRaft replica el estado de control del broker entre réplicas.
+// Cada comando confirmado se entrega, en orden, al almacén.
+type nodoRaft struct {
+	pb.UnimplementedRaftServer
+
+	id          string
+	direcciones map[string]string // id de réplica -> dirección gRPC
+	peers       map[string]pb.RaftClient
+	conexiones  []*grpc.ClientConn
+	almacen     almacenRaft
+
+	mu              sync.Mutex
+	aplicarCond     *sync.Cond
+	estado          estadoRaft
+	termino         int64
+	votoPara        string
+	liderID         string
+	log             []*pb.EntradaLog // log[0] es la última entrada incluida en el snapshot
+	commitIndex     int64
+	ultimoAplicado  int64
+	siguienteIndice map[string]int64
+	indiceReplicado map[string]int64
+	esperas         map[int64]chan int64
+
+	ultimoContacto  time.Time
+	ultimoLatido    time.Time
+	timeoutEleccion time.Duration
+
+	detener chan struct{}
+}
+
+// nuevoNodoRaft crea una réplica. direcciones debe incluir a todas las
+// réplicas del clúster, incluida esta misma.
+func nuevoNodoRaft(id string, direcciones map[string]string, almacen almacenRaft) *nodoRaft {
+	n := &nodoRaft{
+		id:              id,
+		direcciones:     direcciones,
+		peers:           make(map[string]pb.RaftClient),
+		almacen:         almacen,
+		log:             []*pb.EntradaLog{{Termino: 0, Indice: 0}},
+		siguienteIndice: make(map[string]int64),
+		indiceReplicado: make(map[string]int64),
+		esperas:         make(map[int64]chan int64),
+		ultimoContacto:  time.Now(),
+		timeoutEleccion: timeoutAleatorio(),
+		detener:         make(chan struct{}),
+	}
+	n.aplicarCond = sync.NewCond(&n.mu)
+
+	for peerID, addr := range direcciones {
+		if peerID == id {
+			continue
+		}
+		// Reintentos de conexión cortos para detectar pronto a una réplica que vuelve
+		conn, err := grpc.Dial(addr,
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithConnectParams(grpc.ConnectParams{
+				Backoff:           backoff.Config{BaseDelay: 100 * time.Millisecond, Multiplier: 1.6, MaxDelay: time.Second},
+				MinConnectTimeout: timeoutRPCRaft,
+			}))
+		if err != nil {
+			log.Printf("[RAFT %s] Error conectando a réplica %s: %v", id, peerID, err)
+			continue
+		}
+		n.conexiones = append(n.conexiones, conn)
+		n.peers[peerID] = pb.NewRaftClient(conn)
+	}
+
+	return n
+}
+
+// restaurar carga el estado recuperado de disco. Debe llamarse antes de iniciar.
+// entradas son las entradas ya aplicadas posteriores al snapshot y pendientes
+// las que se agregaron al log pero no se sabe si se confirmaron.
+func (n *nodoRaft) restaurar(termino int64, votoPara string, indiceSnapshot, terminoSnapshot int64, entradas, pendientes []*pb.EntradaLog) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.termino = termino
+	n.votoPara = votoPara
+	n.log = append([]*pb.EntradaLog{{Termino: terminoSnapshot, Indice: indiceSnapshot}}, entradas...)
+	n.commitIndex, _ = n.ultimaEntrada()
+	n.ultimoAplicado = n.commitIndex
+	n.log = append(n.log, pendientes...)
+
+	ultimoIndice, _ := n.ultimaEntrada()
+	log.Printf("[RAFT %s] Estado restaurado: término %d, último aplicado %d, último índice %d",
+		n.id, n.termino, n.commitIndex, ultimoIndice)
+}
+
+// compactar descarta del log las entradas hasta indice, que ya quedaron
+// incluidas en un snapshot de la máquina de estados.
+func (n *nodoRaft) compactar(indice int64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	base := n.indiceBase()
+	if indice <= base || indice > n.commitIndex {
+		return
+	}
+	n.log = append([]*pb.EntradaLog{{Termino: n.entrada(indice).GetTermino(), Indice: indice}}, n.log[indice-base+1:]...)
+	if err := n.almacen.reescribirEntradasRaft(n.log[1:]); err != nil {
+		log.Printf("[RAFT %s] Error reescribiendo el log tras el snapshot: %v", n.id, err)
+	}
+}
+
+func timeoutAleatorio() time.Duration {
+	return timeoutEleccionMin + time.Duration(rand.Int63n(int64(timeoutEleccionDelta)))
+}
+
+func (n *nodoRaft) iniciar() {
+	go n.bucleTemporizador()
+	go n.bucleAplicar()
+}
+
+func (n *nodoRaft) cerrar() {
+	close(n.detener)
+	n.mu.Lock()
+	n.aplicarCond.Broadcast()
+	n.mu.Unlock()
+	for _, conn := range n.conexiones {
+		conn.Close()
+	}
+}
+
+// esLider indica si esta réplica es actualmente el líder.
+func (n *nodoRaft) esLider() bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.estado == lider
+}
+
+// direccionLider devuelve la dirección gRPC del líder conocido, o "" si no hay.
+func (n *nodoRaft) direccionLider() string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.liderID == "" {
+		return ""
+	}
+	return n.direcciones[n.liderID]
+}
+
+// proponer agrega un comando al log y espera a que sea confirmado y aplicado
+// localmente. Solo el líder puede proponer.
+func (n *nodoRaft) proponer(ctx context.Context, comando []byte) error {
+	n.mu.Lock()
+	if n.estado != lider {
+		n.mu.Unlock()
+		return errNoLider
+	}
+
+	termino := n.termino
+	ultimoIndice, _ := n.ultimaEntrada()
+	indice := ultimoIndice + 1
+	entrada := &pb.EntradaLog{Termino: termino, Indice: indice, Comando: comando}
+	if err := n.almacen.guardarEntradasRaft([]*pb.EntradaLog{entrada}); err != nil {
+		n.mu.Unlock()
+		return err
+	}
+	n.log = append(n.log, entrada)
+	espera := make(chan int64, 1)
+	n.esperas[indice] = espera
+	n.avanzarCommit()
+	n.mu.Unlock()
+
+	n.replicarATodos()
+
+	select {
+	case terminoAplicado := <-espera:
+		if terminoAplicado != termino {
+			return errEntradaDescartada
+		}
+		return nil
+	case <-ctx.Done():
+		n.mu.Lock()
+		delete(n.esperas, indice)
+		n.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+func (n *nodoRaft) bucleTemporizador() {
+	ticker := time.NewTicker(intervaloTickRaft)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-n.detener:
+			return
+		case <-ticker.C:
+		}
+
+		n.mu.Lock()
+		if n.estado == lider {
+			enviarLatido := time.Since(n.ultimoLatido) >= intervaloLatidoRaft
+			if enviarLatido {
+				n.ultimoLatido = time.Now()
+			}
+			n.mu.Unlock()
+			if enviarLatido {
+				n.replicarATodos()
+			}
+			continue
+		}
+
+		if time.Since(n.ultimoContacto) >= n.timeoutEleccion {
+			n.iniciarEleccion()
+		}
+		n.mu.Unlock()
+	}
+}
+
+// iniciarEleccion debe llamarse con n.mu tomado.
+func (n *nodoRaft) iniciarEleccion() {
+	n.estado = candidato
+	n.termino++
+	n.votoPara = n.id
+	n.liderID = ""
+	n.ultimoContacto = time.Now()
+	n.timeoutEleccion = timeoutAleatorio()
+	if err := n.almacen.guardarEstadoRaft(n.termino, n.votoPara); err != nil {
+		// Sin el voto propio en disco, tras un reinicio podría votar a otro
+		// candidato en este mismo término
+		log.Printf("[RAFT %s] No se pudo guardar el término %d, se pospone la elección: %v", n.id, n.termino, err)
+		n.estado = seguidor
+		return
+	}
+
+	termino := n.termino
+	ultimoIndice, ultimoTermino := n.ultimaEntrada()
+	votos := 1
+
+	log.Printf("[RAFT %s] Iniciando elección para término %d", n.id, termino)
+
+	if n.esMayoria(votos) {
+		n.convertirEnLider()
+		return
+	}
+
+	for peerID, cliente := range n.peers {
+		go func(peerID string, cliente pb.RaftClient) {
+			ctx, cancel := context.WithTimeout(context.Background(), timeoutRPCRaft)
+			defer cancel()
+
+			resp, err := cliente.SolicitarVoto(ctx, &pb.SolicitarVotoRequest{
+				Termino:       termino,
+				CandidatoId:   n.id,
+				UltimoIndice:  ultimoIndice,
+				UltimoTermino: ultimoTermino,
+			})
+			if err != nil {
+				return
+			}
+
+			n.mu.Lock()
+			defer n.mu.Unlock()
+
+			if resp.GetTermino() > n.termino {
+				n.pasarASeguidor(resp.GetTermino())
+				return
+			}
+			if n.estado != candidato || n.termino != termino || !resp.GetVotoConcedido() {
+				return
+			}
+
+			votos++
+			if n.esMayoria(votos) {
+				n.convertirEnLider()
+			}
+		}(peerID, cliente)
+	}
+}
+
+// convertirEnLider debe llamarse con n.mu tomado.
+func (n *nodoRaft) convertirEnLider() {
+	ultimoIndice, _ := n.ultimaEntrada()
+	siguiente := ultimoIndice + 1
+
+	// Entrada vacía del nuevo término para poder confirmar entradas previas
+	vacia := &pb.EntradaLog{Termino: n.termino, Indice: siguiente}
+	if err := n.almacen.guardarEntradasRaft([]*pb.EntradaLog{vacia}); err != nil {
+		log.Printf("[RAFT %s] No se pudo guardar la entrada del término %d, no asume como líder: %v", n.id, n.termino, err)
+		n.estado = seguidor
+		return
+	}
+
+	n.estado = lider
+	n.liderID = n.id
+	log.Printf("[RAFT %s] 👑 Elegido líder para término %d", n.id, n.termino)
+
+	for peerID := range n.peers {
+		n.siguienteIndice[peerID] = siguiente
+		n.indiceReplicado[peerID] = 0
+	}
+
+	n.log = append(n.log, vacia)
+	n.avanzarCommit()
+
+	n.ultimoLatido = time.Now()
+	go n.replicarATodos()
+}
+
+// pasarASeguidor debe llamarse con n.mu tomado.
+func (n *nodoRaft) pasarASeguidor(termino int64) {
+	if termino > n.termino {
+		n.termino = termino
+		n.votoPara = ""
+		if err := n.almacen.guardarEstadoRaft(n.termino, n.votoPara); err != nil {
+			log.Printf("[RAFT %s] Error guardando el término %d: %v", n.id, n.termino, err)
+		}
+	}
+	if n.estado == lider {
+		log.Printf("[RAFT %s] Dejando de ser líder (término %d)", n.id, n.termino)
+	}
+	n.estado = seguidor
+	n.ultimoContacto = time.Now()
+}
+
+func (n *nodoRaft) replicarATodos() {
+	for peerID, cliente := range n.peers {
+		go n.replicarA(peerID, cliente)
+	}
+}
+
+func (n *nodoRaft) replicarA(peerID string, cliente pb.RaftClient) {
+	n.mu.Lock()
+	if n.estado != lider {
+		n.mu.Unlock()
+		return
+	}
+
+	termino := n.termino
+	siguiente := n.siguienteIndice[peerID]
+	if siguiente < 1 {
+		siguiente = 1
+	}
+
+	// El seguidor necesita entradas que ya se compactaron: enviar el snapshot
+	if siguiente <= n.indiceBase() {
+		n.mu.Unlock()
+		n.enviarSnapshot(peerID, cliente, termino)
+		return
+	}
+
+	previo := siguiente - 1
+	pendientes := n.log[siguiente-n.indiceBase():]
+	entradas := make([]*pb.EntradaLog, len(pendientes))
+	copy(entradas, pendientes)
+
+	req := &pb.AgregarEntradasRequest{
+		Termino:       termino,
+		LiderId:       n.id,
+		IndicePrevio:  previo,
+		TerminoPrevio: n.entrada(previo).GetTermino(),
+		Entradas:      entradas,
+		CommitLider:   n.commitIndex,
+	}
+	n.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeoutRPCRaft)
+	defer cancel()
+
+	resp, err := cliente.AgregarEntradas(ctx, req)
+	if err != nil {
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if resp.GetTermino() > n.termino {
+		n.pasarASeguidor(resp.GetTermino())
+		return
+	}
+	if n.estado != lider || n.termino != termino {
+		return
+	}
+
+	if resp.GetExito() {
+		replicado := previo + int64(len(entradas))
+		if replicado > n.indiceReplicado[peerID] {
+			n.indiceReplicado[peerID] = replicado
+		}
+		if replicado+1 > n.siguienteIndice[peerID] {
+			n.siguienteIndice[peerID] = replicado + 1
+		}
+		n.avanzarCommit()
+		return
+	}
+
+	// El seguidor no tiene la entrada previa: retroceder hasta su último índice
+	nuevoSiguiente := resp.GetUltimoIndice() + 1
+	if nuevoSiguiente >= siguiente {
+		nuevoSiguiente = siguiente - 1
+	}
+	if nuevoSiguiente < 1 {
+		nuevoSiguiente = 1
+	}
+	n.siguienteIndice[peerID] = nuevoSiguiente
+}
+
+func (n *nodoRaft) enviarSnapshot(peerID string, cliente pb.RaftClient, termino int64) {
+	indice, terminoIncluido, datos := n.almacen.snapshotRaft()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 4*timeoutRPCRaft)
+	defer cancel()
+
+	resp, err := cliente.InstalarSnapshot(ctx, &pb.InstalarSnapshotRequest{
+		Termino:         termino,
+		LiderId:         n.id,
+		IndiceIncluido:  indice,
+		TerminoIncluido: terminoIncluido,
+		Datos:           datos,
+	})
+	if err != nil {
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if resp.GetTermino() > n.termino {
+		n.pasarASeguidor(resp.GetTermino())
+		return
+	}
+	if n.estado != lider || n.termino != termino {
+		return
+	}
+
+	log.Printf("[RAFT %s] Snapshot hasta índice %d enviado a %s", n.id, indice, peerID)
+	if indice > n.indiceReplicado[peerID] {
+		n.indiceReplicado[peerID] = indice
+	}
+	if indice+1 > n.siguienteIndice[peerID] {
+		n.siguienteIndice[peerID] = indice + 1
+	}
+}
+
+// avanzarCommit debe llamarse con n.mu tomado.
+func (n *nodoRaft) avanzarCommit() {
+	ultimoIndice, _ := n.ultimaEntrada()
+	for indice := ultimoIndice; indice > n.commitIndex; indice-- {
+		if n.entrada(indice).GetTermino() != n.termino {
+			break
+		}
+
+		replicas := 1
+		for _, replicado := range n.indiceReplicado {
+			if replicado >= indice {
+				replicas++
+			}
+		}
+
+		if n.esMayoria(replicas) {
+			n.commitIndex = indice
+			n.aplicarCond.Broadcast()
+			return
+		}
+	}
+}
+
+func (n *nodoRaft) esMayoria(votos int) bool {
+	return votos*2 > len(n.direcciones)
+}
+
+// ultimaEntrada debe llamarse con n.mu tomado.
+func (n *nodoRaft) ultimaEntrada() (int64, int64) {
+	ultima := n.log[len(n.log)-1]
+	return ultima.GetIndice(), ultima.GetTermino()
+}
+
+// indiceBase es el índice de la última entrada compactada en el snapshot.
+// Debe llamarse con n.mu tomado.
+func (n *nodoRaft) indiceBase() int64 {
+	return n.log[0].GetIndice()
+}
+
+// entrada devuelve la entrada con el índice dado, que debe ser >= indiceBase.
+// Debe llamarse con n.mu tomado.
+func (n *nodoRaft) entrada(indice int64) *pb.EntradaLog {
+	return n.log[indice-n.indiceBase()]
+}
+
+func (n *nodoRaft) bucleAplicar() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for {
+		for n.ultimoAplicado >= n.commitIndex {
+			select {
+			case <-n.detener:
+				return
+			default:
+			}
+			n.aplicarCond.Wait()
+		}
+
+		base := n.indiceBase()
+		entradas := make([]*pb.EntradaLog, n.commitIndex-n.ultimoAplicado)
+		copy(entradas, n.log[n.ultimoAplicado+1-base:n.commitIndex+1-base])
+		n.mu.Unlock()
+
+		for _, entrada := range entradas {
+			n.almacen.aplicarEntrada(entrada)
+		}
+
+		n.mu.Lock()
+		for _, entrada := range entradas {
+			if entrada.GetIndice() > n.ultimoAplicado {
+				n.ultimoAplicado = entrada.GetIndice()
+			}
+			if espera, ok := n.esperas[entrada.GetIndice()]; ok {
+				espera <- entrada.GetTermino()
+				delete(n.esperas, entrada.GetIndice())
+			}
+		}
+	}
+}
+
+func (n *nodoRaft) SolicitarVoto(ctx context.Context, in *pb.SolicitarVotoRequest) (*pb.SolicitarVotoResponse, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if in.GetTermino() < n.termino {
+		return &pb.SolicitarVotoResponse{Termino: n.termino, VotoConcedido: false}, nil
+	}
+
+	// Si hay un líder activo se ignora la solicitud, para que una réplica que
+	// vuelve de una caída no fuerce elecciones innecesarias.
+	liderActivo := n.estado == lider ||
+		(n.liderID != "" && time.Since(n.ultimoContacto) < timeoutEleccionMin)
+	if liderActivo {
+		return &pb.SolicitarVotoResponse{Termino: n.termino, VotoConcedido: false}, nil
+	}
+
+	if in.GetTermino() > n.termino {
+		n.pasarASeguidor(in.GetTermino())
+	}
+
+	ultimoIndice, ultimoTermino := n.ultimaEntrada()
+	logActualizado := in.GetUltimoTermino() > ultimoTermino ||
+		(in.GetUltimoTermino() == ultimoTermino && in.GetUltimoIndice() >= ultimoIndice)
+
+	// El voto se concede solo si quedó en disco junto con el término: una
+	// réplica reiniciada no puede votar dos veces en el mismo término
+	concedido := false
+	if (n.votoPara == "" || n.votoPara == in.GetCandidatoId()) && logActualizado {
+		if err := n.almacen.guardarEstadoRaft(n.termino, in.GetCandidatoId()); err != nil {
+			log.Printf("[RAFT %s] No se pudo guardar el voto para %s: %v", n.id, in.GetCandidatoId(), err)
+		} else {
+			n.votoPara = in.GetCandidatoId()
+			n.ultimoContacto = time.Now()
+			concedido = true
+		}
+	}
+
+	return &pb.SolicitarVotoResponse{Termino: n.termino, VotoConcedido: concedido}, nil
+}
+
+func (n *nodoRaft) AgregarEntradas(ctx context.Context, in *pb.AgregarEntradasRequest) (*pb.AgregarEntradasResponse, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	ultimoIndice, _ := n.ultimaEntrada()
+
+	if in.GetTermino() < n.termino {
+		return &pb.AgregarEntradasResponse{Termino: n.termino, Exito: false, UltimoIndice: ultimoIndice}, nil
+	}
+
+	n.pasarASeguidor(in.GetTermino())
+	if n.liderID != in.GetLiderId() {
+		log.Printf("[RAFT %s] Líder actual: %s (término %d)", n.id, in.GetLiderId(), in.GetTermino())
+	}
+	n.liderID = in.GetLiderId()
+
+	previo := in.GetIndicePrevio()
+	entradasNuevas := in.GetEntradas()
+
+	// Las entradas ya incluidas en el snapshot están confirmadas: se omiten
+	if previo < n.indiceBase() {
+		omitir := n.indiceBase() - previo
+		if omitir > int64(len(entradasNuevas)) {
+			omitir = int64(len(entradasNuevas))
+		}
+		entradasNuevas = entradasNuevas[omitir:]
+		previo += omitir
+		if previo < n.indiceBase() {
+			return &pb.AgregarEntradasResponse{Termino: n.termino, Exito: true, UltimoIndice: previo}, nil
+		}
+	} else if previo > ultimoIndice || n.entrada(previo).GetTermino() != in.GetTerminoPrevio() {
+		conocido := ultimoIndice
+		if previo <= ultimoIndice {
+			conocido = previo - 1
+		}
+		return &pb.AgregarEntradasResponse{Termino: n.termino, Exito: false, UltimoIndice: conocido}, nil
+	}
+
+	// Las entradas que ya están en el log se omiten; desde la primera nueva
+	// (o que difiere en término) se reemplaza el resto del log
+	inicio := 0
+	for inicio < len(entradasNuevas) {
+		indice := previo + 1 + int64(inicio)
+		if indice > ultimoIndice || n.entrada(indice).GetTermino() != entradasNuevas[inicio].GetTermino() {
+			break
+		}
+		inicio++
+	}
+	if agregar := entradasNuevas[inicio:]; len(agregar) > 0 {
+		// Quedan en disco antes de confirmarlas al líder, que las cuenta
+		// para el quórum
+		if err := n.almacen.guardarEntradasRaft(agregar); err != nil {
+			log.Printf("[RAFT %s] Error guardando entradas desde %d: %v", n.id, agregar[0].GetIndice(), err)
+			return &pb.AgregarEntradasResponse{Termino: n.termino, Exito: false, UltimoIndice: previo}, nil
+		}
+		if indice := previo + 1 + int64(inicio); indice <= ultimoIndice {
+			n.log = n.log[:indice-n.indiceBase()]
+		}
+		n.log = append(n.log, agregar...)
+	}
+
+	ultimoNuevo := previo + int64(len(entradasNuevas))
+	nuevoCommit := in.GetCommitLider()
+	if nuevoCommit > ultimoNuevo {
+		nuevoCommit = ultimoNuevo
+	}
+	if nuevoCommit > n.commitIndex {
+		n.commitIndex = nuevoCommit
+		n.aplicarCond.Broadcast()
+	}
+
+	return &pb.AgregarEntradasResponse{Termino: n.termino, Exito: true, UltimoIndice: ultimoNuevo}, nil
+}
+
+func (n *nodoRaft) InstalarSnapshot(ctx context.Context, in *pb.InstalarSnapshotRequest) (*pb.InstalarSnapshotResponse, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if in.GetTermino() < n.termino {
+		return &pb.InstalarSnapshotResponse{Termino: n.termino}, nil
+	}
+
+	n.pasarASeguidor(in.GetTermino())
+	n.liderID = in.GetLiderId()
+
+	indice := in.GetIndiceIncluido()
+	if indice <= n.ultimoAplicado {
+		return &pb.InstalarSnapshotResponse{Termino: n.termino}, nil
+	}
+
+	if err := n.almacen.instalarSnapshotRaft(indice, in.GetTerminoIncluido(), in.GetDatos()); err != nil {
+		log.Printf("[RAFT %s] Error instalando snapshot: %v", n.id, err)
+		return nil, err
+	}
+
+	// Conservar las entradas posteriores si el log coincide con el snapshot
+	ultimoIndice, _ := n.ultimaEntrada()
+	base := []*pb.EntradaLog{{Termino: in.GetTerminoIncluido(), Indice: indice}}
+	if indice < ultimoIndice && indice >= n.indiceBase() && n.entrada(indice).GetTermino() == in.GetTerminoIncluido() {
+		n.log = append(base, n.log[indice-n.indiceBase()+1:]...)
+	} else {
+		n.log = base
+	}
+	if err := n.almacen.reescribirEntradasRaft(n.log[1:]); err != nil {
+		log.Printf("[RAFT %s] Error reescribiendo el log tras el snapshot: %v", n.id, err)
+	}
+
+	if indice > n.commitIndex {
+		n.commitIndex = indice
+	}
+	n.ultimoAplicado = indice
+
+	log.Printf("[RAFT %s] Snapshot del líder instalado hasta índice %d", n.id, indice)
+	return &pb.InstalarSnapshotResponse{Termino: n.termino}, nil
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	pb "broker_c1/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Tiempo máximo para que el clúster de prueba elija líder o replique un comando
+const esperaClusterPrueba = 5 * time.Second
+
+// replicaPrueba es un broker con Raft sirviendo gRPC en 127.0.0.1.
+type replicaPrueba struct {
+	id        string
+	direccion string
+	srv       *server
+	grpc      *grpc.Server
+	detenida  bool
+}
+
+func (r *replicaPrueba) detener() {
+	if r.detenida {
+		return
+	}
+	r.detenida = true
+	r.grpc.Stop()
+	r.srv.raft.cerrar()
+}
+
+// iniciarClusterPrueba levanta n réplicas sin nodos DB, cada una con su
+// directorio de persistencia.
+func iniciarClusterPrueba(t *testing.T, n int) []*replicaPrueba {
+	t.Helper()
+
+	tax, err := nuevaTaxonomia(taxonomiaPorDefecto)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listeners := make([]net.Listener, n)
+	direcciones := make(map[string]string, n)
+	for i := range listeners {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		listeners[i] = lis
+		direcciones[fmt.Sprintf("B%d", i+1)] = lis.Addr().String()
+	}
+
+	replicas := make([]*replicaPrueba, n)
+	for i, lis := range listeners {
+		id := fmt.Sprintf("B%d", i+1)
+		srv, _ := nuevoServer(nil, tax, nil)
+		srv.habilitarRaft(id, direcciones)
+		if err := srv.habilitarPersistencia(t.TempDir()); err != nil {
+			t.Fatal(err)
+		}
+
+		grpcServer := grpc.NewServer()
+		srv.registrarServicios(grpcServer)
+		go grpcServer.Serve(lis)
+		srv.raft.iniciar()
+
+		replicas[i] = &replicaPrueba{id: id, direccion: direcciones[id], srv: srv, grpc: grpcServer}
+	}
+
+	t.Cleanup(func() {
+		for _, r := range replicas {
+			r.detener()
+		}
+	})
+	return replicas
+}
+
+// esperarLider espera a que las réplicas vivas tengan un único líder y que
+// todas lo conozcan.
+func esperarLider(t *testing.T, replicas []*replicaPrueba) *replicaPrueba {
+	t.Helper()
+
+	limite := time.Now().Add(esperaClusterPrueba)
+	for time.Now().Before(limite) {
+		var lideres []*replicaPrueba
+		for _, r := range replicas {
+			if !r.detenida && r.srv.raft.esLider() {
+				lideres = append(lideres, r)
+			}
+		}
+		if len(lideres) == 1 {
+			conocido := true
+			for _, r := range replicas {
+				if !r.detenida && r.srv.raft.direccionLider() != lideres[0].direccion {
+					conocido = false
+				}
+			}
+			if conocido {
+				return lideres[0]
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("el clúster no eligió un líder")
+	return nil
+}
+
+func seguidorDe(replicas []*replicaPrueba, lider *replicaPrueba) *replicaPrueba {
+	for _, r := range replicas {
+		if r != lider && !r.detenida {
+			return r
+		}
+	}
+	return nil
+}
+
+// esperarConsumidor espera a que todas las réplicas vivas apliquen el
+// registro del consumidor.
+func esperarConsumidor(t *testing.T, replicas []*replicaPrueba, consumidorID string) {
+	t.Helper()
+
+	limite := time.Now().Add(esperaClusterPrueba)
+	for time.Now().Before(limite) {
+		todas := true
+		for _, r := range replicas {
+			if r.detenida {
+				continue
+			}
+			r.srv.consumidoresMutex.RLock()
+			_, ok := r.srv.consumidores[consumidorID]
+			r.srv.consumidoresMutex.RUnlock()
+			if !ok {
+				todas = false
+			}
+		}
+		if todas {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("el registro de %s no llegó a todas las réplicas", consumidorID)
+}
+
+func clienteConsumidorPrueba(t *testing.T, r *replicaPrueba) pb.ConsumidorClient {
+	t.Helper()
+
+	conn, err := grpc.Dial(r.direccion, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewConsumidorClient(conn)
+}
+
+func registroPrueba(consumidorID string) *pb.RegistroConsumidorRequest {
+	return &pb.RegistroConsumidorRequest{
+		ConsumidorId:   consumidorID,
+		Categorias:     []string{"Electrónica"},
+		WebhookUrl:     "https://consumidor.example/" + consumidorID,
+		WebhookSecreto: "secreto",
+	}
+}
+
+func registrar(t *testing.T, r *replicaPrueba, consumidorID string) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), esperaClusterPrueba)
+	defer cancel()
+	resp, err := clienteConsumidorPrueba(t, r).RegistrarConsumidor(ctx, registroPrueba(consumidorID))
+	if err != nil {
+		t.Fatalf("registro de %s en %s: %v", consumidorID, r.id, err)
+	}
+	if !resp.GetExito() {
+		t.Fatalf("registro de %s en %s rechazado: %s", consumidorID, r.id, resp.GetMensaje())
+	}
+}
+
+func TestRaftEligeUnLider(t *testing.T) {
+	replicas := iniciarClusterPrueba(t, 3)
+	lider := esperarLider(t, replicas)
+
+	for _, r := range replicas {
+		if r != lider && !r.srv.debeReenviar() {
+			t.Errorf("la réplica seguidora %s no reenvía al líder", r.id)
+		}
+	}
+	if lider.srv.debeReenviar() {
+		t.Errorf("el líder %s reenvía sus llamadas", lider.id)
+	}
+}
+
+func TestRaftSeguidorReenviaAlLider(t *testing.T) {
+	replicas := iniciarClusterPrueba(t, 3)
+	lider := esperarLider(t, replicas)
+	seguidor := seguidorDe(replicas, lider)
+
+	registrar(t, seguidor, "C1")
+	esperarConsumidor(t, replicas, "C1")
+
+	// Una llamada ya reenviada no se vuelve a reenviar
+	ctx, cancel := context.WithTimeout(context.Background(), esperaClusterPrueba)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, metadataReenviado, "1")
+	_, err := clienteConsumidorPrueba(t, seguidor).RegistrarConsumidor(ctx, registroPrueba("C2"))
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("llamada reenviada a un seguidor: se esperaba Unavailable, se obtuvo %v", err)
+	}
+}
+
+func TestRaftFailoverTrasDetenerAlLider(t *testing.T) {
+	replicas := iniciarClusterPrueba(t, 3)
+	lider := esperarLider(t, replicas)
+
+	registrar(t, seguidorDe(replicas, lider), "C1")
+	esperarConsumidor(t, replicas, "C1")
+
+	lider.detener()
+	nuevoLider := esperarLider(t, replicas)
+	if nuevoLider == lider {
+		t.Fatal("el líder detenido sigue como líder")
+	}
+
+	// Con 2 de 3 réplicas hay mayoría: el estado anterior se conserva y se
+	// pueden replicar comandos nuevos a través del seguidor que queda
+	registrar(t, seguidorDe(replicas, nuevoLider), "C2")
+	esperarConsumidor(t, replicas, "C1")
+	esperarConsumidor(t, replicas, "C2")
+}
@@ -0,0 +1,372 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	pb "broker_c1/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Marca que agregan las réplicas seguidoras al reenviar una llamada al líder,
+// para no reenviarla otra vez si el líder cambió entretanto.
+const metadataReenviado = "x-broker-reenviado"
+
+// Tipos de comando del estado de control replicado
+const (
+	cmdRegistrarProductor  = "registrar_productor"
+	cmdRegistrarConsumidor = "registrar_consumidor"
+	cmdConsumidorInactivo  = "consumidor_inactivo"
+	cmdOfertaProcesada     = "oferta_procesada"
+	cmdEstadisticas        = "estadisticas"
+	cmdSecuenciaEntregada  = "secuencia_entregada"
+	cmdCartaMuerta         = "carta_muerta"
+	cmdCartaResuelta       = "carta_resuelta"
+	cmdCartasPurgadas      = "cartas_purgadas"
+	cmdSecuencia           = "secuencia"
+	cmdReserva             = "reserva"
+	cmdCompraConfirmada    = "compra_confirmada"
+	cmdReservaLiberada     = "reserva_liberada"
+	cmdComprasArchivadas   = "compras_archivadas"
+	cmdReversionStock      = "reversion_stock"
+	cmdReversionAplicada   = "reversion_aplicada"
+)
+
+// comando es una mutación del estado de control del broker. Se serializa en
+// JSON dentro de las entradas del log de Raft.
+type comando struct {
+	Tipo              string          `json:"tipo"`
+	ClienteID         string          `json:"cliente_id,omitempty"`
+	OfertaID          string          `json:"oferta_id,omitempty"`
+	ConsumidorID      string          `json:"consumidor_id,omitempty"`
+	Categorias        []string        `json:"categorias,omitempty"`
+	Tiendas           []string        `json:"tiendas,omitempty"`
+	PrecioMax         int32           `json:"precio_max,omitempty"`
+	DireccionGRPC     string          `json:"direccion_grpc,omitempty"`
+	WebhookURL        string          `json:"webhook_url,omitempty"`
+	WebhookSecreto    string          `json:"webhook_secreto,omitempty"`
+	Grupo             string          `json:"grupo,omitempty"`
+	EntregaOrdenada   bool            `json:"entrega_ordenada,omitempty"`
+	Secuencia         int64           `json:"secuencia,omitempty"`
+	Carta             *CartaMuerta    `json:"carta,omitempty"`
+	CartaID           string          `json:"carta_id,omitempty"`
+	CartaIDs          []string        `json:"carta_ids,omitempty"`
+	Reserva           *Reserva        `json:"reserva,omitempty"`
+	ReservaID         string          `json:"reserva_id,omitempty"`
+	ReservaIDs        []string        `json:"reserva_ids,omitempty"`
+	Reversion         *ReversionStock `json:"reversion,omitempty"`
+	Seguimientos      []seguimiento   `json:"seguimientos,omitempty"`
+	ProductoID        string          `json:"producto_id,omitempty"`
+	Precio            int32           `json:"precio,omitempty"`
+	ResumenSegundos   int32           `json:"resumen_segundos,omitempty"`
+	ResumenMaxOfertas int32           `json:"resumen_max_ofertas,omitempty"`
+
+	Estadisticas *deltaEstadisticas `json:"estadisticas,omitempty"`
+}
+
+// replicar aplica un comando al estado de control. Sin Raft se aplica
+// directamente; con Raft se propone al clúster y se espera su confirmación.
+func (s *server) replicar(cmd comando) error {
+	datos, err := json.Marshal(cmd)
+	if err != nil {
+		return err
+	}
+
+	if s.raft == nil {
+		s.persistenciaMutex.Lock()
+		s.aplicarComando(cmd)
+		s.registrarAplicado(s.indiceAplicado+1, 0, datos)
+		s.persistenciaMutex.Unlock()
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	if err := s.raft.proponer(ctx, datos); err != nil {
+		log.Printf("[BROKER] Error replicando comando %s: %v", cmd.Tipo, err)
+		return err
+	}
+	return nil
+}
+
+// aplicarComandoSerializado aplica un comando tal como viaja en el log.
+func (s *server) aplicarComandoSerializado(datos []byte) {
+	var cmd comando
+	if err := json.Unmarshal(datos, &cmd); err != nil {
+		log.Printf("[BROKER] Comando replicado inválido: %v", err)
+		return
+	}
+	s.aplicarComando(cmd)
+}
+
+func (s *server) aplicarComando(cmd comando) {
+	switch cmd.Tipo {
+	case cmdRegistrarProductor:
+		if s.esProductorRegistrado(cmd.ClienteID) {
+			return
+		}
+		s.productoresMutex.Lock()
+		s.productores = append(s.productores, cmd.ClienteID)
+		s.productoresMutex.Unlock()
+
+		s.statsMutex.Lock()
+		s.statsProductores[cmd.ClienteID] = &EstadisticasProductor{}
+		s.statsMutex.Unlock()
+
+		log.Printf("[BROKER] Productor %s registrado", cmd.ClienteID)
+
+	case cmdRegistrarConsumidor:
+		s.consumidoresMutex.Lock()
+		anterior := s.consumidores[cmd.ConsumidorID]
+		cliente, conexion, err := s.conectarConsumidor(cmd.ConsumidorID, cmd.DireccionGRPC, cmd.WebhookURL, cmd.WebhookSecreto, anterior)
+		if err != nil {
+			s.consumidoresMutex.Unlock()
+			log.Printf("[BROKER] Error conectando a consumidor %s: %v", cmd.ConsumidorID, err)
+			return
+		}
+
+		// Al volver a registrarse el correlativo de entrega ordenada continúa
+		var secuenciaEntregada int64
+		if anterior != nil {
+			secuenciaEntregada = anterior.SecuenciaEntregada
+		}
+		s.consumidores[cmd.ConsumidorID] = &ConsumidorInfo{
+			ID:                 cmd.ConsumidorID,
+			Categorias:         cmd.Categorias,
+			Tiendas:            cmd.Tiendas,
+			PrecioMax:          cmd.PrecioMax,
+			DireccionGRPC:      cmd.DireccionGRPC,
+			WebhookURL:         cmd.WebhookURL,
+			WebhookSecreto:     cmd.WebhookSecreto,
+			Grupo:              cmd.Grupo,
+			EntregaOrdenada:    cmd.EntregaOrdenada,
+			SecuenciaEntregada: secuenciaEntregada,
+			Seguimientos:       prepararSeguimientos(cmd.Seguimientos),
+			ResumenSegundos:    cmd.ResumenSegundos,
+			ResumenMaxOfertas:  cmd.ResumenMaxOfertas,
+			Cliente:            cliente,
+			Conexion:           conexion,
+			Activo:             true,
+		}
+		s.consumidoresMutex.Unlock()
+
+		// Un re-registro (reconexión, cambio de preferencias) conserva lo ya entregado
+		s.statsMutex.Lock()
+		if stats, ok := s.statsConsumidores[cmd.ConsumidorID]; ok {
+			stats.Activo = true
+		} else {
+			s.statsConsumidores[cmd.ConsumidorID] = &EstadisticasConsumidor{
+				ConsumidorID: cmd.ConsumidorID,
+				Activo:       true,
+			}
+		}
+		s.statsMutex.Unlock()
+
+		log.Printf("[BROKER] Consumidor %s registrado exitosamente", cmd.ConsumidorID)
+
+	case cmdConsumidorInactivo:
+		s.consumidoresMutex.Lock()
+		if consumidor, ok := s.consumidores[cmd.ConsumidorID]; ok {
+			consumidor.Activo = false
+		}
+		s.consumidoresMutex.Unlock()
+
+		s.statsMutex.Lock()
+		if stats, ok := s.statsConsumidores[cmd.ConsumidorID]; ok {
+			stats.Activo = false
+		}
+		s.statsMutex.Unlock()
+
+	case cmdOfertaProcesada:
+		s.ofertasProcesakdasMutex.Lock()
+		s.ofertasProcesadas[cmd.OfertaID] = true
+		s.ofertasProcesakdasMutex.Unlock()
+		if cmd.ProductoID != "" && cmd.Precio > 0 {
+			s.aplicarPrecioMinimo(cmd.ProductoID, cmd.Precio)
+		}
+
+	case cmdEstadisticas:
+		if cmd.Estadisticas != nil {
+			s.aplicarEstadisticas(cmd.Estadisticas)
+		}
+
+	case cmdSecuenciaEntregada:
+		s.aplicarSecuenciaEntregada(cmd.ConsumidorID, cmd.Secuencia)
+
+	case cmdCartaMuerta:
+		if cmd.Carta != nil {
+			// Las descartadas por el máximo salen antes de agregar la nueva
+			s.aplicarCartasPurgadas(cmd.CartaIDs)
+			s.aplicarCartaMuerta(cmd.Carta)
+			s.aplicarSecuenciaEntregada(cmd.Carta.ConsumidorID, cmd.Secuencia)
+		}
+
+	case cmdSecuencia:
+		s.aplicarSecuencia(cmd.Secuencia)
+
+	case cmdCartaResuelta:
+		s.cartasMuertasMutex.Lock()
+		delete(s.cartasMuertas, cmd.CartaID)
+		s.cartasMuertasMutex.Unlock()
+
+	case cmdCartasPurgadas:
+		s.aplicarCartasPurgadas(cmd.CartaIDs)
+
+	case cmdReserva:
+		if cmd.Reserva != nil {
+			s.aplicarReserva(cmd.Reserva)
+		}
+
+	case cmdCompraConfirmada:
+		s.aplicarCompraConfirmada(cmd.ReservaID)
+
+	case cmdReservaLiberada:
+		s.aplicarReservaLiberada(cmd.ReservaID)
+
+	case cmdComprasArchivadas:
+		s.aplicarComprasArchivadas(cmd.ReservaIDs)
+
+	case cmdReversionStock:
+		if cmd.Reversion != nil {
+			s.aplicarReversionStock(cmd.Reversion)
+		}
+
+	case cmdReversionAplicada:
+		s.aplicarReversionAplicada(cmd.OfertaID)
+
+	default:
+		log.Printf("[BROKER] Tipo de comando desconocido: %s", cmd.Tipo)
+	}
+}
+
+// habilitarRaft convierte al broker en una réplica del clúster descrito por
+// direcciones (id de réplica -> dirección gRPC, incluida esta réplica).
+func (s *server) habilitarRaft(id string, direcciones map[string]string) {
+	s.raft = nuevoNodoRaft(id, direcciones, s)
+	s.conexionesLider = make(map[string]*grpc.ClientConn)
+}
+
+// debeReenviar indica si la llamada debe atenderla el líder en vez de esta réplica.
+func (s *server) debeReenviar() bool {
+	return s.raft != nil && !s.raft.esLider()
+}
+
+// conexionLider devuelve una conexión al líder actual para reenviarle llamadas.
+func (s *server) conexionLider(ctx context.Context) (*grpc.ClientConn, error) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get(metadataReenviado)) > 0 {
+		return nil, status.Error(codes.Unavailable, "llamada ya reenviada y esta réplica no es el líder")
+	}
+
+	direccion := s.raft.direccionLider()
+	if direccion == "" {
+		return nil, status.Error(codes.Unavailable, "no hay líder elegido")
+	}
+
+	s.conexionesLiderMutex.Lock()
+	defer s.conexionesLiderMutex.Unlock()
+
+	if conn, ok := s.conexionesLider[direccion]; ok {
+		return conn, nil
+	}
+
+	conn, err := grpc.Dial(direccion, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	s.conexionesLider[direccion] = conn
+	return conn, nil
+}
+
+func contextoReenviado(ctx context.Context) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, metadataReenviado, "1")
+}
+
+func (s *server) reenviarOferta(ctx context.Context, in *pb.OfertaRequest) (*pb.OfertaResponse, error) {
+	conn, err := s.conexionLider(ctx)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[BROKER] Reenviando oferta %s al líder %s", in.GetOfertaId(), conn.Target())
+	return pb.NewOfertasClient(conn).EnviarOferta(contextoReenviado(ctx), in)
+}
+
+func (s *server) reenviarRegistro(ctx context.Context, in *pb.RegistroConsumidorRequest) (*pb.RegistroConsumidorResponse, error) {
+	conn, err := s.conexionLider(ctx)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[BROKER] Reenviando registro de %s al líder %s", in.GetConsumidorId(), conn.Target())
+	return pb.NewConsumidorClient(conn).RegistrarConsumidor(contextoReenviado(ctx), in)
+}
+
+func (s *server) reenviarReproceso(ctx context.Context, in *pb.ReprocesarCartaMuertaRequest) (*pb.ReprocesarCartaMuertaResponse, error) {
+	conn, err := s.conexionLider(ctx)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[BROKER] Reenviando reproceso de %s al líder %s", in.GetId(), conn.Target())
+	return pb.NewCartasMuertasClient(conn).ReprocesarCartaMuerta(contextoReenviado(ctx), in)
+}
+
+func (s *server) reenviarPurga(ctx context.Context, in *pb.PurgarCartasMuertasRequest) (*pb.PurgarCartasMuertasResponse, error) {
+	conn, err := s.conexionLider(ctx)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[BROKER] Reenviando purga de cartas muertas al líder %s", conn.Target())
+	return pb.NewCartasMuertasClient(conn).PurgarCartasMuertas(contextoReenviado(ctx), in)
+}
+
+func (s *server) reenviarReserva(ctx context.Context, in *pb.ReservarOfertaRequest) (*pb.ReservarOfertaResponse, error) {
+	conn, err := s.conexionLider(ctx)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[BROKER] Reenviando reserva de %s al líder %s", in.GetOfertaId(), conn.Target())
+	return pb.NewComprasClient(conn).ReservarOferta(contextoReenviado(ctx), in)
+}
+
+func (s *server) reenviarConfirmacion(ctx context.Context, in *pb.ConfirmarCompraRequest) (*pb.ConfirmarCompraResponse, error) {
+	conn, err := s.conexionLider(ctx)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[BROKER] Reenviando confirmación de %s al líder %s", in.GetReservaId(), conn.Target())
+	return pb.NewComprasClient(conn).ConfirmarCompra(contextoReenviado(ctx), in)
+}
+
+func (s *server) reenviarEliminacion(ctx context.Context, in *pb.EliminarOfertaRequest) (*pb.EliminarOfertaResponse, error) {
+	conn, err := s.conexionLider(ctx)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[BROKER] Reenviando eliminación de %s al líder %s", in.GetOfertaId(), conn.Target())
+	return pb.NewOfertasClient(conn).EliminarOferta(contextoReenviado(ctx), in)
+}
+
+// parsearReplicas interpreta BROKER_PEERS con formato "B1=host1:50051,B2=host2:50051".
+func parsearReplicas(valor string) (map[string]string, error) {
+	replicas := make(map[string]string)
+	for _, par := range strings.Split(valor, ",") {
+		par = strings.TrimSpace(par)
+		if par == "" {
+			continue
+		}
+		partes := strings.SplitN(par, "=", 2)
+		if len(partes) != 2 || partes[0] == "" || partes[1] == "" {
+			return nil, fmt.Errorf("réplica mal formada: %q", par)
+		}
+		replicas[partes[0]] = partes[1]
+	}
+	return replicas, nil
+}
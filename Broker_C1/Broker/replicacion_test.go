@@ -0,0 +1,24 @@
+package main
+
+import "testing"
+
+func TestReRegistroConservaEstadisticasDelConsumidor(t *testing.T) {
+	srv, _ := servidorConNodosPrueba(t, 0)
+
+	registro := comando{Tipo: cmdRegistrarConsumidor, ConsumidorID: "C1", WebhookURL: "https://consumidor.example/ofertas"}
+	srv.aplicarComando(registro)
+
+	srv.statsMutex.Lock()
+	srv.statsConsumidores["C1"].OfertasRecibidas = 7
+	srv.statsConsumidores["C1"].Activo = false
+	srv.statsMutex.Unlock()
+
+	srv.aplicarComando(registro)
+
+	srv.statsMutex.Lock()
+	defer srv.statsMutex.Unlock()
+	stats := srv.statsConsumidores["C1"]
+	if stats.OfertasRecibidas != 7 || !stats.Activo {
+		t.Fatalf("el re-registro debía conservar 7 ofertas y marcarlo activo, se obtuvo %+v", *stats)
+	}
+}
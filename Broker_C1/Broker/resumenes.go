@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	pb "broker_c1/proto"
+)
+
+// colaResumen acumula las ofertas de un consumidor en modo resumen hasta que
+// se cumple el plazo o se junta la cantidad configurada. Vive solo en la
+// memoria del líder: si se cae, el consumidor recupera esas ofertas con
+// SolicitarHistorico.
+type colaResumen struct {
+	ofertas      []*pb.OfertaRequest
+	desde        int64
+	temporizador *time.Timer
+}
+
+func (c *ConsumidorInfo) modoResumen() bool {
+	return c.ResumenSegundos > 0 || c.ResumenMaxOfertas > 0
+}
+
+// validarResumen revisa las opciones de resumen de un registro.
+func validarResumen(in *pb.RegistroConsumidorRequest) error {
+	if in.GetResumenSegundos() < 0 || in.GetResumenMaxOfertas() < 0 {
+		return fmt.Errorf("resumen_segundos y resumen_max_ofertas no pueden ser negativos")
+	}
+	if in.GetResumenSegundos() == 0 && in.GetResumenMaxOfertas() == 0 {
+		return nil
+	}
+	if in.GetGrupo() != "" {
+		return fmt.Errorf("el modo resumen no aplica a miembros de grupos")
+	}
+	if in.GetEntregaOrdenada() {
+		return fmt.Errorf("el modo resumen no se combina con entrega_ordenada")
+	}
+	return nil
+}
+
+// agregarAResumen suma la oferta a la cola del consumidor y la despacha si
+// llegó a resumen_max_ofertas. La primera oferta de cada resumen arma el plazo.
+func (s *server) agregarAResumen(consumidor *ConsumidorInfo, oferta *pb.OfertaRequest) {
+	s.resumenesMutex.Lock()
+	cola, ok := s.resumenes[consumidor.ID]
+	if !ok {
+		cola = &colaResumen{desde: time.Now().Unix()}
+		s.resumenes[consumidor.ID] = cola
+		if consumidor.ResumenSegundos > 0 {
+			plazo := time.Duration(consumidor.ResumenSegundos) * time.Second
+			cola.temporizador = time.AfterFunc(plazo, func() { s.vencerResumen(consumidor.ID, cola) })
+		}
+	}
+	cola.ofertas = append(cola.ofertas, oferta)
+	lleno := consumidor.ResumenMaxOfertas > 0 && len(cola.ofertas) >= int(consumidor.ResumenMaxOfertas)
+	if lleno {
+		s.sacarResumen(consumidor.ID, cola)
+	}
+	s.resumenesMutex.Unlock()
+
+	if lleno {
+		go s.despacharResumen(consumidor.ID, cola)
+	}
+}
+
+// vencerResumen despacha la cola al cumplirse el plazo, salvo que ya se haya
+// despachado por cantidad.
+func (s *server) vencerResumen(consumidorID string, cola *colaResumen) {
+	s.resumenesMutex.Lock()
+	vigente := s.resumenes[consumidorID] == cola
+	if vigente {
+		s.sacarResumen(consumidorID, cola)
+	}
+	s.resumenesMutex.Unlock()
+
+	if vigente {
+		s.despacharResumen(consumidorID, cola)
+	}
+}
+
+// sacarResumen cierra la cola para que las ofertas siguientes empiecen otra.
+// Se llama con resumenesMutex tomado.
+func (s *server) sacarResumen(consumidorID string, cola *colaResumen) {
+	delete(s.resumenes, consumidorID)
+	if cola.temporizador != nil {
+		cola.temporizador.Stop()
+	}
+}
+
+// despacharResumen envía una cola ya cerrada en un solo RecibirResumen.
+func (s *server) despacharResumen(consumidorID string, cola *colaResumen) {
+	ordenarPorDescuento(cola.ofertas)
+
+	s.consumidoresMutex.RLock()
+	consumidor, existe := s.consumidores[consumidorID]
+	s.consumidoresMutex.RUnlock()
+	if !existe {
+		return
+	}
+
+	resumen := &pb.ResumenOfertas{
+		ResumenId:    fmt.Sprintf("resumen-%s-%d", consumidorID, time.Now().UnixNano()),
+		ConsumidorId: consumidorID,
+		Ofertas:      cola.ofertas,
+		Desde:        cola.desde,
+		Hasta:        time.Now().Unix(),
+	}
+
+	timeout := 2 * time.Second
+	if consumidor.WebhookURL != "" {
+		timeout = timeoutEntregaWebhook
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if _, err := consumidor.Cliente.RecibirResumen(ctx, resumen); err != nil {
+		log.Printf("[BROKER] Error enviando resumen de %d ofertas a %s: %v", len(cola.ofertas), consumidorID, err)
+		s.marcarConsumidorInactivo(consumidorID)
+		for _, oferta := range cola.ofertas {
+			s.publicarEntrega(oferta.GetOfertaId(), consumidorID, false)
+		}
+		// Todo el resumen queda como una sola carta muerta
+		s.registrarResumenMuerto("", resumen, err.Error())
+		return
+	}
+
+	for _, oferta := range cola.ofertas {
+		s.incrementarOfertasRecibidas(consumidorID)
+		s.publicarEntrega(oferta.GetOfertaId(), consumidorID, true)
+	}
+	log.Printf("[BROKER] Resumen de %d ofertas enviado a consumidor %s", len(cola.ofertas), consumidorID)
+}
+
+// ordenarPorDescuento deja primero las ofertas con mayor descuento porcentual;
+// las que no traen precio_original van al final, de menor a mayor precio.
+func ordenarPorDescuento(ofertas []*pb.OfertaRequest) {
+	sort.SliceStable(ofertas, func(i, j int) bool {
+		di, dj := porcentajeDescuento(ofertas[i]), porcentajeDescuento(ofertas[j])
+		if di != dj {
+			return di > dj
+		}
+		return ofertas[i].GetPrecioDescuento() < ofertas[j].GetPrecioDescuento()
+	})
+}
+
+// porcentajeDescuento devuelve el descuento sobre precio_original (0 si no viene).
+func porcentajeDescuento(oferta *pb.OfertaRequest) float64 {
+	original := oferta.GetPrecioOriginal()
+	if original <= 0 {
+		return 0
+	}
+	return 100 * float64(original-oferta.GetPrecioDescuento()) / float64(original)
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	pb "broker_c1/proto"
+)
+
+// seguimiento es un producto que un consumidor sigue para recibir solo sus
+// bajas de precio. Se replica junto con el registro del consumidor.
+type seguimiento struct {
+	ProductoID     string `json:"producto_id,omitempty"`
+	Patron         string `json:"patron,omitempty"`
+	PrecioObjetivo int32  `json:"precio_objetivo,omitempty"`
+
+	expresion *regexp.Regexp // Patron compilado (ver prepararSeguimientos)
+}
+
+// seguimientosDesdeProto valida los seguimientos de un registro.
+func seguimientosDesdeProto(lista []*pb.Seguimiento) ([]seguimiento, error) {
+	seguimientos := make([]seguimiento, 0, len(lista))
+	for i, s := range lista {
+		productoID := strings.TrimSpace(s.GetProductoId())
+		patron := strings.TrimSpace(s.GetPatron())
+		if productoID == "" && patron == "" {
+			return nil, fmt.Errorf("seguimiento %d: se requiere producto_id o patron", i)
+		}
+		if productoID != "" && patron != "" {
+			return nil, fmt.Errorf("seguimiento %d: producto_id y patron son excluyentes", i)
+		}
+		if s.GetPrecioObjetivo() < 0 {
+			return nil, fmt.Errorf("seguimiento %d: precio_objetivo negativo", i)
+		}
+		seguimientos = append(seguimientos, seguimiento{
+			ProductoID:     productoID,
+			Patron:         patron,
+			PrecioObjetivo: s.GetPrecioObjetivo(),
+		})
+	}
+	return seguimientos, nil
+}
+
+// prepararSeguimientos devuelve una copia con los patrones compilados, lista
+// para guardar en ConsumidorInfo.
+func prepararSeguimientos(lista []seguimiento) []seguimiento {
+	if len(lista) == 0 {
+		return nil
+	}
+	preparados := make([]seguimiento, len(lista))
+	for i, s := range lista {
+		preparados[i] = s
+		if s.Patron != "" {
+			preparados[i].expresion = compilarPatron(s.Patron)
+		}
+	}
+	return preparados
+}
+
+// compilarPatron convierte un patrón con comodines "*" en una expresión sobre
+// el nombre normalizado (sin tildes ni mayúsculas). Sin comodines, el patrón
+// puede aparecer en cualquier parte del nombre.
+func compilarPatron(patron string) *regexp.Regexp {
+	normalizado := normalizarCategoria(patron)
+	if !strings.Contains(normalizado, "*") {
+		normalizado = "*" + normalizado + "*"
+	}
+	expresion := strings.ReplaceAll(regexp.QuoteMeta(normalizado), `\*`, ".*")
+	return regexp.MustCompile("^" + expresion + "$")
+}
+
+func (s seguimiento) coincide(oferta *pb.OfertaRequest) bool {
+	if s.ProductoID != "" {
+		return s.ProductoID == oferta.GetProductoId()
+	}
+	return s.expresion != nil && s.expresion.MatchString(normalizarCategoria(oferta.GetProducto()))
+}
+
+// esAlerta indica si la oferta es una baja de precio para este seguimiento:
+// mejora el mínimo visto antes de ella o llega al precio objetivo.
+func (s seguimiento) esAlerta(oferta *pb.OfertaRequest) bool {
+	if !s.coincide(oferta) {
+		return false
+	}
+	precio := oferta.GetPrecioDescuento()
+	anterior := oferta.GetPrecioMinimoAnterior()
+	if anterior > 0 && precio < anterior {
+		return true
+	}
+	return s.PrecioObjetivo > 0 && precio <= s.PrecioObjetivo
+}
+
+// cumpleSeguimientos aplica los seguimientos del consumidor como filtro
+// adicional: sin seguimientos pasa todo; con alguno, solo las alertas.
+func cumpleSeguimientos(oferta *pb.OfertaRequest, consumidor *ConsumidorInfo) bool {
+	if len(consumidor.Seguimientos) == 0 {
+		return true
+	}
+	for _, s := range consumidor.Seguimientos {
+		if s.esAlerta(oferta) {
+			return true
+		}
+	}
+	return false
+}
+
+// ========== Precio mínimo por producto (estado replicado) ==========
+
+// precioMinimo devuelve el menor precio aceptado para el producto (0 = nunca visto).
+func (s *server) precioMinimo(productoID string) int32 {
+	s.preciosMinimosMutex.Lock()
+	defer s.preciosMinimosMutex.Unlock()
+	return s.preciosMinimos[productoID]
+}
+
+// aplicarPrecioMinimo solo baja el mínimo, así que el resultado no depende
+// del orden en que se apliquen ofertas concurrentes del mismo producto.
+func (s *server) aplicarPrecioMinimo(productoID string, precio int32) {
+	s.preciosMinimosMutex.Lock()
+	defer s.preciosMinimosMutex.Unlock()
+	if minimo, ok := s.preciosMinimos[productoID]; !ok || precio < minimo {
+		s.preciosMinimos[productoID] = precio
+	}
+}
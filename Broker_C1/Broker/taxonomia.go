@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"unicode"
+
+	pb "broker_c1/proto"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Separador entre categoría y subcategoría en la forma canónica ("Electrónica/Audio")
+const separadorSubcategoria = "/"
+
+type categoriaConfig struct {
+	Nombre        string   `json:"nombre"`
+	Alias         []string `json:"alias,omitempty"`
+	Subcategorias []string `json:"subcategorias,omitempty"`
+}
+
+// taxonomiaConfig es el formato del archivo de categorías (ARCHIVO_CATEGORIAS).
+type taxonomiaConfig struct {
+	Version    int64             `json:"version"`
+	Categorias []categoriaConfig `json:"categorias"`
+}
+
+// Taxonomía usada si no hay archivo de configuración
+var taxonomiaPorDefecto = taxonomiaConfig{
+	Version: 1,
+	Categorias: []categoriaConfig{
+		{Nombre: "Electrónica"},
+		{Nombre: "Moda"},
+		{Nombre: "Hogar"},
+		{Nombre: "Deportes"},
+		{Nombre: "Belleza"},
+		{Nombre: "Infantil"},
+		{Nombre: "Computación"},
+		{Nombre: "Electrodomésticos"},
+		{Nombre: "Herramientas"},
+		{Nombre: "Juguetes"},
+		{Nombre: "Automotriz"},
+		{Nombre: "Mascotas"},
+	},
+}
+
+// taxonomia es el catálogo autoritativo de categorías del broker. Resuelve
+// nombres, alias y subcategorías (sin importar tildes ni mayúsculas) a su
+// forma canónica.
+type taxonomia struct {
+	version    int64
+	categorias []categoriaConfig
+	indice     map[string]string // forma normalizada -> forma canónica
+	padres     map[string]string // forma canónica -> categoría principal
+}
+
+func nuevaTaxonomia(cfg taxonomiaConfig) (*taxonomia, error) {
+	t := &taxonomia{
+		version:    cfg.Version,
+		categorias: cfg.Categorias,
+		indice:     make(map[string]string),
+		padres:     make(map[string]string),
+	}
+
+	agregar := func(clave, canonica string) error {
+		normalizada := normalizarCategoria(clave)
+		if normalizada == "" {
+			return fmt.Errorf("nombre vacío en categoría %q", canonica)
+		}
+		if existente, ok := t.indice[normalizada]; ok && existente != canonica {
+			return fmt.Errorf("%q es ambiguo: corresponde a %q y a %q", clave, existente, canonica)
+		}
+		t.indice[normalizada] = canonica
+		return nil
+	}
+
+	for _, cat := range cfg.Categorias {
+		nombres := append([]string{cat.Nombre}, cat.Alias...)
+		for _, nombre := range nombres {
+			if err := agregar(nombre, cat.Nombre); err != nil {
+				return nil, err
+			}
+		}
+		t.padres[cat.Nombre] = cat.Nombre
+
+		for _, sub := range cat.Subcategorias {
+			canonica := cat.Nombre + separadorSubcategoria + sub
+			t.padres[canonica] = cat.Nombre
+			if err := agregar(sub, canonica); err != nil {
+				return nil, err
+			}
+			for _, nombre := range nombres {
+				if err := agregar(nombre+separadorSubcategoria+sub, canonica); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	return t, nil
+}
+
+// cargarTaxonomia lee la taxonomía desde un archivo JSON. Si el archivo no
+// existe se usa la taxonomía por defecto.
+func cargarTaxonomia(ruta string) (*taxonomia, error) {
+	datos, err := os.ReadFile(ruta)
+	if err != nil {
+		if os.IsNotExist(err) {
+			log.Printf("[BROKER] No existe %s, usando taxonomía por defecto", ruta)
+			return nuevaTaxonomia(taxonomiaPorDefecto)
+		}
+		return nil, err
+	}
+
+	var cfg taxonomiaConfig
+	if err := json.Unmarshal(datos, &cfg); err != nil {
+		return nil, fmt.Errorf("archivo de categorías inválido: %v", err)
+	}
+	return nuevaTaxonomia(cfg)
+}
+
+// normalizarCategoria quita tildes, mayúsculas y espacios sobrantes, y
+// unifica los separadores de subcategoría ("Electronica > audio" -> "electronica/audio").
+func normalizarCategoria(categoria string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(categoria) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+
+	sinTildes := strings.ReplaceAll(b.String(), ">", separadorSubcategoria)
+	partes := strings.Split(sinTildes, separadorSubcategoria)
+	for i, parte := range partes {
+		partes[i] = strings.Join(strings.Fields(parte), " ")
+	}
+	return strings.Join(partes, separadorSubcategoria)
+}
+
+// resolver devuelve la forma canónica de una categoría, o false si no existe.
+func (t *taxonomia) resolver(categoria string) (string, bool) {
+	canonica, ok := t.indice[normalizarCategoria(categoria)]
+	return canonica, ok
+}
+
+// incluye indica si una oferta de la categoría canónica ofertaCat le
+// interesa a quien se suscribió a preferencia (misma categoría o su padre).
+func (t *taxonomia) incluye(preferencia, ofertaCat string) bool {
+	canonica, ok := t.resolver(preferencia)
+	if !ok {
+		return preferencia == ofertaCat
+	}
+	return canonica == ofertaCat || canonica == t.padres[ofertaCat]
+}
+
+func (t *taxonomia) aProto() *pb.ListarCategoriasResponse {
+	resp := &pb.ListarCategoriasResponse{Version: t.version}
+	for _, cat := range t.categorias {
+		resp.Categorias = append(resp.Categorias, &pb.Categoria{
+			Nombre:        cat.Nombre,
+			Alias:         cat.Alias,
+			Subcategorias: cat.Subcategorias,
+		})
+	}
+	return resp
+}
+
+func (s *server) ListarCategorias(ctx context.Context, in *pb.ListarCategoriasRequest) (*pb.ListarCategoriasResponse, error) {
+	return s.taxonomia.aProto(), nil
+}
+
+// ResolverCategorias expone la resolución del broker para que productores y
+// consumidores no repliquen la normalización ni el índice de alias.
+func (s *server) ResolverCategorias(ctx context.Context, in *pb.ResolverCategoriasRequest) (*pb.ResolverCategoriasResponse, error) {
+	resp := &pb.ResolverCategoriasResponse{Version: s.taxonomia.version}
+	for _, cat := range in.GetCategorias() {
+		canonica, _ := s.taxonomia.resolver(cat)
+		resp.Categorias = append(resp.Categorias, &pb.CategoriaResuelta{Categoria: cat, Canonica: canonica})
+	}
+	return resp, nil
+}
+
+// normalizarPreferencias lleva las categorías de un consumidor a su forma
+// canónica; las desconocidas se conservan tal cual.
+func (t *taxonomia) normalizarPreferencias(categorias []string) []string {
+	normalizadas := make([]string, len(categorias))
+	for i, cat := range categorias {
+		if canonica, ok := t.resolver(cat); ok {
+			normalizadas[i] = canonica
+		} else {
+			if cat != "null" {
+				log.Printf("[BROKER] ADVERTENCIA: categoría de consumidor desconocida %q", cat)
+			}
+			normalizadas[i] = cat
+		}
+	}
+	return normalizadas
+}
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	pb "broker_c1/proto"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Dominio de los ErrorInfo que devuelve el broker al rechazar una oferta
+const dominioRechazo = "broker.ofertas"
+
+// Códigos de rechazo (legibles por máquina) de las reglas incluidas
+const (
+	codigoOfertaIDVacio     = "OFERTA_ID_VACIO"
+	codigoProductoVacio     = "PRODUCTO_VACIO"
+	codigoStockInvalido     = "STOCK_INVALIDO"
+	codigoPrecioInvalido    = "PRECIO_INVALIDO"
+	codigoFechaInvalida     = "FECHA_INVALIDA"
+	codigoTimestampFuturo   = "TIMESTAMP_FUTURO"
+	codigoTiendaNoCoincide  = "TIENDA_NO_COINCIDE"
+	codigoCategoriaInvalida = "CATEGORIA_INVALIDA"
+)
+
+// violacion describe por qué una regla rechazó una oferta.
+type violacion struct {
+	Regla   string
+	Codigo  string
+	Campo   string
+	Mensaje string
+}
+
+// reglaValidacion es una regla del pipeline de validación. validar devuelve
+// nil si la oferta la cumple.
+type reglaValidacion interface {
+	nombre() string
+	validar(oferta *pb.OfertaRequest, params parametrosReglas) *violacion
+}
+
+// parametrosReglas son los parámetros efectivos para una oferta (globales
+// más los de su tienda).
+type parametrosReglas struct {
+	Deshabilitadas           []string `json:"deshabilitadas,omitempty"`
+	PrecioMinimo             int32    `json:"precio_minimo,omitempty"`
+	PrecioMaximo             int32    `json:"precio_maximo,omitempty"`
+	StockMaximo              int32    `json:"stock_maximo,omitempty"`
+	ToleranciaFuturoSegundos int64    `json:"tolerancia_futuro_segundos,omitempty"`
+}
+
+// configReglas es el formato del archivo de reglas (ARCHIVO_REGLAS).
+type configReglas struct {
+	parametrosReglas
+	Tiendas map[string]parametrosReglas `json:"tiendas,omitempty"`
+}
+
+var parametrosPorDefecto = parametrosReglas{
+	PrecioMinimo:             1,
+	ToleranciaFuturoSegundos: 300,
+}
+
+// combinar aplica sobre p los valores definidos en la sobreescritura de una tienda.
+func (p parametrosReglas) combinar(tienda parametrosReglas) parametrosReglas {
+	p.Deshabilitadas = append(append([]string{}, p.Deshabilitadas...), tienda.Deshabilitadas...)
+	if tienda.PrecioMinimo != 0 {
+		p.PrecioMinimo = tienda.PrecioMinimo
+	}
+	if tienda.PrecioMaximo != 0 {
+		p.PrecioMaximo = tienda.PrecioMaximo
+	}
+	if tienda.StockMaximo != 0 {
+		p.StockMaximo = tienda.StockMaximo
+	}
+	if tienda.ToleranciaFuturoSegundos != 0 {
+		p.ToleranciaFuturoSegundos = tienda.ToleranciaFuturoSegundos
+	}
+	return p
+}
+
+func (p parametrosReglas) deshabilitada(regla string) bool {
+	for _, nombre := range p.Deshabilitadas {
+		if nombre == regla {
+			return true
+		}
+	}
+	return false
+}
+
+// ========== Reglas incluidas ==========
+
+type reglaOfertaID struct{}
+
+func (reglaOfertaID) nombre() string { return "oferta_id_requerido" }
+
+func (r reglaOfertaID) validar(oferta *pb.OfertaRequest, params parametrosReglas) *violacion {
+	if strings.TrimSpace(oferta.GetOfertaId()) == "" {
+		return &violacion{r.nombre(), codigoOfertaIDVacio, "oferta_id", "oferta_id vacío"}
+	}
+	return nil
+}
+
+type reglaProducto struct{}
+
+func (reglaProducto) nombre() string { return "producto_requerido" }
+
+func (r reglaProducto) validar(oferta *pb.OfertaRequest, params parametrosReglas) *violacion {
+	if strings.TrimSpace(oferta.GetProducto()) == "" {
+		return &violacion{r.nombre(), codigoProductoVacio, "producto", "producto vacío"}
+	}
+	return nil
+}
+
+type reglaStock struct{}
+
+func (reglaStock) nombre() string { return "stock_valido" }
+
+func (r reglaStock) validar(oferta *pb.OfertaRequest, params parametrosReglas) *violacion {
+	if oferta.GetStock() <= 0 {
+		return &violacion{r.nombre(), codigoStockInvalido, "stock", "stock debe ser mayor a 0"}
+	}
+	if params.StockMaximo > 0 && oferta.GetStock() > params.StockMaximo {
+		return &violacion{r.nombre(), codigoStockInvalido, "stock",
+			fmt.Sprintf("stock %d supera el máximo %d", oferta.GetStock(), params.StockMaximo)}
+	}
+	return nil
+}
+
+type reglaPrecio struct{}
+
+func (reglaPrecio) nombre() string { return "precio_valido" }
+
+func (r reglaPrecio) validar(oferta *pb.OfertaRequest, params parametrosReglas) *violacion {
+	precio := oferta.GetPrecioDescuento()
+	if precio <= 0 || precio < params.PrecioMinimo {
+		return &violacion{r.nombre(), codigoPrecioInvalido, "precio_descuento",
+			fmt.Sprintf("precio %d menor al mínimo %d", precio, max(params.PrecioMinimo, 1))}
+	}
+	if params.PrecioMaximo > 0 && precio > params.PrecioMaximo {
+		return &violacion{r.nombre(), codigoPrecioInvalido, "precio_descuento",
+			fmt.Sprintf("precio %d supera el máximo %d", precio, params.PrecioMaximo)}
+	}
+	if original := oferta.GetPrecioOriginal(); original > 0 && precio > original {
+		return &violacion{r.nombre(), codigoPrecioInvalido, "precio_original",
+			fmt.Sprintf("precio con descuento %d supera el original %d", precio, original)}
+	}
+	return nil
+}
+
+type reglaFecha struct{}
+
+func (reglaFecha) nombre() string { return "fecha_valida" }
+
+func (r reglaFecha) validar(oferta *pb.OfertaRequest, params parametrosReglas) *violacion {
+	if _, err := time.Parse("2006-01-02", oferta.GetFecha()); err != nil {
+		return &violacion{r.nombre(), codigoFechaInvalida, "fecha",
+			fmt.Sprintf("fecha '%s' no tiene formato AAAA-MM-DD", oferta.GetFecha())}
+	}
+	return nil
+}
+
+type reglaTimestamp struct{}
+
+func (reglaTimestamp) nombre() string { return "timestamp_no_futuro" }
+
+func (r reglaTimestamp) validar(oferta *pb.OfertaRequest, params parametrosReglas) *violacion {
+	limite := time.Now().Unix() + params.ToleranciaFuturoSegundos
+	if oferta.GetTimestamp() > limite {
+		return &violacion{r.nombre(), codigoTimestampFuturo, "timestamp",
+			fmt.Sprintf("timestamp %d está en el futuro", oferta.GetTimestamp())}
+	}
+	return nil
+}
+
+type reglaTienda struct{}
+
+func (reglaTienda) nombre() string { return "tienda_coincide" }
+
+func (r reglaTienda) validar(oferta *pb.OfertaRequest, params parametrosReglas) *violacion {
+	if !strings.EqualFold(oferta.GetTienda(), oferta.GetClienteId()) {
+		return &violacion{r.nombre(), codigoTiendaNoCoincide, "tienda",
+			fmt.Sprintf("tienda '%s' no corresponde al productor '%s'", oferta.GetTienda(), oferta.GetClienteId())}
+	}
+	return nil
+}
+
+// reglaCategoria valida contra la taxonomía y deja la categoría en forma canónica.
+type reglaCategoria struct {
+	taxonomia *taxonomia
+}
+
+func (reglaCategoria) nombre() string { return "categoria_valida" }
+
+func (r reglaCategoria) validar(oferta *pb.OfertaRequest, params parametrosReglas) *violacion {
+	categoria, ok := r.taxonomia.resolver(oferta.GetCategoria())
+	if !ok {
+		return &violacion{r.nombre(), codigoCategoriaInvalida, "categoria",
+			fmt.Sprintf("categoría %s no válida", oferta.GetCategoria())}
+	}
+	oferta.Categoria = categoria
+	return nil
+}
+
+// ========== Motor ==========
+
+// motorValidacion ejecuta todas las reglas habilitadas sobre cada oferta.
+type motorValidacion struct {
+	reglas []reglaValidacion
+	config configReglas
+}
+
+func nuevoMotorValidacion(cfg configReglas, tax *taxonomia) (*motorValidacion, error) {
+	reglas := []reglaValidacion{
+		reglaOfertaID{},
+		reglaProducto{},
+		reglaStock{},
+		reglaPrecio{},
+		reglaFecha{},
+		reglaTimestamp{},
+		reglaTienda{},
+		reglaCategoria{taxonomia: tax},
+	}
+
+	conocidas := make(map[string]bool)
+	for _, r := range reglas {
+		conocidas[r.nombre()] = true
+	}
+	verificar := func(params parametrosReglas, donde string) error {
+		for _, nombre := range params.Deshabilitadas {
+			if !conocidas[nombre] {
+				return fmt.Errorf("regla desconocida %q en %s", nombre, donde)
+			}
+		}
+		return nil
+	}
+	if err := verificar(cfg.parametrosReglas, "configuración global"); err != nil {
+		return nil, err
+	}
+	for tienda, params := range cfg.Tiendas {
+		if err := verificar(params, "tienda "+tienda); err != nil {
+			return nil, err
+		}
+	}
+
+	return &motorValidacion{reglas: reglas, config: cfg}, nil
+}
+
+// cargarReglas lee la configuración de reglas desde un archivo JSON. Si el
+// archivo no existe se usan los parámetros por defecto.
+func cargarReglas(ruta string) (configReglas, error) {
+	cfg := configReglas{parametrosReglas: parametrosPorDefecto}
+	datos, err := os.ReadFile(ruta)
+	if err != nil {
+		if os.IsNotExist(err) {
+			log.Printf("[BROKER] No existe %s, usando reglas por defecto", ruta)
+			return cfg, nil
+		}
+		return cfg, err
+	}
+	if err := json.Unmarshal(datos, &cfg); err != nil {
+		return cfg, fmt.Errorf("archivo de reglas inválido: %v", err)
+	}
+	return cfg, nil
+}
+
+// parametrosPara devuelve los parámetros efectivos para la tienda de la oferta.
+func (m *motorValidacion) parametrosPara(tienda string) parametrosReglas {
+	params := m.config.parametrosReglas
+	for nombre, sobreescritura := range m.config.Tiendas {
+		if strings.EqualFold(nombre, tienda) {
+			return params.combinar(sobreescritura)
+		}
+	}
+	return params
+}
+
+// validar devuelve todas las violaciones de la oferta (vacío si es válida).
+func (m *motorValidacion) validar(oferta *pb.OfertaRequest) []violacion {
+	params := m.parametrosPara(oferta.GetTienda())
+
+	var violaciones []violacion
+	for _, regla := range m.reglas {
+		if params.deshabilitada(regla.nombre()) {
+			continue
+		}
+		if v := regla.validar(oferta, params); v != nil {
+			violaciones = append(violaciones, *v)
+		}
+	}
+	return violaciones
+}
+
+// errorRechazo arma un error gRPC InvalidArgument con los detalles de cada
+// violación: un ErrorInfo con el primer código y un BadRequest por campo.
+func errorRechazo(oferta *pb.OfertaRequest, violaciones []violacion) error {
+	mensajes := make([]string, len(violaciones))
+	codigosRechazo := make([]string, len(violaciones))
+	badRequest := &errdetails.BadRequest{}
+	for i, v := range violaciones {
+		mensajes[i] = v.Mensaje
+		codigosRechazo[i] = v.Codigo
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       v.Campo,
+			Description: v.Mensaje,
+			Reason:      v.Codigo,
+		})
+	}
+
+	st := status.New(codes.InvalidArgument, "oferta rechazada: "+strings.Join(mensajes, "; "))
+	conDetalles, err := st.WithDetails(
+		&errdetails.ErrorInfo{
+			Reason: violaciones[0].Codigo,
+			Domain: dominioRechazo,
+			Metadata: map[string]string{
+				"oferta_id": oferta.GetOfertaId(),
+				"codigos":   strings.Join(codigosRechazo, ","),
+			},
+		},
+		badRequest,
+	)
+	if err != nil {
+		return st.Err()
+	}
+	return conDetalles.Err()
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	pb "broker_c1/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// Cabeceras de cada entrega por webhook
+const (
+	cabeceraFirma     = "X-Cyberday-Firma"     // "sha256=<hex>"
+	cabeceraTimestamp = "X-Cyberday-Timestamp" // segundos Unix, incluido en la firma
+	cabeceraEntrega   = "X-Cyberday-Entrega"   // oferta_id o resumen_id, para deduplicar reintentos
+)
+
+// Parámetros de entrega por webhook
+const (
+	intentosWebhook       = 3
+	timeoutIntentoWebhook = 3 * time.Second
+	esperaBaseWebhook     = 500 * time.Millisecond
+)
+
+// Tiempo total para entregar a un consumidor webhook (todos los intentos)
+const timeoutEntregaWebhook = intentosWebhook*timeoutIntentoWebhook + 2*esperaBaseWebhook
+
+// payloadWebhook es el cuerpo JSON que recibe el webhook: una oferta, o en
+// modo resumen la lista de ofertas acumuladas.
+type payloadWebhook struct {
+	ConsumidorID string            `json:"consumidor_id"`
+	Oferta       json.RawMessage   `json:"oferta,omitempty"`
+	ResumenID    string            `json:"resumen_id,omitempty"`
+	Ofertas      []json.RawMessage `json:"ofertas,omitempty"`
+}
+
+// clienteWebhook entrega ofertas por HTTP POST. Implementa la misma interfaz
+// que el cliente gRPC de NotificacionesConsumidor, así que el resto del
+// broker (distribución, cartas muertas) no distingue entre ambos.
+type clienteWebhook struct {
+	consumidorID string
+	url          string
+	secreto      []byte
+	http         *http.Client
+}
+
+// errDestinoPrivado es el rechazo de una entrega a una dirección interna.
+var errDestinoPrivado = errors.New("destino en una red privada o loopback")
+
+// validarWebhook revisa que la URL sea http(s) y que venga el secreto. Salvo
+// que se permitan las redes privadas (WEBHOOK_REDES_PRIVADAS), rechaza las
+// direcciones internas escritas en la URL; los nombres que resuelven a una
+// se bloquean al conectar (ver nuevoClienteHTTPWebhook).
+func validarWebhook(webhookURL, secreto string, redesPrivadas bool) error {
+	u, err := url.Parse(webhookURL)
+	if err != nil {
+		return fmt.Errorf("webhook_url inválida: %v", err)
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return fmt.Errorf("webhook_url debe ser https:// (o http://)")
+	}
+	host := u.Hostname()
+	if host == "" {
+		return fmt.Errorf("webhook_url sin host")
+	}
+	if !redesPrivadas {
+		nombre := strings.ToLower(strings.TrimSuffix(host, "."))
+		if nombre == "localhost" || strings.HasSuffix(nombre, ".localhost") {
+			return fmt.Errorf("webhook_url apunta a loopback")
+		}
+		if ip := net.ParseIP(host); ip != nil && esDestinoPrivado(ip) {
+			return fmt.Errorf("webhook_url apunta a %s: %v", ip, errDestinoPrivado)
+		}
+	}
+	if secreto == "" {
+		return fmt.Errorf("webhook_secreto es obligatorio para firmar las entregas")
+	}
+	return nil
+}
+
+// esDestinoPrivado indica si la IP es loopback, privada, de enlace local
+// (incluida la metadata de la nube, 169.254.169.254) o no enrutable.
+func esDestinoPrivado(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast()
+}
+
+// nuevoClienteHTTPWebhook crea el cliente de las entregas. Sin redes privadas
+// revisa la IP de cada conexión, así que tampoco llega a ellas un nombre que
+// resuelve a una ni una redirección. No usa proxy.
+func nuevoClienteHTTPWebhook(redesPrivadas bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeoutIntentoWebhook}
+	if !redesPrivadas {
+		dialer.Control = func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || esDestinoPrivado(ip) {
+				return fmt.Errorf("%s: %w", host, errDestinoPrivado)
+			}
+			return nil
+		}
+	}
+	return &http.Client{Transport: &http.Transport{
+		DialContext:         dialer.DialContext,
+		TLSHandshakeTimeout: timeoutIntentoWebhook,
+	}}
+}
+
+// firmarWebhook calcula la firma HMAC-SHA256 de "timestamp.cuerpo".
+func firmarWebhook(secreto []byte, timestamp string, cuerpo []byte) string {
+	mac := hmac.New(sha256.New, secreto)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(cuerpo)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (c *clienteWebhook) RecibirOferta(ctx context.Context, in *pb.OfertaRequest, opts ...grpc.CallOption) (*pb.AckResponse, error) {
+	oferta, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(in)
+	if err != nil {
+		return nil, err
+	}
+	cuerpo, err := json.Marshal(payloadWebhook{ConsumidorID: c.consumidorID, Oferta: oferta})
+	if err != nil {
+		return nil, err
+	}
+	return c.entregar(ctx, in.GetOfertaId(), cuerpo)
+}
+
+func (c *clienteWebhook) RecibirResumen(ctx context.Context, in *pb.ResumenOfertas, opts ...grpc.CallOption) (*pb.AckResponse, error) {
+	payload := payloadWebhook{ConsumidorID: c.consumidorID, ResumenID: in.GetResumenId()}
+	for _, o := range in.GetOfertas() {
+		oferta, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(o)
+		if err != nil {
+			return nil, err
+		}
+		payload.Ofertas = append(payload.Ofertas, oferta)
+	}
+	cuerpo, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return c.entregar(ctx, in.GetResumenId(), cuerpo)
+}
+
+// entregar hace el POST con reintentos y espera exponencial.
+func (c *clienteWebhook) entregar(ctx context.Context, entregaID string, cuerpo []byte) (*pb.AckResponse, error) {
+	var ultimoErr error
+	for intento := 0; intento < intentosWebhook; intento++ {
+		if intento > 0 {
+			espera := esperaBaseWebhook * time.Duration(1<<(intento-1))
+			select {
+			case <-time.After(espera):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		reintentar, err := c.enviar(ctx, entregaID, cuerpo)
+		if err == nil {
+			return &pb.AckResponse{Exito: true, Mensaje: "Entregado por webhook"}, nil
+		}
+		ultimoErr = err
+		if !reintentar {
+			break
+		}
+		log.Printf("[BROKER] Webhook de %s falló (intento %d/%d): %v", c.consumidorID, intento+1, intentosWebhook, err)
+	}
+	return nil, ultimoErr
+}
+
+// Verificar no aplica a webhooks: la URL se valida al registrarse.
+func (c *clienteWebhook) Verificar(ctx context.Context, in *pb.VerificacionRequest, opts ...grpc.CallOption) (*pb.VerificacionResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "los consumidores webhook no tienen handshake")
+}
+
+// enviar hace un POST. Devuelve si vale la pena reintentar cuando falla.
+func (c *clienteWebhook) enviar(ctx context.Context, entregaID string, cuerpo []byte) (bool, error) {
+	ctxIntento, cancel := context.WithTimeout(ctx, timeoutIntentoWebhook)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctxIntento, http.MethodPost, c.url, bytes.NewReader(cuerpo))
+	if err != nil {
+		return false, err
+	}
+	// La firma se recalcula en cada intento porque incluye el timestamp
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(cabeceraTimestamp, timestamp)
+	req.Header.Set(cabeceraFirma, firmarWebhook(c.secreto, timestamp, cuerpo))
+	req.Header.Set(cabeceraEntrega, entregaID)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		// Un destino bloqueado no cambia con reintentos
+		return !errors.Is(err, errDestinoPrivado), err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	err = fmt.Errorf("webhook respondió %s", resp.Status)
+	// 4xx es un rechazo definitivo, salvo timeout y límite de tasa
+	reintentar := resp.StatusCode >= 500 ||
+		resp.StatusCode == http.StatusRequestTimeout ||
+		resp.StatusCode == http.StatusTooManyRequests
+	return reintentar, err
+}
+
+// nuevoClienteConsumidor crea el cliente de entrega de un consumidor: webhook
+// si registró una URL, o gRPC a su direccion_grpc (en ese caso devuelve
+// también la conexión, para cerrarla si el consumidor cambia de dirección).
+func (s *server) nuevoClienteConsumidor(consumidorID, direccionGRPC, webhookURL, webhookSecreto string) (pb.NotificacionesConsumidorClient, *grpc.ClientConn, error) {
+	if webhookURL != "" {
+		return &clienteWebhook{
+			consumidorID: consumidorID,
+			url:          webhookURL,
+			secreto:      []byte(webhookSecreto),
+			http:         s.clienteHTTPWebhook,
+		}, nil, nil
+	}
+
+	conn, err := grpc.Dial(direccionGRPC, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, nil, err
+	}
+	return pb.NewNotificacionesConsumidorClient(conn), conn, nil
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pb "broker_c1/proto"
+)
+
+const secretoPrueba = "secreto-de-prueba"
+
+// servidorWebhook responde con los códigos dados, uno por intento (el último
+// se repite), y cuenta los intentos.
+func servidorWebhook(t *testing.T, codigos ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var intentos atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(intentos.Add(1))
+		w.WriteHeader(codigos[min(n, len(codigos))-1])
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &intentos
+}
+
+// clienteWebhookPrueba entrega a la URL dada; httptest escucha en loopback,
+// así que se permiten las redes privadas.
+func clienteWebhookPrueba(url string) *clienteWebhook {
+	return &clienteWebhook{
+		consumidorID: "W-1",
+		url:          url,
+		secreto:      []byte(secretoPrueba),
+		http:         nuevoClienteHTTPWebhook(true),
+	}
+}
+
+func ofertaPrueba() *pb.OfertaRequest {
+	return &pb.OfertaRequest{OfertaId: "oferta-1", Tienda: "Riploy", Producto: "Audífonos", PrecioDescuento: 45000}
+}
+
+func TestWebhookFirmaTimestampYCuerpo(t *testing.T) {
+	var firmaOK atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cuerpo, _ := io.ReadAll(r.Body)
+		timestamp := r.Header.Get(cabeceraTimestamp)
+
+		mac := hmac.New(sha256.New, []byte(secretoPrueba))
+		mac.Write([]byte(timestamp + "." + string(cuerpo)))
+		esperada := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+		var payload payloadWebhook
+		firmaOK.Store(hmac.Equal([]byte(r.Header.Get(cabeceraFirma)), []byte(esperada)) &&
+			r.Header.Get(cabeceraEntrega) == "oferta-1" &&
+			json.Unmarshal(cuerpo, &payload) == nil && payload.ConsumidorID == "W-1")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	ack, err := clienteWebhookPrueba(srv.URL).RecibirOferta(context.Background(), ofertaPrueba())
+	if err != nil || !ack.GetExito() {
+		t.Fatalf("entrega con 204: se esperaba éxito, se obtuvo %v, %v", ack, err)
+	}
+	if !firmaOK.Load() {
+		t.Fatal("la firma no corresponde a HMAC-SHA256 de timestamp.cuerpo, o faltan cabeceras")
+	}
+}
+
+func TestWebhookReintentos(t *testing.T) {
+	casos := []struct {
+		nombre   string
+		codigos  []int
+		exito    bool
+		intentos int32
+	}{
+		{"2xx se acepta", []int{http.StatusOK}, true, 1},
+		{"5xx se reintenta", []int{http.StatusBadGateway, http.StatusOK}, true, 2},
+		{"429 se reintenta", []int{http.StatusTooManyRequests, http.StatusAccepted}, true, 2},
+		{"5xx agota los intentos", []int{http.StatusInternalServerError}, false, intentosWebhook},
+		{"otro 4xx no se reintenta", []int{http.StatusBadRequest}, false, 1},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			srv, intentos := servidorWebhook(t, caso.codigos...)
+
+			ack, err := clienteWebhookPrueba(srv.URL).RecibirOferta(context.Background(), ofertaPrueba())
+			if caso.exito && (err != nil || !ack.GetExito()) {
+				t.Fatalf("se esperaba éxito, se obtuvo %v, %v", ack, err)
+			}
+			if !caso.exito && err == nil {
+				t.Fatal("se esperaba un error de entrega")
+			}
+			if n := intentos.Load(); n != caso.intentos {
+				t.Fatalf("se esperaban %d intentos, hubo %d", caso.intentos, n)
+			}
+		})
+	}
+}
+
+func TestWebhookTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Hasta leer el cuerpo el servidor no detecta que el cliente se fue
+		io.Copy(io.Discard, r.Body)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	inicio := time.Now()
+	_, err := clienteWebhookPrueba(srv.URL).RecibirOferta(ctx, ofertaPrueba())
+	if err == nil {
+		t.Fatal("un webhook que no responde debe fallar")
+	}
+	if transcurrido := time.Since(inicio); transcurrido > time.Second {
+		t.Fatalf("la entrega no respetó el plazo: tardó %v", transcurrido)
+	}
+}
+
+func TestValidarWebhookRedesPrivadas(t *testing.T) {
+	internas := []string{
+		"http://127.0.0.1:8080/ofertas",
+		"http://localhost/ofertas",
+		"http://[::1]/ofertas",
+		"http://10.0.0.5/ofertas",
+		"http://192.168.1.10/ofertas",
+		"http://169.254.169.254/latest/meta-data",
+	}
+	for _, url := range internas {
+		if err := validarWebhook(url, secretoPrueba, false); err == nil {
+			t.Errorf("%s: se esperaba rechazo sin WEBHOOK_REDES_PRIVADAS", url)
+		}
+		if err := validarWebhook(url, secretoPrueba, true); err != nil {
+			t.Errorf("%s: con redes privadas permitidas se esperaba aceptarla: %v", url, err)
+		}
+	}
+
+	if err := validarWebhook("https://tienda.example/ofertas", secretoPrueba, false); err != nil {
+		t.Errorf("URL pública rechazada: %v", err)
+	}
+}
+
+func TestWebhookNoConectaARedesPrivadas(t *testing.T) {
+	srv, intentos := servidorWebhook(t, http.StatusOK)
+
+	cliente := clienteWebhookPrueba(srv.URL)
+	cliente.http = nuevoClienteHTTPWebhook(false)
+
+	_, err := cliente.RecibirOferta(context.Background(), ofertaPrueba())
+	if !errors.Is(err, errDestinoPrivado) {
+		t.Fatalf("se esperaba errDestinoPrivado, se obtuvo %v", err)
+	}
+	if n := intentos.Load(); n != 0 {
+		t.Fatalf("el webhook en loopback recibió %d intentos", n)
+	}
+}
@@ -0,0 +1,432 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	pb "consumidor/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// filtroConsulta son los criterios de "consumidor query".
+type filtroConsulta struct {
+	categorias []string // canónicas según el broker, en minúsculas
+	tiendas    []string
+	precioMin  int
+	precioMax  int
+	desde      string // AAAA-MM-DD, sobre el campo fecha
+	hasta      string
+}
+
+func (f filtroConsulta) cumple(oferta *pb.OfertaRequest) bool {
+	if len(f.categorias) > 0 && !incluyeCategoria(f.categorias, strings.ToLower(oferta.GetCategoria())) {
+		return false
+	}
+	if len(f.tiendas) > 0 && !contiene(f.tiendas, strings.ToLower(oferta.GetTienda())) {
+		return false
+	}
+	precio := int(oferta.GetPrecioDescuento())
+	if f.precioMin > 0 && precio < f.precioMin {
+		return false
+	}
+	if f.precioMax > 0 && precio > f.precioMax {
+		return false
+	}
+	// Las fechas AAAA-MM-DD se comparan bien como texto
+	if f.desde != "" && oferta.GetFecha() < f.desde {
+		return false
+	}
+	if f.hasta != "" && oferta.GetFecha() > f.hasta {
+		return false
+	}
+	return true
+}
+
+// incluyeCategoria indica si la categoría de la oferta es alguna de las del
+// filtro o una subcategoría suya ("electrónica" incluye "electrónica/audio"),
+// igual que las preferencias de un consumidor en el broker.
+func incluyeCategoria(filtro []string, categoria string) bool {
+	for _, cat := range filtro {
+		if categoria == cat || strings.HasPrefix(categoria, cat+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// descuento devuelve el porcentaje de descuento, o -1 si la oferta no trae
+// precio_original (ofertas antiguas).
+func descuento(oferta *pb.OfertaRequest) float64 {
+	original := oferta.GetPrecioOriginal()
+	if original <= 0 {
+		return -1
+	}
+	return 100 * float64(original-oferta.GetPrecioDescuento()) / float64(original)
+}
+
+func listaFlag(valor string, normalizar func(string) string) []string {
+	var lista []string
+	for _, v := range strings.Split(valor, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			lista = append(lista, normalizar(v))
+		}
+	}
+	return lista
+}
+
+// ejecutarConsulta implementa "consumidor query": busca entre las ofertas
+// recibidas (en cualquiera de sus destinos) o en el histórico del broker.
+func ejecutarConsulta(args []string) error {
+	fs := flag.NewFlagSet("query", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Uso: consumidor query [opciones]\n\n")
+		fmt.Fprintf(fs.Output(), "Busca entre las ofertas recibidas por un consumidor o en el histórico del broker.\n\n")
+		fs.PrintDefaults()
+	}
+
+	directorioDefecto := os.Getenv("DESTINO_DIR")
+	if directorioDefecto == "" {
+		directorioDefecto = "."
+	}
+	brokerDefecto := os.Getenv("BROKER_ADDR")
+	if brokerDefecto == "" {
+		brokerDefecto = "localhost:50051"
+	}
+
+	id := fs.String("id", os.Getenv("CONSUMIDOR_ID"), "ID del consumidor")
+	fuente := fs.String("fuente", "csv", "de dónde leer: csv, jsonl, sqlite, rotativo o broker")
+	directorio := fs.String("dir", directorioDefecto, "directorio de los destinos del consumidor")
+	broker := fs.String("broker", brokerDefecto, "dirección(es) del broker, separadas por coma (fuente broker)")
+	categoria := fs.String("categoria", "", "categorías, separadas por coma")
+	tienda := fs.String("tienda", "", "tiendas, separadas por coma")
+	precioMin := fs.Int("precio-min", 0, "precio con descuento mínimo")
+	precioMax := fs.Int("precio-max", 0, "precio con descuento máximo")
+	desde := fs.String("desde", "", "fecha mínima (AAAA-MM-DD)")
+	hasta := fs.String("hasta", "", "fecha máxima (AAAA-MM-DD)")
+	orden := fs.String("orden", "fecha", "ordenar por: fecha, precio o descuento")
+	descendente := fs.Bool("desc", false, "orden descendente")
+	limite := fs.Int("limite", 0, "máximo de resultados (0 = todos)")
+	formato := fs.String("formato", "tabla", "salida: tabla o json")
+
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return nil
+		}
+		return err
+	}
+	if *id == "" {
+		return fmt.Errorf("falta -id (o CONSUMIDOR_ID)")
+	}
+	for _, fecha := range []string{*desde, *hasta} {
+		if fecha == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", fecha); err != nil {
+			return fmt.Errorf("fecha %q no tiene formato AAAA-MM-DD", fecha)
+		}
+	}
+	if *orden != "fecha" && *orden != "precio" && *orden != "descuento" {
+		return fmt.Errorf("orden debe ser fecha, precio o descuento, no %q", *orden)
+	}
+	if *formato != "tabla" && *formato != "json" {
+		return fmt.Errorf("formato debe ser tabla o json, no %q", *formato)
+	}
+
+	ofertas, err := leerOfertasConsulta(*fuente, *id, *directorio, *broker)
+	if err != nil {
+		return err
+	}
+
+	filtro := filtroConsulta{
+		categorias: categoriasConsulta(listaFlag(*categoria, strings.TrimSpace), *broker),
+		tiendas:    listaFlag(*tienda, strings.ToLower),
+		precioMin:  *precioMin,
+		precioMax:  *precioMax,
+		desde:      *desde,
+		hasta:      *hasta,
+	}
+	var resultado []*pb.OfertaRequest
+	for _, oferta := range ofertas {
+		if filtro.cumple(oferta) {
+			resultado = append(resultado, oferta)
+		}
+	}
+
+	sort.SliceStable(resultado, func(i, j int) bool {
+		a, b := resultado[i], resultado[j]
+		if *descendente {
+			a, b = b, a
+		}
+		switch *orden {
+		case "precio":
+			return a.GetPrecioDescuento() < b.GetPrecioDescuento()
+		case "descuento":
+			return descuento(a) < descuento(b)
+		default:
+			if a.GetFecha() != b.GetFecha() {
+				return a.GetFecha() < b.GetFecha()
+			}
+			return a.GetTimestamp() < b.GetTimestamp()
+		}
+	})
+	if *limite > 0 && len(resultado) > *limite {
+		resultado = resultado[:*limite]
+	}
+
+	if *formato == "json" {
+		return imprimirJSON(os.Stdout, resultado)
+	}
+	imprimirTabla(os.Stdout, resultado)
+	fmt.Fprintf(os.Stderr, "%d de %d ofertas\n", len(resultado), len(ofertas))
+	return nil
+}
+
+// leerOfertasConsulta carga todas las ofertas de la fuente indicada.
+func leerOfertasConsulta(fuente, consumidorID, directorio, broker string) ([]*pb.OfertaRequest, error) {
+	base := filepath.Join(directorio, consumidorID)
+	switch fuente {
+	case "csv":
+		return leerCSVConsulta(base + ".csv")
+	case "jsonl":
+		return leerJSONLConsulta([]string{base + ".jsonl"})
+	case "rotativo":
+		archivos, err := filepath.Glob(base + "-*.jsonl")
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(archivos)
+		return leerJSONLConsulta(archivos)
+	case "sqlite":
+		return leerSQLiteConsulta(base + ".db")
+	case "broker":
+		return leerBrokerConsulta(broker, consumidorID)
+	default:
+		return nil, fmt.Errorf("fuente desconocida %q (usar csv, jsonl, sqlite, rotativo o broker)", fuente)
+	}
+}
+
+func leerCSVConsulta(archivo string) ([]*pb.OfertaRequest, error) {
+	file, err := os.Open(archivo)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+
+	var ofertas []*pb.OfertaRequest
+	for fila := 0; ; fila++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return ofertas, fmt.Errorf("%s, fila %d: %v", archivo, fila, err)
+		}
+		if fila == 0 {
+			continue // Skip header
+		}
+		if oferta, err := ofertaDesdeFila(record); err == nil {
+			ofertas = append(ofertas, oferta)
+		}
+	}
+	return ofertas, nil
+}
+
+func leerJSONLConsulta(archivos []string) ([]*pb.OfertaRequest, error) {
+	var ofertas []*pb.OfertaRequest
+	for _, archivo := range archivos {
+		file, err := os.Open(archivo)
+		if err != nil {
+			return nil, err
+		}
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			var r registroOferta
+			if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+				continue // línea incompleta
+			}
+			ofertas = append(ofertas, r.aOferta())
+		}
+		file.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("%s: %v", archivo, err)
+		}
+	}
+	return ofertas, nil
+}
+
+func leerSQLiteConsulta(archivo string) ([]*pb.OfertaRequest, error) {
+	if _, err := os.Stat(archivo); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite", archivo+"?mode=ro")
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	filas, err := db.Query(`SELECT oferta_id, producto_id, tienda, categoria, producto, precio_descuento,
+		precio_original, stock, fecha, cliente_id, timestamp, secuencia FROM ofertas`)
+	if err != nil {
+		return nil, err
+	}
+	defer filas.Close()
+
+	var ofertas []*pb.OfertaRequest
+	for filas.Next() {
+		o := &pb.OfertaRequest{}
+		if err := filas.Scan(&o.OfertaId, &o.ProductoId, &o.Tienda, &o.Categoria, &o.Producto,
+			&o.PrecioDescuento, &o.PrecioOriginal, &o.Stock, &o.Fecha, &o.ClienteId, &o.Timestamp, &o.Secuencia); err != nil {
+			return nil, err
+		}
+		ofertas = append(ofertas, o)
+	}
+	return ofertas, filas.Err()
+}
+
+// categoriasConsulta resuelve las categorías del filtro con la taxonomía del
+// broker (alias, tildes, subcategorías). Sin broker se usan tal cual.
+func categoriasConsulta(categorias []string, brokerAddr string) []string {
+	if len(categorias) == 0 {
+		return nil
+	}
+
+	resueltas := make([]string, len(categorias))
+	for i, cat := range categorias {
+		resueltas[i] = strings.ToLower(cat)
+	}
+
+	var ultimoErr error
+	for _, direccion := range strings.Split(brokerAddr, ",") {
+		conn, err := grpc.Dial(direccion, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			ultimoErr = err
+			continue
+		}
+		resp, err := resolverCategorias(conn, categorias)
+		conn.Close()
+		if err != nil {
+			ultimoErr = fmt.Errorf("%s: %v", direccion, err)
+			continue
+		}
+		for i, cat := range resp.GetCategorias() {
+			if cat.GetCanonica() != "" {
+				resueltas[i] = strings.ToLower(cat.GetCanonica())
+			} else {
+				fmt.Fprintf(os.Stderr, "advertencia: la categoría %q no existe en la taxonomía\n", cat.GetCategoria())
+			}
+		}
+		return resueltas
+	}
+	fmt.Fprintf(os.Stderr, "advertencia: no se pudo resolver categorías con el broker (%v); se comparan tal cual\n", ultimoErr)
+	return resueltas
+}
+
+// leerBrokerConsulta pide el histórico al broker (filtrado por las
+// preferencias del consumidor si está registrado), probando cada réplica.
+func leerBrokerConsulta(brokerAddr, consumidorID string) ([]*pb.OfertaRequest, error) {
+	var ultimoErr error
+	for _, direccion := range strings.Split(brokerAddr, ",") {
+		conn, err := grpc.Dial(direccion, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			ultimoErr = err
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		resp, err := pb.NewConsumidorClient(conn).SolicitarHistorico(ctx, &pb.SolicitarHistoricoRequest{
+			ConsumidorId: consumidorID,
+		})
+		cancel()
+		conn.Close()
+		if err == nil {
+			return resp.GetOfertas(), nil
+		}
+		ultimoErr = fmt.Errorf("%s: %v", direccion, err)
+	}
+	return nil, ultimoErr
+}
+
+func (r registroOferta) aOferta() *pb.OfertaRequest {
+	return &pb.OfertaRequest{
+		OfertaId:            r.OfertaID,
+		ProductoId:          r.ProductoID,
+		Tienda:              r.Tienda,
+		Categoria:           r.Categoria,
+		Producto:            r.Producto,
+		PrecioDescuento:     r.PrecioDescuento,
+		PrecioOriginal:      r.PrecioOriginal,
+		Stock:               r.Stock,
+		Fecha:               r.Fecha,
+		ClienteId:           r.ClienteID,
+		Timestamp:           r.Timestamp,
+		Secuencia:           r.Secuencia,
+		SecuenciaConsumidor: r.SecuenciaConsumidor,
+	}
+}
+
+func imprimirTabla(w io.Writer, ofertas []*pb.OfertaRequest) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FECHA\tTIENDA\tCATEGORÍA\tPRODUCTO\tPRECIO\tORIGINAL\tDESC.\tSTOCK\tOFERTA")
+	for _, o := range ofertas {
+		original, desc := "-", "-"
+		if d := descuento(o); d >= 0 {
+			original = fmt.Sprintf("$%d", o.GetPrecioOriginal())
+			desc = fmt.Sprintf("%.0f%%", d)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t$%d\t%s\t%s\t%d\t%s\n",
+			o.GetFecha(), o.GetTienda(), o.GetCategoria(), o.GetProducto(),
+			o.GetPrecioDescuento(), original, desc, o.GetStock(), o.GetOfertaId())
+	}
+	tw.Flush()
+}
+
+// resultadoConsulta es una oferta en la salida JSON, con el descuento calculado.
+type resultadoConsulta struct {
+	registroOferta
+	Descuento *float64 `json:"descuento,omitempty"`
+}
+
+func imprimirJSON(w io.Writer, ofertas []*pb.OfertaRequest) error {
+	resultado := make([]resultadoConsulta, 0, len(ofertas))
+	for _, o := range ofertas {
+		r := resultadoConsulta{registroOferta: registroOferta{
+			OfertaID:            o.GetOfertaId(),
+			ProductoID:          o.GetProductoId(),
+			Tienda:              o.GetTienda(),
+			Categoria:           o.GetCategoria(),
+			Producto:            o.GetProducto(),
+			PrecioDescuento:     o.GetPrecioDescuento(),
+			PrecioOriginal:      o.GetPrecioOriginal(),
+			Stock:               o.GetStock(),
+			Fecha:               o.GetFecha(),
+			ClienteID:           o.GetClienteId(),
+			Timestamp:           o.GetTimestamp(),
+			Secuencia:           o.GetSecuencia(),
+			SecuenciaConsumidor: o.GetSecuenciaConsumidor(),
+		}}
+		if d := descuento(o); d >= 0 {
+			d = float64(int(d*10+0.5)) / 10
+			r.Descuento = &d
+		}
+		resultado = append(resultado, r)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(resultado)
+}
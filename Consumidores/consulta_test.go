@@ -0,0 +1,53 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	pb "consumidor/proto"
+)
+
+func TestFiltroConsulta(t *testing.T) {
+	oferta := &pb.OfertaRequest{
+		Tienda:          "Riploy",
+		Categoria:       "Electrónica/Audio",
+		PrecioDescuento: 19990,
+		Fecha:           "2025-11-20",
+	}
+	casos := []struct {
+		nombre string
+		filtro filtroConsulta
+		cumple bool
+	}{
+		{"sin filtro", filtroConsulta{}, true},
+		{"misma categoría", filtroConsulta{categorias: []string{"electrónica/audio"}}, true},
+		{"categoría padre", filtroConsulta{categorias: []string{"electrónica"}}, true},
+		{"otra subcategoría", filtroConsulta{categorias: []string{"electrónica/video"}}, false},
+		{"prefijo que no es padre", filtroConsulta{categorias: []string{"electro"}}, false},
+		{"alguna de varias", filtroConsulta{categorias: []string{"hogar", "electrónica"}}, true},
+		{"tienda", filtroConsulta{tiendas: []string{"riploy"}}, true},
+		{"otra tienda", filtroConsulta{tiendas: []string{"parisio"}}, false},
+		{"precio en rango", filtroConsulta{precioMin: 10000, precioMax: 20000}, true},
+		{"precio bajo el mínimo", filtroConsulta{precioMin: 20000}, false},
+		{"precio sobre el máximo", filtroConsulta{precioMax: 19989}, false},
+		{"fecha en rango", filtroConsulta{desde: "2025-11-20", hasta: "2025-11-20"}, true},
+		{"antes de desde", filtroConsulta{desde: "2025-11-21"}, false},
+		{"después de hasta", filtroConsulta{hasta: "2025-11-19"}, false},
+	}
+	for _, caso := range casos {
+		if got := caso.filtro.cumple(oferta); got != caso.cumple {
+			t.Errorf("%s: cumple = %v, se esperaba %v", caso.nombre, got, caso.cumple)
+		}
+	}
+}
+
+func TestListaFlag(t *testing.T) {
+	got := listaFlag(" Riploy, ,Parisio,", strings.ToLower)
+	if want := []string{"riploy", "parisio"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("listaFlag = %v, se esperaba %v", got, want)
+	}
+	if got := listaFlag("", strings.ToLower); got != nil {
+		t.Fatalf("listaFlag vacío = %v, se esperaba nil", got)
+	}
+}
@@ -4,12 +4,16 @@ import (
 	"context"
 	"encoding/csv"
 	"fmt"
+	"io"
 	"log"
 	"net"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	pb "consumidor/proto"
@@ -18,6 +22,9 @@ import (
 	"google.golang.org/grpc/credentials/insecure"
 )
 
+// Margen hacia atrás al pedir el histórico desde la última oferta recibida
+const margenHistorico = 60 * time.Second
+
 type Consumidor struct {
 	pb.UnimplementedNotificacionesConsumidorServer
 	
@@ -25,15 +32,32 @@ type Consumidor struct {
 	categorias    []string
 	tiendas       []string
 	precioMax     int32
-	puerto        string
+	puerto        string // donde escuchar (":0" = puerto asignado por el sistema)
+	direccion     string // dirección anunciada al broker, verificada con un handshake
+	grupo         string // grupo de consumidores (vacío = recibe todas sus ofertas)
+	entregaOrdenada bool // pedir al broker entrega en orden de secuencia
+	seguimientos  []*pb.Seguimiento // productos seguidos (solo llegan sus bajas de precio)
+	resumenSegundos   int32 // modo resumen: el broker acumula y envía cada N segundos...
+	resumenMaxOfertas int32 // ...o al juntar M ofertas
+	
+	// Último secuencia_consumidor recibido, para detectar huecos
+	ultimaSecuencia int64
+	huecos          int64
+	secuenciaMutex  sync.Mutex
 	
 	ofertas       []*pb.OfertaRequest
+	vistas        map[string]bool // oferta_id ya almacenados (en memoria y en el CSV)
+	ultimoTimestamp int64         // timestamp más reciente recibido
 	ofertasMutex  sync.Mutex
 	
-	archivoCSV    string
+	archivoCSV    string           // CSV desde el que se recupera tras un reinicio
+	destinos      []destinoOfertas // dónde se guarda cada oferta nueva
 	
 	// Cliente para conectarse al broker
 	brokerClient  pb.ConsumidorClient
+	brokerConn    *grpc.ClientConn
+	brokers       []string
+	brokerActual  int
 	
 	// Estado
 	activo        bool
@@ -48,7 +72,9 @@ func NewConsumidor(id string, categorias, tiendas []string, precioMax int32, pue
 		precioMax:  precioMax,
 		puerto:     puerto,
 		ofertas:    make([]*pb.OfertaRequest, 0),
+		vistas:     make(map[string]bool),
 		archivoCSV: fmt.Sprintf("%s.csv", id),
+		destinos:   []destinoOfertas{&destinoCSV{archivo: fmt.Sprintf("%s.csv", id)}},
 		activo:     true,
 	}
 }
@@ -65,17 +91,40 @@ func (c *Consumidor) RecibirOferta(ctx context.Context, in *pb.OfertaRequest) (*
 		}, nil
 	}
 	
+	// Aviso de stock agotado sobre una oferta ya publicada: no es una oferta nueva
+	if in.GetEvento() == "agotado" {
+		log.Printf("[%s] 🚫 Oferta %s agotada: %s", c.id, in.GetOfertaId(), in.GetProducto())
+		c.marcarAgotada(in.GetOfertaId())
+		return &pb.AckResponse{
+			Exito:   true,
+			NodoId:  c.id,
+			Mensaje: "Aviso recibido",
+		}, nil
+	}
+	
 	log.Printf("[%s] 📦 Recibida oferta %s: %s - $%d", 
 		c.id, in.GetOfertaId(), in.GetProducto(), in.GetPrecioDescuento())
 	
-	// Almacenar oferta
-	c.ofertasMutex.Lock()
-	c.ofertas = append(c.ofertas, in)
-	c.ofertasMutex.Unlock()
+	if in.GetSecuenciaConsumidor() > 0 {
+		c.verificarSecuencia(in)
+	}
+	
+	if len(c.seguimientos) > 0 {
+		if anterior := in.GetPrecioMinimoAnterior(); anterior > 0 && in.GetPrecioDescuento() < anterior {
+			log.Printf("[%s] 📉 %s bajó de $%d a $%d", c.id, in.GetProducto(), anterior, in.GetPrecioDescuento())
+		} else {
+			log.Printf("[%s] 🎯 %s llegó al precio objetivo: $%d", c.id, in.GetProducto(), in.GetPrecioDescuento())
+		}
+	}
 	
-	// Guardar en CSV
-	if err := c.guardarEnCSV(in); err != nil {
-		log.Printf("[%s] Error guardando en CSV: %v", c.id, err)
+	// Almacenar oferta (si el broker la reintenta se confirma sin duplicarla)
+	if !c.almacenarOferta(in) {
+		log.Printf("[%s] 🔁 Oferta %s ya recibida, se ignora", c.id, in.GetOfertaId())
+		return &pb.AckResponse{
+			Exito:   true,
+			NodoId:  c.id,
+			Mensaje: "Oferta ya recibida",
+		}, nil
 	}
 	
 	return &pb.AckResponse{
@@ -85,67 +134,304 @@ func (c *Consumidor) RecibirOferta(ctx context.Context, in *pb.OfertaRequest) (*
 	}, nil
 }
 
-func (c *Consumidor) guardarEnCSV(oferta *pb.OfertaRequest) error {
+// RecibirResumen recibe las ofertas acumuladas del modo resumen, de mayor a
+// menor descuento, y las procesa igual que si hubieran llegado sueltas.
+func (c *Consumidor) RecibirResumen(ctx context.Context, in *pb.ResumenOfertas) (*pb.AckResponse, error) {
+	c.estadoMutex.RLock()
+	activo := c.activo
+	c.estadoMutex.RUnlock()
+	
+	if !activo {
+		return &pb.AckResponse{
+			Exito:   false,
+			Mensaje: "Consumidor inactivo",
+		}, nil
+	}
+	
+	log.Printf("[%s] 📬 Resumen con %d ofertas (mayor descuento primero)", c.id, len(in.GetOfertas()))
+	for _, oferta := range in.GetOfertas() {
+		c.RecibirOferta(ctx, oferta)
+	}
+	
+	return &pb.AckResponse{
+		Exito:   true,
+		NodoId:  c.id,
+		Mensaje: "Resumen recibido",
+	}, nil
+}
+
+// Verificar responde el handshake con el que el broker comprueba, antes de
+// aceptar el registro, que la dirección anunciada llega a este consumidor.
+func (c *Consumidor) Verificar(ctx context.Context, in *pb.VerificacionRequest) (*pb.VerificacionResponse, error) {
+	log.Printf("[%s] 🤝 Handshake del broker", c.id)
+	return &pb.VerificacionResponse{
+		ConsumidorId: c.id,
+		Nonce:        in.GetNonce(),
+	}, nil
+}
+
+// verificarSecuencia revisa el correlativo de la entrega ordenada. Un salto
+// significa que el broker no pudo entregar ofertas intermedias (quedaron como
+// cartas muertas); un número repetido o menor es una reentrega.
+func (c *Consumidor) verificarSecuencia(in *pb.OfertaRequest) {
+	c.secuenciaMutex.Lock()
+	defer c.secuenciaMutex.Unlock()
+	
+	secuencia := in.GetSecuenciaConsumidor()
+	switch {
+	case c.ultimaSecuencia == 0:
+		// Primera oferta desde que se inició el consumidor
+	case secuencia == c.ultimaSecuencia+1:
+	case secuencia > c.ultimaSecuencia+1:
+		faltantes := secuencia - c.ultimaSecuencia - 1
+		c.huecos += faltantes
+		log.Printf("[%s] ⚠️ Hueco en la secuencia: faltan %d ofertas (#%d a #%d)",
+			c.id, faltantes, c.ultimaSecuencia+1, secuencia-1)
+	default:
+		log.Printf("[%s] 🔁 Oferta %s reentregada (#%d, última #%d)",
+			c.id, in.GetOfertaId(), secuencia, c.ultimaSecuencia)
+		return
+	}
+	c.ultimaSecuencia = secuencia
+}
+
+// almacenarOferta guarda la oferta en memoria y en el CSV si no se había
+// visto antes. Devuelve false si era un duplicado.
+func (c *Consumidor) almacenarOferta(oferta *pb.OfertaRequest) bool {
 	c.ofertasMutex.Lock()
 	defer c.ofertasMutex.Unlock()
 	
-	// Verificar si el archivo existe
-	fileExists := true
-	if _, err := os.Stat(c.archivoCSV); os.IsNotExist(err) {
-		fileExists = false
+	if c.vistas[oferta.GetOfertaId()] {
+		return false
+	}
+	c.vistas[oferta.GetOfertaId()] = true
+	c.ofertas = append(c.ofertas, oferta)
+	if oferta.GetTimestamp() > c.ultimoTimestamp {
+		c.ultimoTimestamp = oferta.GetTimestamp()
+	}
+	
+	for _, destino := range c.destinos {
+		if err := destino.guardar(oferta); err != nil {
+			log.Printf("[%s] Error guardando en %s: %v", c.id, destino.nombre(), err)
+		}
 	}
+	return true
+}
+
+// marcarAgotada deja en cero el stock de la oferta en memoria. Los destinos
+// no se tocan: guardan la oferta tal como llegó.
+func (c *Consumidor) marcarAgotada(ofertaID string) {
+	c.ofertasMutex.Lock()
+	defer c.ofertasMutex.Unlock()
 	
-	file, err := os.OpenFile(c.archivoCSV, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	for _, oferta := range c.ofertas {
+		if oferta.GetOfertaId() == ofertaID {
+			oferta.Stock = 0
+		}
+	}
+}
+
+// cargarOfertasCSV recupera las ofertas recibidas antes de un reinicio desde
+// el CSV: las vuelve a cargar en memoria y reconstruye el índice de vistas.
+func (c *Consumidor) cargarOfertasCSV() error {
+	file, err := os.Open(c.archivoCSV)
 	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
 		return err
 	}
 	defer file.Close()
 	
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	
+	c.ofertasMutex.Lock()
+	defer c.ofertasMutex.Unlock()
 	
-	// Escribir header si es archivo nuevo
-	if !fileExists {
-		header := []string{"oferta_id", "producto_id", "tienda", "categoria", "producto", "precio_descuento", "stock", "fecha", "timestamp"}
-		if err := writer.Write(header); err != nil {
-			return err
+	for fila := 0; ; fila++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// Última línea incompleta por una caída a mitad de escritura
+			log.Printf("[%s] CSV truncado en la fila %d: %v", c.id, fila, err)
+			break
+		}
+		if fila == 0 || len(record) == 0 || record[0] == "" {
+			continue // Skip header
+		}
+		oferta, err := ofertaDesdeFila(record)
+		if err != nil {
+			log.Printf("[%s] Fila %d del CSV inválida: %v", c.id, fila, err)
+			continue
+		}
+		if c.vistas[oferta.GetOfertaId()] {
+			continue
+		}
+		c.vistas[oferta.GetOfertaId()] = true
+		c.ofertas = append(c.ofertas, oferta)
+		if oferta.GetTimestamp() > c.ultimoTimestamp {
+			c.ultimoTimestamp = oferta.GetTimestamp()
 		}
 	}
 	
-	// Escribir fila
-	row := []string{
-		oferta.GetOfertaId(),
-		oferta.GetProductoId(),
-		oferta.GetTienda(),
-		oferta.GetCategoria(),
-		oferta.GetProducto(),
-		fmt.Sprintf("%d", oferta.GetPrecioDescuento()),
-		fmt.Sprintf("%d", oferta.GetStock()),
-		oferta.GetFecha(),
-		fmt.Sprintf("%d", oferta.GetTimestamp()),
+	log.Printf("[%s] 📂 Recuperadas %d ofertas de %s", c.id, len(c.ofertas), c.archivoCSV)
+	return nil
+}
+
+// ofertaDesdeFila convierte una fila del CSV (mismas columnas que escribe
+// destinoCSV) en una oferta. Los CSV anteriores no tienen precio_original.
+func ofertaDesdeFila(record []string) (*pb.OfertaRequest, error) {
+	if len(record) != 9 && len(record) != 10 {
+		return nil, fmt.Errorf("se esperaban 9 o 10 columnas, hay %d", len(record))
 	}
-	
-	return writer.Write(row)
+	precio, err := strconv.Atoi(record[5])
+	if err != nil {
+		return nil, fmt.Errorf("precio_descuento: %v", err)
+	}
+	stock, err := strconv.Atoi(record[6])
+	if err != nil {
+		return nil, fmt.Errorf("stock: %v", err)
+	}
+	timestamp, err := strconv.ParseInt(record[8], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("timestamp: %v", err)
+	}
+	original := 0
+	if len(record) == 10 {
+		if original, err = strconv.Atoi(record[9]); err != nil {
+			return nil, fmt.Errorf("precio_original: %v", err)
+		}
+	}
+	return &pb.OfertaRequest{
+		OfertaId:        record[0],
+		ProductoId:      record[1],
+		Tienda:          record[2],
+		Categoria:       record[3],
+		Producto:        record[4],
+		PrecioDescuento: int32(precio),
+		Stock:           int32(stock),
+		Fecha:           record[7],
+		Timestamp:       timestamp,
+		PrecioOriginal:  int32(original),
+	}, nil
 }
 
-func (c *Consumidor) registrarEnBroker(brokerAddr string) error {
+func (c *Consumidor) conectarBroker(brokerAddr string) error {
 	conn, err := grpc.Dial(brokerAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
 		return err
 	}
 	
+	if c.brokerConn != nil {
+		c.brokerConn.Close()
+	}
+	c.brokerConn = conn
 	c.brokerClient = pb.NewConsumidorClient(conn)
+	return nil
+}
+
+// siguienteBroker pasa a la siguiente réplica del broker (failover).
+func (c *Consumidor) siguienteBroker() error {
+	c.brokerActual = (c.brokerActual + 1) % len(c.brokers)
+	log.Printf("[%s] Cambiando a réplica del broker %s", c.id, c.brokers[c.brokerActual])
+	return c.conectarBroker(c.brokers[c.brokerActual])
+}
+
+// resolverCategorias pide al broker la forma canónica de cada categoría; las
+// desconocidas vuelven con canónica vacía.
+func resolverCategorias(conn *grpc.ClientConn, categorias []string) (*pb.ResolverCategoriasResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	
+	return pb.NewTaxonomiaClient(conn).ResolverCategorias(ctx, &pb.ResolverCategoriasRequest{Categorias: categorias})
+}
+
+// normalizarPreferencias consulta la taxonomía del broker al iniciar y lleva
+// las categorías del consumidor a su forma canónica. Las que no están
+// escritas así (alias, sin tildes) las resuelve el broker; las desconocidas
+// se avisan y se conservan.
+func (c *Consumidor) normalizarPreferencias() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	
+	taxonomia, err := pb.NewTaxonomiaClient(c.brokerConn).ListarCategorias(ctx, &pb.ListarCategoriasRequest{})
+	if err != nil {
+		return err
+	}
+	log.Printf("[%s] 📚 Taxonomía versión %d (%d categorías)", c.id, taxonomia.GetVersion(), len(taxonomia.GetCategorias()))
+	
+	canonicas := make(map[string]bool)
+	for _, cat := range taxonomia.GetCategorias() {
+		canonicas[cat.GetNombre()] = true
+		for _, sub := range cat.GetSubcategorias() {
+			canonicas[cat.GetNombre()+"/"+sub] = true
+		}
+	}
+	
+	var pendientes []string
+	var posiciones []int
+	for i, cat := range c.categorias {
+		if cat != "null" && !canonicas[cat] {
+			pendientes = append(pendientes, cat)
+			posiciones = append(posiciones, i)
+		}
+	}
+	if len(pendientes) == 0 {
+		return nil
+	}
+	
+	resp, err := resolverCategorias(c.brokerConn, pendientes)
+	if err != nil {
+		return err
+	}
+	for i, cat := range resp.GetCategorias() {
+		if cat.GetCanonica() != "" {
+			c.categorias[posiciones[i]] = cat.GetCanonica()
+		} else {
+			log.Printf("[%s] ⚠️  Categoría '%s' no existe en la taxonomía (versión %d)", c.id, cat.GetCategoria(), taxonomia.GetVersion())
+		}
+	}
+	return nil
+}
+
+// registrarEnBroker acepta una o varias direcciones separadas por coma
+// (réplicas del broker) y prueba cada una hasta que alguna acepte el registro.
+func (c *Consumidor) registrarEnBroker(brokerAddr string) error {
+	c.brokers = strings.Split(brokerAddr, ",")
+	if err := c.conectarBroker(c.brokers[c.brokerActual%len(c.brokers)]); err != nil {
+		return err
+	}
 	
-	// Obtener IP del contenedor/host
-	miDireccion := fmt.Sprintf("%s%s", c.id, c.puerto)
+	if err := c.normalizarPreferencias(); err != nil {
+		log.Printf("[%s] ⚠️  No se pudo obtener la taxonomía: %v", c.id, err)
+	}
 	
-	resp, err := c.brokerClient.RegistrarConsumidor(context.Background(), &pb.RegistroConsumidorRequest{
-		ConsumidorId:   c.id,
-		Categorias:     c.categorias,
-		Tiendas:        c.tiendas,
-		PrecioMax:      c.precioMax,
-		DireccionGrpc:  miDireccion,
-	})
+	var resp *pb.RegistroConsumidorResponse
+	var err error
+	for intento := 0; intento < len(c.brokers); intento++ {
+		resp, err = c.brokerClient.RegistrarConsumidor(context.Background(), &pb.RegistroConsumidorRequest{
+			ConsumidorId:   c.id,
+			Categorias:     c.categorias,
+			Tiendas:        c.tiendas,
+			PrecioMax:      c.precioMax,
+			DireccionGrpc:  c.direccion,
+			Grupo:          c.grupo,
+			EntregaOrdenada: c.entregaOrdenada,
+			Seguimientos:   c.seguimientos,
+			ResumenSegundos:   c.resumenSegundos,
+			ResumenMaxOfertas: c.resumenMaxOfertas,
+		})
+		if err == nil || len(c.brokers) == 1 {
+			break
+		}
+		log.Printf("[%s] Broker no disponible: %v", c.id, err)
+		if errConn := c.siguienteBroker(); errConn != nil {
+			return errConn
+		}
+	}
 	
 	if err != nil {
 		return err
@@ -159,19 +445,49 @@ func (c *Consumidor) registrarEnBroker(brokerAddr string) error {
 	return nil
 }
 
+// desdeHistorico es el timestamp desde el que se pide el histórico: la última
+// oferta recibida menos margenHistorico, o 0 (todo) si aún no llegó ninguna.
+func desdeHistorico(ultimoTimestamp int64) int64 {
+	if ultimoTimestamp <= 0 {
+		return 0
+	}
+	return ultimoTimestamp - int64(margenHistorico/time.Second)
+}
+
 func (c *Consumidor) solicitarHistorico() error {
 	if c.brokerClient == nil {
 		return fmt.Errorf("no conectado al broker")
 	}
 	
-	log.Printf("[%s] 🔍 Solicitando histórico al broker...", c.id)
-	
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	// Solo lo posterior a la última oferta recibida; el margen cubre ofertas
+	// con timestamp algo anterior que se aceptaron después (los duplicados
+	// se descartan al almacenar)
+	c.ofertasMutex.Lock()
+	desde := desdeHistorico(c.ultimoTimestamp)
+	c.ofertasMutex.Unlock()
+	if desde > 0 {
+		log.Printf("[%s] 🔍 Solicitando histórico al broker desde %s...", c.id, time.Unix(desde, 0).Format("15:04:05"))
+	} else {
+		log.Printf("[%s] 🔍 Solicitando histórico al broker...", c.id)
+	}
 	
-	resp, err := c.brokerClient.SolicitarHistorico(ctx, &pb.SolicitarHistoricoRequest{
-		ConsumidorId: c.id,
-	})
+	var resp *pb.HistoricoConsumidorResponse
+	var err error
+	for intento := 0; intento < len(c.brokers); intento++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		resp, err = c.brokerClient.SolicitarHistorico(ctx, &pb.SolicitarHistoricoRequest{
+			ConsumidorId:   c.id,
+			DesdeTimestamp: desde,
+		})
+		cancel()
+		if err == nil || len(c.brokers) == 1 {
+			break
+		}
+		log.Printf("[%s] Broker no disponible: %v", c.id, err)
+		if errConn := c.siguienteBroker(); errConn != nil {
+			return errConn
+		}
+	}
 	
 	if err != nil {
 		return err
@@ -179,23 +495,14 @@ func (c *Consumidor) solicitarHistorico() error {
 	
 	log.Printf("[%s] 📚 Recibidas %d ofertas históricas", c.id, len(resp.GetOfertas()))
 	
-	// Guardar ofertas históricas
+	// Guardar ofertas históricas (solo las que no se habían recibido)
+	nuevas := 0
 	for _, oferta := range resp.GetOfertas() {
-		// Verificar si ya tenemos esta oferta
-		existe := false
-		c.ofertasMutex.Lock()
-		for _, o := range c.ofertas {
-			if o.GetOfertaId() == oferta.GetOfertaId() {
-				existe = true
-				break
-			}
-		}
-		if !existe {
-			c.ofertas = append(c.ofertas, oferta)
-			c.guardarEnCSV(oferta)
+		if c.almacenarOferta(oferta) {
+			nuevas++
 		}
-		c.ofertasMutex.Unlock()
 	}
+	log.Printf("[%s] %d ofertas históricas nuevas, %d ya estaban", c.id, nuevas, len(resp.GetOfertas())-nuevas)
 	
 	return nil
 }
@@ -266,41 +573,64 @@ func cargarPreferenciasConsumidor(archivoCSV, consumidorID string) (*Consumidor,
 				precioMax = int32(precio)
 			}
 			
-			// Determinar puerto basado en ID
-			puerto := determinarPuerto(consumidorID)
-			
-			return NewConsumidor(consumidorID, categorias, tiendas, precioMax, puerto), nil
+			return NewConsumidor(consumidorID, categorias, tiendas, precioMax, puertoEscucha()), nil
 		}
 	}
 	
 	return nil, fmt.Errorf("consumidor %s no encontrado en CSV", consumidorID)
 }
 
-func determinarPuerto(consumidorID string) string {
-	// Asignar puertos únicos basados en el ID
-	puertos := map[string]string{
-		"C-E1": ":50061",
-		"C-E2": ":50062",
-		"C-E3": ":50063",
-		"C-E4": ":50064",
-		"C-M1": ":50065",
-		"C-M2": ":50066",
-		"C-M3": ":50067",
-		"C-M4": ":50068",
-		"C-H1": ":50069",
-		"C-H2": ":50070",
-		"C-H3": ":50071",
-		"C-H4": ":50072",
-	}
-	
-	if puerto, existe := puertos[consumidorID]; existe {
-		return puerto
-	}
-	
-	return ":50061" // Default
+// puertoEscucha devuelve el puerto configurado en PUERTO ("50061" o
+// ":50061"). Sin PUERTO el sistema operativo asigna uno libre.
+func puertoEscucha() string {
+	puerto := os.Getenv("PUERTO")
+	if puerto == "" {
+		return ":0"
+	}
+	if !strings.HasPrefix(puerto, ":") {
+		puerto = ":" + puerto
+	}
+	return puerto
+}
+
+// direccionAnunciada arma la dirección que se entrega al broker para que
+// llame a RecibirOferta. DIRECCION_ANUNCIADA la fija (con o sin puerto); si
+// no, se usa la IP local con la que se llega al broker y el puerto real en
+// que se está escuchando.
+func direccionAnunciada(lis net.Listener, brokerAddr string) string {
+	puerto := strconv.Itoa(lis.Addr().(*net.TCPAddr).Port)
+	
+	if anunciada := os.Getenv("DIRECCION_ANUNCIADA"); anunciada != "" {
+		if _, _, err := net.SplitHostPort(anunciada); err == nil {
+			return anunciada
+		}
+		return net.JoinHostPort(anunciada, puerto)
+	}
+	
+	// Con UDP no se envía nada: solo se resuelve la ruta hacia el broker
+	broker := strings.Split(brokerAddr, ",")[0]
+	if conn, err := net.Dial("udp", broker); err == nil {
+		ip := conn.LocalAddr().(*net.UDPAddr).IP.String()
+		conn.Close()
+		return net.JoinHostPort(ip, puerto)
+	}
+	
+	host, err := os.Hostname()
+	if err != nil {
+		host = "localhost"
+	}
+	return net.JoinHostPort(host, puerto)
 }
 
 func main() {
+	// Subcomando de consulta: consumidor query [opciones]
+	if len(os.Args) > 1 && os.Args[1] == "query" {
+		if err := ejecutarConsulta(os.Args[2:]); err != nil {
+			log.Fatalf("❌ Error en la consulta: %v", err)
+		}
+		return
+	}
+
 	// Leer ID del consumidor desde argumentos o variable de entorno
 	consumidorID := os.Getenv("CONSUMIDOR_ID")
 	if consumidorID == "" {
@@ -323,16 +653,94 @@ func main() {
 	
 	log.Printf("[CONSUMIDOR] Iniciando consumidor %s", consumidorID)
 	
+	// Grupo de consumidores: las instancias con el mismo GRUPO se reparten las ofertas
+	grupo := os.Getenv("GRUPO")
+	
+	// Entrega ordenada: el broker envía de a una oferta, en orden de secuencia
+	entregaOrdenada := os.Getenv("ENTREGA_ORDENADA") == "true"
+	
 	// Cargar preferencias desde CSV
 	consumidor, err := cargarPreferenciasConsumidor(archivoConfig, consumidorID)
 	if err != nil {
 		log.Fatalf("Error cargando preferencias: %v", err)
 	}
+	consumidor.grupo = grupo
+	consumidor.entregaOrdenada = entregaOrdenada
+	
+	// Productos seguidos: SEGUIMIENTOS="RI-001;~notebook<=400000"
+	seguimientos, err := parsearSeguimientos(os.Getenv("SEGUIMIENTOS"))
+	if err != nil {
+		log.Fatalf("SEGUIMIENTOS inválido: %v", err)
+	}
+	consumidor.seguimientos = seguimientos
+	
+	// Modo resumen: RESUMEN_SEGUNDOS y/o RESUMEN_MAX_OFERTAS
+	for variable, destino := range map[string]*int32{
+		"RESUMEN_SEGUNDOS":    &consumidor.resumenSegundos,
+		"RESUMEN_MAX_OFERTAS": &consumidor.resumenMaxOfertas,
+	} {
+		if valor := os.Getenv(variable); valor != "" {
+			n, err := strconv.Atoi(valor)
+			if err != nil || n < 0 {
+				log.Fatalf("%s inválido: %q", variable, valor)
+			}
+			*destino = int32(n)
+		}
+	}
+	
+	// Destinos donde se guardan las ofertas (DESTINOS=csv,jsonl,sqlite,stdout,rotativo)
+	cfgDestinos, err := cargarConfigDestinos()
+	if err != nil {
+		log.Fatalf("Error en la configuración de destinos: %v", err)
+	}
+	destinos, err := nuevosDestinos(cfgDestinos, consumidorID)
+	if err != nil {
+		log.Fatalf("Error abriendo destinos: %v", err)
+	}
+	consumidor.destinos = destinos
+	consumidor.archivoCSV = filepath.Join(cfgDestinos.directorio, consumidorID+".csv")
+	
+	// Ofertas recibidas antes de un reinicio (también sirven para ignorar
+	// reintentos y repeticiones del histórico)
+	if err := consumidor.cargarOfertasCSV(); err != nil {
+		log.Fatalf("Error leyendo %s: %v", consumidor.archivoCSV, err)
+	}
 	
 	log.Printf("[%s] Preferencias:", consumidor.id)
 	log.Printf("  - Categorías: %v", consumidor.categorias)
 	log.Printf("  - Tiendas: %v", consumidor.tiendas)
 	log.Printf("  - Precio máximo: %d", consumidor.precioMax)
+	if consumidor.grupo != "" {
+		log.Printf("  - Grupo: %s", consumidor.grupo)
+	}
+	if consumidor.entregaOrdenada {
+		log.Printf("  - Entrega ordenada: sí")
+	}
+	if consumidor.resumenSegundos > 0 || consumidor.resumenMaxOfertas > 0 {
+		var limites []string
+		if consumidor.resumenSegundos > 0 {
+			limites = append(limites, fmt.Sprintf("cada %ds", consumidor.resumenSegundos))
+		}
+		if consumidor.resumenMaxOfertas > 0 {
+			limites = append(limites, fmt.Sprintf("cada %d ofertas", consumidor.resumenMaxOfertas))
+		}
+		log.Printf("  - Modo resumen: %s", strings.Join(limites, " o "))
+	}
+	for _, seg := range consumidor.seguimientos {
+		objetivo := "mínimo histórico"
+		if seg.GetPrecioObjetivo() > 0 {
+			objetivo = fmt.Sprintf("$%d o mínimo histórico", seg.GetPrecioObjetivo())
+		}
+		if seg.GetProductoId() != "" {
+			log.Printf("  - Sigue producto %s (%s)", seg.GetProductoId(), objetivo)
+		} else {
+			log.Printf("  - Sigue productos \"%s\" (%s)", seg.GetPatron(), objetivo)
+		}
+	}
+	log.Printf("  - Destinos: %v", cfgDestinos.tipos)
+	if !contiene(cfgDestinos.tipos, "csv") {
+		log.Printf("[%s] ⚠️  Sin destino csv no se recuperan las ofertas tras un reinicio", consumidor.id)
+	}
 	
 	// Iniciar servidor gRPC para recibir ofertas
 	lis, err := net.Listen("tcp", consumidor.puerto)
@@ -340,11 +748,13 @@ func main() {
 		log.Fatalf("Error escuchando: %v", err)
 	}
 	
+	consumidor.direccion = direccionAnunciada(lis, brokerAddr)
+	
 	grpcServer := grpc.NewServer()
 	pb.RegisterNotificacionesConsumidorServer(grpcServer, consumidor)
 	
 	go func() {
-		log.Printf("[%s] Escuchando en %v", consumidor.id, lis.Addr())
+		log.Printf("[%s] Escuchando en %v (anunciada como %s)", consumidor.id, lis.Addr(), consumidor.direccion)
 		if err := grpcServer.Serve(lis); err != nil {
 			log.Fatalf("Error sirviendo: %v", err)
 		}
@@ -367,6 +777,14 @@ func main() {
 		log.Fatalf("[%s] No se pudo registrar en el broker después de 5 intentos", consumidor.id)
 	}
 	
+	// Tras una caída se recupera lo perdido igual que al volver de una desconexión
+	if len(consumidor.ofertas) > 0 {
+		log.Printf("[%s] 🔄 Reinicio detectado - Solicitando ofertas perdidas", consumidor.id)
+		if err := consumidor.solicitarHistorico(); err != nil {
+			log.Printf("[%s] Error solicitando histórico: %v", consumidor.id, err)
+		}
+	}
+	
 	// Simular desconexión para algunos consumidores
 	if consumidorID == "C-E3" {
 		go func() {
@@ -383,6 +801,55 @@ func main() {
 	// Mantener el programa corriendo
 	log.Printf("[%s] ✅ Consumidor activo y esperando ofertas...", consumidor.id)
 	
-	// Bloquear indefinidamente
-	select {}
+	// Esperar la señal de término y cerrar los destinos (SQLite, archivos)
+	senales := make(chan os.Signal, 1)
+	signal.Notify(senales, syscall.SIGINT, syscall.SIGTERM)
+	<-senales
+	
+	log.Printf("[%s] Deteniendo consumidor...", consumidor.id)
+	grpcServer.GracefulStop()
+	consumidor.ofertasMutex.Lock()
+	cerrarDestinos(consumidor.destinos)
+	consumidor.ofertasMutex.Unlock()
+}
+
+// parsearSeguimientos interpreta SEGUIMIENTOS: entradas separadas por ";",
+// cada una un producto_id o, con "~" delante, un patrón sobre el nombre,
+// seguido opcionalmente de "<=precio" objetivo.
+func parsearSeguimientos(valor string) ([]*pb.Seguimiento, error) {
+	var seguimientos []*pb.Seguimiento
+	for _, entrada := range strings.Split(valor, ";") {
+		entrada = strings.TrimSpace(entrada)
+		if entrada == "" {
+			continue
+		}
+		seg := &pb.Seguimiento{}
+		if clave, precio, ok := strings.Cut(entrada, "<="); ok {
+			objetivo, err := strconv.Atoi(strings.TrimSpace(precio))
+			if err != nil || objetivo <= 0 {
+				return nil, fmt.Errorf("precio objetivo inválido en %q", entrada)
+			}
+			seg.PrecioObjetivo = int32(objetivo)
+			entrada = strings.TrimSpace(clave)
+		}
+		if patron, ok := strings.CutPrefix(entrada, "~"); ok {
+			seg.Patron = strings.TrimSpace(patron)
+		} else {
+			seg.ProductoId = entrada
+		}
+		if seg.Patron == "" && seg.ProductoId == "" {
+			return nil, fmt.Errorf("entrada vacía en %q", valor)
+		}
+		seguimientos = append(seguimientos, seg)
+	}
+	return seguimientos, nil
+}
+
+func contiene(lista []string, valor string) bool {
+	for _, v := range lista {
+		if v == valor {
+			return true
+		}
+	}
+	return false
 }
\ No newline at end of file
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pb "consumidor/proto"
+)
+
+// consumidorPrueba arma un consumidor que guarda su CSV en un directorio
+// temporal.
+func consumidorPrueba(t *testing.T, archivoCSV string) *Consumidor {
+	t.Helper()
+	c := NewConsumidor("C1", nil, nil, 0, ":0")
+	c.archivoCSV = archivoCSV
+	c.destinos = []destinoOfertas{&destinoCSV{archivo: archivoCSV}}
+	return c
+}
+
+func ofertaPrueba(id string, timestamp int64) *pb.OfertaRequest {
+	return &pb.OfertaRequest{
+		OfertaId:        id,
+		ProductoId:      "P-" + id,
+		Tienda:          "Riploy",
+		Categoria:       "Electrónica",
+		Producto:        "Audífonos",
+		PrecioDescuento: 19990,
+		PrecioOriginal:  29990,
+		Stock:           5,
+		Fecha:           "2025-11-20",
+		Timestamp:       timestamp,
+	}
+}
+
+func TestOfertaRepetidaSeIgnora(t *testing.T) {
+	archivo := filepath.Join(t.TempDir(), "C1.csv")
+	c := consumidorPrueba(t, archivo)
+
+	for i, esperado := range []string{"Oferta recibida", "Oferta ya recibida"} {
+		resp, err := c.RecibirOferta(context.Background(), ofertaPrueba("o1", 100))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !resp.GetExito() || resp.GetMensaje() != esperado {
+			t.Fatalf("envío %d: %v, se esperaba %q", i+1, resp, esperado)
+		}
+	}
+
+	datos, err := os.ReadFile(archivo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filas := strings.Count(string(datos), "\n"); filas != 2 {
+		t.Fatalf("el CSV tiene %d filas, se esperaban el encabezado y una oferta", filas)
+	}
+}
+
+func TestRecuperaOfertasDelCSVTrasReiniciar(t *testing.T) {
+	archivo := filepath.Join(t.TempDir(), "C1.csv")
+	c := consumidorPrueba(t, archivo)
+	c.almacenarOferta(ofertaPrueba("o1", 100))
+	c.almacenarOferta(ofertaPrueba("o2", 300))
+
+	reiniciado := consumidorPrueba(t, archivo)
+	if err := reiniciado.cargarOfertasCSV(); err != nil {
+		t.Fatal(err)
+	}
+	if len(reiniciado.ofertas) != 2 || reiniciado.ultimoTimestamp != 300 {
+		t.Fatalf("recuperadas %d ofertas hasta %d, se esperaban 2 hasta 300", len(reiniciado.ofertas), reiniciado.ultimoTimestamp)
+	}
+	if got := reiniciado.ofertas[1]; got.GetPrecioOriginal() != 29990 || got.GetCategoria() != "Electrónica" {
+		t.Fatalf("oferta recuperada = %v", got)
+	}
+
+	// El histórico puede volver a traerlas: no se duplican
+	if reiniciado.almacenarOferta(ofertaPrueba("o1", 100)) {
+		t.Fatal("una oferta recuperada del CSV no debería guardarse de nuevo")
+	}
+	if !reiniciado.almacenarOferta(ofertaPrueba("o3", 200)) {
+		t.Fatal("una oferta nueva debería guardarse")
+	}
+	if reiniciado.ultimoTimestamp != 300 {
+		t.Fatalf("ultimoTimestamp = %d, una oferta más antigua no debería bajarlo", reiniciado.ultimoTimestamp)
+	}
+}
+
+func TestCargarOfertasCSV(t *testing.T) {
+	encabezado := "oferta_id,producto_id,tienda,categoria,producto,precio_descuento,stock,fecha,timestamp,precio_original\n"
+	casos := []struct {
+		nombre    string
+		contenido string // "" = sin archivo
+		ids       []string
+		ultimo    int64
+	}{
+		{"sin archivo", "", nil, 0},
+		{"solo encabezado", encabezado, nil, 0},
+		{"con precio original", encabezado + "o1,P1,Riploy,Hogar,Silla,100,3,2025-11-20,50,150\n", []string{"o1"}, 50},
+		{"CSV antiguo de 9 columnas", "oferta_id,producto_id,tienda,categoria,producto,precio_descuento,stock,fecha,timestamp\n" +
+			"o1,P1,Riploy,Hogar,Silla,100,3,2025-11-20,50\n", []string{"o1"}, 50},
+		{"fila repetida", encabezado + "o1,P1,Riploy,Hogar,Silla,100,3,2025-11-20,50,150\no1,P1,Riploy,Hogar,Silla,100,3,2025-11-20,50,150\n", []string{"o1"}, 50},
+		{"fila inválida", encabezado + "o1,P1,Riploy,Hogar,Silla,cien,3,2025-11-20,50,150\no2,P2,Riploy,Hogar,Mesa,200,1,2025-11-20,60,0\n", []string{"o2"}, 60},
+		{"última fila cortada", encabezado + "o1,P1,Riploy,Hogar,Silla,100,3,2025-11-20,50,150\no2,P2,Riploy,Hogar,\"Me", []string{"o1"}, 50},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			archivo := filepath.Join(t.TempDir(), "C1.csv")
+			if caso.contenido != "" {
+				if err := os.WriteFile(archivo, []byte(caso.contenido), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			c := consumidorPrueba(t, archivo)
+			if err := c.cargarOfertasCSV(); err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, oferta := range c.ofertas {
+				ids = append(ids, oferta.GetOfertaId())
+			}
+			if strings.Join(ids, ",") != strings.Join(caso.ids, ",") || c.ultimoTimestamp != caso.ultimo {
+				t.Fatalf("ofertas %v hasta %d, se esperaban %v hasta %d", ids, c.ultimoTimestamp, caso.ids, caso.ultimo)
+			}
+			for _, id := range caso.ids {
+				if !c.vistas[id] {
+					t.Fatalf("%s no quedó en vistas", id)
+				}
+			}
+		})
+	}
+}
+
+func TestDesdeHistorico(t *testing.T) {
+	margen := int64(margenHistorico.Seconds())
+	casos := []struct {
+		ultimo, desde int64
+	}{
+		{0, 0},
+		{-5, 0},
+		{1_700_000_000, 1_700_000_000 - margen},
+		{margen, 0},
+	}
+	for _, caso := range casos {
+		if got := desdeHistorico(caso.ultimo); got != caso.desde {
+			t.Errorf("desdeHistorico(%d) = %d, se esperaba %d", caso.ultimo, got, caso.desde)
+		}
+	}
+}
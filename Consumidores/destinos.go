@@ -0,0 +1,399 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "consumidor/proto"
+
+	_ "modernc.org/sqlite"
+)
+
+// destinoOfertas es un lugar donde el consumidor deja cada oferta nueva.
+// guardar se llama con c.ofertasMutex tomado, así que nunca en paralelo.
+type destinoOfertas interface {
+	nombre() string
+	guardar(oferta *pb.OfertaRequest) error
+	cerrar() error
+}
+
+// configDestinos se arma desde las variables de entorno del consumidor.
+type configDestinos struct {
+	tipos      []string // DESTINOS, separados por coma (defecto: csv)
+	directorio string   // DESTINO_DIR (defecto: directorio actual)
+	rotacion   string   // ROTACION: tamano o dia
+	maxBytes   int64    // ROTACION_MAX_MB, solo para rotación por tamaño
+}
+
+func cargarConfigDestinos() (configDestinos, error) {
+	cfg := configDestinos{
+		tipos:      []string{"csv"},
+		directorio: ".",
+		rotacion:   "tamano",
+		maxBytes:   10 << 20,
+	}
+	if valor := os.Getenv("DESTINOS"); valor != "" {
+		cfg.tipos = nil
+		for _, tipo := range strings.Split(valor, ",") {
+			if tipo = strings.TrimSpace(strings.ToLower(tipo)); tipo != "" {
+				cfg.tipos = append(cfg.tipos, tipo)
+			}
+		}
+	}
+	if valor := os.Getenv("DESTINO_DIR"); valor != "" {
+		cfg.directorio = valor
+	}
+	if valor := os.Getenv("ROTACION"); valor != "" {
+		if valor != "tamano" && valor != "dia" {
+			return cfg, fmt.Errorf("ROTACION debe ser tamano o dia, no %q", valor)
+		}
+		cfg.rotacion = valor
+	}
+	if valor := os.Getenv("ROTACION_MAX_MB"); valor != "" {
+		mb, err := strconv.Atoi(valor)
+		if err != nil || mb <= 0 {
+			return cfg, fmt.Errorf("ROTACION_MAX_MB inválido: %q", valor)
+		}
+		cfg.maxBytes = int64(mb) << 20
+	}
+	return cfg, nil
+}
+
+// nuevosDestinos abre los destinos configurados. Si uno falla se cierran los
+// que ya se habían abierto.
+func nuevosDestinos(cfg configDestinos, consumidorID string) ([]destinoOfertas, error) {
+	if err := os.MkdirAll(cfg.directorio, 0755); err != nil {
+		return nil, err
+	}
+	base := filepath.Join(cfg.directorio, consumidorID)
+
+	var destinos []destinoOfertas
+	for _, tipo := range cfg.tipos {
+		var destino destinoOfertas
+		var err error
+		switch tipo {
+		case "csv":
+			destino = &destinoCSV{archivo: base + ".csv"}
+		case "jsonl":
+			destino, err = nuevoDestinoJSONL(base + ".jsonl")
+		case "sqlite":
+			destino, err = nuevoDestinoSQLite(base + ".db")
+		case "stdout":
+			destino = &destinoStdout{}
+		case "rotativo":
+			destino, err = nuevoDestinoRotativo(base, cfg.rotacion, cfg.maxBytes)
+		default:
+			err = fmt.Errorf("destino desconocido %q (usar csv, jsonl, sqlite, stdout o rotativo)", tipo)
+		}
+		if err != nil {
+			cerrarDestinos(destinos)
+			return nil, err
+		}
+		destinos = append(destinos, destino)
+	}
+	return destinos, nil
+}
+
+func cerrarDestinos(destinos []destinoOfertas) {
+	for _, destino := range destinos {
+		if err := destino.cerrar(); err != nil {
+			log.Printf("Error cerrando destino %s: %v", destino.nombre(), err)
+		}
+	}
+}
+
+// registroOferta es la forma JSON de una oferta en los destinos jsonl,
+// stdout y rotativo: nombres del .proto y enteros como números.
+type registroOferta struct {
+	OfertaID            string `json:"oferta_id"`
+	ProductoID          string `json:"producto_id"`
+	Tienda              string `json:"tienda"`
+	Categoria           string `json:"categoria"`
+	Producto            string `json:"producto"`
+	PrecioDescuento     int32  `json:"precio_descuento"`
+	PrecioOriginal      int32  `json:"precio_original,omitempty"`
+	Stock               int32  `json:"stock"`
+	Fecha               string `json:"fecha"`
+	ClienteID           string `json:"cliente_id"`
+	Timestamp           int64  `json:"timestamp"`
+	Secuencia           int64  `json:"secuencia,omitempty"`
+	SecuenciaConsumidor int64  `json:"secuencia_consumidor,omitempty"`
+	Recibida            int64  `json:"recibida"` // momento en que la guardó el consumidor
+}
+
+func lineaJSON(oferta *pb.OfertaRequest) ([]byte, error) {
+	linea, err := json.Marshal(registroOferta{
+		OfertaID:            oferta.GetOfertaId(),
+		ProductoID:          oferta.GetProductoId(),
+		Tienda:              oferta.GetTienda(),
+		Categoria:           oferta.GetCategoria(),
+		Producto:            oferta.GetProducto(),
+		PrecioDescuento:     oferta.GetPrecioDescuento(),
+		PrecioOriginal:      oferta.GetPrecioOriginal(),
+		Stock:               oferta.GetStock(),
+		Fecha:               oferta.GetFecha(),
+		ClienteID:           oferta.GetClienteId(),
+		Timestamp:           oferta.GetTimestamp(),
+		Secuencia:           oferta.GetSecuencia(),
+		SecuenciaConsumidor: oferta.GetSecuenciaConsumidor(),
+		Recibida:            time.Now().Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append(linea, '\n'), nil
+}
+
+// ========== CSV ==========
+
+// destinoCSV es el formato original. Además es el que lee el consumidor al
+// reiniciar para recuperar lo recibido.
+type destinoCSV struct {
+	archivo string
+}
+
+func (d *destinoCSV) nombre() string { return "csv" }
+
+func (d *destinoCSV) guardar(oferta *pb.OfertaRequest) error {
+	// Verificar si el archivo existe
+	fileExists := true
+	if _, err := os.Stat(d.archivo); os.IsNotExist(err) {
+		fileExists = false
+	}
+
+	file, err := os.OpenFile(d.archivo, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	// Escribir header si es archivo nuevo
+	if !fileExists {
+		header := []string{"oferta_id", "producto_id", "tienda", "categoria", "producto", "precio_descuento", "stock", "fecha", "timestamp", "precio_original"}
+		if err := writer.Write(header); err != nil {
+			return err
+		}
+	}
+
+	// Escribir fila
+	row := []string{
+		oferta.GetOfertaId(),
+		oferta.GetProductoId(),
+		oferta.GetTienda(),
+		oferta.GetCategoria(),
+		oferta.GetProducto(),
+		fmt.Sprintf("%d", oferta.GetPrecioDescuento()),
+		fmt.Sprintf("%d", oferta.GetStock()),
+		oferta.GetFecha(),
+		fmt.Sprintf("%d", oferta.GetTimestamp()),
+		fmt.Sprintf("%d", oferta.GetPrecioOriginal()),
+	}
+
+	return writer.Write(row)
+}
+
+func (d *destinoCSV) cerrar() error { return nil }
+
+// ========== JSON Lines ==========
+
+type destinoJSONL struct {
+	file *os.File
+}
+
+func nuevoDestinoJSONL(archivo string) (*destinoJSONL, error) {
+	file, err := os.OpenFile(archivo, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &destinoJSONL{file: file}, nil
+}
+
+func (d *destinoJSONL) nombre() string { return "jsonl" }
+
+func (d *destinoJSONL) guardar(oferta *pb.OfertaRequest) error {
+	linea, err := lineaJSON(oferta)
+	if err != nil {
+		return err
+	}
+	_, err = d.file.Write(linea)
+	return err
+}
+
+func (d *destinoJSONL) cerrar() error { return d.file.Close() }
+
+// ========== SQLite ==========
+
+const esquemaSQLite = `
+CREATE TABLE IF NOT EXISTS ofertas (
+	oferta_id            TEXT PRIMARY KEY,
+	producto_id          TEXT NOT NULL,
+	tienda               TEXT NOT NULL,
+	categoria            TEXT NOT NULL,
+	producto             TEXT NOT NULL,
+	precio_descuento     INTEGER NOT NULL,
+	stock                INTEGER NOT NULL,
+	fecha                TEXT NOT NULL,
+	cliente_id           TEXT NOT NULL,
+	timestamp            INTEGER NOT NULL,
+	secuencia            INTEGER NOT NULL,
+	secuencia_consumidor INTEGER NOT NULL,
+	recibida             INTEGER NOT NULL,
+	precio_original      INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS ofertas_categoria ON ofertas (categoria);
+CREATE INDEX IF NOT EXISTS ofertas_timestamp ON ofertas (timestamp);
+`
+
+// destinoSQLite guarda las ofertas en una base SQLite embebida (sin cgo).
+type destinoSQLite struct {
+	db *sql.DB
+}
+
+func nuevoDestinoSQLite(archivo string) (*destinoSQLite, error) {
+	db, err := sql.Open("sqlite", archivo)
+	if err != nil {
+		return nil, err
+	}
+	// Un solo escritor; WAL deja que otros procesos lean mientras tanto
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec("PRAGMA journal_mode=WAL; PRAGMA busy_timeout=5000;"); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if _, err := db.Exec(esquemaSQLite); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("creando esquema en %s: %v", archivo, err)
+	}
+	// Bases creadas antes de que existiera precio_original
+	if _, err := db.Exec("ALTER TABLE ofertas ADD COLUMN precio_original INTEGER NOT NULL DEFAULT 0"); err != nil &&
+		!strings.Contains(err.Error(), "duplicate column") {
+		db.Close()
+		return nil, fmt.Errorf("migrando esquema en %s: %v", archivo, err)
+	}
+	return &destinoSQLite{db: db}, nil
+}
+
+func (d *destinoSQLite) nombre() string { return "sqlite" }
+
+func (d *destinoSQLite) guardar(oferta *pb.OfertaRequest) error {
+	_, err := d.db.Exec(`INSERT OR IGNORE INTO ofertas
+		(oferta_id, producto_id, tienda, categoria, producto, precio_descuento, stock, fecha,
+		 cliente_id, timestamp, secuencia, secuencia_consumidor, recibida, precio_original)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		oferta.GetOfertaId(), oferta.GetProductoId(), oferta.GetTienda(), oferta.GetCategoria(),
+		oferta.GetProducto(), oferta.GetPrecioDescuento(), oferta.GetStock(), oferta.GetFecha(),
+		oferta.GetClienteId(), oferta.GetTimestamp(), oferta.GetSecuencia(),
+		oferta.GetSecuenciaConsumidor(), time.Now().Unix(), oferta.GetPrecioOriginal())
+	return err
+}
+
+func (d *destinoSQLite) cerrar() error { return d.db.Close() }
+
+// ========== Stdout ==========
+
+// destinoStdout escribe JSON Lines en la salida estándar (los logs van a
+// stderr), para conectar el consumidor por tubería a otra herramienta.
+type destinoStdout struct{}
+
+func (d *destinoStdout) nombre() string { return "stdout" }
+
+func (d *destinoStdout) guardar(oferta *pb.OfertaRequest) error {
+	linea, err := lineaJSON(oferta)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(linea)
+	return err
+}
+
+func (d *destinoStdout) cerrar() error { return nil }
+
+// ========== Archivo rotativo ==========
+
+// destinoRotativo escribe JSON Lines en <id>-AAAAMMDD-NNN.jsonl y pasa a un
+// archivo nuevo al cambiar el día o, en modo tamano, al superar maxBytes.
+type destinoRotativo struct {
+	base     string
+	modo     string
+	maxBytes int64
+
+	file   *os.File
+	dia    string
+	bytes  int64
+	numero int
+}
+
+func nuevoDestinoRotativo(base, modo string, maxBytes int64) (*destinoRotativo, error) {
+	d := &destinoRotativo{base: base, modo: modo, maxBytes: maxBytes}
+	if err := d.rotar(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (d *destinoRotativo) nombre() string { return "rotativo" }
+
+// rotar cierra el archivo actual y abre el siguiente que no exista.
+func (d *destinoRotativo) rotar() error {
+	if d.file != nil {
+		d.file.Close()
+		d.file = nil
+	}
+
+	dia := time.Now().Format("20060102")
+	if dia != d.dia {
+		d.dia = dia
+		d.numero = 0
+	}
+	for {
+		d.numero++
+		archivo := fmt.Sprintf("%s-%s-%03d.jsonl", d.base, d.dia, d.numero)
+		file, err := os.OpenFile(archivo, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		d.file = file
+		d.bytes = 0
+		return nil
+	}
+}
+
+func (d *destinoRotativo) guardar(oferta *pb.OfertaRequest) error {
+	linea, err := lineaJSON(oferta)
+	if err != nil {
+		return err
+	}
+
+	cambioDia := time.Now().Format("20060102") != d.dia
+	lleno := d.modo == "tamano" && d.bytes > 0 && d.bytes+int64(len(linea)) > d.maxBytes
+	if cambioDia || lleno {
+		if err := d.rotar(); err != nil {
+			return err
+		}
+	}
+
+	n, err := d.file.Write(linea)
+	d.bytes += int64(n)
+	return err
+}
+
+func (d *destinoRotativo) cerrar() error {
+	if d.file == nil {
+		return nil
+	}
+	return d.file.Close()
+}
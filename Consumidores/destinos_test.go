@@ -0,0 +1,70 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNuevosDestinos(t *testing.T) {
+	cfg := configDestinos{
+		tipos:      []string{"csv", "jsonl", "sqlite", "stdout", "rotativo"},
+		directorio: t.TempDir(),
+		rotacion:   "tamano",
+		maxBytes:   1 << 20,
+	}
+	destinos, err := nuevosDestinos(cfg, "C1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cerrarDestinos(destinos)
+
+	var nombres []string
+	for _, destino := range destinos {
+		nombres = append(nombres, destino.nombre())
+		if err := destino.guardar(ofertaPrueba("o1", 100)); err != nil {
+			t.Errorf("%s: %v", destino.nombre(), err)
+		}
+	}
+	if !reflect.DeepEqual(nombres, cfg.tipos) {
+		t.Fatalf("destinos = %v, se esperaba %v", nombres, cfg.tipos)
+	}
+
+	cfg.tipos = []string{"csv", "papel"}
+	if _, err := nuevosDestinos(cfg, "C1"); err == nil {
+		t.Fatal("un destino desconocido debería dar error")
+	}
+}
+
+func TestCargarConfigDestinos(t *testing.T) {
+	for _, variable := range []string{"DESTINOS", "DESTINO_DIR", "ROTACION", "ROTACION_MAX_MB"} {
+		t.Setenv(variable, "")
+	}
+	cfg, err := cargarConfigDestinos()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(cfg.tipos, []string{"csv"}) {
+		t.Fatalf("destinos por defecto = %v, se esperaba [csv]", cfg.tipos)
+	}
+
+	t.Setenv("DESTINOS", " JSONL, ,sqlite")
+	t.Setenv("ROTACION", "dia")
+	t.Setenv("ROTACION_MAX_MB", "5")
+	cfg, err = cargarConfigDestinos()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(cfg.tipos, []string{"jsonl", "sqlite"}) || cfg.rotacion != "dia" || cfg.maxBytes != 5<<20 {
+		t.Fatalf("configuración = %+v", cfg)
+	}
+
+	t.Setenv("ROTACION", "hora")
+	if _, err := cargarConfigDestinos(); err == nil {
+		t.Error("ROTACION=hora debería dar error")
+	}
+	t.Setenv("ROTACION", "dia")
+	t.Setenv("ROTACION_MAX_MB", "0")
+	if _, err := cargarConfigDestinos(); err == nil {
+		t.Error("ROTACION_MAX_MB=0 debería dar error")
+	}
+}
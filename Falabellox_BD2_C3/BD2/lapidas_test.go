@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	pb "falabellox_bd2_c3/proto"
+)
+
+// sincronizar pasa a destino el registro que tiene origen, como lo hace la
+// sincronización entre peers.
+func sincronizar(t *testing.T, origen, destino *DBNode, ofertaID string) {
+	t.Helper()
+	registro, existe := leerRegistro(t, origen, ofertaID)
+	if !existe {
+		t.Fatalf("%s no tiene la oferta %s", origen.nodoID, ofertaID)
+	}
+	_, err := destino.Sincronizar(context.Background(), &pb.SincronizarRequest{
+		NodoOrigen: origen.nodoID,
+		Ofertas:    []*pb.OfertaRequest{registro},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLapidaSeRecolectaCuandoLaConfirmanTodas(t *testing.T) {
+	replicas := []string{"DB1", "DB2", "DB3"}
+	nodos := make([]*DBNode, len(replicas))
+	for i, id := range replicas {
+		nodos[i] = nodoPrueba(t, id, replicas...)
+	}
+	db1, db2, db3 := nodos[0], nodos[1], nodos[2]
+	ctx := context.Background()
+
+	if resp, _ := db1.GuardarOferta(ctx, &pb.OfertaRequest{OfertaId: "o1", PrecioDescuento: 1000, Stock: 5}); !resp.GetExito() {
+		t.Fatalf("GuardarOferta: %s", resp.GetMensaje())
+	}
+	sincronizar(t, db1, db2, "o1")
+	sincronizar(t, db1, db3, "o1")
+	viva, _ := leerRegistro(t, db3, "o1")
+
+	if resp, _ := db1.EliminarOferta(ctx, &pb.EliminarOfertaRequest{OfertaId: "o1", RelojVectorial: viva.GetRelojVectorial()}); !resp.GetExito() {
+		t.Fatalf("EliminarOferta: %s", resp.GetMensaje())
+	}
+	sincronizar(t, db1, db2, "o1")
+	sincronizar(t, db2, db1, "o1")
+
+	lapida, _ := leerRegistro(t, db2, "o1")
+	if !lapida.GetEliminada() || !reflect.DeepEqual(lapida.GetConfirmadaPor(), []string{"DB1", "DB2"}) {
+		t.Fatalf("lápida en DB2 = %v", lapida)
+	}
+
+	// Falta DB3: la lápida se queda, y la versión viva que aún tiene DB3 no
+	// la reemplaza
+	for _, db := range nodos {
+		db.recolectarLapidas()
+	}
+	if _, err := db1.Sincronizar(ctx, &pb.SincronizarRequest{NodoOrigen: "DB3", Ofertas: []*pb.OfertaRequest{viva}}); err != nil {
+		t.Fatal(err)
+	}
+	for _, db := range []*DBNode{db1, db2} {
+		registro, existe := leerRegistro(t, db, "o1")
+		if !existe || !registro.GetEliminada() || len(registro.GetHermanas()) > 0 {
+			t.Fatalf("%s: registro = %v (existe %v), se esperaba la lápida sola", db.nodoID, registro, existe)
+		}
+	}
+
+	// DB3 la recibe y la confirma, y su confirmación vuelve a los demás:
+	// ya la tienen todas y cada uno la borra
+	sincronizar(t, db2, db3, "o1")
+	sincronizar(t, db3, db1, "o1")
+	sincronizar(t, db3, db2, "o1")
+	for _, db := range nodos {
+		registro, _ := leerRegistro(t, db, "o1")
+		if !reflect.DeepEqual(registro.GetConfirmadaPor(), replicas) {
+			t.Fatalf("%s: confirmada por %v, se esperaba %v", db.nodoID, registro.GetConfirmadaPor(), replicas)
+		}
+		db.recolectarLapidas()
+		if _, existe := leerRegistro(t, db, "o1"); existe {
+			t.Fatalf("%s debería haber recolectado la lápida", db.nodoID)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	pb "falabellox_bd2_c3/proto"
+)
+
+// nodosIguales arma dos nodos con las mismas n ofertas.
+func nodosIguales(t *testing.T, n int) (*DBNode, *DBNode) {
+	t.Helper()
+	ofertas := make([]*pb.OfertaRequest, n)
+	for i := range ofertas {
+		ofertas[i] = &pb.OfertaRequest{
+			OfertaId:        fmt.Sprintf("oferta-%03d", i),
+			PrecioDescuento: int32(1000 + i),
+			Stock:           10,
+			RelojVectorial:  map[string]int64{"DB1": 1},
+		}
+	}
+	a, b := nodoPrueba(t, "DB1"), nodoPrueba(t, "DB2")
+	for _, db := range []*DBNode{a, b} {
+		if _, err := db.fusionarOfertas(ofertas); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return a, b
+}
+
+func todasLasHojas() []int32 {
+	posiciones := make([]int32, rangosMerkle)
+	for i := range posiciones {
+		posiciones[i] = int32(i)
+	}
+	return posiciones
+}
+
+func TestMerkleEncuentraLaUnicaClaveDistinta(t *testing.T) {
+	a, b := nodosIguales(t, 200)
+	distinta := "oferta-042"
+	if resp, _ := b.GuardarOferta(context.Background(), &pb.OfertaRequest{OfertaId: distinta, PrecioDescuento: 1}); !resp.GetExito() {
+		t.Fatalf("GuardarOferta: %s", resp.GetMensaje())
+	}
+
+	// En las hojas solo cambia el rango de esa oferta
+	hojasA, err := a.hashesLocales(nivelesMerkle, todasLasHojas())
+	if err != nil {
+		t.Fatal(err)
+	}
+	hojasB, err := b.hashesLocales(nivelesMerkle, todasLasHojas())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range hojasA {
+		if distinto := !bytes.Equal(hojasA[i], hojasB[i]); distinto != (i == rangoDe(distinta)) {
+			t.Errorf("hoja %d: distinta = %v", i, distinto)
+		}
+	}
+
+	// La anti-entropía baja solo hasta ese rango y deja ambos iguales
+	peer := &clienteLocal{db: b}
+	if err := a.antiEntropia("DB2", peer); err != nil {
+		t.Fatal(err)
+	}
+	if len(peer.pedidos) != 1 || int(peer.pedidos[0]) != rangoDe(distinta) {
+		t.Fatalf("rangos pedidos = %v, se esperaba [%d]", peer.pedidos, rangoDe(distinta))
+	}
+	registro, _ := leerRegistro(t, a, distinta)
+	if registro.GetPrecioDescuento() != 1 {
+		t.Fatalf("precio en DB1 = %d, se esperaba el de DB2", registro.GetPrecioDescuento())
+	}
+
+	peer.pedidos = nil
+	if err := a.antiEntropia("DB2", peer); err != nil {
+		t.Fatal(err)
+	}
+	if len(peer.pedidos) != 0 {
+		t.Fatalf("tras sincronizar se pidieron los rangos %v", peer.pedidos)
+	}
+}
+
+func TestMerkleSinDiferenciasNoPideRangos(t *testing.T) {
+	a, b := nodosIguales(t, 50)
+	peer := &clienteLocal{db: b}
+	if err := a.antiEntropia("DB2", peer); err != nil {
+		t.Fatal(err)
+	}
+	if len(peer.pedidos) != 0 {
+		t.Fatalf("rangos pedidos = %v, se esperaba ninguno", peer.pedidos)
+	}
+}
@@ -0,0 +1,325 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	pb "falabellox_bd2_c3/proto"
+)
+
+// storageMemoria guarda todas las ofertas en un mapa. La durabilidad la da el
+// WAL (<nodo>_wal_NNNNNN.log) más un snapshot periódico (<nodo>_ofertas.snap).
+type storageMemoria struct {
+	nodoID          string
+	archivoSnapshot string
+	archivoJSON     string // snapshot de versiones anteriores, solo se lee
+	prefijoWAL      string
+
+	ofertas map[string]*pb.OfertaRequest
+	mu      sync.RWMutex
+
+	wal               *walOfertas
+	compactacionMutex sync.Mutex
+	snapshotPendiente chan struct{}
+	detener           chan struct{}
+}
+
+func abrirStorageMemoria(nodoID string) (*storageMemoria, error) {
+	s := &storageMemoria{
+		nodoID:            nodoID,
+		archivoSnapshot:   fmt.Sprintf("%s_ofertas.snap", nodoID),
+		archivoJSON:       fmt.Sprintf("%s_ofertas.json", nodoID),
+		prefijoWAL:        fmt.Sprintf("%s_wal", nodoID),
+		ofertas:           make(map[string]*pb.OfertaRequest),
+		snapshotPendiente: make(chan struct{}, 1),
+		detener:           make(chan struct{}),
+	}
+	if err := s.cargar(); err != nil {
+		return nil, err
+	}
+	go s.bucleSnapshots()
+	return s, nil
+}
+
+func (s *storageMemoria) Get(ofertaID string) (*pb.OfertaRequest, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	oferta, existe := s.ofertas[ofertaID]
+	return oferta, existe, nil
+}
+
+// Put cambia el mapa y el WAL juntos para que el orden del WAL sea el mismo.
+// Si el WAL falla el mapa vuelve a como estaba.
+func (s *storageMemoria) Put(ofertas ...*pb.OfertaRequest) error {
+	if len(ofertas) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	anteriores := make(map[string]*pb.OfertaRequest, len(ofertas))
+	for _, oferta := range ofertas {
+		id := oferta.GetOfertaId()
+		if _, visto := anteriores[id]; !visto {
+			anteriores[id] = s.ofertas[id]
+		}
+		s.ofertas[id] = oferta
+	}
+
+	err := s.anotar(ofertas)
+	if err == nil {
+		err = s.wal.sincronizar()
+	}
+	if err != nil {
+		for id, anterior := range anteriores {
+			if anterior == nil {
+				delete(s.ofertas, id)
+			} else {
+				s.ofertas[id] = anterior
+			}
+		}
+		s.pedirSnapshot()
+		return err
+	}
+
+	if s.wal.cantidadRegistros() >= maxRegistrosWAL {
+		s.pedirSnapshot()
+	}
+	return nil
+}
+
+// Delete saca las ofertas del mapa y anota los borrados en el WAL, con la
+// misma vuelta atrás que Put si el WAL falla.
+func (s *storageMemoria) Delete(ofertaIDs ...string) error {
+	if len(ofertaIDs) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	anteriores := make(map[string]*pb.OfertaRequest, len(ofertaIDs))
+	for _, id := range ofertaIDs {
+		if oferta, existe := s.ofertas[id]; existe {
+			anteriores[id] = oferta
+			delete(s.ofertas, id)
+		}
+	}
+
+	var err error
+	for _, id := range ofertaIDs {
+		if err = s.wal.agregarBorrado(id); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = s.wal.sincronizar()
+	}
+	if err != nil {
+		for id, anterior := range anteriores {
+			s.ofertas[id] = anterior
+		}
+		s.pedirSnapshot()
+		return err
+	}
+
+	if s.wal.cantidadRegistros() >= maxRegistrosWAL {
+		s.pedirSnapshot()
+	}
+	return nil
+}
+
+func (s *storageMemoria) anotar(ofertas []*pb.OfertaRequest) error {
+	for _, oferta := range ofertas {
+		if err := s.wal.agregar(oferta); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *storageMemoria) Scan(fn func(*pb.OfertaRequest) bool) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, oferta := range s.ofertas {
+		if !fn(oferta) {
+			break
+		}
+	}
+	return nil
+}
+
+func (s *storageMemoria) RangoTimestamp(desde, hasta int64, fn func(*pb.OfertaRequest) bool) error {
+	return s.Scan(func(oferta *pb.OfertaRequest) bool {
+		if !enRango(oferta.GetTimestamp(), desde, hasta) {
+			return true
+		}
+		return fn(oferta)
+	})
+}
+
+func (s *storageMemoria) Cantidad() (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.ofertas), nil
+}
+
+func (s *storageMemoria) Close() error {
+	close(s.detener)
+	s.compactacionMutex.Lock()
+	defer s.compactacionMutex.Unlock()
+	return s.wal.cerrar()
+}
+
+func (s *storageMemoria) pedirSnapshot() {
+	select {
+	case s.snapshotPendiente <- struct{}{}:
+	default:
+	}
+}
+
+// compactar escribe un snapshot con todas las ofertas y borra los segmentos
+// del WAL que quedaron cubiertos por él.
+func (s *storageMemoria) compactar() error {
+	s.compactacionMutex.Lock()
+	defer s.compactacionMutex.Unlock()
+
+	// Copiar el mapa y rotar juntos: lo que se escriba después va al
+	// segmento nuevo, que el snapshot no cubre
+	s.mu.Lock()
+	ofertas := make(map[string]*pb.OfertaRequest, len(s.ofertas))
+	for id, oferta := range s.ofertas {
+		ofertas[id] = oferta
+	}
+	segmento, err := s.wal.rotar()
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := escribirSnapshot(s.archivoSnapshot, ofertas); err != nil {
+		return err
+	}
+	s.wal.limpiarFallo()
+	if err := eliminarSegmentos(s.prefijoWAL, segmento); err != nil {
+		return err
+	}
+
+	log.Printf("[%s] Snapshot con %d ofertas, WAL compactado", s.nodoID, len(ofertas))
+	return nil
+}
+
+// bucleSnapshots compacta el WAL periódicamente, al llegar a maxRegistrosWAL
+// o después de un error de escritura.
+func (s *storageMemoria) bucleSnapshots() {
+	ticker := time.NewTicker(intervaloSnapshot)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-s.snapshotPendiente:
+		case <-s.detener:
+			return
+		}
+
+		if s.wal.cantidadRegistros() == 0 && !s.wal.conFallo() {
+			continue
+		}
+		if err := s.compactar(); err != nil {
+			log.Printf("[%s] Error tomando snapshot: %v", s.nodoID, err)
+		}
+	}
+}
+
+// cargar reconstruye el estado al iniciar: carga el último snapshot, reaplica
+// los segmentos del WAL en orden y abre uno nuevo para escribir.
+func (s *storageMemoria) cargar() error {
+	ofertas, err := leerSnapshot(s.archivoSnapshot)
+	migrar := false
+	switch {
+	case os.IsNotExist(err):
+		ofertas, migrar, err = s.cargarJSON()
+		if err != nil {
+			return err
+		}
+	case err != nil:
+		// El checksum no coincide o el archivo no está completo; se aparta
+		// y los peers completan lo que falte
+		log.Printf("[%s] Snapshot dañado, se aparta como %s.danado: %v", s.nodoID, s.archivoSnapshot, err)
+		if err := os.Rename(s.archivoSnapshot, s.archivoSnapshot+".danado"); err != nil {
+			return err
+		}
+	}
+	if ofertas != nil {
+		s.ofertas = ofertas
+	}
+	log.Printf("[%s] Cargadas %d ofertas desde el snapshot", s.nodoID, len(s.ofertas))
+
+	segmentos, err := segmentosWAL(s.prefijoWAL)
+	if err != nil {
+		return err
+	}
+	registros := 0
+	for _, segmento := range segmentos {
+		n, err := leerSegmento(rutaSegmento(s.prefijoWAL, segmento), func(oferta *pb.OfertaRequest) {
+			s.ofertas[oferta.GetOfertaId()] = oferta
+		}, func(ofertaID string) {
+			delete(s.ofertas, ofertaID)
+		})
+		if err != nil {
+			return err
+		}
+		registros += n
+	}
+
+	siguiente := 1
+	if len(segmentos) > 0 {
+		siguiente = segmentos[len(segmentos)-1] + 1
+		log.Printf("[%s] Reaplicados %d registros del WAL (%d ofertas en total)", s.nodoID, registros, len(s.ofertas))
+	}
+
+	// Dejar el estado recuperado en un snapshot para no volver a reaplicar
+	// estos segmentos ni leer el JSON anterior en el próximo inicio
+	if len(segmentos) > 0 || migrar {
+		if err := escribirSnapshot(s.archivoSnapshot, s.ofertas); err != nil {
+			log.Printf("[%s] Error tomando snapshot: %v", s.nodoID, err)
+		} else if err := eliminarSegmentos(s.prefijoWAL, siguiente); err != nil {
+			log.Printf("[%s] Error borrando segmentos del WAL: %v", s.nodoID, err)
+		} else if migrar {
+			if err := os.Remove(s.archivoJSON); err != nil {
+				log.Printf("[%s] Error borrando %s: %v", s.nodoID, s.archivoJSON, err)
+			}
+		}
+	}
+
+	s.wal, err = abrirWAL(s.prefijoWAL, siguiente)
+	return err
+}
+
+// cargarJSON lee el snapshot en JSON de versiones anteriores, si existe.
+// Devuelve si hay que migrarlo al formato actual.
+func (s *storageMemoria) cargarJSON() (map[string]*pb.OfertaRequest, bool, error) {
+	datos, err := os.ReadFile(s.archivoJSON)
+	switch {
+	case os.IsNotExist(err):
+		log.Printf("[%s] No hay snapshot previo", s.nodoID)
+		return nil, false, nil
+	case err != nil:
+		return nil, false, err
+	}
+
+	ofertas := make(map[string]*pb.OfertaRequest)
+	if err := json.Unmarshal(datos, &ofertas); err != nil {
+		// Solo puede pasar con un archivo escrito a medias; se aparta y
+		// los peers completan lo que falte
+		log.Printf("[%s] Snapshot dañado, se aparta como %s.danado: %v", s.nodoID, s.archivoJSON, err)
+		return nil, false, os.Rename(s.archivoJSON, s.archivoJSON+".danado")
+	}
+	return ofertas, true, nil
+}
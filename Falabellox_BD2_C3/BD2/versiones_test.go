@@ -0,0 +1,111 @@
+package main
+
+import (
+	"testing"
+
+	pb "falabellox_bd2_c3/proto"
+)
+
+func version(id string, reloj map[string]int64, precio, stock int32) *pb.OfertaRequest {
+	return &pb.OfertaRequest{
+		OfertaId:        id,
+		PrecioDescuento: precio,
+		Stock:           stock,
+		RelojVectorial:  reloj,
+	}
+}
+
+func TestEscriturasConcurrentesQuedanComoHermanas(t *testing.T) {
+	db := nodoPrueba(t, "DB1")
+
+	base := version("o1", map[string]int64{"DB1": 1}, 1000, 5)
+	enDB1 := version("o1", map[string]int64{"DB1": 2}, 900, 5)
+	enDB2 := version("o1", map[string]int64{"DB1": 1, "DB2": 1}, 800, 5)
+
+	// La que desciende de base la reemplaza; las dos ramas se conservan
+	versiones, _ := fusionarVersiones(nil, []*pb.OfertaRequest{base, enDB1})
+	versiones, cambio := fusionarVersiones(versiones, []*pb.OfertaRequest{enDB2})
+	if !cambio || len(versiones) != 2 {
+		t.Fatalf("versiones = %d (cambio %v), se esperaban 2 hermanas", len(versiones), cambio)
+	}
+	registro := db.empaquetar(versiones)
+	if len(registro.GetHermanas()) != 1 {
+		t.Fatalf("hermanas = %d, se esperaba 1", len(registro.GetHermanas()))
+	}
+
+	// Volver a recibir una de ellas no cambia nada
+	if _, cambio := fusionarVersiones(versionesDe(registro), []*pb.OfertaRequest{enDB2}); cambio {
+		t.Fatal("una versión repetida no debería cambiar el registro")
+	}
+
+	// La lectura resuelve con el reloj de ambas, y una escritura basada en
+	// ella las reemplaza a las dos
+	resuelta := db.resolver(registro)
+	if got := resuelta.GetRelojVectorial(); got["DB1"] != 2 || got["DB2"] != 1 {
+		t.Fatalf("reloj resuelto = %v, se esperaba DB1:2 DB2:1", got)
+	}
+	nueva := version("o1", nil, 700, 5)
+	db.nuevaVersion(nueva, registro, resuelta.GetRelojVectorial())
+	versiones, _ = fusionarVersiones(versionesDe(registro), []*pb.OfertaRequest{nueva})
+	if len(versiones) != 1 || versiones[0].GetPrecioDescuento() != 700 {
+		t.Fatalf("versiones tras escribir sobre la resuelta = %v", versiones)
+	}
+}
+
+func TestResolvedores(t *testing.T) {
+	barata := &pb.OfertaRequest{OfertaId: "o1", PrecioDescuento: 800, Stock: 2, EscritaNs: 1}
+	conStock := &pb.OfertaRequest{OfertaId: "o1", PrecioDescuento: 900, Stock: 9, EscritaNs: 2}
+	reciente := &pb.OfertaRequest{OfertaId: "o1", PrecioDescuento: 1000, Stock: 5, EscritaNs: 3}
+	comprada := &pb.OfertaRequest{OfertaId: "o1", PrecioDescuento: 1200, Stock: 1, EscritaNs: 0, VersionStock: 1}
+
+	casos := []struct {
+		nombre    string
+		versiones []*pb.OfertaRequest
+		ganadora  *pb.OfertaRequest
+	}{
+		{"", []*pb.OfertaRequest{barata, conStock, reciente}, reciente},
+		{resolucionUltimaEscritura, []*pb.OfertaRequest{barata, conStock, reciente}, reciente},
+		{resolucionMenorPrecio, []*pb.OfertaRequest{conStock, reciente, barata}, barata},
+		{resolucionMayorStock, []*pb.OfertaRequest{barata, reciente, conStock}, conStock},
+		// La versión de stock más alta gana con cualquier criterio
+		{resolucionUltimaEscritura, []*pb.OfertaRequest{reciente, comprada}, comprada},
+		{resolucionMenorPrecio, []*pb.OfertaRequest{barata, comprada}, comprada},
+		{resolucionMayorStock, []*pb.OfertaRequest{conStock, comprada}, comprada},
+	}
+	for _, c := range casos {
+		resolvedor, err := resolvedorPorNombre(c.nombre)
+		if err != nil {
+			t.Fatalf("%q: %v", c.nombre, err)
+		}
+		db := &DBNode{resolvedor: resolvedor}
+		registro := db.empaquetar(c.versiones)
+		if registro.GetPrecioDescuento() != c.ganadora.GetPrecioDescuento() {
+			t.Errorf("%q: ganó la de precio %d, se esperaba %d", c.nombre, registro.GetPrecioDescuento(), c.ganadora.GetPrecioDescuento())
+		}
+		if len(registro.GetHermanas()) != len(c.versiones)-1 {
+			t.Errorf("%q: %d hermanas, se esperaban %d", c.nombre, len(registro.GetHermanas()), len(c.versiones)-1)
+		}
+	}
+
+	if _, err := resolvedorPorNombre("primero"); err == nil {
+		t.Fatal("un resolvedor desconocido debería dar error")
+	}
+}
+
+func TestHermanasLleganPorSincronizar(t *testing.T) {
+	db := nodoPrueba(t, "DB1")
+
+	local := version("o1", map[string]int64{"DB1": 1}, 1000, 5)
+	if err := db.storage.Put(local); err != nil {
+		t.Fatal(err)
+	}
+	concurrente := version("o1", map[string]int64{"DB2": 1}, 900, 5)
+	sincronizadas, err := db.fusionarOfertas([]*pb.OfertaRequest{concurrente})
+	if err != nil {
+		t.Fatal(err)
+	}
+	registro, _ := leerRegistro(t, db, "o1")
+	if sincronizadas != 1 || len(versionesDe(registro)) != 2 {
+		t.Fatalf("sincronizadas = %d, versiones = %d; se esperaban 1 y 2", sincronizadas, len(versionesDe(registro)))
+	}
+}
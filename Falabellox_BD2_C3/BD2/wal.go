@@ -0,0 +1,383 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	pb "falabellox_bd2_c3/proto"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// Cantidad de registros en el WAL que dispara un snapshot
+const maxRegistrosWAL = 1000
+
+// Cada cuánto se compacta el WAL aunque no llegue a maxRegistrosWAL
+const intervaloSnapshot = 5 * time.Minute
+
+// Encabezado de cada registro: largo del contenido y su CRC-32C (4 bytes c/u)
+const encabezadoRegistro = 8
+
+// Un largo mayor solo puede venir de un registro dañado
+const maxTamanoRegistro = 16 << 20
+
+// Bit del largo que marca un registro de borrado, cuyo contenido es solo el
+// oferta_id. Los largos reales no llegan a usarlo
+const bitBorrado = 1 << 31
+
+// Encabezado del snapshot: marca de formato y cantidad de ofertas (4 bytes
+// c/u). Después van las ofertas con el mismo formato que los registros del WAL
+var marcaSnapshot = []byte("SNP1")
+
+const encabezadoSnapshot = 8
+
+var tablaCRC = crc32.MakeTable(crc32.Castagnoli)
+
+// walOfertas es el registro de escritura anticipada del nodo. Cada oferta que
+// cambia se agrega completa al segmento activo (<nodo>_wal_000001.log, ...);
+// al compactar se abre un segmento nuevo y los anteriores se borran una vez
+// que el snapshot que los cubre quedó en disco.
+type walOfertas struct {
+	mu        sync.Mutex
+	prefijo   string
+	segmento  int
+	archivo   *os.File
+	tamano    int64
+	registros int
+
+	// Después de un registro a medias o un fsync fallido no se sabe qué
+	// quedó en disco: se rechazan las escrituras hasta que un snapshot
+	// vuelva a cubrir todo el estado.
+	fallo error
+}
+
+func rutaSegmento(prefijo string, segmento int) string {
+	return fmt.Sprintf("%s_%06d.log", prefijo, segmento)
+}
+
+// segmentosWAL devuelve los segmentos que hay en disco, de menor a mayor.
+func segmentosWAL(prefijo string) ([]int, error) {
+	rutas, err := filepath.Glob(prefijo + "_*.log")
+	if err != nil {
+		return nil, err
+	}
+	segmentos := make([]int, 0, len(rutas))
+	for _, ruta := range rutas {
+		numero := strings.TrimSuffix(strings.TrimPrefix(ruta, prefijo+"_"), ".log")
+		segmento, err := strconv.Atoi(numero)
+		if err != nil {
+			continue
+		}
+		segmentos = append(segmentos, segmento)
+	}
+	sort.Ints(segmentos)
+	return segmentos, nil
+}
+
+func abrirWAL(prefijo string, segmento int) (*walOfertas, error) {
+	w := &walOfertas{prefijo: prefijo}
+	archivo, err := w.crearSegmento(segmento)
+	if err != nil {
+		return nil, err
+	}
+	w.segmento = segmento
+	w.archivo = archivo
+	return w, nil
+}
+
+func (w *walOfertas) crearSegmento(segmento int) (*os.File, error) {
+	ruta := rutaSegmento(w.prefijo, segmento)
+	archivo, err := os.OpenFile(ruta, os.O_APPEND|os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := sincronizarDirectorio(ruta); err != nil {
+		archivo.Close()
+		return nil, err
+	}
+	return archivo, nil
+}
+
+// agregar escribe el registro sin esperar al disco (eso lo hace sincronizar).
+func (w *walOfertas) agregar(oferta *pb.OfertaRequest) error {
+	contenido, err := proto.Marshal(oferta)
+	if err != nil {
+		return err
+	}
+	return w.escribir(uint32(len(contenido)), contenido)
+}
+
+// agregarBorrado anota que la oferta se sacó del almacenamiento.
+func (w *walOfertas) agregarBorrado(ofertaID string) error {
+	contenido := []byte(ofertaID)
+	return w.escribir(uint32(len(contenido))|bitBorrado, contenido)
+}
+
+// codificarRegistro antepone al contenido su largo (con las marcas que traiga)
+// y su CRC-32C.
+func codificarRegistro(largo uint32, contenido []byte) []byte {
+	registro := make([]byte, encabezadoRegistro+len(contenido))
+	binary.BigEndian.PutUint32(registro[0:4], largo)
+	binary.BigEndian.PutUint32(registro[4:8], crc32.Checksum(contenido, tablaCRC))
+	copy(registro[encabezadoRegistro:], contenido)
+	return registro
+}
+
+// recorrerRegistros entrega cada registro válido de datos, en orden, y se
+// detiene en el primero cortado, con checksum inválido o que fn rechace.
+// Devuelve cuántos entregó y hasta qué byte llegó.
+func recorrerRegistros(datos []byte, fn func(marca uint32, contenido []byte) error) (int, int) {
+	registros := 0
+	offset := 0
+	for len(datos)-offset >= encabezadoRegistro {
+		marca := binary.BigEndian.Uint32(datos[offset : offset+4])
+		largo := int(marca &^ bitBorrado)
+		checksum := binary.BigEndian.Uint32(datos[offset+4 : offset+8])
+		inicio := offset + encabezadoRegistro
+		if largo > maxTamanoRegistro || largo > len(datos)-inicio {
+			break
+		}
+		contenido := datos[inicio : inicio+largo]
+		if crc32.Checksum(contenido, tablaCRC) != checksum {
+			break
+		}
+		if err := fn(marca, contenido); err != nil {
+			break
+		}
+		registros++
+		offset = inicio + largo
+	}
+	return registros, offset
+}
+
+func (w *walOfertas) escribir(largo uint32, contenido []byte) error {
+	registro := codificarRegistro(largo, contenido)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.fallo != nil {
+		return w.fallo
+	}
+	if _, err := w.archivo.Write(registro); err != nil {
+		// Se corta lo que haya quedado a medias para que los registros
+		// siguientes no queden detrás de uno ilegible
+		w.archivo.Truncate(w.tamano)
+		w.fallo = err
+		return err
+	}
+	w.tamano += int64(len(registro))
+	w.registros++
+	return nil
+}
+
+// sincronizar hace fsync del segmento activo.
+func (w *walOfertas) sincronizar() error {
+	w.mu.Lock()
+	archivo, fallo := w.archivo, w.fallo
+	w.mu.Unlock()
+	if fallo != nil {
+		return fallo
+	}
+
+	// rotar hace fsync antes de cerrar un segmento, así que si ya está
+	// cerrado sus registros están en disco
+	if err := archivo.Sync(); err != nil && !errors.Is(err, os.ErrClosed) {
+		w.mu.Lock()
+		w.fallo = err
+		w.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+func (w *walOfertas) cantidadRegistros() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.registros
+}
+
+func (w *walOfertas) conFallo() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.fallo != nil
+}
+
+// rotar cierra el segmento activo y abre el siguiente. Devuelve el número del
+// segmento nuevo: todos los anteriores quedan cubiertos por el próximo snapshot.
+func (w *walOfertas) rotar() (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	siguiente := w.segmento + 1
+	archivo, err := w.crearSegmento(siguiente)
+	if err != nil {
+		return 0, err
+	}
+	if err := w.archivo.Sync(); err != nil && w.fallo == nil {
+		w.fallo = err
+	}
+	w.archivo.Close()
+
+	w.segmento = siguiente
+	w.archivo = archivo
+	w.tamano = 0
+	w.registros = 0
+	return siguiente, nil
+}
+
+func (w *walOfertas) cerrar() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.archivo.Sync(); err != nil {
+		w.archivo.Close()
+		return err
+	}
+	return w.archivo.Close()
+}
+
+// limpiarFallo se llama después de un snapshot que incluye todo el mapa.
+func (w *walOfertas) limpiarFallo() {
+	w.mu.Lock()
+	w.fallo = nil
+	w.mu.Unlock()
+}
+
+// eliminarSegmentos borra los segmentos menores a hasta.
+func eliminarSegmentos(prefijo string, hasta int) error {
+	segmentos, err := segmentosWAL(prefijo)
+	if err != nil {
+		return err
+	}
+	for _, segmento := range segmentos {
+		if segmento >= hasta {
+			break
+		}
+		if err := os.Remove(rutaSegmento(prefijo, segmento)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// leerSegmento aplica los registros del segmento en orden. Un registro
+// cortado o con checksum inválido marca el final de lo que alcanzó a llegar
+// al disco: se descarta junto con lo que venga después.
+func leerSegmento(ruta string, aplicar func(*pb.OfertaRequest), borrar func(string)) (int, error) {
+	datos, err := os.ReadFile(ruta)
+	if err != nil {
+		return 0, err
+	}
+
+	registros, offset := recorrerRegistros(datos, func(marca uint32, contenido []byte) error {
+		if marca&bitBorrado != 0 {
+			borrar(string(contenido))
+			return nil
+		}
+		oferta := &pb.OfertaRequest{}
+		if err := proto.Unmarshal(contenido, oferta); err != nil {
+			return err
+		}
+		aplicar(oferta)
+		return nil
+	})
+
+	if offset < len(datos) {
+		log.Printf("WAL %s: se descartan %d bytes desde el byte %d (registro incompleto o dañado)",
+			ruta, len(datos)-offset, offset)
+	}
+	return registros, nil
+}
+
+// escribirSnapshot reemplaza el snapshot de forma atómica. Cada oferta va
+// como un registro del WAL (proto con largo y CRC-32C).
+func escribirSnapshot(ruta string, ofertas map[string]*pb.OfertaRequest) error {
+	var datos bytes.Buffer
+	datos.Write(marcaSnapshot)
+	binary.Write(&datos, binary.BigEndian, uint32(len(ofertas)))
+	for _, oferta := range ofertas {
+		contenido, err := proto.Marshal(oferta)
+		if err != nil {
+			return err
+		}
+		datos.Write(codificarRegistro(uint32(len(contenido)), contenido))
+	}
+	return escribirAtomico(ruta, datos.Bytes())
+}
+
+// leerSnapshot carga un snapshot de escribirSnapshot. A diferencia del WAL,
+// el snapshot se escribe entero antes del rename: un registro cortado o
+// dañado, o una cantidad distinta de la anotada, lo invalida completo.
+func leerSnapshot(ruta string) (map[string]*pb.OfertaRequest, error) {
+	datos, err := os.ReadFile(ruta)
+	if err != nil {
+		return nil, err
+	}
+	if len(datos) < encabezadoSnapshot || !bytes.Equal(datos[:len(marcaSnapshot)], marcaSnapshot) {
+		return nil, fmt.Errorf("%s no es un snapshot", ruta)
+	}
+	esperadas := int(binary.BigEndian.Uint32(datos[len(marcaSnapshot):encabezadoSnapshot]))
+
+	ofertas := make(map[string]*pb.OfertaRequest, esperadas)
+	registros, offset := recorrerRegistros(datos[encabezadoSnapshot:], func(marca uint32, contenido []byte) error {
+		if marca&bitBorrado != 0 {
+			return fmt.Errorf("registro de borrado en el snapshot")
+		}
+		oferta := &pb.OfertaRequest{}
+		if err := proto.Unmarshal(contenido, oferta); err != nil {
+			return err
+		}
+		ofertas[oferta.GetOfertaId()] = oferta
+		return nil
+	})
+	if registros != esperadas || encabezadoSnapshot+offset != len(datos) {
+		return nil, fmt.Errorf("snapshot %s dañado: %d de %d ofertas legibles", ruta, registros, esperadas)
+	}
+	return ofertas, nil
+}
+
+// escribirAtomico reemplaza el archivo (temporal, fsync y rename), así que un
+// corte deja el anterior o el nuevo, nunca uno a medias.
+func escribirAtomico(ruta string, datos []byte) error {
+	tmp := ruta + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(datos); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, ruta); err != nil {
+		return err
+	}
+	return sincronizarDirectorio(ruta)
+}
+
+// sincronizarDirectorio hace fsync del directorio del archivo para que su
+// creación o rename sobreviva a un corte de luz.
+func sincronizarDirectorio(ruta string) error {
+	dir, err := os.Open(filepath.Dir(ruta))
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
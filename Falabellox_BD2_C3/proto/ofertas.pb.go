@@ -21,6 +21,63 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// Estados de un nodo DB en la membresía. A igual encarnación, un estado
+// posterior en esta lista reemplaza a uno anterior
+type EstadoMiembro int32
+
+const (
+	EstadoMiembro_VIVO       EstadoMiembro = 0
+	EstadoMiembro_SOSPECHOSO EstadoMiembro = 1 // no respondió a un ping directo ni a los indirectos
+	EstadoMiembro_MUERTO     EstadoMiembro = 2 // siguió sospechoso sin desmentirlo
+	EstadoMiembro_SALIO      EstadoMiembro = 3 // se detuvo avisando; puede volver con sus datos
+	EstadoMiembro_RETIRADO   EstadoMiembro = 4 // dejó el clúster para siempre y entregó sus datos
+)
+
+// Enum value maps for EstadoMiembro.
+var (
+	EstadoMiembro_name = map[int32]string{
+		0: "VIVO",
+		1: "SOSPECHOSO",
+		2: "MUERTO",
+		3: "SALIO",
+		4: "RETIRADO",
+	}
+	EstadoMiembro_value = map[string]int32{
+		"VIVO":       0,
+		"SOSPECHOSO": 1,
+		"MUERTO":     2,
+		"SALIO":      3,
+		"RETIRADO":   4,
+	}
+)
+
+func (x EstadoMiembro) Enum() *EstadoMiembro {
+	p := new(EstadoMiembro)
+	*p = x
+	return p
+}
+
+func (x EstadoMiembro) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (EstadoMiembro) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_ofertas_proto_enumTypes[0].Descriptor()
+}
+
+func (EstadoMiembro) Type() protoreflect.EnumType {
+	return &file_proto_ofertas_proto_enumTypes[0]
+}
+
+func (x EstadoMiembro) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use EstadoMiembro.Descriptor instead.
+func (EstadoMiembro) EnumDescriptor() ([]byte, []int) {
+	return file_proto_ofertas_proto_rawDescGZIP(), []int{0}
+}
+
 // Mensajes
 type OfertaRequest struct {
 	state           protoimpl.MessageState `protogen:"open.v1"`
@@ -34,8 +91,31 @@ type OfertaRequest struct {
 	Fecha           string                 `protobuf:"bytes,8,opt,name=fecha,proto3" json:"fecha,omitempty"`
 	ClienteId       string                 `protobuf:"bytes,9,opt,name=cliente_id,json=clienteId,proto3" json:"cliente_id,omitempty"`
 	Timestamp       int64                  `protobuf:"varint,10,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
-	unknownFields   protoimpl.UnknownFields
-	sizeCache       protoimpl.SizeCache
+	// Asignados por el broker: secuencia global al aceptar la oferta y, en la
+	// entrega ordenada, número correlativo por consumidor (detecta huecos)
+	Secuencia           int64 `protobuf:"varint,11,opt,name=secuencia,proto3" json:"secuencia,omitempty"`
+	SecuenciaConsumidor int64 `protobuf:"varint,12,opt,name=secuencia_consumidor,json=secuenciaConsumidor,proto3" json:"secuencia_consumidor,omitempty"`
+	PrecioOriginal      int32 `protobuf:"varint,13,opt,name=precio_original,json=precioOriginal,proto3" json:"precio_original,omitempty"` // precio antes del descuento (0 = desconocido)
+	// Stock replicado: versión de la última escritura condicional y la
+	// operación que la hizo (para reintentos idempotentes)
+	VersionStock   int64  `protobuf:"varint,14,opt,name=version_stock,json=versionStock,proto3" json:"version_stock,omitempty"`
+	OperacionStock string `protobuf:"bytes,15,opt,name=operacion_stock,json=operacionStock,proto3" json:"operacion_stock,omitempty"`
+	// Vacío en una oferta nueva; "agotado" cuando el broker avisa que el stock llegó a cero
+	Evento string `protobuf:"bytes,16,opt,name=evento,proto3" json:"evento,omitempty"`
+	// Asignado por el broker: precio mínimo del producto antes de esta oferta (0 = primera vez)
+	PrecioMinimoAnterior int32 `protobuf:"varint,17,opt,name=precio_minimo_anterior,json=precioMinimoAnterior,proto3" json:"precio_minimo_anterior,omitempty"`
+	// Versionado entre réplicas (lo asignan los nodos DB): reloj vectorial de
+	// esta versión, versiones concurrentes guardadas junto a ella y hora de la
+	// escritura (para last-writer-wins)
+	RelojVectorial map[string]int64 `protobuf:"bytes,18,rep,name=reloj_vectorial,json=relojVectorial,proto3" json:"reloj_vectorial,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	Hermanas       []*OfertaRequest `protobuf:"bytes,19,rep,name=hermanas,proto3" json:"hermanas,omitempty"`
+	EscritaNs      int64            `protobuf:"varint,20,opt,name=escrita_ns,json=escritaNs,proto3" json:"escrita_ns,omitempty"`
+	// Lápida: la oferta fue eliminada. confirmada_por son los nodos DB que ya
+	// la tienen; cuando están todas las réplicas la lápida se puede borrar
+	Eliminada     bool     `protobuf:"varint,21,opt,name=eliminada,proto3" json:"eliminada,omitempty"`
+	ConfirmadaPor []string `protobuf:"bytes,22,rep,name=confirmada_por,json=confirmadaPor,proto3" json:"confirmada_por,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *OfertaRequest) Reset() {
@@ -138,6 +218,90 @@ func (x *OfertaRequest) GetTimestamp() int64 {
 	return 0
 }
 
+func (x *OfertaRequest) GetSecuencia() int64 {
+	if x != nil {
+		return x.Secuencia
+	}
+	return 0
+}
+
+func (x *OfertaRequest) GetSecuenciaConsumidor() int64 {
+	if x != nil {
+		return x.SecuenciaConsumidor
+	}
+	return 0
+}
+
+func (x *OfertaRequest) GetPrecioOriginal() int32 {
+	if x != nil {
+		return x.PrecioOriginal
+	}
+	return 0
+}
+
+func (x *OfertaRequest) GetVersionStock() int64 {
+	if x != nil {
+		return x.VersionStock
+	}
+	return 0
+}
+
+func (x *OfertaRequest) GetOperacionStock() string {
+	if x != nil {
+		return x.OperacionStock
+	}
+	return ""
+}
+
+func (x *OfertaRequest) GetEvento() string {
+	if x != nil {
+		return x.Evento
+	}
+	return ""
+}
+
+func (x *OfertaRequest) GetPrecioMinimoAnterior() int32 {
+	if x != nil {
+		return x.PrecioMinimoAnterior
+	}
+	return 0
+}
+
+func (x *OfertaRequest) GetRelojVectorial() map[string]int64 {
+	if x != nil {
+		return x.RelojVectorial
+	}
+	return nil
+}
+
+func (x *OfertaRequest) GetHermanas() []*OfertaRequest {
+	if x != nil {
+		return x.Hermanas
+	}
+	return nil
+}
+
+func (x *OfertaRequest) GetEscritaNs() int64 {
+	if x != nil {
+		return x.EscritaNs
+	}
+	return 0
+}
+
+func (x *OfertaRequest) GetEliminada() bool {
+	if x != nil {
+		return x.Eliminada
+	}
+	return false
+}
+
+func (x *OfertaRequest) GetConfirmadaPor() []string {
+	if x != nil {
+		return x.ConfirmadaPor
+	}
+	return nil
+}
+
 type OfertaResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Exito         bool                   `protobuf:"varint,1,opt,name=exito,proto3" json:"exito,omitempty"`
@@ -250,31 +414,31 @@ func (x *AckResponse) GetMensaje() string {
 	return ""
 }
 
-type RegistroConsumidorRequest struct {
+type ResumenOfertas struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	ConsumidorId  string                 `protobuf:"bytes,1,opt,name=consumidor_id,json=consumidorId,proto3" json:"consumidor_id,omitempty"`
-	Categorias    []string               `protobuf:"bytes,2,rep,name=categorias,proto3" json:"categorias,omitempty"`
-	Tiendas       []string               `protobuf:"bytes,3,rep,name=tiendas,proto3" json:"tiendas,omitempty"`
-	PrecioMax     int32                  `protobuf:"varint,4,opt,name=precio_max,json=precioMax,proto3" json:"precio_max,omitempty"`
-	DireccionGrpc string                 `protobuf:"bytes,5,opt,name=direccion_grpc,json=direccionGrpc,proto3" json:"direccion_grpc,omitempty"`
+	ResumenId     string                 `protobuf:"bytes,1,opt,name=resumen_id,json=resumenId,proto3" json:"resumen_id,omitempty"`
+	ConsumidorId  string                 `protobuf:"bytes,2,opt,name=consumidor_id,json=consumidorId,proto3" json:"consumidor_id,omitempty"`
+	Ofertas       []*OfertaRequest       `protobuf:"bytes,3,rep,name=ofertas,proto3" json:"ofertas,omitempty"` // de mayor a menor descuento
+	Desde         int64                  `protobuf:"varint,4,opt,name=desde,proto3" json:"desde,omitempty"`    // unix: llegada de la primera oferta acumulada
+	Hasta         int64                  `protobuf:"varint,5,opt,name=hasta,proto3" json:"hasta,omitempty"`    // unix: envío del resumen
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *RegistroConsumidorRequest) Reset() {
-	*x = RegistroConsumidorRequest{}
+func (x *ResumenOfertas) Reset() {
+	*x = ResumenOfertas{}
 	mi := &file_proto_ofertas_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *RegistroConsumidorRequest) String() string {
+func (x *ResumenOfertas) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*RegistroConsumidorRequest) ProtoMessage() {}
+func (*ResumenOfertas) ProtoMessage() {}
 
-func (x *RegistroConsumidorRequest) ProtoReflect() protoreflect.Message {
+func (x *ResumenOfertas) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_ofertas_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -286,68 +450,68 @@ func (x *RegistroConsumidorRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use RegistroConsumidorRequest.ProtoReflect.Descriptor instead.
-func (*RegistroConsumidorRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use ResumenOfertas.ProtoReflect.Descriptor instead.
+func (*ResumenOfertas) Descriptor() ([]byte, []int) {
 	return file_proto_ofertas_proto_rawDescGZIP(), []int{3}
 }
 
-func (x *RegistroConsumidorRequest) GetConsumidorId() string {
+func (x *ResumenOfertas) GetResumenId() string {
 	if x != nil {
-		return x.ConsumidorId
+		return x.ResumenId
 	}
 	return ""
 }
 
-func (x *RegistroConsumidorRequest) GetCategorias() []string {
+func (x *ResumenOfertas) GetConsumidorId() string {
 	if x != nil {
-		return x.Categorias
+		return x.ConsumidorId
 	}
-	return nil
+	return ""
 }
 
-func (x *RegistroConsumidorRequest) GetTiendas() []string {
+func (x *ResumenOfertas) GetOfertas() []*OfertaRequest {
 	if x != nil {
-		return x.Tiendas
+		return x.Ofertas
 	}
 	return nil
 }
 
-func (x *RegistroConsumidorRequest) GetPrecioMax() int32 {
+func (x *ResumenOfertas) GetDesde() int64 {
 	if x != nil {
-		return x.PrecioMax
+		return x.Desde
 	}
 	return 0
 }
 
-func (x *RegistroConsumidorRequest) GetDireccionGrpc() string {
+func (x *ResumenOfertas) GetHasta() int64 {
 	if x != nil {
-		return x.DireccionGrpc
+		return x.Hasta
 	}
-	return ""
+	return 0
 }
 
-type RegistroConsumidorResponse struct {
+type VerificacionRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Exito         bool                   `protobuf:"varint,1,opt,name=exito,proto3" json:"exito,omitempty"`
-	Mensaje       string                 `protobuf:"bytes,2,opt,name=mensaje,proto3" json:"mensaje,omitempty"`
+	ConsumidorId  string                 `protobuf:"bytes,1,opt,name=consumidor_id,json=consumidorId,proto3" json:"consumidor_id,omitempty"`
+	Nonce         string                 `protobuf:"bytes,2,opt,name=nonce,proto3" json:"nonce,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *RegistroConsumidorResponse) Reset() {
-	*x = RegistroConsumidorResponse{}
+func (x *VerificacionRequest) Reset() {
+	*x = VerificacionRequest{}
 	mi := &file_proto_ofertas_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *RegistroConsumidorResponse) String() string {
+func (x *VerificacionRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*RegistroConsumidorResponse) ProtoMessage() {}
+func (*VerificacionRequest) ProtoMessage() {}
 
-func (x *RegistroConsumidorResponse) ProtoReflect() protoreflect.Message {
+func (x *VerificacionRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_ofertas_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -359,46 +523,47 @@ func (x *RegistroConsumidorResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use RegistroConsumidorResponse.ProtoReflect.Descriptor instead.
-func (*RegistroConsumidorResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use VerificacionRequest.ProtoReflect.Descriptor instead.
+func (*VerificacionRequest) Descriptor() ([]byte, []int) {
 	return file_proto_ofertas_proto_rawDescGZIP(), []int{4}
 }
 
-func (x *RegistroConsumidorResponse) GetExito() bool {
+func (x *VerificacionRequest) GetConsumidorId() string {
 	if x != nil {
-		return x.Exito
+		return x.ConsumidorId
 	}
-	return false
+	return ""
 }
 
-func (x *RegistroConsumidorResponse) GetMensaje() string {
+func (x *VerificacionRequest) GetNonce() string {
 	if x != nil {
-		return x.Mensaje
+		return x.Nonce
 	}
 	return ""
 }
 
-type SolicitarHistoricoRequest struct {
+type VerificacionResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	ConsumidorId  string                 `protobuf:"bytes,1,opt,name=consumidor_id,json=consumidorId,proto3" json:"consumidor_id,omitempty"`
+	Nonce         string                 `protobuf:"bytes,2,opt,name=nonce,proto3" json:"nonce,omitempty"` // el mismo nonce recibido
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SolicitarHistoricoRequest) Reset() {
-	*x = SolicitarHistoricoRequest{}
+func (x *VerificacionResponse) Reset() {
+	*x = VerificacionResponse{}
 	mi := &file_proto_ofertas_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SolicitarHistoricoRequest) String() string {
+func (x *VerificacionResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SolicitarHistoricoRequest) ProtoMessage() {}
+func (*VerificacionResponse) ProtoMessage() {}
 
-func (x *SolicitarHistoricoRequest) ProtoReflect() protoreflect.Message {
+func (x *VerificacionResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_ofertas_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -410,39 +575,63 @@ func (x *SolicitarHistoricoRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SolicitarHistoricoRequest.ProtoReflect.Descriptor instead.
-func (*SolicitarHistoricoRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use VerificacionResponse.ProtoReflect.Descriptor instead.
+func (*VerificacionResponse) Descriptor() ([]byte, []int) {
 	return file_proto_ofertas_proto_rawDescGZIP(), []int{5}
 }
 
-func (x *SolicitarHistoricoRequest) GetConsumidorId() string {
+func (x *VerificacionResponse) GetConsumidorId() string {
 	if x != nil {
 		return x.ConsumidorId
 	}
 	return ""
 }
 
-type HistoricoConsumidorResponse struct {
+func (x *VerificacionResponse) GetNonce() string {
+	if x != nil {
+		return x.Nonce
+	}
+	return ""
+}
+
+type RegistroConsumidorRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Ofertas       []*OfertaRequest       `protobuf:"bytes,1,rep,name=ofertas,proto3" json:"ofertas,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	ConsumidorId  string                 `protobuf:"bytes,1,opt,name=consumidor_id,json=consumidorId,proto3" json:"consumidor_id,omitempty"`
+	Categorias    []string               `protobuf:"bytes,2,rep,name=categorias,proto3" json:"categorias,omitempty"`
+	Tiendas       []string               `protobuf:"bytes,3,rep,name=tiendas,proto3" json:"tiendas,omitempty"`
+	PrecioMax     int32                  `protobuf:"varint,4,opt,name=precio_max,json=precioMax,proto3" json:"precio_max,omitempty"`
+	DireccionGrpc string                 `protobuf:"bytes,5,opt,name=direccion_grpc,json=direccionGrpc,proto3" json:"direccion_grpc,omitempty"`
+	// Entrega por webhook HTTP en vez de RecibirOferta (opcional)
+	WebhookUrl     string `protobuf:"bytes,6,opt,name=webhook_url,json=webhookUrl,proto3" json:"webhook_url,omitempty"`
+	WebhookSecreto string `protobuf:"bytes,7,opt,name=webhook_secreto,json=webhookSecreto,proto3" json:"webhook_secreto,omitempty"` // clave HMAC para firmar cada entrega
+	// Grupo de consumidores: los miembros se reparten las ofertas (opcional)
+	Grupo string `protobuf:"bytes,8,opt,name=grupo,proto3" json:"grupo,omitempty"`
+	// Entrega en orden de secuencia, de a una oferta a la vez (opcional)
+	EntregaOrdenada bool `protobuf:"varint,9,opt,name=entrega_ordenada,json=entregaOrdenada,proto3" json:"entrega_ordenada,omitempty"`
+	// Seguimiento de productos: con alguno, solo llegan las ofertas que bajan de precio (opcional)
+	Seguimientos []*Seguimiento `protobuf:"bytes,10,rep,name=seguimientos,proto3" json:"seguimientos,omitempty"`
+	// Modo resumen: acumular las ofertas y enviarlas juntas cada resumen_segundos
+	// o al juntar resumen_max_ofertas, lo que ocurra primero (opcional)
+	ResumenSegundos   int32 `protobuf:"varint,11,opt,name=resumen_segundos,json=resumenSegundos,proto3" json:"resumen_segundos,omitempty"`
+	ResumenMaxOfertas int32 `protobuf:"varint,12,opt,name=resumen_max_ofertas,json=resumenMaxOfertas,proto3" json:"resumen_max_ofertas,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
 }
 
-func (x *HistoricoConsumidorResponse) Reset() {
-	*x = HistoricoConsumidorResponse{}
+func (x *RegistroConsumidorRequest) Reset() {
+	*x = RegistroConsumidorRequest{}
 	mi := &file_proto_ofertas_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *HistoricoConsumidorResponse) String() string {
+func (x *RegistroConsumidorRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*HistoricoConsumidorResponse) ProtoMessage() {}
+func (*RegistroConsumidorRequest) ProtoMessage() {}
 
-func (x *HistoricoConsumidorResponse) ProtoReflect() protoreflect.Message {
+func (x *RegistroConsumidorRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_ofertas_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -454,40 +643,120 @@ func (x *HistoricoConsumidorResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use HistoricoConsumidorResponse.ProtoReflect.Descriptor instead.
-func (*HistoricoConsumidorResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use RegistroConsumidorRequest.ProtoReflect.Descriptor instead.
+func (*RegistroConsumidorRequest) Descriptor() ([]byte, []int) {
 	return file_proto_ofertas_proto_rawDescGZIP(), []int{6}
 }
 
-func (x *HistoricoConsumidorResponse) GetOfertas() []*OfertaRequest {
+func (x *RegistroConsumidorRequest) GetConsumidorId() string {
 	if x != nil {
-		return x.Ofertas
+		return x.ConsumidorId
+	}
+	return ""
+}
+
+func (x *RegistroConsumidorRequest) GetCategorias() []string {
+	if x != nil {
+		return x.Categorias
 	}
 	return nil
 }
 
-type LeerHistoricoRequest struct {
+func (x *RegistroConsumidorRequest) GetTiendas() []string {
+	if x != nil {
+		return x.Tiendas
+	}
+	return nil
+}
+
+func (x *RegistroConsumidorRequest) GetPrecioMax() int32 {
+	if x != nil {
+		return x.PrecioMax
+	}
+	return 0
+}
+
+func (x *RegistroConsumidorRequest) GetDireccionGrpc() string {
+	if x != nil {
+		return x.DireccionGrpc
+	}
+	return ""
+}
+
+func (x *RegistroConsumidorRequest) GetWebhookUrl() string {
+	if x != nil {
+		return x.WebhookUrl
+	}
+	return ""
+}
+
+func (x *RegistroConsumidorRequest) GetWebhookSecreto() string {
+	if x != nil {
+		return x.WebhookSecreto
+	}
+	return ""
+}
+
+func (x *RegistroConsumidorRequest) GetGrupo() string {
+	if x != nil {
+		return x.Grupo
+	}
+	return ""
+}
+
+func (x *RegistroConsumidorRequest) GetEntregaOrdenada() bool {
+	if x != nil {
+		return x.EntregaOrdenada
+	}
+	return false
+}
+
+func (x *RegistroConsumidorRequest) GetSeguimientos() []*Seguimiento {
+	if x != nil {
+		return x.Seguimientos
+	}
+	return nil
+}
+
+func (x *RegistroConsumidorRequest) GetResumenSegundos() int32 {
+	if x != nil {
+		return x.ResumenSegundos
+	}
+	return 0
+}
+
+func (x *RegistroConsumidorRequest) GetResumenMaxOfertas() int32 {
+	if x != nil {
+		return x.ResumenMaxOfertas
+	}
+	return 0
+}
+
+// Un producto seguido, por id exacto o por patrón sobre el nombre. Avisa cuando
+// una oferta nueva mejora el mínimo visto antes o llega al precio objetivo.
+type Seguimiento struct {
 	state          protoimpl.MessageState `protogen:"open.v1"`
-	NodoId         string                 `protobuf:"bytes,1,opt,name=nodo_id,json=nodoId,proto3" json:"nodo_id,omitempty"`
-	DesdeTimestamp int64                  `protobuf:"varint,2,opt,name=desde_timestamp,json=desdeTimestamp,proto3" json:"desde_timestamp,omitempty"`
+	ProductoId     string                 `protobuf:"bytes,1,opt,name=producto_id,json=productoId,proto3" json:"producto_id,omitempty"`
+	Patron         string                 `protobuf:"bytes,2,opt,name=patron,proto3" json:"patron,omitempty"`                                        // "*" es comodín; sin comodines basta con que el nombre lo contenga
+	PrecioObjetivo int32                  `protobuf:"varint,3,opt,name=precio_objetivo,json=precioObjetivo,proto3" json:"precio_objetivo,omitempty"` // 0 = solo avisar cuando mejora el mínimo
 	unknownFields  protoimpl.UnknownFields
 	sizeCache      protoimpl.SizeCache
 }
 
-func (x *LeerHistoricoRequest) Reset() {
-	*x = LeerHistoricoRequest{}
+func (x *Seguimiento) Reset() {
+	*x = Seguimiento{}
 	mi := &file_proto_ofertas_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *LeerHistoricoRequest) String() string {
+func (x *Seguimiento) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*LeerHistoricoRequest) ProtoMessage() {}
+func (*Seguimiento) ProtoMessage() {}
 
-func (x *LeerHistoricoRequest) ProtoReflect() protoreflect.Message {
+func (x *Seguimiento) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_ofertas_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -499,48 +768,2585 @@ func (x *LeerHistoricoRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use LeerHistoricoRequest.ProtoReflect.Descriptor instead.
-func (*LeerHistoricoRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use Seguimiento.ProtoReflect.Descriptor instead.
+func (*Seguimiento) Descriptor() ([]byte, []int) {
 	return file_proto_ofertas_proto_rawDescGZIP(), []int{7}
 }
 
-func (x *LeerHistoricoRequest) GetNodoId() string {
+func (x *Seguimiento) GetProductoId() string {
 	if x != nil {
-		return x.NodoId
+		return x.ProductoId
 	}
 	return ""
 }
 
-func (x *LeerHistoricoRequest) GetDesdeTimestamp() int64 {
+func (x *Seguimiento) GetPatron() string {
 	if x != nil {
-		return x.DesdeTimestamp
+		return x.Patron
 	}
-	return 0
+	return ""
 }
 
-type HistoricoResponse struct {
+func (x *Seguimiento) GetPrecioObjetivo() int32 {
+	if x != nil {
+		return x.PrecioObjetivo
+	}
+	return 0
+}
+
+type RegistroConsumidorResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Exito         bool                   `protobuf:"varint,1,opt,name=exito,proto3" json:"exito,omitempty"`
+	Mensaje       string                 `protobuf:"bytes,2,opt,name=mensaje,proto3" json:"mensaje,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RegistroConsumidorResponse) Reset() {
+	*x = RegistroConsumidorResponse{}
+	mi := &file_proto_ofertas_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegistroConsumidorResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegistroConsumidorResponse) ProtoMessage() {}
+
+func (x *RegistroConsumidorResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ofertas_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegistroConsumidorResponse.ProtoReflect.Descriptor instead.
+func (*RegistroConsumidorResponse) Descriptor() ([]byte, []int) {
+	return file_proto_ofertas_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *RegistroConsumidorResponse) GetExito() bool {
+	if x != nil {
+		return x.Exito
+	}
+	return false
+}
+
+func (x *RegistroConsumidorResponse) GetMensaje() string {
+	if x != nil {
+		return x.Mensaje
+	}
+	return ""
+}
+
+type SolicitarHistoricoRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ConsumidorId   string                 `protobuf:"bytes,1,opt,name=consumidor_id,json=consumidorId,proto3" json:"consumidor_id,omitempty"`
+	DesdeTimestamp int64                  `protobuf:"varint,2,opt,name=desde_timestamp,json=desdeTimestamp,proto3" json:"desde_timestamp,omitempty"` // solo ofertas con timestamp >= este valor (0 = todas)
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *SolicitarHistoricoRequest) Reset() {
+	*x = SolicitarHistoricoRequest{}
+	mi := &file_proto_ofertas_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SolicitarHistoricoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SolicitarHistoricoRequest) ProtoMessage() {}
+
+func (x *SolicitarHistoricoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ofertas_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SolicitarHistoricoRequest.ProtoReflect.Descriptor instead.
+func (*SolicitarHistoricoRequest) Descriptor() ([]byte, []int) {
+	return file_proto_ofertas_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *SolicitarHistoricoRequest) GetConsumidorId() string {
+	if x != nil {
+		return x.ConsumidorId
+	}
+	return ""
+}
+
+func (x *SolicitarHistoricoRequest) GetDesdeTimestamp() int64 {
+	if x != nil {
+		return x.DesdeTimestamp
+	}
+	return 0
+}
+
+type HistoricoConsumidorResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ofertas       []*OfertaRequest       `protobuf:"bytes,1,rep,name=ofertas,proto3" json:"ofertas,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HistoricoConsumidorResponse) Reset() {
+	*x = HistoricoConsumidorResponse{}
+	mi := &file_proto_ofertas_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HistoricoConsumidorResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HistoricoConsumidorResponse) ProtoMessage() {}
+
+func (x *HistoricoConsumidorResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ofertas_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HistoricoConsumidorResponse.ProtoReflect.Descriptor instead.
+func (*HistoricoConsumidorResponse) Descriptor() ([]byte, []int) {
+	return file_proto_ofertas_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *HistoricoConsumidorResponse) GetOfertas() []*OfertaRequest {
+	if x != nil {
+		return x.Ofertas
+	}
+	return nil
+}
+
+type LeerHistoricoRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	NodoId         string                 `protobuf:"bytes,1,opt,name=nodo_id,json=nodoId,proto3" json:"nodo_id,omitempty"`
+	DesdeTimestamp int64                  `protobuf:"varint,2,opt,name=desde_timestamp,json=desdeTimestamp,proto3" json:"desde_timestamp,omitempty"`
+	// Entre nodos DB: devolver cada oferta con sus hermanas en vez de resuelta
+	ConHermanas   bool `protobuf:"varint,3,opt,name=con_hermanas,json=conHermanas,proto3" json:"con_hermanas,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LeerHistoricoRequest) Reset() {
+	*x = LeerHistoricoRequest{}
+	mi := &file_proto_ofertas_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LeerHistoricoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LeerHistoricoRequest) ProtoMessage() {}
+
+func (x *LeerHistoricoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ofertas_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LeerHistoricoRequest.ProtoReflect.Descriptor instead.
+func (*LeerHistoricoRequest) Descriptor() ([]byte, []int) {
+	return file_proto_ofertas_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *LeerHistoricoRequest) GetNodoId() string {
+	if x != nil {
+		return x.NodoId
+	}
+	return ""
+}
+
+func (x *LeerHistoricoRequest) GetDesdeTimestamp() int64 {
+	if x != nil {
+		return x.DesdeTimestamp
+	}
+	return 0
+}
+
+func (x *LeerHistoricoRequest) GetConHermanas() bool {
+	if x != nil {
+		return x.ConHermanas
+	}
+	return false
+}
+
+type HistoricoResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ofertas       []*OfertaRequest       `protobuf:"bytes,1,rep,name=ofertas,proto3" json:"ofertas,omitempty"`
+	NodoId        string                 `protobuf:"bytes,2,opt,name=nodo_id,json=nodoId,proto3" json:"nodo_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HistoricoResponse) Reset() {
+	*x = HistoricoResponse{}
+	mi := &file_proto_ofertas_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HistoricoResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HistoricoResponse) ProtoMessage() {}
+
+func (x *HistoricoResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ofertas_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HistoricoResponse.ProtoReflect.Descriptor instead.
+func (*HistoricoResponse) Descriptor() ([]byte, []int) {
+	return file_proto_ofertas_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *HistoricoResponse) GetOfertas() []*OfertaRequest {
+	if x != nil {
+		return x.Ofertas
+	}
+	return nil
+}
+
+func (x *HistoricoResponse) GetNodoId() string {
+	if x != nil {
+		return x.NodoId
+	}
+	return ""
+}
+
+type SincronizarRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodoOrigen    string                 `protobuf:"bytes,1,opt,name=nodo_origen,json=nodoOrigen,proto3" json:"nodo_origen,omitempty"`
+	Ofertas       []*OfertaRequest       `protobuf:"bytes,2,rep,name=ofertas,proto3" json:"ofertas,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SincronizarRequest) Reset() {
+	*x = SincronizarRequest{}
+	mi := &file_proto_ofertas_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SincronizarRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SincronizarRequest) ProtoMessage() {}
+
+func (x *SincronizarRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ofertas_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SincronizarRequest.ProtoReflect.Descriptor instead.
+func (*SincronizarRequest) Descriptor() ([]byte, []int) {
+	return file_proto_ofertas_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *SincronizarRequest) GetNodoOrigen() string {
+	if x != nil {
+		return x.NodoOrigen
+	}
+	return ""
+}
+
+func (x *SincronizarRequest) GetOfertas() []*OfertaRequest {
+	if x != nil {
+		return x.Ofertas
+	}
+	return nil
+}
+
+type SincronizarResponse struct {
+	state                protoimpl.MessageState `protogen:"open.v1"`
+	Exito                bool                   `protobuf:"varint,1,opt,name=exito,proto3" json:"exito,omitempty"`
+	OfertasSincronizadas int32                  `protobuf:"varint,2,opt,name=ofertas_sincronizadas,json=ofertasSincronizadas,proto3" json:"ofertas_sincronizadas,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *SincronizarResponse) Reset() {
+	*x = SincronizarResponse{}
+	mi := &file_proto_ofertas_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SincronizarResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SincronizarResponse) ProtoMessage() {}
+
+func (x *SincronizarResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ofertas_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SincronizarResponse.ProtoReflect.Descriptor instead.
+func (*SincronizarResponse) Descriptor() ([]byte, []int) {
+	return file_proto_ofertas_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *SincronizarResponse) GetExito() bool {
+	if x != nil {
+		return x.Exito
+	}
+	return false
+}
+
+func (x *SincronizarResponse) GetOfertasSincronizadas() int32 {
+	if x != nil {
+		return x.OfertasSincronizadas
+	}
+	return 0
+}
+
+// Nodos del árbol de Merkle de un nivel (0 = raíz); los nodos del último
+// nivel son los rangos de oferta_id
+type HashesMerkleRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodoOrigen    string                 `protobuf:"bytes,1,opt,name=nodo_origen,json=nodoOrigen,proto3" json:"nodo_origen,omitempty"`
+	Nivel         int32                  `protobuf:"varint,2,opt,name=nivel,proto3" json:"nivel,omitempty"`
+	Posiciones    []int32                `protobuf:"varint,3,rep,packed,name=posiciones,proto3" json:"posiciones,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HashesMerkleRequest) Reset() {
+	*x = HashesMerkleRequest{}
+	mi := &file_proto_ofertas_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HashesMerkleRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HashesMerkleRequest) ProtoMessage() {}
+
+func (x *HashesMerkleRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ofertas_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HashesMerkleRequest.ProtoReflect.Descriptor instead.
+func (*HashesMerkleRequest) Descriptor() ([]byte, []int) {
+	return file_proto_ofertas_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *HashesMerkleRequest) GetNodoOrigen() string {
+	if x != nil {
+		return x.NodoOrigen
+	}
+	return ""
+}
+
+func (x *HashesMerkleRequest) GetNivel() int32 {
+	if x != nil {
+		return x.Nivel
+	}
+	return 0
+}
+
+func (x *HashesMerkleRequest) GetPosiciones() []int32 {
+	if x != nil {
+		return x.Posiciones
+	}
+	return nil
+}
+
+type HashesMerkleResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Hashes        [][]byte               `protobuf:"bytes,1,rep,name=hashes,proto3" json:"hashes,omitempty"` // en el orden de las posiciones pedidas
+	NodoId        string                 `protobuf:"bytes,2,opt,name=nodo_id,json=nodoId,proto3" json:"nodo_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HashesMerkleResponse) Reset() {
+	*x = HashesMerkleResponse{}
+	mi := &file_proto_ofertas_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HashesMerkleResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HashesMerkleResponse) ProtoMessage() {}
+
+func (x *HashesMerkleResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ofertas_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HashesMerkleResponse.ProtoReflect.Descriptor instead.
+func (*HashesMerkleResponse) Descriptor() ([]byte, []int) {
+	return file_proto_ofertas_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *HashesMerkleResponse) GetHashes() [][]byte {
+	if x != nil {
+		return x.Hashes
+	}
+	return nil
+}
+
+func (x *HashesMerkleResponse) GetNodoId() string {
+	if x != nil {
+		return x.NodoId
+	}
+	return ""
+}
+
+// Ofertas guardadas (con sus hermanas) en los rangos pedidos
+type LeerRangosRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodoOrigen    string                 `protobuf:"bytes,1,opt,name=nodo_origen,json=nodoOrigen,proto3" json:"nodo_origen,omitempty"`
+	Rangos        []int32                `protobuf:"varint,2,rep,packed,name=rangos,proto3" json:"rangos,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LeerRangosRequest) Reset() {
+	*x = LeerRangosRequest{}
+	mi := &file_proto_ofertas_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LeerRangosRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LeerRangosRequest) ProtoMessage() {}
+
+func (x *LeerRangosRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ofertas_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LeerRangosRequest.ProtoReflect.Descriptor instead.
+func (*LeerRangosRequest) Descriptor() ([]byte, []int) {
+	return file_proto_ofertas_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *LeerRangosRequest) GetNodoOrigen() string {
+	if x != nil {
+		return x.NodoOrigen
+	}
+	return ""
+}
+
+func (x *LeerRangosRequest) GetRangos() []int32 {
+	if x != nil {
+		return x.Rangos
+	}
+	return nil
+}
+
+// Cambios que aplicó un nodo desde una secuencia. La secuencia vale dentro
+// de una época, que cambia cada vez que el nodo inicia
+type LeerCambiosRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	NodoOrigen     string                 `protobuf:"bytes,1,opt,name=nodo_origen,json=nodoOrigen,proto3" json:"nodo_origen,omitempty"`
+	Epoca          int64                  `protobuf:"varint,2,opt,name=epoca,proto3" json:"epoca,omitempty"`                                         // 0 = sin marca previa
+	DesdeSecuencia int64                  `protobuf:"varint,3,opt,name=desde_secuencia,json=desdeSecuencia,proto3" json:"desde_secuencia,omitempty"` // última secuencia ya replicada
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *LeerCambiosRequest) Reset() {
+	*x = LeerCambiosRequest{}
+	mi := &file_proto_ofertas_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LeerCambiosRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LeerCambiosRequest) ProtoMessage() {}
+
+func (x *LeerCambiosRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ofertas_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LeerCambiosRequest.ProtoReflect.Descriptor instead.
+func (*LeerCambiosRequest) Descriptor() ([]byte, []int) {
+	return file_proto_ofertas_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *LeerCambiosRequest) GetNodoOrigen() string {
+	if x != nil {
+		return x.NodoOrigen
+	}
+	return ""
+}
+
+func (x *LeerCambiosRequest) GetEpoca() int64 {
+	if x != nil {
+		return x.Epoca
+	}
+	return 0
+}
+
+func (x *LeerCambiosRequest) GetDesdeSecuencia() int64 {
+	if x != nil {
+		return x.DesdeSecuencia
+	}
+	return 0
+}
+
+type LeerCambiosResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Ofertas        []*OfertaRequest       `protobuf:"bytes,1,rep,name=ofertas,proto3" json:"ofertas,omitempty"` // registros actuales, con sus hermanas
+	Epoca          int64                  `protobuf:"varint,2,opt,name=epoca,proto3" json:"epoca,omitempty"`
+	HastaSecuencia int64                  `protobuf:"varint,3,opt,name=hasta_secuencia,json=hastaSecuencia,proto3" json:"hasta_secuencia,omitempty"`
+	Brecha         bool                   `protobuf:"varint,4,opt,name=brecha,proto3" json:"brecha,omitempty"` // otra época o cambios ya descartados: hace falta anti-entropía completa
+	HayMas         bool                   `protobuf:"varint,5,opt,name=hay_mas,json=hayMas,proto3" json:"hay_mas,omitempty"`
+	NodoId         string                 `protobuf:"bytes,6,opt,name=nodo_id,json=nodoId,proto3" json:"nodo_id,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *LeerCambiosResponse) Reset() {
+	*x = LeerCambiosResponse{}
+	mi := &file_proto_ofertas_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LeerCambiosResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LeerCambiosResponse) ProtoMessage() {}
+
+func (x *LeerCambiosResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ofertas_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LeerCambiosResponse.ProtoReflect.Descriptor instead.
+func (*LeerCambiosResponse) Descriptor() ([]byte, []int) {
+	return file_proto_ofertas_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *LeerCambiosResponse) GetOfertas() []*OfertaRequest {
+	if x != nil {
+		return x.Ofertas
+	}
+	return nil
+}
+
+func (x *LeerCambiosResponse) GetEpoca() int64 {
+	if x != nil {
+		return x.Epoca
+	}
+	return 0
+}
+
+func (x *LeerCambiosResponse) GetHastaSecuencia() int64 {
+	if x != nil {
+		return x.HastaSecuencia
+	}
+	return 0
+}
+
+func (x *LeerCambiosResponse) GetBrecha() bool {
+	if x != nil {
+		return x.Brecha
+	}
+	return false
+}
+
+func (x *LeerCambiosResponse) GetHayMas() bool {
+	if x != nil {
+		return x.HayMas
+	}
+	return false
+}
+
+func (x *LeerCambiosResponse) GetNodoId() string {
+	if x != nil {
+		return x.NodoId
+	}
+	return ""
+}
+
+type Miembro struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodoId        string                 `protobuf:"bytes,1,opt,name=nodo_id,json=nodoId,proto3" json:"nodo_id,omitempty"`
+	Direccion     string                 `protobuf:"bytes,2,opt,name=direccion,proto3" json:"direccion,omitempty"`
+	Estado        EstadoMiembro          `protobuf:"varint,3,opt,name=estado,proto3,enum=EstadoMiembro" json:"estado,omitempty"`
+	Encarnacion   int64                  `protobuf:"varint,4,opt,name=encarnacion,proto3" json:"encarnacion,omitempty"` // solo la sube el propio nodo, para desmentir sospechas
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Miembro) Reset() {
+	*x = Miembro{}
+	mi := &file_proto_ofertas_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Miembro) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Miembro) ProtoMessage() {}
+
+func (x *Miembro) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ofertas_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Miembro.ProtoReflect.Descriptor instead.
+func (*Miembro) Descriptor() ([]byte, []int) {
+	return file_proto_ofertas_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *Miembro) GetNodoId() string {
+	if x != nil {
+		return x.NodoId
+	}
+	return ""
+}
+
+func (x *Miembro) GetDireccion() string {
+	if x != nil {
+		return x.Direccion
+	}
+	return ""
+}
+
+func (x *Miembro) GetEstado() EstadoMiembro {
+	if x != nil {
+		return x.Estado
+	}
+	return EstadoMiembro_VIVO
+}
+
+func (x *Miembro) GetEncarnacion() int64 {
+	if x != nil {
+		return x.Encarnacion
+	}
+	return 0
+}
+
+// Los pings llevan adjuntos los últimos cambios de membresía (gossip)
+type PingRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Origen        *Miembro               `protobuf:"bytes,1,opt,name=origen,proto3" json:"origen,omitempty"`
+	Novedades     []*Miembro             `protobuf:"bytes,2,rep,name=novedades,proto3" json:"novedades,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PingRequest) Reset() {
+	*x = PingRequest{}
+	mi := &file_proto_ofertas_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PingRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PingRequest) ProtoMessage() {}
+
+func (x *PingRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ofertas_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PingRequest.ProtoReflect.Descriptor instead.
+func (*PingRequest) Descriptor() ([]byte, []int) {
+	return file_proto_ofertas_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *PingRequest) GetOrigen() *Miembro {
+	if x != nil {
+		return x.Origen
+	}
+	return nil
+}
+
+func (x *PingRequest) GetNovedades() []*Miembro {
+	if x != nil {
+		return x.Novedades
+	}
+	return nil
+}
+
+type PingResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ack           bool                   `protobuf:"varint,1,opt,name=ack,proto3" json:"ack,omitempty"`
+	NodoId        string                 `protobuf:"bytes,2,opt,name=nodo_id,json=nodoId,proto3" json:"nodo_id,omitempty"`
+	Novedades     []*Miembro             `protobuf:"bytes,3,rep,name=novedades,proto3" json:"novedades,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PingResponse) Reset() {
+	*x = PingResponse{}
+	mi := &file_proto_ofertas_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PingResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PingResponse) ProtoMessage() {}
+
+func (x *PingResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ofertas_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PingResponse.ProtoReflect.Descriptor instead.
+func (*PingResponse) Descriptor() ([]byte, []int) {
+	return file_proto_ofertas_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *PingResponse) GetAck() bool {
+	if x != nil {
+		return x.Ack
+	}
+	return false
+}
+
+func (x *PingResponse) GetNodoId() string {
+	if x != nil {
+		return x.NodoId
+	}
+	return ""
+}
+
+func (x *PingResponse) GetNovedades() []*Miembro {
+	if x != nil {
+		return x.Novedades
+	}
+	return nil
+}
+
+// Pide a un miembro que haga ping al destino en nombre del origen
+type PingIndirectoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Origen        *Miembro               `protobuf:"bytes,1,opt,name=origen,proto3" json:"origen,omitempty"`
+	Destino       *Miembro               `protobuf:"bytes,2,opt,name=destino,proto3" json:"destino,omitempty"`
+	Novedades     []*Miembro             `protobuf:"bytes,3,rep,name=novedades,proto3" json:"novedades,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PingIndirectoRequest) Reset() {
+	*x = PingIndirectoRequest{}
+	mi := &file_proto_ofertas_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PingIndirectoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PingIndirectoRequest) ProtoMessage() {}
+
+func (x *PingIndirectoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ofertas_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PingIndirectoRequest.ProtoReflect.Descriptor instead.
+func (*PingIndirectoRequest) Descriptor() ([]byte, []int) {
+	return file_proto_ofertas_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *PingIndirectoRequest) GetOrigen() *Miembro {
+	if x != nil {
+		return x.Origen
+	}
+	return nil
+}
+
+func (x *PingIndirectoRequest) GetDestino() *Miembro {
+	if x != nil {
+		return x.Destino
+	}
+	return nil
+}
+
+func (x *PingIndirectoRequest) GetNovedades() []*Miembro {
+	if x != nil {
+		return x.Novedades
+	}
+	return nil
+}
+
+type UnirseRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Miembro       *Miembro               `protobuf:"bytes,1,opt,name=miembro,proto3" json:"miembro,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UnirseRequest) Reset() {
+	*x = UnirseRequest{}
+	mi := &file_proto_ofertas_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnirseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnirseRequest) ProtoMessage() {}
+
+func (x *UnirseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ofertas_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnirseRequest.ProtoReflect.Descriptor instead.
+func (*UnirseRequest) Descriptor() ([]byte, []int) {
+	return file_proto_ofertas_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *UnirseRequest) GetMiembro() *Miembro {
+	if x != nil {
+		return x.Miembro
+	}
+	return nil
+}
+
+type MiembrosRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodoOrigen    string                 `protobuf:"bytes,1,opt,name=nodo_origen,json=nodoOrigen,proto3" json:"nodo_origen,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MiembrosRequest) Reset() {
+	*x = MiembrosRequest{}
+	mi := &file_proto_ofertas_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MiembrosRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MiembrosRequest) ProtoMessage() {}
+
+func (x *MiembrosRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ofertas_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MiembrosRequest.ProtoReflect.Descriptor instead.
+func (*MiembrosRequest) Descriptor() ([]byte, []int) {
+	return file_proto_ofertas_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *MiembrosRequest) GetNodoOrigen() string {
+	if x != nil {
+		return x.NodoOrigen
+	}
+	return ""
+}
+
+type MiembrosResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Miembros      []*Miembro             `protobuf:"bytes,1,rep,name=miembros,proto3" json:"miembros,omitempty"` // incluye al nodo que responde
+	NodoId        string                 `protobuf:"bytes,2,opt,name=nodo_id,json=nodoId,proto3" json:"nodo_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MiembrosResponse) Reset() {
+	*x = MiembrosResponse{}
+	mi := &file_proto_ofertas_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MiembrosResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MiembrosResponse) ProtoMessage() {}
+
+func (x *MiembrosResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ofertas_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MiembrosResponse.ProtoReflect.Descriptor instead.
+func (*MiembrosResponse) Descriptor() ([]byte, []int) {
+	return file_proto_ofertas_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *MiembrosResponse) GetMiembros() []*Miembro {
+	if x != nil {
+		return x.Miembros
+	}
+	return nil
+}
+
+func (x *MiembrosResponse) GetNodoId() string {
+	if x != nil {
+		return x.NodoId
+	}
+	return ""
+}
+
+type LeerOfertaRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OfertaId      string                 `protobuf:"bytes,1,opt,name=oferta_id,json=ofertaId,proto3" json:"oferta_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LeerOfertaRequest) Reset() {
+	*x = LeerOfertaRequest{}
+	mi := &file_proto_ofertas_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LeerOfertaRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LeerOfertaRequest) ProtoMessage() {}
+
+func (x *LeerOfertaRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ofertas_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LeerOfertaRequest.ProtoReflect.Descriptor instead.
+func (*LeerOfertaRequest) Descriptor() ([]byte, []int) {
+	return file_proto_ofertas_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *LeerOfertaRequest) GetOfertaId() string {
+	if x != nil {
+		return x.OfertaId
+	}
+	return ""
+}
+
+type LeerOfertaResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Existe        bool                   `protobuf:"varint,1,opt,name=existe,proto3" json:"existe,omitempty"`
+	Oferta        *OfertaRequest         `protobuf:"bytes,2,opt,name=oferta,proto3" json:"oferta,omitempty"`
+	NodoId        string                 `protobuf:"bytes,3,opt,name=nodo_id,json=nodoId,proto3" json:"nodo_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LeerOfertaResponse) Reset() {
+	*x = LeerOfertaResponse{}
+	mi := &file_proto_ofertas_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LeerOfertaResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LeerOfertaResponse) ProtoMessage() {}
+
+func (x *LeerOfertaResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ofertas_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LeerOfertaResponse.ProtoReflect.Descriptor instead.
+func (*LeerOfertaResponse) Descriptor() ([]byte, []int) {
+	return file_proto_ofertas_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *LeerOfertaResponse) GetExiste() bool {
+	if x != nil {
+		return x.Existe
+	}
+	return false
+}
+
+func (x *LeerOfertaResponse) GetOferta() *OfertaRequest {
+	if x != nil {
+		return x.Oferta
+	}
+	return nil
+}
+
+func (x *LeerOfertaResponse) GetNodoId() string {
+	if x != nil {
+		return x.NodoId
+	}
+	return ""
+}
+
+// El broker completa reloj_vectorial y version_stock con lo leído por quórum
+type EliminarOfertaRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	OfertaId       string                 `protobuf:"bytes,1,opt,name=oferta_id,json=ofertaId,proto3" json:"oferta_id,omitempty"`
+	RelojVectorial map[string]int64       `protobuf:"bytes,2,rep,name=reloj_vectorial,json=relojVectorial,proto3" json:"reloj_vectorial,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	VersionStock   int64                  `protobuf:"varint,3,opt,name=version_stock,json=versionStock,proto3" json:"version_stock,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *EliminarOfertaRequest) Reset() {
+	*x = EliminarOfertaRequest{}
+	mi := &file_proto_ofertas_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EliminarOfertaRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EliminarOfertaRequest) ProtoMessage() {}
+
+func (x *EliminarOfertaRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ofertas_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EliminarOfertaRequest.ProtoReflect.Descriptor instead.
+func (*EliminarOfertaRequest) Descriptor() ([]byte, []int) {
+	return file_proto_ofertas_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *EliminarOfertaRequest) GetOfertaId() string {
+	if x != nil {
+		return x.OfertaId
+	}
+	return ""
+}
+
+func (x *EliminarOfertaRequest) GetRelojVectorial() map[string]int64 {
+	if x != nil {
+		return x.RelojVectorial
+	}
+	return nil
+}
+
+func (x *EliminarOfertaRequest) GetVersionStock() int64 {
+	if x != nil {
+		return x.VersionStock
+	}
+	return 0
+}
+
+type EliminarOfertaResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Exito          bool                   `protobuf:"varint,1,opt,name=exito,proto3" json:"exito,omitempty"`
+	Mensaje        string                 `protobuf:"bytes,2,opt,name=mensaje,proto3" json:"mensaje,omitempty"`
+	Confirmaciones int32                  `protobuf:"varint,3,opt,name=confirmaciones,proto3" json:"confirmaciones,omitempty"` // nodos DB que escribieron la lápida
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *EliminarOfertaResponse) Reset() {
+	*x = EliminarOfertaResponse{}
+	mi := &file_proto_ofertas_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EliminarOfertaResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EliminarOfertaResponse) ProtoMessage() {}
+
+func (x *EliminarOfertaResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ofertas_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EliminarOfertaResponse.ProtoReflect.Descriptor instead.
+func (*EliminarOfertaResponse) Descriptor() ([]byte, []int) {
+	return file_proto_ofertas_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *EliminarOfertaResponse) GetExito() bool {
+	if x != nil {
+		return x.Exito
+	}
+	return false
+}
+
+func (x *EliminarOfertaResponse) GetMensaje() string {
+	if x != nil {
+		return x.Mensaje
+	}
+	return ""
+}
+
+func (x *EliminarOfertaResponse) GetConfirmaciones() int32 {
+	if x != nil {
+		return x.Confirmaciones
+	}
+	return 0
+}
+
+// El nodo aplica la escritura si su version_stock es menor que version; con
+// la misma versión solo la acepta si viene de la misma operación (reintento).
+type ActualizarStockRequest struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	OfertaId    string                 `protobuf:"bytes,1,opt,name=oferta_id,json=ofertaId,proto3" json:"oferta_id,omitempty"`
+	Stock       int32                  `protobuf:"varint,2,opt,name=stock,proto3" json:"stock,omitempty"`
+	Version     int64                  `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"`
+	OperacionId string                 `protobuf:"bytes,4,opt,name=operacion_id,json=operacionId,proto3" json:"operacion_id,omitempty"`
+	// Relojes de las versiones leídas: la nueva versión los supera a todos
+	RelojVectorial map[string]int64 `protobuf:"bytes,5,rep,name=reloj_vectorial,json=relojVectorial,proto3" json:"reloj_vectorial,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ActualizarStockRequest) Reset() {
+	*x = ActualizarStockRequest{}
+	mi := &file_proto_ofertas_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ActualizarStockRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ActualizarStockRequest) ProtoMessage() {}
+
+func (x *ActualizarStockRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ofertas_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ActualizarStockRequest.ProtoReflect.Descriptor instead.
+func (*ActualizarStockRequest) Descriptor() ([]byte, []int) {
+	return file_proto_ofertas_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *ActualizarStockRequest) GetOfertaId() string {
+	if x != nil {
+		return x.OfertaId
+	}
+	return ""
+}
+
+func (x *ActualizarStockRequest) GetStock() int32 {
+	if x != nil {
+		return x.Stock
+	}
+	return 0
+}
+
+func (x *ActualizarStockRequest) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *ActualizarStockRequest) GetOperacionId() string {
+	if x != nil {
+		return x.OperacionId
+	}
+	return ""
+}
+
+func (x *ActualizarStockRequest) GetRelojVectorial() map[string]int64 {
+	if x != nil {
+		return x.RelojVectorial
+	}
+	return nil
+}
+
+type ActualizarStockResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Exito         bool                   `protobuf:"varint,1,opt,name=exito,proto3" json:"exito,omitempty"`
+	NodoId        string                 `protobuf:"bytes,2,opt,name=nodo_id,json=nodoId,proto3" json:"nodo_id,omitempty"`
+	Stock         int32                  `protobuf:"varint,3,opt,name=stock,proto3" json:"stock,omitempty"` // stock del nodo después de la llamada
+	Version       int64                  `protobuf:"varint,4,opt,name=version,proto3" json:"version,omitempty"`
+	Mensaje       string                 `protobuf:"bytes,5,opt,name=mensaje,proto3" json:"mensaje,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ActualizarStockResponse) Reset() {
+	*x = ActualizarStockResponse{}
+	mi := &file_proto_ofertas_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ActualizarStockResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ActualizarStockResponse) ProtoMessage() {}
+
+func (x *ActualizarStockResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ofertas_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ActualizarStockResponse.ProtoReflect.Descriptor instead.
+func (*ActualizarStockResponse) Descriptor() ([]byte, []int) {
+	return file_proto_ofertas_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *ActualizarStockResponse) GetExito() bool {
+	if x != nil {
+		return x.Exito
+	}
+	return false
+}
+
+func (x *ActualizarStockResponse) GetNodoId() string {
+	if x != nil {
+		return x.NodoId
+	}
+	return ""
+}
+
+func (x *ActualizarStockResponse) GetStock() int32 {
+	if x != nil {
+		return x.Stock
+	}
+	return 0
+}
+
+func (x *ActualizarStockResponse) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *ActualizarStockResponse) GetMensaje() string {
+	if x != nil {
+		return x.Mensaje
+	}
+	return ""
+}
+
+type ReservarOfertaRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OfertaId      string                 `protobuf:"bytes,1,opt,name=oferta_id,json=ofertaId,proto3" json:"oferta_id,omitempty"`
+	ConsumidorId  string                 `protobuf:"bytes,2,opt,name=consumidor_id,json=consumidorId,proto3" json:"consumidor_id,omitempty"`
+	Cantidad      int32                  `protobuf:"varint,3,opt,name=cantidad,proto3" json:"cantidad,omitempty"` // 0 = 1 unidad
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReservarOfertaRequest) Reset() {
+	*x = ReservarOfertaRequest{}
+	mi := &file_proto_ofertas_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReservarOfertaRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReservarOfertaRequest) ProtoMessage() {}
+
+func (x *ReservarOfertaRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ofertas_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReservarOfertaRequest.ProtoReflect.Descriptor instead.
+func (*ReservarOfertaRequest) Descriptor() ([]byte, []int) {
+	return file_proto_ofertas_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *ReservarOfertaRequest) GetOfertaId() string {
+	if x != nil {
+		return x.OfertaId
+	}
+	return ""
+}
+
+func (x *ReservarOfertaRequest) GetConsumidorId() string {
+	if x != nil {
+		return x.ConsumidorId
+	}
+	return ""
+}
+
+func (x *ReservarOfertaRequest) GetCantidad() int32 {
+	if x != nil {
+		return x.Cantidad
+	}
+	return 0
+}
+
+type ReservarOfertaResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Exito         bool                   `protobuf:"varint,1,opt,name=exito,proto3" json:"exito,omitempty"`
+	ReservaId     string                 `protobuf:"bytes,2,opt,name=reserva_id,json=reservaId,proto3" json:"reserva_id,omitempty"`
+	StockRestante int32                  `protobuf:"varint,3,opt,name=stock_restante,json=stockRestante,proto3" json:"stock_restante,omitempty"`
+	Expira        int64                  `protobuf:"varint,4,opt,name=expira,proto3" json:"expira,omitempty"` // unix; sin confirmar antes de esto, las unidades vuelven al stock
+	Mensaje       string                 `protobuf:"bytes,5,opt,name=mensaje,proto3" json:"mensaje,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReservarOfertaResponse) Reset() {
+	*x = ReservarOfertaResponse{}
+	mi := &file_proto_ofertas_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReservarOfertaResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReservarOfertaResponse) ProtoMessage() {}
+
+func (x *ReservarOfertaResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ofertas_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReservarOfertaResponse.ProtoReflect.Descriptor instead.
+func (*ReservarOfertaResponse) Descriptor() ([]byte, []int) {
+	return file_proto_ofertas_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *ReservarOfertaResponse) GetExito() bool {
+	if x != nil {
+		return x.Exito
+	}
+	return false
+}
+
+func (x *ReservarOfertaResponse) GetReservaId() string {
+	if x != nil {
+		return x.ReservaId
+	}
+	return ""
+}
+
+func (x *ReservarOfertaResponse) GetStockRestante() int32 {
+	if x != nil {
+		return x.StockRestante
+	}
+	return 0
+}
+
+func (x *ReservarOfertaResponse) GetExpira() int64 {
+	if x != nil {
+		return x.Expira
+	}
+	return 0
+}
+
+func (x *ReservarOfertaResponse) GetMensaje() string {
+	if x != nil {
+		return x.Mensaje
+	}
+	return ""
+}
+
+type ConfirmarCompraRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ReservaId     string                 `protobuf:"bytes,1,opt,name=reserva_id,json=reservaId,proto3" json:"reserva_id,omitempty"`
+	ConsumidorId  string                 `protobuf:"bytes,2,opt,name=consumidor_id,json=consumidorId,proto3" json:"consumidor_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConfirmarCompraRequest) Reset() {
+	*x = ConfirmarCompraRequest{}
+	mi := &file_proto_ofertas_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConfirmarCompraRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConfirmarCompraRequest) ProtoMessage() {}
+
+func (x *ConfirmarCompraRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ofertas_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConfirmarCompraRequest.ProtoReflect.Descriptor instead.
+func (*ConfirmarCompraRequest) Descriptor() ([]byte, []int) {
+	return file_proto_ofertas_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *ConfirmarCompraRequest) GetReservaId() string {
+	if x != nil {
+		return x.ReservaId
+	}
+	return ""
+}
+
+func (x *ConfirmarCompraRequest) GetConsumidorId() string {
+	if x != nil {
+		return x.ConsumidorId
+	}
+	return ""
+}
+
+type ConfirmarCompraResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Exito         bool                   `protobuf:"varint,1,opt,name=exito,proto3" json:"exito,omitempty"`
+	Mensaje       string                 `protobuf:"bytes,2,opt,name=mensaje,proto3" json:"mensaje,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConfirmarCompraResponse) Reset() {
+	*x = ConfirmarCompraResponse{}
+	mi := &file_proto_ofertas_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConfirmarCompraResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConfirmarCompraResponse) ProtoMessage() {}
+
+func (x *ConfirmarCompraResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ofertas_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConfirmarCompraResponse.ProtoReflect.Descriptor instead.
+func (*ConfirmarCompraResponse) Descriptor() ([]byte, []int) {
+	return file_proto_ofertas_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *ConfirmarCompraResponse) GetExito() bool {
+	if x != nil {
+		return x.Exito
+	}
+	return false
+}
+
+func (x *ConfirmarCompraResponse) GetMensaje() string {
+	if x != nil {
+		return x.Mensaje
+	}
+	return ""
+}
+
+type EntradaLog struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Termino       int64                  `protobuf:"varint,1,opt,name=termino,proto3" json:"termino,omitempty"`
+	Indice        int64                  `protobuf:"varint,2,opt,name=indice,proto3" json:"indice,omitempty"`
+	Comando       []byte                 `protobuf:"bytes,3,opt,name=comando,proto3" json:"comando,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EntradaLog) Reset() {
+	*x = EntradaLog{}
+	mi := &file_proto_ofertas_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EntradaLog) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EntradaLog) ProtoMessage() {}
+
+func (x *EntradaLog) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ofertas_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EntradaLog.ProtoReflect.Descriptor instead.
+func (*EntradaLog) Descriptor() ([]byte, []int) {
+	return file_proto_ofertas_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *EntradaLog) GetTermino() int64 {
+	if x != nil {
+		return x.Termino
+	}
+	return 0
+}
+
+func (x *EntradaLog) GetIndice() int64 {
+	if x != nil {
+		return x.Indice
+	}
+	return 0
+}
+
+func (x *EntradaLog) GetComando() []byte {
+	if x != nil {
+		return x.Comando
+	}
+	return nil
+}
+
+type SolicitarVotoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Termino       int64                  `protobuf:"varint,1,opt,name=termino,proto3" json:"termino,omitempty"`
+	CandidatoId   string                 `protobuf:"bytes,2,opt,name=candidato_id,json=candidatoId,proto3" json:"candidato_id,omitempty"`
+	UltimoIndice  int64                  `protobuf:"varint,3,opt,name=ultimo_indice,json=ultimoIndice,proto3" json:"ultimo_indice,omitempty"`
+	UltimoTermino int64                  `protobuf:"varint,4,opt,name=ultimo_termino,json=ultimoTermino,proto3" json:"ultimo_termino,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SolicitarVotoRequest) Reset() {
+	*x = SolicitarVotoRequest{}
+	mi := &file_proto_ofertas_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SolicitarVotoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SolicitarVotoRequest) ProtoMessage() {}
+
+func (x *SolicitarVotoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ofertas_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SolicitarVotoRequest.ProtoReflect.Descriptor instead.
+func (*SolicitarVotoRequest) Descriptor() ([]byte, []int) {
+	return file_proto_ofertas_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *SolicitarVotoRequest) GetTermino() int64 {
+	if x != nil {
+		return x.Termino
+	}
+	return 0
+}
+
+func (x *SolicitarVotoRequest) GetCandidatoId() string {
+	if x != nil {
+		return x.CandidatoId
+	}
+	return ""
+}
+
+func (x *SolicitarVotoRequest) GetUltimoIndice() int64 {
+	if x != nil {
+		return x.UltimoIndice
+	}
+	return 0
+}
+
+func (x *SolicitarVotoRequest) GetUltimoTermino() int64 {
+	if x != nil {
+		return x.UltimoTermino
+	}
+	return 0
+}
+
+type SolicitarVotoResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Termino       int64                  `protobuf:"varint,1,opt,name=termino,proto3" json:"termino,omitempty"`
+	VotoConcedido bool                   `protobuf:"varint,2,opt,name=voto_concedido,json=votoConcedido,proto3" json:"voto_concedido,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SolicitarVotoResponse) Reset() {
+	*x = SolicitarVotoResponse{}
+	mi := &file_proto_ofertas_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SolicitarVotoResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SolicitarVotoResponse) ProtoMessage() {}
+
+func (x *SolicitarVotoResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ofertas_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SolicitarVotoResponse.ProtoReflect.Descriptor instead.
+func (*SolicitarVotoResponse) Descriptor() ([]byte, []int) {
+	return file_proto_ofertas_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *SolicitarVotoResponse) GetTermino() int64 {
+	if x != nil {
+		return x.Termino
+	}
+	return 0
+}
+
+func (x *SolicitarVotoResponse) GetVotoConcedido() bool {
+	if x != nil {
+		return x.VotoConcedido
+	}
+	return false
+}
+
+type AgregarEntradasRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Termino       int64                  `protobuf:"varint,1,opt,name=termino,proto3" json:"termino,omitempty"`
+	LiderId       string                 `protobuf:"bytes,2,opt,name=lider_id,json=liderId,proto3" json:"lider_id,omitempty"`
+	IndicePrevio  int64                  `protobuf:"varint,3,opt,name=indice_previo,json=indicePrevio,proto3" json:"indice_previo,omitempty"`
+	TerminoPrevio int64                  `protobuf:"varint,4,opt,name=termino_previo,json=terminoPrevio,proto3" json:"termino_previo,omitempty"`
+	Entradas      []*EntradaLog          `protobuf:"bytes,5,rep,name=entradas,proto3" json:"entradas,omitempty"`
+	CommitLider   int64                  `protobuf:"varint,6,opt,name=commit_lider,json=commitLider,proto3" json:"commit_lider,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AgregarEntradasRequest) Reset() {
+	*x = AgregarEntradasRequest{}
+	mi := &file_proto_ofertas_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AgregarEntradasRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgregarEntradasRequest) ProtoMessage() {}
+
+func (x *AgregarEntradasRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ofertas_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgregarEntradasRequest.ProtoReflect.Descriptor instead.
+func (*AgregarEntradasRequest) Descriptor() ([]byte, []int) {
+	return file_proto_ofertas_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *AgregarEntradasRequest) GetTermino() int64 {
+	if x != nil {
+		return x.Termino
+	}
+	return 0
+}
+
+func (x *AgregarEntradasRequest) GetLiderId() string {
+	if x != nil {
+		return x.LiderId
+	}
+	return ""
+}
+
+func (x *AgregarEntradasRequest) GetIndicePrevio() int64 {
+	if x != nil {
+		return x.IndicePrevio
+	}
+	return 0
+}
+
+func (x *AgregarEntradasRequest) GetTerminoPrevio() int64 {
+	if x != nil {
+		return x.TerminoPrevio
+	}
+	return 0
+}
+
+func (x *AgregarEntradasRequest) GetEntradas() []*EntradaLog {
+	if x != nil {
+		return x.Entradas
+	}
+	return nil
+}
+
+func (x *AgregarEntradasRequest) GetCommitLider() int64 {
+	if x != nil {
+		return x.CommitLider
+	}
+	return 0
+}
+
+type AgregarEntradasResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Termino       int64                  `protobuf:"varint,1,opt,name=termino,proto3" json:"termino,omitempty"`
+	Exito         bool                   `protobuf:"varint,2,opt,name=exito,proto3" json:"exito,omitempty"`
+	UltimoIndice  int64                  `protobuf:"varint,3,opt,name=ultimo_indice,json=ultimoIndice,proto3" json:"ultimo_indice,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AgregarEntradasResponse) Reset() {
+	*x = AgregarEntradasResponse{}
+	mi := &file_proto_ofertas_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AgregarEntradasResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgregarEntradasResponse) ProtoMessage() {}
+
+func (x *AgregarEntradasResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ofertas_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgregarEntradasResponse.ProtoReflect.Descriptor instead.
+func (*AgregarEntradasResponse) Descriptor() ([]byte, []int) {
+	return file_proto_ofertas_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *AgregarEntradasResponse) GetTermino() int64 {
+	if x != nil {
+		return x.Termino
+	}
+	return 0
+}
+
+func (x *AgregarEntradasResponse) GetExito() bool {
+	if x != nil {
+		return x.Exito
+	}
+	return false
+}
+
+func (x *AgregarEntradasResponse) GetUltimoIndice() int64 {
+	if x != nil {
+		return x.UltimoIndice
+	}
+	return 0
+}
+
+type InstalarSnapshotRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Termino         int64                  `protobuf:"varint,1,opt,name=termino,proto3" json:"termino,omitempty"`
+	LiderId         string                 `protobuf:"bytes,2,opt,name=lider_id,json=liderId,proto3" json:"lider_id,omitempty"`
+	IndiceIncluido  int64                  `protobuf:"varint,3,opt,name=indice_incluido,json=indiceIncluido,proto3" json:"indice_incluido,omitempty"`
+	TerminoIncluido int64                  `protobuf:"varint,4,opt,name=termino_incluido,json=terminoIncluido,proto3" json:"termino_incluido,omitempty"`
+	Datos           []byte                 `protobuf:"bytes,5,opt,name=datos,proto3" json:"datos,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *InstalarSnapshotRequest) Reset() {
+	*x = InstalarSnapshotRequest{}
+	mi := &file_proto_ofertas_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InstalarSnapshotRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InstalarSnapshotRequest) ProtoMessage() {}
+
+func (x *InstalarSnapshotRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ofertas_proto_msgTypes[42]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InstalarSnapshotRequest.ProtoReflect.Descriptor instead.
+func (*InstalarSnapshotRequest) Descriptor() ([]byte, []int) {
+	return file_proto_ofertas_proto_rawDescGZIP(), []int{42}
+}
+
+func (x *InstalarSnapshotRequest) GetTermino() int64 {
+	if x != nil {
+		return x.Termino
+	}
+	return 0
+}
+
+func (x *InstalarSnapshotRequest) GetLiderId() string {
+	if x != nil {
+		return x.LiderId
+	}
+	return ""
+}
+
+func (x *InstalarSnapshotRequest) GetIndiceIncluido() int64 {
+	if x != nil {
+		return x.IndiceIncluido
+	}
+	return 0
+}
+
+func (x *InstalarSnapshotRequest) GetTerminoIncluido() int64 {
+	if x != nil {
+		return x.TerminoIncluido
+	}
+	return 0
+}
+
+func (x *InstalarSnapshotRequest) GetDatos() []byte {
+	if x != nil {
+		return x.Datos
+	}
+	return nil
+}
+
+type InstalarSnapshotResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Termino       int64                  `protobuf:"varint,1,opt,name=termino,proto3" json:"termino,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InstalarSnapshotResponse) Reset() {
+	*x = InstalarSnapshotResponse{}
+	mi := &file_proto_ofertas_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InstalarSnapshotResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InstalarSnapshotResponse) ProtoMessage() {}
+
+func (x *InstalarSnapshotResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ofertas_proto_msgTypes[43]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InstalarSnapshotResponse.ProtoReflect.Descriptor instead.
+func (*InstalarSnapshotResponse) Descriptor() ([]byte, []int) {
+	return file_proto_ofertas_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *InstalarSnapshotResponse) GetTermino() int64 {
+	if x != nil {
+		return x.Termino
+	}
+	return 0
+}
+
+type ListarCategoriasRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListarCategoriasRequest) Reset() {
+	*x = ListarCategoriasRequest{}
+	mi := &file_proto_ofertas_proto_msgTypes[44]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListarCategoriasRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListarCategoriasRequest) ProtoMessage() {}
+
+func (x *ListarCategoriasRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ofertas_proto_msgTypes[44]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListarCategoriasRequest.ProtoReflect.Descriptor instead.
+func (*ListarCategoriasRequest) Descriptor() ([]byte, []int) {
+	return file_proto_ofertas_proto_rawDescGZIP(), []int{44}
+}
+
+type Categoria struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Nombre        string                 `protobuf:"bytes,1,opt,name=nombre,proto3" json:"nombre,omitempty"`
+	Alias         []string               `protobuf:"bytes,2,rep,name=alias,proto3" json:"alias,omitempty"`
+	Subcategorias []string               `protobuf:"bytes,3,rep,name=subcategorias,proto3" json:"subcategorias,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Categoria) Reset() {
+	*x = Categoria{}
+	mi := &file_proto_ofertas_proto_msgTypes[45]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Categoria) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Categoria) ProtoMessage() {}
+
+func (x *Categoria) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ofertas_proto_msgTypes[45]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Categoria.ProtoReflect.Descriptor instead.
+func (*Categoria) Descriptor() ([]byte, []int) {
+	return file_proto_ofertas_proto_rawDescGZIP(), []int{45}
+}
+
+func (x *Categoria) GetNombre() string {
+	if x != nil {
+		return x.Nombre
+	}
+	return ""
+}
+
+func (x *Categoria) GetAlias() []string {
+	if x != nil {
+		return x.Alias
+	}
+	return nil
+}
+
+func (x *Categoria) GetSubcategorias() []string {
+	if x != nil {
+		return x.Subcategorias
+	}
+	return nil
+}
+
+type ListarCategoriasResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Version       int64                  `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	Categorias    []*Categoria           `protobuf:"bytes,2,rep,name=categorias,proto3" json:"categorias,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListarCategoriasResponse) Reset() {
+	*x = ListarCategoriasResponse{}
+	mi := &file_proto_ofertas_proto_msgTypes[46]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListarCategoriasResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListarCategoriasResponse) ProtoMessage() {}
+
+func (x *ListarCategoriasResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ofertas_proto_msgTypes[46]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListarCategoriasResponse.ProtoReflect.Descriptor instead.
+func (*ListarCategoriasResponse) Descriptor() ([]byte, []int) {
+	return file_proto_ofertas_proto_rawDescGZIP(), []int{46}
+}
+
+func (x *ListarCategoriasResponse) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *ListarCategoriasResponse) GetCategorias() []*Categoria {
+	if x != nil {
+		return x.Categorias
+	}
+	return nil
+}
+
+type ResolverCategoriasRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Categorias    []string               `protobuf:"bytes,1,rep,name=categorias,proto3" json:"categorias,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResolverCategoriasRequest) Reset() {
+	*x = ResolverCategoriasRequest{}
+	mi := &file_proto_ofertas_proto_msgTypes[47]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResolverCategoriasRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResolverCategoriasRequest) ProtoMessage() {}
+
+func (x *ResolverCategoriasRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ofertas_proto_msgTypes[47]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResolverCategoriasRequest.ProtoReflect.Descriptor instead.
+func (*ResolverCategoriasRequest) Descriptor() ([]byte, []int) {
+	return file_proto_ofertas_proto_rawDescGZIP(), []int{47}
+}
+
+func (x *ResolverCategoriasRequest) GetCategorias() []string {
+	if x != nil {
+		return x.Categorias
+	}
+	return nil
+}
+
+type CategoriaResuelta struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Categoria     string                 `protobuf:"bytes,1,opt,name=categoria,proto3" json:"categoria,omitempty"` // tal como se pidió
+	Canonica      string                 `protobuf:"bytes,2,opt,name=canonica,proto3" json:"canonica,omitempty"`   // vacía si no existe en la taxonomía
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CategoriaResuelta) Reset() {
+	*x = CategoriaResuelta{}
+	mi := &file_proto_ofertas_proto_msgTypes[48]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CategoriaResuelta) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CategoriaResuelta) ProtoMessage() {}
+
+func (x *CategoriaResuelta) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ofertas_proto_msgTypes[48]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CategoriaResuelta.ProtoReflect.Descriptor instead.
+func (*CategoriaResuelta) Descriptor() ([]byte, []int) {
+	return file_proto_ofertas_proto_rawDescGZIP(), []int{48}
+}
+
+func (x *CategoriaResuelta) GetCategoria() string {
+	if x != nil {
+		return x.Categoria
+	}
+	return ""
+}
+
+func (x *CategoriaResuelta) GetCanonica() string {
+	if x != nil {
+		return x.Canonica
+	}
+	return ""
+}
+
+type ResolverCategoriasResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Version       int64                  `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	Categorias    []*CategoriaResuelta   `protobuf:"bytes,2,rep,name=categorias,proto3" json:"categorias,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResolverCategoriasResponse) Reset() {
+	*x = ResolverCategoriasResponse{}
+	mi := &file_proto_ofertas_proto_msgTypes[49]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResolverCategoriasResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResolverCategoriasResponse) ProtoMessage() {}
+
+func (x *ResolverCategoriasResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ofertas_proto_msgTypes[49]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResolverCategoriasResponse.ProtoReflect.Descriptor instead.
+func (*ResolverCategoriasResponse) Descriptor() ([]byte, []int) {
+	return file_proto_ofertas_proto_rawDescGZIP(), []int{49}
+}
+
+func (x *ResolverCategoriasResponse) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *ResolverCategoriasResponse) GetCategorias() []*CategoriaResuelta {
+	if x != nil {
+		return x.Categorias
+	}
+	return nil
+}
+
+// Oferta que no pudo completar una etapa: "validacion", "quorum" o "entrega",
+// o resumen que no se pudo enviar (etapa "resumen")
+type CartaMuerta struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Oferta        *OfertaRequest         `protobuf:"bytes,2,opt,name=oferta,proto3" json:"oferta,omitempty"`
+	Etapa         string                 `protobuf:"bytes,3,opt,name=etapa,proto3" json:"etapa,omitempty"`
+	Motivo        string                 `protobuf:"bytes,4,opt,name=motivo,proto3" json:"motivo,omitempty"`
+	Codigos       []string               `protobuf:"bytes,5,rep,name=codigos,proto3" json:"codigos,omitempty"`
+	ConsumidorId  string                 `protobuf:"bytes,6,opt,name=consumidor_id,json=consumidorId,proto3" json:"consumidor_id,omitempty"` // solo en etapas "entrega" y "resumen"
+	Timestamp     int64                  `protobuf:"varint,7,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Intentos      int32                  `protobuf:"varint,8,opt,name=intentos,proto3" json:"intentos,omitempty"`
+	Resumen       *ResumenOfertas        `protobuf:"bytes,9,opt,name=resumen,proto3" json:"resumen,omitempty"` // solo en etapa "resumen" (oferta vacía)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CartaMuerta) Reset() {
+	*x = CartaMuerta{}
+	mi := &file_proto_ofertas_proto_msgTypes[50]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CartaMuerta) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CartaMuerta) ProtoMessage() {}
+
+func (x *CartaMuerta) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ofertas_proto_msgTypes[50]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CartaMuerta.ProtoReflect.Descriptor instead.
+func (*CartaMuerta) Descriptor() ([]byte, []int) {
+	return file_proto_ofertas_proto_rawDescGZIP(), []int{50}
+}
+
+func (x *CartaMuerta) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *CartaMuerta) GetOferta() *OfertaRequest {
+	if x != nil {
+		return x.Oferta
+	}
+	return nil
+}
+
+func (x *CartaMuerta) GetEtapa() string {
+	if x != nil {
+		return x.Etapa
+	}
+	return ""
+}
+
+func (x *CartaMuerta) GetMotivo() string {
+	if x != nil {
+		return x.Motivo
+	}
+	return ""
+}
+
+func (x *CartaMuerta) GetCodigos() []string {
+	if x != nil {
+		return x.Codigos
+	}
+	return nil
+}
+
+func (x *CartaMuerta) GetConsumidorId() string {
+	if x != nil {
+		return x.ConsumidorId
+	}
+	return ""
+}
+
+func (x *CartaMuerta) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *CartaMuerta) GetIntentos() int32 {
+	if x != nil {
+		return x.Intentos
+	}
+	return 0
+}
+
+func (x *CartaMuerta) GetResumen() *ResumenOfertas {
+	if x != nil {
+		return x.Resumen
+	}
+	return nil
+}
+
+type ListarCartasMuertasRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Ofertas       []*OfertaRequest       `protobuf:"bytes,1,rep,name=ofertas,proto3" json:"ofertas,omitempty"`
-	NodoId        string                 `protobuf:"bytes,2,opt,name=nodo_id,json=nodoId,proto3" json:"nodo_id,omitempty"`
+	Etapa         string                 `protobuf:"bytes,1,opt,name=etapa,proto3" json:"etapa,omitempty"`    // vacío = todas
+	Limite        int32                  `protobuf:"varint,2,opt,name=limite,proto3" json:"limite,omitempty"` // 0 = sin límite
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *HistoricoResponse) Reset() {
-	*x = HistoricoResponse{}
-	mi := &file_proto_ofertas_proto_msgTypes[8]
+func (x *ListarCartasMuertasRequest) Reset() {
+	*x = ListarCartasMuertasRequest{}
+	mi := &file_proto_ofertas_proto_msgTypes[51]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *HistoricoResponse) String() string {
+func (x *ListarCartasMuertasRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*HistoricoResponse) ProtoMessage() {}
+func (*ListarCartasMuertasRequest) ProtoMessage() {}
 
-func (x *HistoricoResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_ofertas_proto_msgTypes[8]
+func (x *ListarCartasMuertasRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ofertas_proto_msgTypes[51]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -551,48 +3357,47 @@ func (x *HistoricoResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use HistoricoResponse.ProtoReflect.Descriptor instead.
-func (*HistoricoResponse) Descriptor() ([]byte, []int) {
-	return file_proto_ofertas_proto_rawDescGZIP(), []int{8}
+// Deprecated: Use ListarCartasMuertasRequest.ProtoReflect.Descriptor instead.
+func (*ListarCartasMuertasRequest) Descriptor() ([]byte, []int) {
+	return file_proto_ofertas_proto_rawDescGZIP(), []int{51}
 }
 
-func (x *HistoricoResponse) GetOfertas() []*OfertaRequest {
+func (x *ListarCartasMuertasRequest) GetEtapa() string {
 	if x != nil {
-		return x.Ofertas
+		return x.Etapa
 	}
-	return nil
+	return ""
 }
 
-func (x *HistoricoResponse) GetNodoId() string {
+func (x *ListarCartasMuertasRequest) GetLimite() int32 {
 	if x != nil {
-		return x.NodoId
+		return x.Limite
 	}
-	return ""
+	return 0
 }
 
-type SincronizarRequest struct {
+type ListarCartasMuertasResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	NodoOrigen    string                 `protobuf:"bytes,1,opt,name=nodo_origen,json=nodoOrigen,proto3" json:"nodo_origen,omitempty"`
-	Ofertas       []*OfertaRequest       `protobuf:"bytes,2,rep,name=ofertas,proto3" json:"ofertas,omitempty"`
+	Cartas        []*CartaMuerta         `protobuf:"bytes,1,rep,name=cartas,proto3" json:"cartas,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SincronizarRequest) Reset() {
-	*x = SincronizarRequest{}
-	mi := &file_proto_ofertas_proto_msgTypes[9]
+func (x *ListarCartasMuertasResponse) Reset() {
+	*x = ListarCartasMuertasResponse{}
+	mi := &file_proto_ofertas_proto_msgTypes[52]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SincronizarRequest) String() string {
+func (x *ListarCartasMuertasResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SincronizarRequest) ProtoMessage() {}
+func (*ListarCartasMuertasResponse) ProtoMessage() {}
 
-func (x *SincronizarRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_ofertas_proto_msgTypes[9]
+func (x *ListarCartasMuertasResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ofertas_proto_msgTypes[52]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -603,48 +3408,129 @@ func (x *SincronizarRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SincronizarRequest.ProtoReflect.Descriptor instead.
-func (*SincronizarRequest) Descriptor() ([]byte, []int) {
-	return file_proto_ofertas_proto_rawDescGZIP(), []int{9}
+// Deprecated: Use ListarCartasMuertasResponse.ProtoReflect.Descriptor instead.
+func (*ListarCartasMuertasResponse) Descriptor() ([]byte, []int) {
+	return file_proto_ofertas_proto_rawDescGZIP(), []int{52}
 }
 
-func (x *SincronizarRequest) GetNodoOrigen() string {
+func (x *ListarCartasMuertasResponse) GetCartas() []*CartaMuerta {
 	if x != nil {
-		return x.NodoOrigen
+		return x.Cartas
+	}
+	return nil
+}
+
+type ObtenerCartaMuertaRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ObtenerCartaMuertaRequest) Reset() {
+	*x = ObtenerCartaMuertaRequest{}
+	mi := &file_proto_ofertas_proto_msgTypes[53]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ObtenerCartaMuertaRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ObtenerCartaMuertaRequest) ProtoMessage() {}
+
+func (x *ObtenerCartaMuertaRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ofertas_proto_msgTypes[53]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ObtenerCartaMuertaRequest.ProtoReflect.Descriptor instead.
+func (*ObtenerCartaMuertaRequest) Descriptor() ([]byte, []int) {
+	return file_proto_ofertas_proto_rawDescGZIP(), []int{53}
+}
+
+func (x *ObtenerCartaMuertaRequest) GetId() string {
+	if x != nil {
+		return x.Id
 	}
 	return ""
 }
 
-func (x *SincronizarRequest) GetOfertas() []*OfertaRequest {
+type ReprocesarCartaMuertaRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReprocesarCartaMuertaRequest) Reset() {
+	*x = ReprocesarCartaMuertaRequest{}
+	mi := &file_proto_ofertas_proto_msgTypes[54]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReprocesarCartaMuertaRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReprocesarCartaMuertaRequest) ProtoMessage() {}
+
+func (x *ReprocesarCartaMuertaRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ofertas_proto_msgTypes[54]
 	if x != nil {
-		return x.Ofertas
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return nil
+	return mi.MessageOf(x)
 }
 
-type SincronizarResponse struct {
-	state                protoimpl.MessageState `protogen:"open.v1"`
-	Exito                bool                   `protobuf:"varint,1,opt,name=exito,proto3" json:"exito,omitempty"`
-	OfertasSincronizadas int32                  `protobuf:"varint,2,opt,name=ofertas_sincronizadas,json=ofertasSincronizadas,proto3" json:"ofertas_sincronizadas,omitempty"`
-	unknownFields        protoimpl.UnknownFields
-	sizeCache            protoimpl.SizeCache
+// Deprecated: Use ReprocesarCartaMuertaRequest.ProtoReflect.Descriptor instead.
+func (*ReprocesarCartaMuertaRequest) Descriptor() ([]byte, []int) {
+	return file_proto_ofertas_proto_rawDescGZIP(), []int{54}
 }
 
-func (x *SincronizarResponse) Reset() {
-	*x = SincronizarResponse{}
-	mi := &file_proto_ofertas_proto_msgTypes[10]
+func (x *ReprocesarCartaMuertaRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type ReprocesarCartaMuertaResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Exito         bool                   `protobuf:"varint,1,opt,name=exito,proto3" json:"exito,omitempty"`
+	Mensaje       string                 `protobuf:"bytes,2,opt,name=mensaje,proto3" json:"mensaje,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReprocesarCartaMuertaResponse) Reset() {
+	*x = ReprocesarCartaMuertaResponse{}
+	mi := &file_proto_ofertas_proto_msgTypes[55]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SincronizarResponse) String() string {
+func (x *ReprocesarCartaMuertaResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SincronizarResponse) ProtoMessage() {}
+func (*ReprocesarCartaMuertaResponse) ProtoMessage() {}
 
-func (x *SincronizarResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_ofertas_proto_msgTypes[10]
+func (x *ReprocesarCartaMuertaResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ofertas_proto_msgTypes[55]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -655,21 +3541,117 @@ func (x *SincronizarResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SincronizarResponse.ProtoReflect.Descriptor instead.
-func (*SincronizarResponse) Descriptor() ([]byte, []int) {
-	return file_proto_ofertas_proto_rawDescGZIP(), []int{10}
+// Deprecated: Use ReprocesarCartaMuertaResponse.ProtoReflect.Descriptor instead.
+func (*ReprocesarCartaMuertaResponse) Descriptor() ([]byte, []int) {
+	return file_proto_ofertas_proto_rawDescGZIP(), []int{55}
 }
 
-func (x *SincronizarResponse) GetExito() bool {
+func (x *ReprocesarCartaMuertaResponse) GetExito() bool {
 	if x != nil {
 		return x.Exito
 	}
 	return false
 }
 
-func (x *SincronizarResponse) GetOfertasSincronizadas() int32 {
+func (x *ReprocesarCartaMuertaResponse) GetMensaje() string {
 	if x != nil {
-		return x.OfertasSincronizadas
+		return x.Mensaje
+	}
+	return ""
+}
+
+type PurgarCartasMuertasRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Etapa         string                 `protobuf:"bytes,1,opt,name=etapa,proto3" json:"etapa,omitempty"`                     // vacío = todas
+	AntesDe       int64                  `protobuf:"varint,2,opt,name=antes_de,json=antesDe,proto3" json:"antes_de,omitempty"` // unix: solo las anteriores; 0 = sin límite de fecha
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PurgarCartasMuertasRequest) Reset() {
+	*x = PurgarCartasMuertasRequest{}
+	mi := &file_proto_ofertas_proto_msgTypes[56]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PurgarCartasMuertasRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PurgarCartasMuertasRequest) ProtoMessage() {}
+
+func (x *PurgarCartasMuertasRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ofertas_proto_msgTypes[56]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PurgarCartasMuertasRequest.ProtoReflect.Descriptor instead.
+func (*PurgarCartasMuertasRequest) Descriptor() ([]byte, []int) {
+	return file_proto_ofertas_proto_rawDescGZIP(), []int{56}
+}
+
+func (x *PurgarCartasMuertasRequest) GetEtapa() string {
+	if x != nil {
+		return x.Etapa
+	}
+	return ""
+}
+
+func (x *PurgarCartasMuertasRequest) GetAntesDe() int64 {
+	if x != nil {
+		return x.AntesDe
+	}
+	return 0
+}
+
+type PurgarCartasMuertasResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Eliminadas    int32                  `protobuf:"varint,1,opt,name=eliminadas,proto3" json:"eliminadas,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PurgarCartasMuertasResponse) Reset() {
+	*x = PurgarCartasMuertasResponse{}
+	mi := &file_proto_ofertas_proto_msgTypes[57]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PurgarCartasMuertasResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PurgarCartasMuertasResponse) ProtoMessage() {}
+
+func (x *PurgarCartasMuertasResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_ofertas_proto_msgTypes[57]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PurgarCartasMuertasResponse.ProtoReflect.Descriptor instead.
+func (*PurgarCartasMuertasResponse) Descriptor() ([]byte, []int) {
+	return file_proto_ofertas_proto_rawDescGZIP(), []int{57}
+}
+
+func (x *PurgarCartasMuertasResponse) GetEliminadas() int32 {
+	if x != nil {
+		return x.Eliminadas
 	}
 	return 0
 }
@@ -678,7 +3660,7 @@ var File_proto_ofertas_proto protoreflect.FileDescriptor
 
 const file_proto_ofertas_proto_rawDesc = "" +
 	"\n" +
-	"\x13proto/ofertas.proto\"\xb3\x02\n" +
+	"\x13proto/ofertas.proto\"\xe9\x06\n" +
 	"\rOfertaRequest\x12\x1b\n" +
 	"\toferta_id\x18\x01 \x01(\tR\bofertaId\x12\x1f\n" +
 	"\vproducto_id\x18\x02 \x01(\tR\n" +
@@ -692,14 +3674,43 @@ const file_proto_ofertas_proto_rawDesc = "" +
 	"\n" +
 	"cliente_id\x18\t \x01(\tR\tclienteId\x12\x1c\n" +
 	"\ttimestamp\x18\n" +
-	" \x01(\x03R\ttimestamp\"@\n" +
+	" \x01(\x03R\ttimestamp\x12\x1c\n" +
+	"\tsecuencia\x18\v \x01(\x03R\tsecuencia\x121\n" +
+	"\x14secuencia_consumidor\x18\f \x01(\x03R\x13secuenciaConsumidor\x12'\n" +
+	"\x0fprecio_original\x18\r \x01(\x05R\x0eprecioOriginal\x12#\n" +
+	"\rversion_stock\x18\x0e \x01(\x03R\fversionStock\x12'\n" +
+	"\x0foperacion_stock\x18\x0f \x01(\tR\x0eoperacionStock\x12\x16\n" +
+	"\x06evento\x18\x10 \x01(\tR\x06evento\x124\n" +
+	"\x16precio_minimo_anterior\x18\x11 \x01(\x05R\x14precioMinimoAnterior\x12K\n" +
+	"\x0freloj_vectorial\x18\x12 \x03(\v2\".OfertaRequest.RelojVectorialEntryR\x0erelojVectorial\x12*\n" +
+	"\bhermanas\x18\x13 \x03(\v2\x0e.OfertaRequestR\bhermanas\x12\x1d\n" +
+	"\n" +
+	"escrita_ns\x18\x14 \x01(\x03R\tescritaNs\x12\x1c\n" +
+	"\teliminada\x18\x15 \x01(\bR\teliminada\x12%\n" +
+	"\x0econfirmada_por\x18\x16 \x03(\tR\rconfirmadaPor\x1aA\n" +
+	"\x13RelojVectorialEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x03R\x05value:\x028\x01\"@\n" +
 	"\x0eOfertaResponse\x12\x14\n" +
 	"\x05exito\x18\x01 \x01(\bR\x05exito\x12\x18\n" +
 	"\amensaje\x18\x02 \x01(\tR\amensaje\"V\n" +
 	"\vAckResponse\x12\x14\n" +
 	"\x05exito\x18\x01 \x01(\bR\x05exito\x12\x17\n" +
 	"\anodo_id\x18\x02 \x01(\tR\x06nodoId\x12\x18\n" +
-	"\amensaje\x18\x03 \x01(\tR\amensaje\"\xc0\x01\n" +
+	"\amensaje\x18\x03 \x01(\tR\amensaje\"\xaa\x01\n" +
+	"\x0eResumenOfertas\x12\x1d\n" +
+	"\n" +
+	"resumen_id\x18\x01 \x01(\tR\tresumenId\x12#\n" +
+	"\rconsumidor_id\x18\x02 \x01(\tR\fconsumidorId\x12(\n" +
+	"\aofertas\x18\x03 \x03(\v2\x0e.OfertaRequestR\aofertas\x12\x14\n" +
+	"\x05desde\x18\x04 \x01(\x03R\x05desde\x12\x14\n" +
+	"\x05hasta\x18\x05 \x01(\x03R\x05hasta\"P\n" +
+	"\x13VerificacionRequest\x12#\n" +
+	"\rconsumidor_id\x18\x01 \x01(\tR\fconsumidorId\x12\x14\n" +
+	"\x05nonce\x18\x02 \x01(\tR\x05nonce\"Q\n" +
+	"\x14VerificacionResponse\x12#\n" +
+	"\rconsumidor_id\x18\x01 \x01(\tR\fconsumidorId\x12\x14\n" +
+	"\x05nonce\x18\x02 \x01(\tR\x05nonce\"\xd8\x03\n" +
 	"\x19RegistroConsumidorRequest\x12#\n" +
 	"\rconsumidor_id\x18\x01 \x01(\tR\fconsumidorId\x12\x1e\n" +
 	"\n" +
@@ -708,17 +3719,33 @@ const file_proto_ofertas_proto_rawDesc = "" +
 	"\atiendas\x18\x03 \x03(\tR\atiendas\x12\x1d\n" +
 	"\n" +
 	"precio_max\x18\x04 \x01(\x05R\tprecioMax\x12%\n" +
-	"\x0edireccion_grpc\x18\x05 \x01(\tR\rdireccionGrpc\"L\n" +
+	"\x0edireccion_grpc\x18\x05 \x01(\tR\rdireccionGrpc\x12\x1f\n" +
+	"\vwebhook_url\x18\x06 \x01(\tR\n" +
+	"webhookUrl\x12'\n" +
+	"\x0fwebhook_secreto\x18\a \x01(\tR\x0ewebhookSecreto\x12\x14\n" +
+	"\x05grupo\x18\b \x01(\tR\x05grupo\x12)\n" +
+	"\x10entrega_ordenada\x18\t \x01(\bR\x0fentregaOrdenada\x120\n" +
+	"\fseguimientos\x18\n" +
+	" \x03(\v2\f.SeguimientoR\fseguimientos\x12)\n" +
+	"\x10resumen_segundos\x18\v \x01(\x05R\x0fresumenSegundos\x12.\n" +
+	"\x13resumen_max_ofertas\x18\f \x01(\x05R\x11resumenMaxOfertas\"o\n" +
+	"\vSeguimiento\x12\x1f\n" +
+	"\vproducto_id\x18\x01 \x01(\tR\n" +
+	"productoId\x12\x16\n" +
+	"\x06patron\x18\x02 \x01(\tR\x06patron\x12'\n" +
+	"\x0fprecio_objetivo\x18\x03 \x01(\x05R\x0eprecioObjetivo\"L\n" +
 	"\x1aRegistroConsumidorResponse\x12\x14\n" +
 	"\x05exito\x18\x01 \x01(\bR\x05exito\x12\x18\n" +
-	"\amensaje\x18\x02 \x01(\tR\amensaje\"@\n" +
+	"\amensaje\x18\x02 \x01(\tR\amensaje\"i\n" +
 	"\x19SolicitarHistoricoRequest\x12#\n" +
-	"\rconsumidor_id\x18\x01 \x01(\tR\fconsumidorId\"G\n" +
+	"\rconsumidor_id\x18\x01 \x01(\tR\fconsumidorId\x12'\n" +
+	"\x0fdesde_timestamp\x18\x02 \x01(\x03R\x0edesdeTimestamp\"G\n" +
 	"\x1bHistoricoConsumidorResponse\x12(\n" +
-	"\aofertas\x18\x01 \x03(\v2\x0e.OfertaRequestR\aofertas\"X\n" +
+	"\aofertas\x18\x01 \x03(\v2\x0e.OfertaRequestR\aofertas\"{\n" +
 	"\x14LeerHistoricoRequest\x12\x17\n" +
 	"\anodo_id\x18\x01 \x01(\tR\x06nodoId\x12'\n" +
-	"\x0fdesde_timestamp\x18\x02 \x01(\x03R\x0edesdeTimestamp\"V\n" +
+	"\x0fdesde_timestamp\x18\x02 \x01(\x03R\x0edesdeTimestamp\x12!\n" +
+	"\fcon_hermanas\x18\x03 \x01(\bR\vconHermanas\"V\n" +
 	"\x11HistoricoResponse\x12(\n" +
 	"\aofertas\x18\x01 \x03(\v2\x0e.OfertaRequestR\aofertas\x12\x17\n" +
 	"\anodo_id\x18\x02 \x01(\tR\x06nodoId\"_\n" +
@@ -728,19 +3755,241 @@ const file_proto_ofertas_proto_rawDesc = "" +
 	"\aofertas\x18\x02 \x03(\v2\x0e.OfertaRequestR\aofertas\"`\n" +
 	"\x13SincronizarResponse\x12\x14\n" +
 	"\x05exito\x18\x01 \x01(\bR\x05exito\x123\n" +
-	"\x15ofertas_sincronizadas\x18\x02 \x01(\x05R\x14ofertasSincronizadas2:\n" +
+	"\x15ofertas_sincronizadas\x18\x02 \x01(\x05R\x14ofertasSincronizadas\"l\n" +
+	"\x13HashesMerkleRequest\x12\x1f\n" +
+	"\vnodo_origen\x18\x01 \x01(\tR\n" +
+	"nodoOrigen\x12\x14\n" +
+	"\x05nivel\x18\x02 \x01(\x05R\x05nivel\x12\x1e\n" +
+	"\n" +
+	"posiciones\x18\x03 \x03(\x05R\n" +
+	"posiciones\"G\n" +
+	"\x14HashesMerkleResponse\x12\x16\n" +
+	"\x06hashes\x18\x01 \x03(\fR\x06hashes\x12\x17\n" +
+	"\anodo_id\x18\x02 \x01(\tR\x06nodoId\"L\n" +
+	"\x11LeerRangosRequest\x12\x1f\n" +
+	"\vnodo_origen\x18\x01 \x01(\tR\n" +
+	"nodoOrigen\x12\x16\n" +
+	"\x06rangos\x18\x02 \x03(\x05R\x06rangos\"t\n" +
+	"\x12LeerCambiosRequest\x12\x1f\n" +
+	"\vnodo_origen\x18\x01 \x01(\tR\n" +
+	"nodoOrigen\x12\x14\n" +
+	"\x05epoca\x18\x02 \x01(\x03R\x05epoca\x12'\n" +
+	"\x0fdesde_secuencia\x18\x03 \x01(\x03R\x0edesdeSecuencia\"\xc8\x01\n" +
+	"\x13LeerCambiosResponse\x12(\n" +
+	"\aofertas\x18\x01 \x03(\v2\x0e.OfertaRequestR\aofertas\x12\x14\n" +
+	"\x05epoca\x18\x02 \x01(\x03R\x05epoca\x12'\n" +
+	"\x0fhasta_secuencia\x18\x03 \x01(\x03R\x0ehastaSecuencia\x12\x16\n" +
+	"\x06brecha\x18\x04 \x01(\bR\x06brecha\x12\x17\n" +
+	"\ahay_mas\x18\x05 \x01(\bR\x06hayMas\x12\x17\n" +
+	"\anodo_id\x18\x06 \x01(\tR\x06nodoId\"\x8a\x01\n" +
+	"\aMiembro\x12\x17\n" +
+	"\anodo_id\x18\x01 \x01(\tR\x06nodoId\x12\x1c\n" +
+	"\tdireccion\x18\x02 \x01(\tR\tdireccion\x12&\n" +
+	"\x06estado\x18\x03 \x01(\x0e2\x0e.EstadoMiembroR\x06estado\x12 \n" +
+	"\vencarnacion\x18\x04 \x01(\x03R\vencarnacion\"W\n" +
+	"\vPingRequest\x12 \n" +
+	"\x06origen\x18\x01 \x01(\v2\b.MiembroR\x06origen\x12&\n" +
+	"\tnovedades\x18\x02 \x03(\v2\b.MiembroR\tnovedades\"a\n" +
+	"\fPingResponse\x12\x10\n" +
+	"\x03ack\x18\x01 \x01(\bR\x03ack\x12\x17\n" +
+	"\anodo_id\x18\x02 \x01(\tR\x06nodoId\x12&\n" +
+	"\tnovedades\x18\x03 \x03(\v2\b.MiembroR\tnovedades\"\x84\x01\n" +
+	"\x14PingIndirectoRequest\x12 \n" +
+	"\x06origen\x18\x01 \x01(\v2\b.MiembroR\x06origen\x12\"\n" +
+	"\adestino\x18\x02 \x01(\v2\b.MiembroR\adestino\x12&\n" +
+	"\tnovedades\x18\x03 \x03(\v2\b.MiembroR\tnovedades\"3\n" +
+	"\rUnirseRequest\x12\"\n" +
+	"\amiembro\x18\x01 \x01(\v2\b.MiembroR\amiembro\"2\n" +
+	"\x0fMiembrosRequest\x12\x1f\n" +
+	"\vnodo_origen\x18\x01 \x01(\tR\n" +
+	"nodoOrigen\"Q\n" +
+	"\x10MiembrosResponse\x12$\n" +
+	"\bmiembros\x18\x01 \x03(\v2\b.MiembroR\bmiembros\x12\x17\n" +
+	"\anodo_id\x18\x02 \x01(\tR\x06nodoId\"0\n" +
+	"\x11LeerOfertaRequest\x12\x1b\n" +
+	"\toferta_id\x18\x01 \x01(\tR\bofertaId\"m\n" +
+	"\x12LeerOfertaResponse\x12\x16\n" +
+	"\x06existe\x18\x01 \x01(\bR\x06existe\x12&\n" +
+	"\x06oferta\x18\x02 \x01(\v2\x0e.OfertaRequestR\x06oferta\x12\x17\n" +
+	"\anodo_id\x18\x03 \x01(\tR\x06nodoId\"\xf1\x01\n" +
+	"\x15EliminarOfertaRequest\x12\x1b\n" +
+	"\toferta_id\x18\x01 \x01(\tR\bofertaId\x12S\n" +
+	"\x0freloj_vectorial\x18\x02 \x03(\v2*.EliminarOfertaRequest.RelojVectorialEntryR\x0erelojVectorial\x12#\n" +
+	"\rversion_stock\x18\x03 \x01(\x03R\fversionStock\x1aA\n" +
+	"\x13RelojVectorialEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x03R\x05value:\x028\x01\"p\n" +
+	"\x16EliminarOfertaResponse\x12\x14\n" +
+	"\x05exito\x18\x01 \x01(\bR\x05exito\x12\x18\n" +
+	"\amensaje\x18\x02 \x01(\tR\amensaje\x12&\n" +
+	"\x0econfirmaciones\x18\x03 \x01(\x05R\x0econfirmaciones\"\xa1\x02\n" +
+	"\x16ActualizarStockRequest\x12\x1b\n" +
+	"\toferta_id\x18\x01 \x01(\tR\bofertaId\x12\x14\n" +
+	"\x05stock\x18\x02 \x01(\x05R\x05stock\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\x03R\aversion\x12!\n" +
+	"\foperacion_id\x18\x04 \x01(\tR\voperacionId\x12T\n" +
+	"\x0freloj_vectorial\x18\x05 \x03(\v2+.ActualizarStockRequest.RelojVectorialEntryR\x0erelojVectorial\x1aA\n" +
+	"\x13RelojVectorialEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x03R\x05value:\x028\x01\"\x92\x01\n" +
+	"\x17ActualizarStockResponse\x12\x14\n" +
+	"\x05exito\x18\x01 \x01(\bR\x05exito\x12\x17\n" +
+	"\anodo_id\x18\x02 \x01(\tR\x06nodoId\x12\x14\n" +
+	"\x05stock\x18\x03 \x01(\x05R\x05stock\x12\x18\n" +
+	"\aversion\x18\x04 \x01(\x03R\aversion\x12\x18\n" +
+	"\amensaje\x18\x05 \x01(\tR\amensaje\"u\n" +
+	"\x15ReservarOfertaRequest\x12\x1b\n" +
+	"\toferta_id\x18\x01 \x01(\tR\bofertaId\x12#\n" +
+	"\rconsumidor_id\x18\x02 \x01(\tR\fconsumidorId\x12\x1a\n" +
+	"\bcantidad\x18\x03 \x01(\x05R\bcantidad\"\xa6\x01\n" +
+	"\x16ReservarOfertaResponse\x12\x14\n" +
+	"\x05exito\x18\x01 \x01(\bR\x05exito\x12\x1d\n" +
+	"\n" +
+	"reserva_id\x18\x02 \x01(\tR\treservaId\x12%\n" +
+	"\x0estock_restante\x18\x03 \x01(\x05R\rstockRestante\x12\x16\n" +
+	"\x06expira\x18\x04 \x01(\x03R\x06expira\x12\x18\n" +
+	"\amensaje\x18\x05 \x01(\tR\amensaje\"\\\n" +
+	"\x16ConfirmarCompraRequest\x12\x1d\n" +
+	"\n" +
+	"reserva_id\x18\x01 \x01(\tR\treservaId\x12#\n" +
+	"\rconsumidor_id\x18\x02 \x01(\tR\fconsumidorId\"I\n" +
+	"\x17ConfirmarCompraResponse\x12\x14\n" +
+	"\x05exito\x18\x01 \x01(\bR\x05exito\x12\x18\n" +
+	"\amensaje\x18\x02 \x01(\tR\amensaje\"X\n" +
+	"\n" +
+	"EntradaLog\x12\x18\n" +
+	"\atermino\x18\x01 \x01(\x03R\atermino\x12\x16\n" +
+	"\x06indice\x18\x02 \x01(\x03R\x06indice\x12\x18\n" +
+	"\acomando\x18\x03 \x01(\fR\acomando\"\x9f\x01\n" +
+	"\x14SolicitarVotoRequest\x12\x18\n" +
+	"\atermino\x18\x01 \x01(\x03R\atermino\x12!\n" +
+	"\fcandidato_id\x18\x02 \x01(\tR\vcandidatoId\x12#\n" +
+	"\rultimo_indice\x18\x03 \x01(\x03R\fultimoIndice\x12%\n" +
+	"\x0eultimo_termino\x18\x04 \x01(\x03R\rultimoTermino\"X\n" +
+	"\x15SolicitarVotoResponse\x12\x18\n" +
+	"\atermino\x18\x01 \x01(\x03R\atermino\x12%\n" +
+	"\x0evoto_concedido\x18\x02 \x01(\bR\rvotoConcedido\"\xe5\x01\n" +
+	"\x16AgregarEntradasRequest\x12\x18\n" +
+	"\atermino\x18\x01 \x01(\x03R\atermino\x12\x19\n" +
+	"\blider_id\x18\x02 \x01(\tR\aliderId\x12#\n" +
+	"\rindice_previo\x18\x03 \x01(\x03R\findicePrevio\x12%\n" +
+	"\x0etermino_previo\x18\x04 \x01(\x03R\rterminoPrevio\x12'\n" +
+	"\bentradas\x18\x05 \x03(\v2\v.EntradaLogR\bentradas\x12!\n" +
+	"\fcommit_lider\x18\x06 \x01(\x03R\vcommitLider\"n\n" +
+	"\x17AgregarEntradasResponse\x12\x18\n" +
+	"\atermino\x18\x01 \x01(\x03R\atermino\x12\x14\n" +
+	"\x05exito\x18\x02 \x01(\bR\x05exito\x12#\n" +
+	"\rultimo_indice\x18\x03 \x01(\x03R\fultimoIndice\"\xb8\x01\n" +
+	"\x17InstalarSnapshotRequest\x12\x18\n" +
+	"\atermino\x18\x01 \x01(\x03R\atermino\x12\x19\n" +
+	"\blider_id\x18\x02 \x01(\tR\aliderId\x12'\n" +
+	"\x0findice_incluido\x18\x03 \x01(\x03R\x0eindiceIncluido\x12)\n" +
+	"\x10termino_incluido\x18\x04 \x01(\x03R\x0fterminoIncluido\x12\x14\n" +
+	"\x05datos\x18\x05 \x01(\fR\x05datos\"4\n" +
+	"\x18InstalarSnapshotResponse\x12\x18\n" +
+	"\atermino\x18\x01 \x01(\x03R\atermino\"\x19\n" +
+	"\x17ListarCategoriasRequest\"_\n" +
+	"\tCategoria\x12\x16\n" +
+	"\x06nombre\x18\x01 \x01(\tR\x06nombre\x12\x14\n" +
+	"\x05alias\x18\x02 \x03(\tR\x05alias\x12$\n" +
+	"\rsubcategorias\x18\x03 \x03(\tR\rsubcategorias\"`\n" +
+	"\x18ListarCategoriasResponse\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\x03R\aversion\x12*\n" +
+	"\n" +
+	"categorias\x18\x02 \x03(\v2\n" +
+	".CategoriaR\n" +
+	"categorias\";\n" +
+	"\x19ResolverCategoriasRequest\x12\x1e\n" +
+	"\n" +
+	"categorias\x18\x01 \x03(\tR\n" +
+	"categorias\"M\n" +
+	"\x11CategoriaResuelta\x12\x1c\n" +
+	"\tcategoria\x18\x01 \x01(\tR\tcategoria\x12\x1a\n" +
+	"\bcanonica\x18\x02 \x01(\tR\bcanonica\"j\n" +
+	"\x1aResolverCategoriasResponse\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\x03R\aversion\x122\n" +
+	"\n" +
+	"categorias\x18\x02 \x03(\v2\x12.CategoriaResueltaR\n" +
+	"categorias\"\x97\x02\n" +
+	"\vCartaMuerta\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12&\n" +
+	"\x06oferta\x18\x02 \x01(\v2\x0e.OfertaRequestR\x06oferta\x12\x14\n" +
+	"\x05etapa\x18\x03 \x01(\tR\x05etapa\x12\x16\n" +
+	"\x06motivo\x18\x04 \x01(\tR\x06motivo\x12\x18\n" +
+	"\acodigos\x18\x05 \x03(\tR\acodigos\x12#\n" +
+	"\rconsumidor_id\x18\x06 \x01(\tR\fconsumidorId\x12\x1c\n" +
+	"\ttimestamp\x18\a \x01(\x03R\ttimestamp\x12\x1a\n" +
+	"\bintentos\x18\b \x01(\x05R\bintentos\x12)\n" +
+	"\aresumen\x18\t \x01(\v2\x0f.ResumenOfertasR\aresumen\"J\n" +
+	"\x1aListarCartasMuertasRequest\x12\x14\n" +
+	"\x05etapa\x18\x01 \x01(\tR\x05etapa\x12\x16\n" +
+	"\x06limite\x18\x02 \x01(\x05R\x06limite\"C\n" +
+	"\x1bListarCartasMuertasResponse\x12$\n" +
+	"\x06cartas\x18\x01 \x03(\v2\f.CartaMuertaR\x06cartas\"+\n" +
+	"\x19ObtenerCartaMuertaRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\".\n" +
+	"\x1cReprocesarCartaMuertaRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"O\n" +
+	"\x1dReprocesarCartaMuertaResponse\x12\x14\n" +
+	"\x05exito\x18\x01 \x01(\bR\x05exito\x12\x18\n" +
+	"\amensaje\x18\x02 \x01(\tR\amensaje\"M\n" +
+	"\x1aPurgarCartasMuertasRequest\x12\x14\n" +
+	"\x05etapa\x18\x01 \x01(\tR\x05etapa\x12\x19\n" +
+	"\bantes_de\x18\x02 \x01(\x03R\aantesDe\"=\n" +
+	"\x1bPurgarCartasMuertasResponse\x12\x1e\n" +
+	"\n" +
+	"eliminadas\x18\x01 \x01(\x05R\n" +
+	"eliminadas*N\n" +
+	"\rEstadoMiembro\x12\b\n" +
+	"\x04VIVO\x10\x00\x12\x0e\n" +
+	"\n" +
+	"SOSPECHOSO\x10\x01\x12\n" +
+	"\n" +
+	"\x06MUERTO\x10\x02\x12\t\n" +
+	"\x05SALIO\x10\x03\x12\f\n" +
+	"\bRETIRADO\x10\x042}\n" +
 	"\aOfertas\x12/\n" +
-	"\fEnviarOferta\x12\x0e.OfertaRequest\x1a\x0f.OfertaResponse2\xaf\x01\n" +
+	"\fEnviarOferta\x12\x0e.OfertaRequest\x1a\x0f.OfertaResponse\x12A\n" +
+	"\x0eEliminarOferta\x12\x16.EliminarOfertaRequest\x1a\x17.EliminarOfertaResponse2\xcb\x05\n" +
 	"\bDynamoDB\x12-\n" +
 	"\rGuardarOferta\x12\x0e.OfertaRequest\x1a\f.AckResponse\x12:\n" +
 	"\rLeerHistorico\x12\x15.LeerHistoricoRequest\x1a\x12.HistoricoResponse\x128\n" +
-	"\vSincronizar\x12\x13.SincronizarRequest\x1a\x14.SincronizarResponse2\xac\x01\n" +
+	"\vSincronizar\x12\x13.SincronizarRequest\x1a\x14.SincronizarResponse\x125\n" +
+	"\n" +
+	"LeerOferta\x12\x12.LeerOfertaRequest\x1a\x13.LeerOfertaResponse\x12D\n" +
+	"\x0fActualizarStock\x12\x17.ActualizarStockRequest\x1a\x18.ActualizarStockResponse\x126\n" +
+	"\x0eEliminarOferta\x12\x16.EliminarOfertaRequest\x1a\f.AckResponse\x12;\n" +
+	"\fHashesMerkle\x12\x14.HashesMerkleRequest\x1a\x15.HashesMerkleResponse\x124\n" +
+	"\n" +
+	"LeerRangos\x12\x12.LeerRangosRequest\x1a\x12.HistoricoResponse\x128\n" +
+	"\vLeerCambios\x12\x13.LeerCambiosRequest\x1a\x14.LeerCambiosResponse\x12#\n" +
+	"\x04Ping\x12\f.PingRequest\x1a\r.PingResponse\x125\n" +
+	"\rPingIndirecto\x12\x15.PingIndirectoRequest\x1a\r.PingResponse\x12+\n" +
+	"\x06Unirse\x12\x0e.UnirseRequest\x1a\x11.MiembrosResponse\x12/\n" +
+	"\bMiembros\x12\x10.MiembrosRequest\x1a\x11.MiembrosResponse2\xac\x01\n" +
 	"\n" +
 	"Consumidor\x12N\n" +
 	"\x13RegistrarConsumidor\x12\x1a.RegistroConsumidorRequest\x1a\x1b.RegistroConsumidorResponse\x12N\n" +
-	"\x12SolicitarHistorico\x12\x1a.SolicitarHistoricoRequest\x1a\x1c.HistoricoConsumidorResponse2I\n" +
+	"\x12SolicitarHistorico\x12\x1a.SolicitarHistoricoRequest\x1a\x1c.HistoricoConsumidorResponse2\xb4\x01\n" +
 	"\x18NotificacionesConsumidor\x12-\n" +
-	"\rRecibirOferta\x12\x0e.OfertaRequest\x1a\f.AckResponseB\bZ\x06/protob\x06proto3"
+	"\rRecibirOferta\x12\x0e.OfertaRequest\x1a\f.AckResponse\x12/\n" +
+	"\x0eRecibirResumen\x12\x0f.ResumenOfertas\x1a\f.AckResponse\x128\n" +
+	"\tVerificar\x12\x14.VerificacionRequest\x1a\x15.VerificacionResponse2\x92\x01\n" +
+	"\aCompras\x12A\n" +
+	"\x0eReservarOferta\x12\x16.ReservarOfertaRequest\x1a\x17.ReservarOfertaResponse\x12D\n" +
+	"\x0fConfirmarCompra\x12\x17.ConfirmarCompraRequest\x1a\x18.ConfirmarCompraResponse2\xa3\x01\n" +
+	"\tTaxonomia\x12G\n" +
+	"\x10ListarCategorias\x12\x18.ListarCategoriasRequest\x1a\x19.ListarCategoriasResponse\x12M\n" +
+	"\x12ResolverCategorias\x12\x1a.ResolverCategoriasRequest\x1a\x1b.ResolverCategoriasResponse2\xcb\x02\n" +
+	"\rCartasMuertas\x12P\n" +
+	"\x13ListarCartasMuertas\x12\x1b.ListarCartasMuertasRequest\x1a\x1c.ListarCartasMuertasResponse\x12>\n" +
+	"\x12ObtenerCartaMuerta\x12\x1a.ObtenerCartaMuertaRequest\x1a\f.CartaMuerta\x12V\n" +
+	"\x15ReprocesarCartaMuerta\x12\x1d.ReprocesarCartaMuertaRequest\x1a\x1e.ReprocesarCartaMuertaResponse\x12P\n" +
+	"\x13PurgarCartasMuertas\x12\x1b.PurgarCartasMuertasRequest\x1a\x1c.PurgarCartasMuertasResponse2\xd5\x01\n" +
+	"\x04Raft\x12>\n" +
+	"\rSolicitarVoto\x12\x15.SolicitarVotoRequest\x1a\x16.SolicitarVotoResponse\x12D\n" +
+	"\x0fAgregarEntradas\x12\x17.AgregarEntradasRequest\x1a\x18.AgregarEntradasResponse\x12G\n" +
+	"\x10InstalarSnapshot\x12\x18.InstalarSnapshotRequest\x1a\x19.InstalarSnapshotResponseB\bZ\x06/protob\x06proto3"
 
 var (
 	file_proto_ofertas_proto_rawDescOnce sync.Once
@@ -754,43 +4003,166 @@ func file_proto_ofertas_proto_rawDescGZIP() []byte {
 	return file_proto_ofertas_proto_rawDescData
 }
 
-var file_proto_ofertas_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_proto_ofertas_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_proto_ofertas_proto_msgTypes = make([]protoimpl.MessageInfo, 61)
 var file_proto_ofertas_proto_goTypes = []any{
-	(*OfertaRequest)(nil),               // 0: OfertaRequest
-	(*OfertaResponse)(nil),              // 1: OfertaResponse
-	(*AckResponse)(nil),                 // 2: AckResponse
-	(*RegistroConsumidorRequest)(nil),   // 3: RegistroConsumidorRequest
-	(*RegistroConsumidorResponse)(nil),  // 4: RegistroConsumidorResponse
-	(*SolicitarHistoricoRequest)(nil),   // 5: SolicitarHistoricoRequest
-	(*HistoricoConsumidorResponse)(nil), // 6: HistoricoConsumidorResponse
-	(*LeerHistoricoRequest)(nil),        // 7: LeerHistoricoRequest
-	(*HistoricoResponse)(nil),           // 8: HistoricoResponse
-	(*SincronizarRequest)(nil),          // 9: SincronizarRequest
-	(*SincronizarResponse)(nil),         // 10: SincronizarResponse
+	(EstadoMiembro)(0),                    // 0: EstadoMiembro
+	(*OfertaRequest)(nil),                 // 1: OfertaRequest
+	(*OfertaResponse)(nil),                // 2: OfertaResponse
+	(*AckResponse)(nil),                   // 3: AckResponse
+	(*ResumenOfertas)(nil),                // 4: ResumenOfertas
+	(*VerificacionRequest)(nil),           // 5: VerificacionRequest
+	(*VerificacionResponse)(nil),          // 6: VerificacionResponse
+	(*RegistroConsumidorRequest)(nil),     // 7: RegistroConsumidorRequest
+	(*Seguimiento)(nil),                   // 8: Seguimiento
+	(*RegistroConsumidorResponse)(nil),    // 9: RegistroConsumidorResponse
+	(*SolicitarHistoricoRequest)(nil),     // 10: SolicitarHistoricoRequest
+	(*HistoricoConsumidorResponse)(nil),   // 11: HistoricoConsumidorResponse
+	(*LeerHistoricoRequest)(nil),          // 12: LeerHistoricoRequest
+	(*HistoricoResponse)(nil),             // 13: HistoricoResponse
+	(*SincronizarRequest)(nil),            // 14: SincronizarRequest
+	(*SincronizarResponse)(nil),           // 15: SincronizarResponse
+	(*HashesMerkleRequest)(nil),           // 16: HashesMerkleRequest
+	(*HashesMerkleResponse)(nil),          // 17: HashesMerkleResponse
+	(*LeerRangosRequest)(nil),             // 18: LeerRangosRequest
+	(*LeerCambiosRequest)(nil),            // 19: LeerCambiosRequest
+	(*LeerCambiosResponse)(nil),           // 20: LeerCambiosResponse
+	(*Miembro)(nil),                       // 21: Miembro
+	(*PingRequest)(nil),                   // 22: PingRequest
+	(*PingResponse)(nil),                  // 23: PingResponse
+	(*PingIndirectoRequest)(nil),          // 24: PingIndirectoRequest
+	(*UnirseRequest)(nil),                 // 25: UnirseRequest
+	(*MiembrosRequest)(nil),               // 26: MiembrosRequest
+	(*MiembrosResponse)(nil),              // 27: MiembrosResponse
+	(*LeerOfertaRequest)(nil),             // 28: LeerOfertaRequest
+	(*LeerOfertaResponse)(nil),            // 29: LeerOfertaResponse
+	(*EliminarOfertaRequest)(nil),         // 30: EliminarOfertaRequest
+	(*EliminarOfertaResponse)(nil),        // 31: EliminarOfertaResponse
+	(*ActualizarStockRequest)(nil),        // 32: ActualizarStockRequest
+	(*ActualizarStockResponse)(nil),       // 33: ActualizarStockResponse
+	(*ReservarOfertaRequest)(nil),         // 34: ReservarOfertaRequest
+	(*ReservarOfertaResponse)(nil),        // 35: ReservarOfertaResponse
+	(*ConfirmarCompraRequest)(nil),        // 36: ConfirmarCompraRequest
+	(*ConfirmarCompraResponse)(nil),       // 37: ConfirmarCompraResponse
+	(*EntradaLog)(nil),                    // 38: EntradaLog
+	(*SolicitarVotoRequest)(nil),          // 39: SolicitarVotoRequest
+	(*SolicitarVotoResponse)(nil),         // 40: SolicitarVotoResponse
+	(*AgregarEntradasRequest)(nil),        // 41: AgregarEntradasRequest
+	(*AgregarEntradasResponse)(nil),       // 42: AgregarEntradasResponse
+	(*InstalarSnapshotRequest)(nil),       // 43: InstalarSnapshotRequest
+	(*InstalarSnapshotResponse)(nil),      // 44: InstalarSnapshotResponse
+	(*ListarCategoriasRequest)(nil),       // 45: ListarCategoriasRequest
+	(*Categoria)(nil),                     // 46: Categoria
+	(*ListarCategoriasResponse)(nil),      // 47: ListarCategoriasResponse
+	(*ResolverCategoriasRequest)(nil),     // 48: ResolverCategoriasRequest
+	(*CategoriaResuelta)(nil),             // 49: CategoriaResuelta
+	(*ResolverCategoriasResponse)(nil),    // 50: ResolverCategoriasResponse
+	(*CartaMuerta)(nil),                   // 51: CartaMuerta
+	(*ListarCartasMuertasRequest)(nil),    // 52: ListarCartasMuertasRequest
+	(*ListarCartasMuertasResponse)(nil),   // 53: ListarCartasMuertasResponse
+	(*ObtenerCartaMuertaRequest)(nil),     // 54: ObtenerCartaMuertaRequest
+	(*ReprocesarCartaMuertaRequest)(nil),  // 55: ReprocesarCartaMuertaRequest
+	(*ReprocesarCartaMuertaResponse)(nil), // 56: ReprocesarCartaMuertaResponse
+	(*PurgarCartasMuertasRequest)(nil),    // 57: PurgarCartasMuertasRequest
+	(*PurgarCartasMuertasResponse)(nil),   // 58: PurgarCartasMuertasResponse
+	nil,                                   // 59: OfertaRequest.RelojVectorialEntry
+	nil,                                   // 60: EliminarOfertaRequest.RelojVectorialEntry
+	nil,                                   // 61: ActualizarStockRequest.RelojVectorialEntry
 }
 var file_proto_ofertas_proto_depIdxs = []int32{
-	0,  // 0: HistoricoConsumidorResponse.ofertas:type_name -> OfertaRequest
-	0,  // 1: HistoricoResponse.ofertas:type_name -> OfertaRequest
-	0,  // 2: SincronizarRequest.ofertas:type_name -> OfertaRequest
-	0,  // 3: Ofertas.EnviarOferta:input_type -> OfertaRequest
-	0,  // 4: DynamoDB.GuardarOferta:input_type -> OfertaRequest
-	7,  // 5: DynamoDB.LeerHistorico:input_type -> LeerHistoricoRequest
-	9,  // 6: DynamoDB.Sincronizar:input_type -> SincronizarRequest
-	3,  // 7: Consumidor.RegistrarConsumidor:input_type -> RegistroConsumidorRequest
-	5,  // 8: Consumidor.SolicitarHistorico:input_type -> SolicitarHistoricoRequest
-	0,  // 9: NotificacionesConsumidor.RecibirOferta:input_type -> OfertaRequest
-	1,  // 10: Ofertas.EnviarOferta:output_type -> OfertaResponse
-	2,  // 11: DynamoDB.GuardarOferta:output_type -> AckResponse
-	8,  // 12: DynamoDB.LeerHistorico:output_type -> HistoricoResponse
-	10, // 13: DynamoDB.Sincronizar:output_type -> SincronizarResponse
-	4,  // 14: Consumidor.RegistrarConsumidor:output_type -> RegistroConsumidorResponse
-	6,  // 15: Consumidor.SolicitarHistorico:output_type -> HistoricoConsumidorResponse
-	2,  // 16: NotificacionesConsumidor.RecibirOferta:output_type -> AckResponse
-	10, // [10:17] is the sub-list for method output_type
-	3,  // [3:10] is the sub-list for method input_type
-	3,  // [3:3] is the sub-list for extension type_name
-	3,  // [3:3] is the sub-list for extension extendee
-	0,  // [0:3] is the sub-list for field type_name
+	59, // 0: OfertaRequest.reloj_vectorial:type_name -> OfertaRequest.RelojVectorialEntry
+	1,  // 1: OfertaRequest.hermanas:type_name -> OfertaRequest
+	1,  // 2: ResumenOfertas.ofertas:type_name -> OfertaRequest
+	8,  // 3: RegistroConsumidorRequest.seguimientos:type_name -> Seguimiento
+	1,  // 4: HistoricoConsumidorResponse.ofertas:type_name -> OfertaRequest
+	1,  // 5: HistoricoResponse.ofertas:type_name -> OfertaRequest
+	1,  // 6: SincronizarRequest.ofertas:type_name -> OfertaRequest
+	1,  // 7: LeerCambiosResponse.ofertas:type_name -> OfertaRequest
+	0,  // 8: Miembro.estado:type_name -> EstadoMiembro
+	21, // 9: PingRequest.origen:type_name -> Miembro
+	21, // 10: PingRequest.novedades:type_name -> Miembro
+	21, // 11: PingResponse.novedades:type_name -> Miembro
+	21, // 12: PingIndirectoRequest.origen:type_name -> Miembro
+	21, // 13: PingIndirectoRequest.destino:type_name -> Miembro
+	21, // 14: PingIndirectoRequest.novedades:type_name -> Miembro
+	21, // 15: UnirseRequest.miembro:type_name -> Miembro
+	21, // 16: MiembrosResponse.miembros:type_name -> Miembro
+	1,  // 17: LeerOfertaResponse.oferta:type_name -> OfertaRequest
+	60, // 18: EliminarOfertaRequest.reloj_vectorial:type_name -> EliminarOfertaRequest.RelojVectorialEntry
+	61, // 19: ActualizarStockRequest.reloj_vectorial:type_name -> ActualizarStockRequest.RelojVectorialEntry
+	38, // 20: AgregarEntradasRequest.entradas:type_name -> EntradaLog
+	46, // 21: ListarCategoriasResponse.categorias:type_name -> Categoria
+	49, // 22: ResolverCategoriasResponse.categorias:type_name -> CategoriaResuelta
+	1,  // 23: CartaMuerta.oferta:type_name -> OfertaRequest
+	4,  // 24: CartaMuerta.resumen:type_name -> ResumenOfertas
+	51, // 25: ListarCartasMuertasResponse.cartas:type_name -> CartaMuerta
+	1,  // 26: Ofertas.EnviarOferta:input_type -> OfertaRequest
+	30, // 27: Ofertas.EliminarOferta:input_type -> EliminarOfertaRequest
+	1,  // 28: DynamoDB.GuardarOferta:input_type -> OfertaRequest
+	12, // 29: DynamoDB.LeerHistorico:input_type -> LeerHistoricoRequest
+	14, // 30: DynamoDB.Sincronizar:input_type -> SincronizarRequest
+	28, // 31: DynamoDB.LeerOferta:input_type -> LeerOfertaRequest
+	32, // 32: DynamoDB.ActualizarStock:input_type -> ActualizarStockRequest
+	30, // 33: DynamoDB.EliminarOferta:input_type -> EliminarOfertaRequest
+	16, // 34: DynamoDB.HashesMerkle:input_type -> HashesMerkleRequest
+	18, // 35: DynamoDB.LeerRangos:input_type -> LeerRangosRequest
+	19, // 36: DynamoDB.LeerCambios:input_type -> LeerCambiosRequest
+	22, // 37: DynamoDB.Ping:input_type -> PingRequest
+	24, // 38: DynamoDB.PingIndirecto:input_type -> PingIndirectoRequest
+	25, // 39: DynamoDB.Unirse:input_type -> UnirseRequest
+	26, // 40: DynamoDB.Miembros:input_type -> MiembrosRequest
+	7,  // 41: Consumidor.RegistrarConsumidor:input_type -> RegistroConsumidorRequest
+	10, // 42: Consumidor.SolicitarHistorico:input_type -> SolicitarHistoricoRequest
+	1,  // 43: NotificacionesConsumidor.RecibirOferta:input_type -> OfertaRequest
+	4,  // 44: NotificacionesConsumidor.RecibirResumen:input_type -> ResumenOfertas
+	5,  // 45: NotificacionesConsumidor.Verificar:input_type -> VerificacionRequest
+	34, // 46: Compras.ReservarOferta:input_type -> ReservarOfertaRequest
+	36, // 47: Compras.ConfirmarCompra:input_type -> ConfirmarCompraRequest
+	45, // 48: Taxonomia.ListarCategorias:input_type -> ListarCategoriasRequest
+	48, // 49: Taxonomia.ResolverCategorias:input_type -> ResolverCategoriasRequest
+	52, // 50: CartasMuertas.ListarCartasMuertas:input_type -> ListarCartasMuertasRequest
+	54, // 51: CartasMuertas.ObtenerCartaMuerta:input_type -> ObtenerCartaMuertaRequest
+	55, // 52: CartasMuertas.ReprocesarCartaMuerta:input_type -> ReprocesarCartaMuertaRequest
+	57, // 53: CartasMuertas.PurgarCartasMuertas:input_type -> PurgarCartasMuertasRequest
+	39, // 54: Raft.SolicitarVoto:input_type -> SolicitarVotoRequest
+	41, // 55: Raft.AgregarEntradas:input_type -> AgregarEntradasRequest
+	43, // 56: Raft.InstalarSnapshot:input_type -> InstalarSnapshotRequest
+	2,  // 57: Ofertas.EnviarOferta:output_type -> OfertaResponse
+	31, // 58: Ofertas.EliminarOferta:output_type -> EliminarOfertaResponse
+	3,  // 59: DynamoDB.GuardarOferta:output_type -> AckResponse
+	13, // 60: DynamoDB.LeerHistorico:output_type -> HistoricoResponse
+	15, // 61: DynamoDB.Sincronizar:output_type -> SincronizarResponse
+	29, // 62: DynamoDB.LeerOferta:output_type -> LeerOfertaResponse
+	33, // 63: DynamoDB.ActualizarStock:output_type -> ActualizarStockResponse
+	3,  // 64: DynamoDB.EliminarOferta:output_type -> AckResponse
+	17, // 65: DynamoDB.HashesMerkle:output_type -> HashesMerkleResponse
+	13, // 66: DynamoDB.LeerRangos:output_type -> HistoricoResponse
+	20, // 67: DynamoDB.LeerCambios:output_type -> LeerCambiosResponse
+	23, // 68: DynamoDB.Ping:output_type -> PingResponse
+	23, // 69: DynamoDB.PingIndirecto:output_type -> PingResponse
+	27, // 70: DynamoDB.Unirse:output_type -> MiembrosResponse
+	27, // 71: DynamoDB.Miembros:output_type -> MiembrosResponse
+	9,  // 72: Consumidor.RegistrarConsumidor:output_type -> RegistroConsumidorResponse
+	11, // 73: Consumidor.SolicitarHistorico:output_type -> HistoricoConsumidorResponse
+	3,  // 74: NotificacionesConsumidor.RecibirOferta:output_type -> AckResponse
+	3,  // 75: NotificacionesConsumidor.RecibirResumen:output_type -> AckResponse
+	6,  // 76: NotificacionesConsumidor.Verificar:output_type -> VerificacionResponse
+	35, // 77: Compras.ReservarOferta:output_type -> ReservarOfertaResponse
+	37, // 78: Compras.ConfirmarCompra:output_type -> ConfirmarCompraResponse
+	47, // 79: Taxonomia.ListarCategorias:output_type -> ListarCategoriasResponse
+	50, // 80: Taxonomia.ResolverCategorias:output_type -> ResolverCategoriasResponse
+	53, // 81: CartasMuertas.ListarCartasMuertas:output_type -> ListarCartasMuertasResponse
+	51, // 82: CartasMuertas.ObtenerCartaMuerta:output_type -> CartaMuerta
+	56, // 83: CartasMuertas.ReprocesarCartaMuerta:output_type -> ReprocesarCartaMuertaResponse
+	58, // 84: CartasMuertas.PurgarCartasMuertas:output_type -> PurgarCartasMuertasResponse
+	40, // 85: Raft.SolicitarVoto:output_type -> SolicitarVotoResponse
+	42, // 86: Raft.AgregarEntradas:output_type -> AgregarEntradasResponse
+	44, // 87: Raft.InstalarSnapshot:output_type -> InstalarSnapshotResponse
+	57, // [57:88] is the sub-list for method output_type
+	26, // [26:57] is the sub-list for method input_type
+	26, // [26:26] is the sub-list for extension type_name
+	26, // [26:26] is the sub-list for extension extendee
+	0,  // [0:26] is the sub-list for field type_name
 }
 
 func init() { file_proto_ofertas_proto_init() }
@@ -803,13 +4175,14 @@ func file_proto_ofertas_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_ofertas_proto_rawDesc), len(file_proto_ofertas_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   11,
+			NumEnums:      1,
+			NumMessages:   61,
 			NumExtensions: 0,
-			NumServices:   4,
+			NumServices:   8,
 		},
 		GoTypes:           file_proto_ofertas_proto_goTypes,
 		DependencyIndexes: file_proto_ofertas_proto_depIdxs,
+		EnumInfos:         file_proto_ofertas_proto_enumTypes,
 		MessageInfos:      file_proto_ofertas_proto_msgTypes,
 	}.Build()
 	File_proto_ofertas_proto = out.File
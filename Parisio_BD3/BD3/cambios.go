@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	pb "parisio_bd3/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	maxCambiosRegistrados = 10000 // cambios que se recuerdan para los peers atrasados
+	maxCambiosPorLectura  = 500   // ofertas por respuesta de LeerCambios
+	rondasAntiEntropia    = 10    // cada cuántas rondas se hace anti-entropía completa igual
+)
+
+// registroCambios numera los cambios que aplica este nodo (escrituras y
+// fusiones) para que los peers pidan solo lo nuevo. Vive en memoria: al
+// reiniciar empieza otra época, y un peer que la ve distinta hace una ronda
+// completa de anti-entropía. Lo protege el ofertasMutex del DBNode.
+type registroCambios struct {
+	epoca     int64
+	secuencia int64    // última secuencia asignada
+	primera   int64    // secuencia de ids[0]
+	ids       []string // oferta que cambió en cada secuencia
+}
+
+func nuevoRegistroCambios() *registroCambios {
+	return &registroCambios{epoca: time.Now().UnixNano(), primera: 1}
+}
+
+func (r *registroCambios) anotar(ofertaID string) {
+	r.secuencia++
+	r.ids = append(r.ids, ofertaID)
+
+	// Recortar de a maxCambiosRegistrados para no copiar en cada cambio
+	if len(r.ids) >= 2*maxCambiosRegistrados {
+		descartados := len(r.ids) - maxCambiosRegistrados
+		r.ids = append([]string(nil), r.ids[descartados:]...)
+		r.primera += int64(descartados)
+	}
+}
+
+// desde devuelve las ofertas que cambiaron después de la secuencia (sin
+// repetir) y la secuencia hasta la que llegan. ok es false si hay una brecha:
+// la marca es de otra época o sus cambios ya se descartaron.
+func (r *registroCambios) desde(epoca, secuencia int64) (ids []string, hasta int64, ok bool) {
+	if epoca != r.epoca || secuencia > r.secuencia || secuencia+1 < r.primera {
+		return nil, 0, false
+	}
+
+	vistos := make(map[string]bool)
+	hasta = secuencia
+	for i := secuencia + 1 - r.primera; i < int64(len(r.ids)); i++ {
+		id := r.ids[i]
+		if !vistos[id] {
+			if len(ids) == maxCambiosPorLectura {
+				break
+			}
+			vistos[id] = true
+			ids = append(ids, id)
+		}
+		hasta = r.primera + i
+	}
+	return ids, hasta, true
+}
+
+// registrarEscritura deja una escritura ya guardada en el árbol de Merkle y
+// en el registro de cambios. Quien llama tiene ofertasMutex.
+func (db *DBNode) registrarEscritura(anterior, nueva *pb.OfertaRequest) {
+	db.merkle.actualizar(anterior, nueva)
+	db.cambios.anotar(nueva.GetOfertaId())
+}
+
+func (db *DBNode) LeerCambios(ctx context.Context, in *pb.LeerCambiosRequest) (*pb.LeerCambiosResponse, error) {
+	if !db.estaActivo() {
+		return nil, status.Error(codes.Unavailable, "nodo inactivo")
+	}
+
+	db.ofertasMutex.RLock()
+	defer db.ofertasMutex.RUnlock()
+
+	resp := &pb.LeerCambiosResponse{
+		Epoca:          db.cambios.epoca,
+		HastaSecuencia: db.cambios.secuencia,
+		NodoId:         db.nodoID,
+	}
+	ids, hasta, ok := db.cambios.desde(in.GetEpoca(), in.GetDesdeSecuencia())
+	if !ok {
+		resp.Brecha = true
+		return resp, nil
+	}
+
+	for _, id := range ids {
+		registro, existe, err := db.storage.Get(id)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "error leyendo oferta: %v", err)
+		}
+		if existe {
+			resp.Ofertas = append(resp.Ofertas, registro)
+		}
+	}
+	resp.HastaSecuencia = hasta
+	resp.HayMas = hasta < db.cambios.secuencia
+	return resp, nil
+}
+
+// ========== Marcas por peer ==========
+
+// marcaPeer es hasta dónde se replicaron los cambios de un peer.
+type marcaPeer struct {
+	epoca     int64
+	secuencia int64
+}
+
+type marcasPeers struct {
+	porNodo map[string]marcaPeer
+	mu      sync.Mutex
+}
+
+func (m *marcasPeers) leer(nodoID string) marcaPeer {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.porNodo[nodoID]
+}
+
+// guardar no retrocede una marca de la misma época: dos rondas con el mismo
+// peer pueden terminar en desorden.
+func (m *marcasPeers) guardar(nodoID string, marca marcaPeer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if actual, ok := m.porNodo[nodoID]; ok && actual.epoca == marca.epoca && actual.secuencia > marca.secuencia {
+		return
+	}
+	m.porNodo[nodoID] = marca
+}
+
+// sincronizarConPeer trae los cambios del peer desde su marca. Si hay una
+// brecha (el peer reinició, descartó esos cambios o no hay marca), o si se
+// pide completa, hace una ronda de anti-entropía y deja la marca en la
+// posición que tenía el peer antes de empezarla.
+func (db *DBNode) sincronizarConPeer(peer miembro, completa bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	nodo := peer.info.GetNodoId()
+	marca := db.marcas.leer(nodo)
+	if completa {
+		marca = marcaPeer{}
+	}
+
+	inicio := time.Now()
+	transferidos, recibidas, actualizadas := 0, 0, 0
+	desde := marca.secuencia
+	for {
+		req := &pb.LeerCambiosRequest{
+			NodoOrigen:     db.nodoID,
+			Epoca:          marca.epoca,
+			DesdeSecuencia: marca.secuencia,
+		}
+		resp, err := peer.cliente.LeerCambios(ctx, req)
+		if err != nil {
+			return err
+		}
+		transferidos += proto.Size(req) + proto.Size(resp)
+
+		if resp.GetBrecha() {
+			if !completa {
+				log.Printf("[%s] Sin cambios continuos de %s desde la secuencia %d, anti-entropía completa", db.nodoID, nodo, marca.secuencia)
+			}
+			if err := db.antiEntropia(peer.info.GetDireccion(), peer.cliente); err != nil {
+				return err
+			}
+			db.marcas.guardar(nodo, marcaPeer{epoca: resp.GetEpoca(), secuencia: resp.GetHastaSecuencia()})
+			return nil
+		}
+
+		actualizadasAhora, err := db.fusionarOfertas(resp.GetOfertas())
+		if err != nil {
+			return err
+		}
+		recibidas += len(resp.GetOfertas())
+		actualizadas += actualizadasAhora
+
+		marca = marcaPeer{epoca: resp.GetEpoca(), secuencia: resp.GetHastaSecuencia()}
+		db.marcas.guardar(nodo, marca)
+		if !resp.GetHayMas() {
+			break
+		}
+	}
+
+	if recibidas > 0 {
+		log.Printf("[%s] Cambios de %s: %d ofertas, %d actualizadas (secuencia %d -> %d, %s en %v)",
+			db.nodoID, nodo, recibidas, actualizadas, desde, marca.secuencia, formatearBytes(transferidos), time.Since(inicio).Round(time.Millisecond))
+	}
+	return nil
+}
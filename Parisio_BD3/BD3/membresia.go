@@ -0,0 +1,686 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	pb "parisio_bd3/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Membresía de los nodos DB al estilo SWIM. En cada ronda un nodo hace ping a
+// otro miembro; si no responde, pide a kIndirectos miembros que lo intenten
+// por él y, si tampoco lo logran, lo marca sospechoso. Un sospechoso que no lo
+// desmiente en tiempoSospecha queda muerto. Los cambios de estado viajan
+// adjuntos a los pings, así que se propagan sin mensajes propios.
+const (
+	intervaloPing   = 1 * time.Second
+	timeoutPing     = 500 * time.Millisecond
+	kIndirectos     = 2
+	tiempoSospecha  = 5 * time.Second
+	maxNovedades    = 8  // cambios adjuntos por mensaje
+	rondasReintento = 10 // cada cuántas rondas se intenta con un miembro muerto o con las semillas
+)
+
+type miembro struct {
+	info            *pb.Miembro
+	cliente         pb.DynamoDBClient
+	conn            *grpc.ClientConn
+	sospechosoDesde time.Time
+}
+
+// novedad es un cambio de estado que se adjunta a los mensajes hasta llegar
+// a limiteEnvios.
+type novedad struct {
+	info   *pb.Miembro
+	envios int
+}
+
+type membresia struct {
+	yo       *pb.Miembro
+	semillas []string
+
+	miembros  map[string]*miembro // por nodo_id, sin este nodo
+	novedades map[string]*novedad // el último cambio de cada nodo
+	ronda     []string            // orden de los pings de esta vuelta
+	mu        sync.Mutex
+
+	// Todos los nodos que fueron miembros, incluido este, salvo los que se
+	// retiraron para siempre; se guardan en archivoReplicas
+	conocidos       map[string]bool
+	archivoReplicas string
+
+	rondas int // solo lo usa bucleMembresia
+}
+
+func nuevaMembresia(nodoID, direccion string, semillas []string) *membresia {
+	m := &membresia{
+		yo: &pb.Miembro{
+			NodoId:    nodoID,
+			Direccion: direccion,
+			Estado:    pb.EstadoMiembro_VIVO,
+			// Al reiniciar, el nodo parte con una encarnación mayor que la que
+			// los demás recuerdan de él
+			Encarnacion: time.Now().UnixMilli(),
+		},
+		semillas:  semillas,
+		miembros:  make(map[string]*miembro),
+		novedades: make(map[string]*novedad),
+		conocidos: map[string]bool{nodoID: true},
+	}
+	m.anunciar(m.yo)
+	return m
+}
+
+// supera indica si el estado a reemplaza a b: gana la encarnación mayor y, a
+// igual encarnación, el estado más avanzado (VIVO < SOSPECHOSO < MUERTO < SALIO
+// < RETIRADO).
+func supera(a, b *pb.Miembro) bool {
+	if a.GetEncarnacion() != b.GetEncarnacion() {
+		return a.GetEncarnacion() > b.GetEncarnacion()
+	}
+	return a.GetEstado() > b.GetEstado()
+}
+
+// limiteEnvios es cuántas veces se adjunta un cambio: suficiente para que
+// llegue a todos con alta probabilidad, O(log n).
+func limiteEnvios(n int) int {
+	return 3 * int(math.Ceil(math.Log2(float64(n+1))))
+}
+
+func (m *membresia) anunciar(info *pb.Miembro) {
+	m.novedades[info.GetNodoId()] = &novedad{info: proto.Clone(info).(*pb.Miembro)}
+}
+
+// aplicar incorpora un cambio recibido. Quien llama tiene m.mu.
+func (m *membresia) aplicar(info *pb.Miembro) {
+	id := info.GetNodoId()
+	if id == "" {
+		return
+	}
+
+	if id == m.yo.GetNodoId() {
+		// Otro nodo cree que este está caído: se desmiente con una
+		// encarnación mayor
+		if m.yo.GetEstado() == pb.EstadoMiembro_VIVO && info.GetEstado() != pb.EstadoMiembro_VIVO &&
+			info.GetEncarnacion() >= m.yo.GetEncarnacion() {
+			m.yo.Encarnacion = info.GetEncarnacion() + 1
+			log.Printf("[%s] Desmintiendo estado %s (encarnación %d)", id, info.GetEstado(), m.yo.GetEncarnacion())
+			m.anunciar(m.yo)
+		}
+		return
+	}
+
+	actual, existe := m.miembros[id]
+	if existe && !supera(info, actual.info) {
+		return
+	}
+
+	if !existe {
+		actual = &miembro{}
+		m.miembros[id] = actual
+		log.Printf("[%s] Nuevo miembro %s (%s) %s", m.yo.GetNodoId(), id, info.GetDireccion(), info.GetEstado())
+	} else if actual.info.GetEstado() != info.GetEstado() {
+		log.Printf("[%s] Miembro %s: %s -> %s", m.yo.GetNodoId(), id, actual.info.GetEstado(), info.GetEstado())
+	}
+
+	// Un nodo retirado entregó sus datos y vació su almacenamiento: ya no
+	// puede traer ofertas de vuelta, así que deja de ser réplica. Si vuelve
+	// con una encarnación mayor, se lo cuenta de nuevo
+	if retirado := info.GetEstado() == pb.EstadoMiembro_RETIRADO; m.conocidos[id] == retirado {
+		if retirado {
+			delete(m.conocidos, id)
+			log.Printf("[%s] %s se retiró: deja de contarse como réplica", m.yo.GetNodoId(), id)
+		} else {
+			m.conocidos[id] = true
+		}
+		if err := m.guardarReplicas(); err != nil {
+			log.Printf("[%s] Error guardando las réplicas conocidas: %v", m.yo.GetNodoId(), err)
+		}
+	}
+
+	if actual.conn == nil || actual.info.GetDireccion() != info.GetDireccion() {
+		m.conectar(actual, info.GetDireccion())
+	}
+	if info.GetEstado() == pb.EstadoMiembro_SOSPECHOSO && actual.info.GetEstado() != pb.EstadoMiembro_SOSPECHOSO {
+		actual.sospechosoDesde = time.Now()
+	}
+	actual.info = proto.Clone(info).(*pb.Miembro)
+	m.anunciar(info)
+}
+
+// conectar abre la conexión gRPC del miembro; se reconecta sola si el nodo se cae.
+func (m *membresia) conectar(x *miembro, direccion string) {
+	if x.conn != nil {
+		x.conn.Close()
+	}
+	conn, err := grpc.Dial(direccion, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Printf("[%s] Error conectando a %s: %v", m.yo.GetNodoId(), direccion, err)
+		x.conn, x.cliente = nil, nil
+		return
+	}
+	x.conn = conn
+	x.cliente = pb.NewDynamoDBClient(conn)
+}
+
+func (m *membresia) recibir(infos []*pb.Miembro) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, info := range infos {
+		m.aplicar(info)
+	}
+}
+
+// adjuntar elige los cambios menos enviados para un mensaje a para. Si este
+// nodo cree que para está caído se lo incluye, así puede desmentirlo. Quien
+// llama tiene m.mu.
+func (m *membresia) adjuntar(para string) []*pb.Miembro {
+	pendientes := make([]*novedad, 0, len(m.novedades))
+	for _, n := range m.novedades {
+		pendientes = append(pendientes, n)
+	}
+	sort.Slice(pendientes, func(i, j int) bool {
+		if pendientes[i].envios != pendientes[j].envios {
+			return pendientes[i].envios < pendientes[j].envios
+		}
+		return pendientes[i].info.GetNodoId() < pendientes[j].info.GetNodoId()
+	})
+
+	limite := limiteEnvios(len(m.miembros) + 1)
+	var adjuntas []*pb.Miembro
+	incluyeDestino := false
+	for _, n := range pendientes {
+		if len(adjuntas) == maxNovedades {
+			break
+		}
+		adjuntas = append(adjuntas, n.info)
+		incluyeDestino = incluyeDestino || n.info.GetNodoId() == para
+		n.envios++
+		if n.envios >= limite {
+			delete(m.novedades, n.info.GetNodoId())
+		}
+	}
+
+	if x, ok := m.miembros[para]; ok && !incluyeDestino && x.info.GetEstado() != pb.EstadoMiembro_VIVO {
+		adjuntas = append(adjuntas, proto.Clone(x.info).(*pb.Miembro))
+	}
+	return adjuntas
+}
+
+// ping hace un ping directo y aplica los cambios que traiga la respuesta.
+func (m *membresia) ping(ctx context.Context, destino string, cliente pb.DynamoDBClient) bool {
+	if cliente == nil {
+		return false
+	}
+	m.mu.Lock()
+	req := &pb.PingRequest{
+		Origen:    proto.Clone(m.yo).(*pb.Miembro),
+		Novedades: m.adjuntar(destino),
+	}
+	m.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, timeoutPing)
+	defer cancel()
+
+	resp, err := cliente.Ping(ctx, req)
+	if err != nil {
+		return false
+	}
+	m.recibir(resp.GetNovedades())
+	return resp.GetAck()
+}
+
+func (m *membresia) pingIndirecto(ayudante *miembro, destino *pb.Miembro) bool {
+	m.mu.Lock()
+	req := &pb.PingIndirectoRequest{
+		Origen:    proto.Clone(m.yo).(*pb.Miembro),
+		Destino:   destino,
+		Novedades: m.adjuntar(ayudante.info.GetNodoId()),
+	}
+	m.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*timeoutPing)
+	defer cancel()
+
+	resp, err := ayudante.cliente.PingIndirecto(ctx, req)
+	if err != nil {
+		return false
+	}
+	m.recibir(resp.GetNovedades())
+	return resp.GetAck()
+}
+
+// siguienteDestino recorre los miembros vivos y sospechosos en un orden
+// aleatorio que se renueva en cada vuelta, así todos reciben un ping en a lo
+// más dos vueltas.
+func (m *membresia) siguienteDestino() (*pb.Miembro, pb.DynamoDBClient) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for intento := 0; intento < 2; intento++ {
+		for len(m.ronda) > 0 {
+			id := m.ronda[0]
+			m.ronda = m.ronda[1:]
+			if x, ok := m.miembros[id]; ok && enContacto(x.info) {
+				return proto.Clone(x.info).(*pb.Miembro), x.cliente
+			}
+		}
+		for id, x := range m.miembros {
+			if enContacto(x.info) {
+				m.ronda = append(m.ronda, id)
+			}
+		}
+		rand.Shuffle(len(m.ronda), func(i, j int) { m.ronda[i], m.ronda[j] = m.ronda[j], m.ronda[i] })
+	}
+	return nil, nil
+}
+
+// enContacto indica si al miembro se le siguen haciendo pings.
+func enContacto(info *pb.Miembro) bool {
+	return info.GetEstado() == pb.EstadoMiembro_VIVO || info.GetEstado() == pb.EstadoMiembro_SOSPECHOSO
+}
+
+// ayudantes elige hasta k miembros vivos distintos del destino.
+func (m *membresia) ayudantes(k int, destino string) []*miembro {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var candidatos []*miembro
+	for id, x := range m.miembros {
+		if id != destino && x.info.GetEstado() == pb.EstadoMiembro_VIVO && x.cliente != nil {
+			candidatos = append(candidatos, x)
+		}
+	}
+	rand.Shuffle(len(candidatos), func(i, j int) { candidatos[i], candidatos[j] = candidatos[j], candidatos[i] })
+	if len(candidatos) > k {
+		candidatos = candidatos[:k]
+	}
+	return candidatos
+}
+
+// sondear es una ronda de SWIM: ping directo y, si falla, pings indirectos.
+func (m *membresia) sondear() {
+	destino, cliente := m.siguienteDestino()
+	if destino == nil {
+		return
+	}
+	if m.ping(context.Background(), destino.GetNodoId(), cliente) {
+		return
+	}
+
+	ayudantes := m.ayudantes(kIndirectos, destino.GetNodoId())
+	resultados := make(chan bool, len(ayudantes))
+	for _, ayudante := range ayudantes {
+		go func(ayudante *miembro) {
+			resultados <- m.pingIndirecto(ayudante, destino)
+		}(ayudante)
+	}
+	for range ayudantes {
+		if <-resultados {
+			return
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// Solo si nadie lo desmintió mientras tanto
+	if x, ok := m.miembros[destino.GetNodoId()]; ok && x.info.GetEstado() == pb.EstadoMiembro_VIVO &&
+		x.info.GetEncarnacion() == destino.GetEncarnacion() {
+		sospechoso := proto.Clone(x.info).(*pb.Miembro)
+		sospechoso.Estado = pb.EstadoMiembro_SOSPECHOSO
+		m.aplicar(sospechoso)
+	}
+}
+
+// vencerSospechas declara muertos a los sospechosos que no desmintieron a tiempo.
+func (m *membresia) vencerSospechas() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, x := range m.miembros {
+		if x.info.GetEstado() == pb.EstadoMiembro_SOSPECHOSO && time.Since(x.sospechosoDesde) >= tiempoSospecha {
+			muerto := proto.Clone(x.info).(*pb.Miembro)
+			muerto.Estado = pb.EstadoMiembro_MUERTO
+			m.aplicar(muerto)
+		}
+	}
+}
+
+// reintentarMuerto hace ping a un miembro muerto al azar. Si volvió, el ping
+// le dice que está muerto, lo desmiente y la respuesta trae su nuevo estado.
+func (m *membresia) reintentarMuerto() {
+	m.mu.Lock()
+	var muertos []*miembro
+	for _, x := range m.miembros {
+		if x.info.GetEstado() == pb.EstadoMiembro_MUERTO {
+			muertos = append(muertos, x)
+		}
+	}
+	if len(muertos) == 0 {
+		m.mu.Unlock()
+		return
+	}
+	x := muertos[rand.Intn(len(muertos))]
+	id, cliente := x.info.GetNodoId(), x.cliente
+	m.mu.Unlock()
+
+	m.ping(context.Background(), id, cliente)
+}
+
+// unirse pide la lista de miembros a la primera semilla que responda.
+func (m *membresia) unirse() bool {
+	m.mu.Lock()
+	yo := proto.Clone(m.yo).(*pb.Miembro)
+	m.mu.Unlock()
+
+	for _, semilla := range m.semillas {
+		if semilla == yo.GetDireccion() {
+			continue
+		}
+		conn, err := grpc.Dial(semilla, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		resp, err := pb.NewDynamoDBClient(conn).Unirse(ctx, &pb.UnirseRequest{Miembro: yo})
+		cancel()
+		conn.Close()
+		if err != nil {
+			log.Printf("[%s] Semilla %s no disponible: %v", yo.GetNodoId(), semilla, err)
+			continue
+		}
+
+		m.recibir(resp.GetMiembros())
+		log.Printf("[%s] Unido al clúster vía %s (%d miembros)", yo.GetNodoId(), semilla, len(resp.GetMiembros()))
+		return true
+	}
+	return false
+}
+
+// salir avisa directamente a los miembros vivos que este nodo se detiene
+// (SALIO) o que deja el clúster para siempre (RETIRADO).
+func (m *membresia) salir(estado pb.EstadoMiembro) {
+	m.mu.Lock()
+	m.yo.Estado = estado
+	m.anunciar(m.yo)
+	m.mu.Unlock()
+
+	log.Printf("[%s] Saliendo del clúster (%s)", m.yo.GetNodoId(), estado)
+
+	var wg sync.WaitGroup
+	for _, x := range m.vivos() {
+		wg.Add(1)
+		go func(id string, cliente pb.DynamoDBClient) {
+			defer wg.Done()
+			m.ping(context.Background(), id, cliente)
+		}(x.info.GetNodoId(), x.cliente)
+	}
+	wg.Wait()
+}
+
+// retirarse saca al nodo del clúster para siempre: entrega sus ofertas a los
+// miembros vivos con la anti-entropía, vacía su almacenamiento y avisa
+// RETIRADO, así los demás dejan de esperarlo para recolectar lápidas. Si no
+// logra entregarlas, solo se detiene (SALIO) y conserva sus datos.
+func (db *DBNode) retirarse() {
+	// Desde aquí no acepta escrituras: lo que llegue después no se entregaría
+	db.estadoMutex.Lock()
+	db.activo = false
+	db.estadoMutex.Unlock()
+
+	vivos := db.membresia.vivos()
+	if len(vivos) == 0 {
+		log.Printf("[%s] No hay miembros vivos a quienes entregar las ofertas; se detiene sin retirarse", db.nodoID)
+		db.membresia.salir(pb.EstadoMiembro_SALIO)
+		return
+	}
+	for _, peer := range vivos {
+		if err := db.antiEntropia(peer.info.GetNodoId(), peer.cliente); err != nil {
+			log.Printf("[%s] No se pudo entregar las ofertas a %s (%v); se detiene sin retirarse", db.nodoID, peer.info.GetNodoId(), err)
+			db.membresia.salir(pb.EstadoMiembro_SALIO)
+			return
+		}
+	}
+
+	// Sin datos, el nodo no puede revivir ofertas cuyas lápidas se
+	// recolecten después de su salida
+	db.ofertasMutex.Lock()
+	var ids []string
+	err := db.storage.Scan(func(registro *pb.OfertaRequest) bool {
+		ids = append(ids, registro.GetOfertaId())
+		return true
+	})
+	if err == nil {
+		err = db.storage.Delete(ids...)
+	}
+	db.ofertasMutex.Unlock()
+	if err != nil {
+		log.Printf("[%s] Error vaciando el almacenamiento (%v); se detiene sin retirarse", db.nodoID, err)
+		db.membresia.salir(pb.EstadoMiembro_SALIO)
+		return
+	}
+
+	log.Printf("[%s] %d ofertas entregadas a %d miembros", db.nodoID, len(ids), len(vivos))
+	db.membresia.salir(pb.EstadoMiembro_RETIRADO)
+}
+
+// vivos devuelve una copia de los miembros vivos, ordenados por nodo_id.
+func (m *membresia) vivos() []miembro {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var vivos []miembro
+	for _, x := range m.miembros {
+		if x.info.GetEstado() == pb.EstadoMiembro_VIVO && x.cliente != nil {
+			vivos = append(vivos, miembro{info: proto.Clone(x.info).(*pb.Miembro), cliente: x.cliente})
+		}
+	}
+	sort.Slice(vivos, func(i, j int) bool { return vivos[i].info.GetNodoId() < vivos[j].info.GetNodoId() })
+	return vivos
+}
+
+// lista devuelve todos los miembros conocidos, incluido este nodo.
+func (m *membresia) lista() []*pb.Miembro {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	lista := []*pb.Miembro{proto.Clone(m.yo).(*pb.Miembro)}
+	for _, x := range m.miembros {
+		lista = append(lista, proto.Clone(x.info).(*pb.Miembro))
+	}
+	sort.Slice(lista, func(i, j int) bool { return lista[i].GetNodoId() < lista[j].GetNodoId() })
+	return lista
+}
+
+// replicas devuelve los nodos que pueden tener cada oferta: todos los que
+// fueron miembros y no se retiraron. Un nodo muerto, detenido o que este
+// olvidó al reiniciar puede volver con sus datos, así que no alcanza con la
+// membresía actual.
+func (m *membresia) replicas() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.listaConocidos()
+}
+
+// listaConocidos devuelve los nodos conocidos ordenados. Quien llama tiene m.mu.
+func (m *membresia) listaConocidos() []string {
+	replicas := make([]string, 0, len(m.conocidos))
+	for id := range m.conocidos {
+		replicas = append(replicas, id)
+	}
+	sort.Strings(replicas)
+	return replicas
+}
+
+type replicasPersistidas struct {
+	Nodos []string `json:"nodos"`
+}
+
+// cargarReplicas lee las réplicas conocidas guardadas en ruta y, desde ahí,
+// guarda en ese archivo cada miembro nuevo. Debe llamarse antes de unirse.
+func (m *membresia) cargarReplicas(ruta string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.archivoReplicas = ruta
+	datos, err := os.ReadFile(ruta)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err == nil {
+		var guardadas replicasPersistidas
+		if err := json.Unmarshal(datos, &guardadas); err != nil {
+			return fmt.Errorf("archivo de réplicas corrupto: %v", err)
+		}
+		for _, id := range guardadas.Nodos {
+			m.conocidos[id] = true
+		}
+	}
+	return m.guardarReplicas()
+}
+
+// guardarReplicas escribe las réplicas conocidas. Quien llama tiene m.mu.
+func (m *membresia) guardarReplicas() error {
+	if m.archivoReplicas == "" {
+		return nil
+	}
+	datos, err := json.Marshal(replicasPersistidas{Nodos: m.listaConocidos()})
+	if err != nil {
+		return err
+	}
+	return escribirAtomico(m.archivoReplicas, datos)
+}
+
+func (m *membresia) sinContacto() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, x := range m.miembros {
+		if enContacto(x.info) {
+			return false
+		}
+	}
+	return true
+}
+
+// ========== Servicio y bucle de la membresía ==========
+
+func (db *DBNode) estaActivo() bool {
+	db.estadoMutex.RLock()
+	defer db.estadoMutex.RUnlock()
+	return db.activo
+}
+
+func (db *DBNode) Ping(ctx context.Context, in *pb.PingRequest) (*pb.PingResponse, error) {
+	if !db.estaActivo() {
+		return nil, status.Error(codes.Unavailable, "nodo inactivo")
+	}
+
+	m := db.membresia
+	m.recibir(append([]*pb.Miembro{in.GetOrigen()}, in.GetNovedades()...))
+
+	m.mu.Lock()
+	novedades := append(m.adjuntar(in.GetOrigen().GetNodoId()), proto.Clone(m.yo).(*pb.Miembro))
+	m.mu.Unlock()
+
+	return &pb.PingResponse{
+		Ack:       true,
+		NodoId:    db.nodoID,
+		Novedades: novedades,
+	}, nil
+}
+
+func (db *DBNode) PingIndirecto(ctx context.Context, in *pb.PingIndirectoRequest) (*pb.PingResponse, error) {
+	if !db.estaActivo() {
+		return nil, status.Error(codes.Unavailable, "nodo inactivo")
+	}
+
+	m := db.membresia
+	m.recibir(append([]*pb.Miembro{in.GetOrigen(), in.GetDestino()}, in.GetNovedades()...))
+
+	var cliente pb.DynamoDBClient
+	m.mu.Lock()
+	if x, ok := m.miembros[in.GetDestino().GetNodoId()]; ok {
+		cliente = x.cliente
+	}
+	m.mu.Unlock()
+
+	ack := m.ping(ctx, in.GetDestino().GetNodoId(), cliente)
+
+	m.mu.Lock()
+	novedades := m.adjuntar(in.GetOrigen().GetNodoId())
+	m.mu.Unlock()
+
+	return &pb.PingResponse{
+		Ack:       ack,
+		NodoId:    db.nodoID,
+		Novedades: novedades,
+	}, nil
+}
+
+func (db *DBNode) Unirse(ctx context.Context, in *pb.UnirseRequest) (*pb.MiembrosResponse, error) {
+	if !db.estaActivo() {
+		return nil, status.Error(codes.Unavailable, "nodo inactivo")
+	}
+
+	db.membresia.recibir([]*pb.Miembro{in.GetMiembro()})
+
+	return &pb.MiembrosResponse{
+		Miembros: db.membresia.lista(),
+		NodoId:   db.nodoID,
+	}, nil
+}
+
+// Miembros devuelve la membresía vista por este nodo; la consulta el broker
+// para saber a qué nodos DB escribir.
+func (db *DBNode) Miembros(ctx context.Context, in *pb.MiembrosRequest) (*pb.MiembrosResponse, error) {
+	if !db.estaActivo() {
+		return nil, status.Error(codes.Unavailable, "nodo inactivo")
+	}
+
+	return &pb.MiembrosResponse{
+		Miembros: db.membresia.lista(),
+		NodoId:   db.nodoID,
+	}, nil
+}
+
+// bucleMembresia se une al clúster y hace una ronda de SWIM cada
+// intervaloPing. Mientras el nodo simula una caída no envía nada, igual que
+// un nodo caído de verdad.
+func (db *DBNode) bucleMembresia() {
+	m := db.membresia
+	m.unirse()
+
+	ticker := time.NewTicker(intervaloPing)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if !db.estaActivo() {
+			continue
+		}
+		m.rondas++
+
+		if m.rondas%rondasReintento == 0 {
+			// Sin nadie a quien hacer ping se vuelve a las semillas
+			if m.sinContacto() {
+				m.unirse()
+			}
+			m.reintentarMuerto()
+		}
+		m.sondear()
+		m.vencerSospechas()
+	}
+}
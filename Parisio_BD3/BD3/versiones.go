@@ -0,0 +1,305 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"time"
+
+	pb "parisio_bd3/proto"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// ordenVersiones es la relación entre dos versiones de una misma oferta.
+type ordenVersiones int
+
+const (
+	versionIgual ordenVersiones = iota
+	versionAnterior
+	versionPosterior
+	versionConcurrente
+)
+
+// compararRelojes ubica el reloj a respecto de b.
+func compararRelojes(a, b map[string]int64) ordenVersiones {
+	menor, mayor := false, false
+	for nodo, n := range a {
+		if n > b[nodo] {
+			mayor = true
+		} else if n < b[nodo] {
+			menor = true
+		}
+	}
+	for nodo, n := range b {
+		if _, ok := a[nodo]; !ok && n > 0 {
+			menor = true
+		}
+	}
+
+	switch {
+	case menor && mayor:
+		return versionConcurrente
+	case menor:
+		return versionAnterior
+	case mayor:
+		return versionPosterior
+	default:
+		return versionIgual
+	}
+}
+
+// compararVersiones ubica la versión a respecto de b. Las ofertas guardadas
+// antes de los relojes vectoriales no traen reloj: entre ellas se sigue
+// usando version_stock, y cualquier versión con reloj es posterior.
+func compararVersiones(a, b *pb.OfertaRequest) ordenVersiones {
+	if len(a.GetRelojVectorial()) == 0 && len(b.GetRelojVectorial()) == 0 {
+		switch {
+		case a.GetVersionStock() < b.GetVersionStock():
+			return versionAnterior
+		case a.GetVersionStock() > b.GetVersionStock():
+			return versionPosterior
+		case mismoContenido(a, b):
+			return versionIgual
+		default:
+			return versionConcurrente
+		}
+	}
+	return compararRelojes(a.GetRelojVectorial(), b.GetRelojVectorial())
+}
+
+func fusionarRelojes(relojes ...map[string]int64) map[string]int64 {
+	fusionado := make(map[string]int64)
+	for _, reloj := range relojes {
+		for nodo, n := range reloj {
+			if n > fusionado[nodo] {
+				fusionado[nodo] = n
+			}
+		}
+	}
+	return fusionado
+}
+
+// mismoContenido compara dos versiones sin su metadata de versionado.
+func mismoContenido(a, b *pb.OfertaRequest) bool {
+	return proto.Equal(sinVersionado(a), sinVersionado(b))
+}
+
+func sinVersionado(oferta *pb.OfertaRequest) *pb.OfertaRequest {
+	copia := proto.Clone(oferta).(*pb.OfertaRequest)
+	copia.RelojVectorial = nil
+	copia.Hermanas = nil
+	copia.EscritaNs = 0
+	copia.ConfirmadaPor = nil
+	return copia
+}
+
+// versionesDe devuelve el registro guardado como lista plana: la versión
+// principal y sus hermanas, cada una sin hermanas propias.
+func versionesDe(registro *pb.OfertaRequest) []*pb.OfertaRequest {
+	if registro == nil {
+		return nil
+	}
+	if len(registro.GetHermanas()) == 0 {
+		return []*pb.OfertaRequest{registro}
+	}
+	principal := proto.Clone(registro).(*pb.OfertaRequest)
+	principal.Hermanas = nil
+	return append([]*pb.OfertaRequest{principal}, registro.GetHermanas()...)
+}
+
+// agregarVersion incorpora una versión al conjunto de versiones concurrentes
+// de una oferta: se descarta si alguna ya la cubre, reemplaza a las que la
+// preceden y, si es concurrente con el resto, queda como hermana. Devuelve el
+// conjunto y si cambió.
+func agregarVersion(versiones []*pb.OfertaRequest, nueva *pb.OfertaRequest) ([]*pb.OfertaRequest, bool) {
+	resultado := make([]*pb.OfertaRequest, 0, len(versiones)+1)
+	for i, actual := range versiones {
+		switch compararVersiones(nueva, actual) {
+		case versionIgual:
+			// La misma lápida puede llegar con más nodos que la confirmaron
+			confirmada, crecio := unirConfirmaciones(actual, nueva)
+			if !crecio {
+				return versiones, false
+			}
+			copia := append([]*pb.OfertaRequest(nil), versiones...)
+			copia[i] = confirmada
+			return copia, true
+		case versionAnterior:
+			return versiones, false
+		case versionPosterior:
+			// La nueva reemplaza a esta
+		default:
+			if !mismoContenido(nueva, actual) {
+				resultado = append(resultado, actual)
+				continue
+			}
+			// La misma escritura aplicada en varios nodos (el broker escribe
+			// en paralelo): queda una sola versión con los relojes fusionados
+			fusionada := proto.Clone(actual).(*pb.OfertaRequest)
+			fusionada.RelojVectorial = fusionarRelojes(actual.GetRelojVectorial(), nueva.GetRelojVectorial())
+			fusionada.EscritaNs = max(actual.GetEscritaNs(), nueva.GetEscritaNs())
+			fusionada.ConfirmadaPor = unirNodos(actual.GetConfirmadaPor(), nueva.GetConfirmadaPor())
+			nueva = fusionada
+		}
+	}
+	return append(resultado, nueva), true
+}
+
+// unirConfirmaciones devuelve la versión actual con los nodos que
+// confirmaron la nueva, y si agregó alguno.
+func unirConfirmaciones(actual, nueva *pb.OfertaRequest) (*pb.OfertaRequest, bool) {
+	unidos := unirNodos(actual.GetConfirmadaPor(), nueva.GetConfirmadaPor())
+	if len(unidos) == len(actual.GetConfirmadaPor()) {
+		return actual, false
+	}
+	confirmada := proto.Clone(actual).(*pb.OfertaRequest)
+	confirmada.ConfirmadaPor = unidos
+	return confirmada, true
+}
+
+// unirNodos une dos listas de nodos en una ordenada y sin repetidos, para
+// que la misma lápida se guarde igual en todos los nodos.
+func unirNodos(a, b []string) []string {
+	if len(a) == 0 && len(b) == 0 {
+		return nil
+	}
+	vistos := make(map[string]bool, len(a)+len(b))
+	var unidos []string
+	for _, nodo := range append(append([]string(nil), a...), b...) {
+		if !vistos[nodo] {
+			vistos[nodo] = true
+			unidos = append(unidos, nodo)
+		}
+	}
+	sort.Strings(unidos)
+	return unidos
+}
+
+// fusionarVersiones agrega al conjunto local las versiones recibidas de un peer.
+func fusionarVersiones(locales, recibidas []*pb.OfertaRequest) ([]*pb.OfertaRequest, bool) {
+	cambio := false
+	for _, recibida := range recibidas {
+		var agregada bool
+		locales, agregada = agregarVersion(locales, recibida)
+		cambio = cambio || agregada
+	}
+	return locales, cambio
+}
+
+// ========== Resolución de versiones concurrentes ==========
+
+// resolvedor indica si la versión a gana sobre b.
+type resolvedor func(a, b *pb.OfertaRequest) bool
+
+// Resolvedores disponibles en RESOLUCION_CONFLICTOS
+const (
+	resolucionUltimaEscritura = "lww"          // la escritura más reciente (defecto)
+	resolucionMenorPrecio     = "menor_precio" // el menor precio_descuento
+	resolucionMayorStock      = "mayor_stock"  // el mayor stock
+)
+
+func resolvedorPorNombre(nombre string) (resolvedor, error) {
+	var criterio func(a, b *pb.OfertaRequest) int
+	switch nombre {
+	case "", resolucionUltimaEscritura:
+		criterio = func(a, b *pb.OfertaRequest) int { return 0 }
+	case resolucionMenorPrecio:
+		criterio = func(a, b *pb.OfertaRequest) int {
+			return int(b.GetPrecioDescuento()) - int(a.GetPrecioDescuento())
+		}
+	case resolucionMayorStock:
+		criterio = func(a, b *pb.OfertaRequest) int {
+			return int(a.GetStock()) - int(b.GetStock())
+		}
+	default:
+		return nil, fmt.Errorf("resolución de conflictos desconocida %q (usar %s, %s o %s)",
+			nombre, resolucionUltimaEscritura, resolucionMenorPrecio, resolucionMayorStock)
+	}
+
+	return func(a, b *pb.OfertaRequest) bool {
+		// El stock lo confirma el broker con quórum y CAS por versión: la
+		// versión de stock más alta es siempre la última compra confirmada
+		if a.GetVersionStock() != b.GetVersionStock() {
+			return a.GetVersionStock() > b.GetVersionStock()
+		}
+		if c := criterio(a, b); c != 0 {
+			return c > 0
+		}
+		if a.GetEscritaNs() != b.GetEscritaNs() {
+			return a.GetEscritaNs() > b.GetEscritaNs()
+		}
+		return desempate(a, b)
+	}, nil
+}
+
+// desempate da el mismo resultado en todos los nodos aunque dos versiones
+// tengan la misma hora de escritura.
+func desempate(a, b *pb.OfertaRequest) bool {
+	opciones := proto.MarshalOptions{Deterministic: true}
+	datosA, _ := opciones.Marshal(a)
+	datosB, _ := opciones.Marshal(b)
+	return bytes.Compare(datosA, datosB) > 0
+}
+
+// empaquetar arma el registro a guardar: la versión que elige el resolvedor,
+// con las demás como hermanas.
+func (db *DBNode) empaquetar(versiones []*pb.OfertaRequest) *pb.OfertaRequest {
+	if len(versiones) == 1 {
+		return versiones[0]
+	}
+	ganadora := 0
+	for i := 1; i < len(versiones); i++ {
+		if db.resolvedor(versiones[i], versiones[ganadora]) {
+			ganadora = i
+		}
+	}
+
+	registro := proto.Clone(versiones[ganadora]).(*pb.OfertaRequest)
+	registro.Hermanas = make([]*pb.OfertaRequest, 0, len(versiones)-1)
+	for i, version := range versiones {
+		if i != ganadora {
+			registro.Hermanas = append(registro.Hermanas, version)
+		}
+	}
+	// Mismo orden en todos los nodos, para que el mismo conjunto de versiones
+	// se guarde igual y el árbol de Merkle no marque diferencias falsas
+	sort.Slice(registro.Hermanas, func(i, j int) bool {
+		return desempate(registro.Hermanas[i], registro.Hermanas[j])
+	})
+	return registro
+}
+
+// resolver devuelve la versión que ven las lecturas. Lleva el reloj de todas
+// las hermanas, así una escritura basada en ella las reemplaza a todas.
+func (db *DBNode) resolver(registro *pb.OfertaRequest) *pb.OfertaRequest {
+	if len(registro.GetHermanas()) == 0 {
+		return registro
+	}
+
+	versiones := versionesDe(registro)
+	relojes := make([]map[string]int64, len(versiones))
+	for i, version := range versiones {
+		relojes[i] = version.GetRelojVectorial()
+	}
+
+	resuelta := proto.Clone(db.empaquetar(versiones)).(*pb.OfertaRequest)
+	resuelta.Hermanas = nil
+	resuelta.RelojVectorial = fusionarRelojes(relojes...)
+	return resuelta
+}
+
+// nuevaVersion marca la oferta como escrita por este nodo: su reloj supera a
+// todas las versiones locales y al contexto que trae la escritura.
+func (db *DBNode) nuevaVersion(oferta *pb.OfertaRequest, registro *pb.OfertaRequest, contexto map[string]int64) {
+	relojes := []map[string]int64{contexto}
+	for _, version := range versionesDe(registro) {
+		relojes = append(relojes, version.GetRelojVectorial())
+	}
+	reloj := fusionarRelojes(relojes...)
+	reloj[db.nodoID]++
+
+	oferta.RelojVectorial = reloj
+	oferta.Hermanas = nil
+	oferta.EscritaNs = time.Now().UnixNano()
+}
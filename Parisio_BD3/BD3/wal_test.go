@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	pb "parisio_bd3/proto"
+)
+
+// escribirSegmento deja en disco un segmento con las ofertas dadas y devuelve
+// su ruta.
+func escribirSegmento(t *testing.T, ids ...string) string {
+	t.Helper()
+	prefijo := filepath.Join(t.TempDir(), "DB1_wal")
+	w, err := abrirWAL(prefijo, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range ids {
+		if err := w.agregar(&pb.OfertaRequest{OfertaId: id, Producto: "Producto " + id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.cerrar(); err != nil {
+		t.Fatal(err)
+	}
+	return rutaSegmento(prefijo, 1)
+}
+
+func reproducir(t *testing.T, ruta string) []string {
+	t.Helper()
+	var aplicadas []string
+	_, err := leerSegmento(ruta,
+		func(oferta *pb.OfertaRequest) { aplicadas = append(aplicadas, oferta.GetOfertaId()) },
+		func(id string) { aplicadas = append(aplicadas, "-"+id) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	return aplicadas
+}
+
+func TestWALSeDetieneEnRegistroCortado(t *testing.T) {
+	ruta := escribirSegmento(t, "a", "b", "c")
+	datos, err := os.ReadFile(ruta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Un corte a mitad del último registro
+	if err := os.WriteFile(ruta, datos[:len(datos)-3], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := reproducir(t, ruta); len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Fatalf("aplicadas = %v, se esperaba [a b]", got)
+	}
+}
+
+func TestWALSeDetieneEnRegistroDañado(t *testing.T) {
+	ruta := escribirSegmento(t, "a", "b", "c")
+	datos, err := os.ReadFile(ruta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Un byte cambiado en el contenido del segundo registro: su CRC ya no
+	// coincide, y el tercero se descarta con él aunque esté sano
+	primero := encabezadoRegistro + int(binary.BigEndian.Uint32(datos[0:4]))
+	datos[primero+encabezadoRegistro] ^= 0xFF
+	if err := os.WriteFile(ruta, datos, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := reproducir(t, ruta); len(got) != 1 || got[0] != "a" {
+		t.Fatalf("aplicadas = %v, se esperaba [a]", got)
+	}
+}
+
+func TestWALReproduceBorrados(t *testing.T) {
+	prefijo := filepath.Join(t.TempDir(), "DB1_wal")
+	w, err := abrirWAL(prefijo, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.agregar(&pb.OfertaRequest{OfertaId: "a"})
+	w.agregarBorrado("a")
+	if err := w.cerrar(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := reproducir(t, rutaSegmento(prefijo, 1)); len(got) != 2 || got[0] != "a" || got[1] != "-a" {
+		t.Fatalf("aplicadas = %v, se esperaba [a -a]", got)
+	}
+}
+
+func TestSnapshotDañadoSeRechaza(t *testing.T) {
+	ruta := filepath.Join(t.TempDir(), "DB1_ofertas.snap")
+	ofertas := map[string]*pb.OfertaRequest{
+		"a": {OfertaId: "a", PrecioDescuento: 1000},
+		"b": {OfertaId: "b", PrecioDescuento: 2000},
+	}
+	if err := escribirSnapshot(ruta, ofertas); err != nil {
+		t.Fatal(err)
+	}
+	leidas, err := leerSnapshot(ruta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(leidas) != 2 || leidas["b"].GetPrecioDescuento() != 2000 {
+		t.Fatalf("snapshot leído = %v", leidas)
+	}
+
+	datos, err := os.ReadFile(ruta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	casos := map[string][]byte{
+		"cortado": datos[:len(datos)-1],
+		"dañado":  append(append([]byte{}, datos[:len(datos)-1]...), datos[len(datos)-1]^0xFF),
+	}
+	for nombre, contenido := range casos {
+		if err := os.WriteFile(ruta, contenido, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := leerSnapshot(ruta); err == nil {
+			t.Errorf("snapshot %s: se esperaba un error", nombre)
+		}
+	}
+}
@@ -14,31 +14,28 @@ import (
 
 	pb "parisio_bd3/proto"
 
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 )
 
-
-var validCategorias = map[string]bool{
-	"Electrónica":        true,
-	"Moda":               true,
-	"Hogar":              true,
-	"Deportes":           true,
-	"Belleza":            true,
-	"Infantil":           true,
-	"Computación":        true,
-	"Electrodomésticos":  true,
-	"Herramientas":       true,
-	"Juguetes":           true,
-	"Automotriz":         true,
-	"Mascotas":           true,
-}
-
 type Productor struct {
 	nombre    string
 	catalogo  string
 	client    pb.OfertasClient
+	conn      *grpc.ClientConn
 	rand      *rand.Rand
+	
+	// Réplicas del broker, para failover
+	brokers      []string
+	brokerActual int
+	
+	// Taxonomía del broker: categoría tal como viene en el catálogo -> forma
+	// canónica ("" si no existe). Si es nil, las categorías las decide el broker.
+	categorias       map[string]string
+	versionTaxonomia int64
 }
 
 func NewProductor(nombre, catalogo string) *Productor {
@@ -49,17 +46,106 @@ func NewProductor(nombre, catalogo string) *Productor {
 	}
 }
 
+// conectarBroker acepta una o varias direcciones separadas por coma
+// (réplicas del broker) y se conecta a la primera.
 func (p *Productor) conectarBroker(brokerAddr string) error {
-	conn, err := grpc.Dial(brokerAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	p.brokers = strings.Split(brokerAddr, ",")
+	p.brokerActual = 0
+	return p.conectarA(p.brokers[p.brokerActual])
+}
+
+func (p *Productor) conectarA(addr string) error {
+	conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
 		return err
 	}
 	
+	if p.conn != nil {
+		p.conn.Close()
+	}
+	p.conn = conn
 	p.client = pb.NewOfertasClient(conn)
-	log.Printf("[%s] ✅ Conectado al broker", p.nombre)
+	log.Printf("[%s] ✅ Conectado al broker %s", p.nombre, addr)
+	return nil
+}
+
+// enviarOferta envía la oferta al broker actual y, si no responde, reintenta
+// con las demás réplicas. Reenviar la misma oferta_id es seguro porque el
+// broker descarta duplicados.
+func (p *Productor) enviarOferta(oferta *pb.OfertaRequest) (*pb.OfertaResponse, error) {
+	var err error
+	for intento := 0; intento < len(p.brokers); intento++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		var resp *pb.OfertaResponse
+		resp, err = p.client.EnviarOferta(ctx, oferta)
+		cancel()
+		if err == nil {
+			return resp, nil
+		}
+		
+		// Un rechazo por validación no se reintenta en otra réplica
+		if status.Code(err) == codes.InvalidArgument || len(p.brokers) == 1 {
+			break
+		}
+		log.Printf("[%s] ⚠️  Broker %s no disponible (%v), probando otra réplica", 
+			p.nombre, p.brokers[p.brokerActual], err)
+		p.brokerActual = (p.brokerActual + 1) % len(p.brokers)
+		if errConn := p.conectarA(p.brokers[p.brokerActual]); errConn != nil {
+			err = errConn
+		}
+	}
+	return nil, err
+}
+
+// cargarTaxonomia pide al broker sus categorías vigentes. Las escritas en
+// forma canónica se aceptan tal cual; el resto se resuelve con el broker al
+// aparecer en el catálogo, sin repetir aquí su normalización.
+func (p *Productor) cargarTaxonomia() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	
+	resp, err := pb.NewTaxonomiaClient(p.conn).ListarCategorias(ctx, &pb.ListarCategoriasRequest{})
+	if err != nil {
+		return err
+	}
+	
+	categorias := make(map[string]string)
+	for _, cat := range resp.GetCategorias() {
+		categorias[cat.GetNombre()] = cat.GetNombre()
+		for _, sub := range cat.GetSubcategorias() {
+			categorias[cat.GetNombre()+"/"+sub] = cat.GetNombre() + "/" + sub
+		}
+	}
+	p.categorias = categorias
+	p.versionTaxonomia = resp.GetVersion()
+	log.Printf("[%s] 📚 Taxonomía versión %d cargada (%d categorías)", 
+		p.nombre, resp.GetVersion(), len(resp.GetCategorias()))
 	return nil
 }
 
+// categoriaCanonica devuelve la forma canónica de una categoría del catálogo
+// y si existe en la taxonomía. Los alias y variantes se resuelven con el
+// broker una sola vez; si no responde, la categoría va tal cual y decide él.
+func (p *Productor) categoriaCanonica(categoria string) (string, bool) {
+	if p.categorias == nil {
+		return categoria, true
+	}
+	if canonica, ok := p.categorias[categoria]; ok {
+		return canonica, canonica != ""
+	}
+	
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	
+	resp, err := pb.NewTaxonomiaClient(p.conn).ResolverCategorias(ctx, &pb.ResolverCategoriasRequest{Categorias: []string{categoria}})
+	if err != nil || len(resp.GetCategorias()) != 1 {
+		return categoria, true
+	}
+	canonica := resp.GetCategorias()[0].GetCanonica()
+	p.categorias[categoria] = canonica
+	return canonica, canonica != ""
+}
+
 func (p *Productor) generarUUID() string {
 	// Generar UUID simple: timestamp + random
 	return fmt.Sprintf("%s-%d-%d", p.nombre, time.Now().UnixNano(), p.rand.Intn(999999))
@@ -68,10 +154,12 @@ func (p *Productor) generarUUID() string {
 func (p *Productor) validarYEnviarOferta(record []string) error {
 	// record: [producto_id, tienda, categoria, producto, precio_base, stock]
 	
-	// Validar categoría
-	categoria := record[2]
-	if !validCategorias[categoria] {
-		log.Printf("[%s] ⚠️  Categoría '%s' no válida, saltando", p.nombre, categoria)
+	// Validar categoría contra la taxonomía del broker; si no se pudo cargar,
+	// el broker la resuelve y rechaza con InvalidArgument si no existe
+	categoria, ok := p.categoriaCanonica(record[2])
+	if !ok {
+		log.Printf("[%s] ⚠️  Categoría '%s' no existe en la taxonomía (versión %d), saltando", 
+			p.nombre, record[2], p.versionTaxonomia)
 		return fmt.Errorf("categoría no válida")
 	}
 	
@@ -115,17 +203,19 @@ func (p *Productor) validarYEnviarOferta(record []string) error {
 		Categoria:       categoria,
 		Producto:        record[3],
 		PrecioDescuento: finalPrecio,
+		PrecioOriginal:  int32(originalPrecioBase),
 		Stock:           int32(stock),
 		Fecha:           formattedDate,
 		ClienteId:       p.nombre,
 		Timestamp:       time.Now().Unix(),
 	}
 	
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	
-	resp, err := p.client.EnviarOferta(ctx, oferta)
+	resp, err := p.enviarOferta(oferta)
 	if err != nil {
+		if st, ok := status.FromError(err); ok && st.Code() == codes.InvalidArgument {
+			p.registrarRechazo(record[0], st)
+			return err
+		}
 		log.Printf("[%s] ❌ Error enviando oferta %s: %v", p.nombre, record[0], err)
 		return err
 	}
@@ -140,6 +230,20 @@ func (p *Productor) validarYEnviarOferta(record []string) error {
 	return nil
 }
 
+// registrarRechazo muestra los códigos de rechazo que el broker adjunta al error
+func (p *Productor) registrarRechazo(productoID string, st *status.Status) {
+	for _, detalle := range st.Details() {
+		if badRequest, ok := detalle.(*errdetails.BadRequest); ok {
+			for _, v := range badRequest.GetFieldViolations() {
+				log.Printf("[%s] ⚠️  Oferta %s rechazada [%s] %s: %s", 
+					p.nombre, productoID, v.GetReason(), v.GetField(), v.GetDescription())
+			}
+			return
+		}
+	}
+	log.Printf("[%s] ⚠️  Oferta %s rechazada: %s", p.nombre, productoID, st.Message())
+}
+
 func (p *Productor) procesarCatalogo() error {
 	file, err := os.Open(p.catalogo)
 	if err != nil {
@@ -210,6 +314,7 @@ func main() {
     if brokerAddr == "" {
         brokerAddr = "broker:50051" // Mantenemos un default
     }
+	// Con réplicas: BROKER_ADDR="broker:50051,broker2:50051,broker3:50051"
 	
 	log.Printf("[PRODUCTOR] Iniciando %s", nombre)
 	log.Printf("[PRODUCTOR] Catálogo: %s", catalogo)
@@ -237,6 +342,11 @@ func main() {
 	// Esperar un poco antes de empezar a enviar
 	time.Sleep(5 * time.Second)
 	
+	// Obtener la taxonomía de categorías del broker
+	if err := productor.cargarTaxonomia(); err != nil {
+		log.Printf("[%s] ⚠️  No se pudo obtener la taxonomía (%v), el broker validará las categorías", nombre, err)
+	}
+	
 	// Procesar catálogo y enviar ofertas
 	if err := productor.procesarCatalogo(); err != nil {
 		log.Fatalf("[%s] Error procesando catálogo: %v", nombre, err)
@@ -2,20 +2,21 @@ package main
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"io"
 	"log"
 	"net"
 	"os"
+	"os/signal"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	pb "riploy_bd1_c2/proto"
 
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
 type DBNode struct {
@@ -23,28 +24,30 @@ type DBNode struct {
 	
 	nodoID          string
 	puerto          string
-	ofertas         map[string]*pb.OfertaRequest
-	ofertasMutex    sync.RWMutex
-	
-	peers           []string
-	peerClients     []pb.DynamoDBClient
-	peersMutex      sync.RWMutex
+	storage         Storage
+	ofertasMutex    sync.RWMutex     // serializa las lecturas y escrituras que dependen una de otra
+	resolvedor      resolvedor       // elige entre versiones concurrentes al leer
+	merkle          *arbolMerkle     // hashes por rango para la anti-entropía
+	membresia       *membresia       // miembros del clúster, descubiertos por gossip
+	cambios         *registroCambios // cambios numerados, para que los peers pidan solo lo nuevo
+	marcas          marcasPeers      // hasta dónde se replicó cada peer
+	rondasSync      int              // solo lo usa el bucle de sincronización
 	
 	activo          bool
 	estadoMutex     sync.RWMutex
-	
-	archivoPersistencia string
 }
 
-func NewDBNode(nodoID, puerto string, peers []string) *DBNode {
+func NewDBNode(nodoID, puerto, direccion string, semillas []string, storage Storage, resolvedor resolvedor) *DBNode {
 	return &DBNode{
-		nodoID:              nodoID,
-		puerto:              puerto,
-		ofertas:             make(map[string]*pb.OfertaRequest),
-		peers:               peers,
-		peerClients:         make([]pb.DynamoDBClient, len(peers)),
-		activo:              true,
-		archivoPersistencia: fmt.Sprintf("%s_ofertas.json", nodoID),
+		nodoID:      nodoID,
+		puerto:      puerto,
+		storage:     storage,
+		resolvedor:  resolvedor,
+		merkle:      &arbolMerkle{},
+		membresia:   nuevaMembresia(nodoID, direccion, semillas),
+		cambios:     nuevoRegistroCambios(),
+		marcas:      marcasPeers{porNodo: make(map[string]marcaPeer)},
+		activo:      true,
 	}
 }
 
@@ -64,12 +67,43 @@ func (db *DBNode) GuardarOferta(ctx context.Context, in *pb.OfertaRequest) (*pb.
 	ofertaID := in.GetOfertaId()
 	log.Printf("[%s] Guardando oferta %s", db.nodoID, ofertaID)
 	
+	// Las lápidas solo se crean con EliminarOferta y las confirmaciones las
+	// agrega cada nodo: no se aceptan desde quien escribe
+	in.Eliminada = false
+	in.ConfirmadaPor = nil
+	
 	db.ofertasMutex.Lock()
-	db.ofertas[ofertaID] = in
+	registro, ok, err := db.storage.Get(ofertaID)
+	if err == nil {
+		// La versión de stock la lleva este nodo, no quien escribe: un
+		// reenvío no debe deshacer el stock ya descontado, y una oferta que
+		// se vuelve a crear sobre su lápida debe quedar por encima de ella
+		in.VersionStock = 0
+		in.OperacionStock = ""
+		if existente := db.resolver(registro); ok && !existente.GetEliminada() {
+			in.Stock = existente.GetStock()
+			in.VersionStock = existente.GetVersionStock()
+			in.OperacionStock = existente.GetOperacionStock()
+		} else if ok {
+			in.VersionStock = existente.GetVersionStock() + 1
+		}
+		// La escritura reemplaza a todas las versiones locales
+		db.nuevaVersion(in, registro, in.GetRelojVectorial())
+		err = db.storage.Put(in)
+	}
+	if err == nil {
+		db.registrarEscritura(registro, in)
+	}
 	db.ofertasMutex.Unlock()
 	
-	if err := db.persistirOfertas(); err != nil {
+	// Sin la oferta en disco no se confirma: el broker cuenta este nodo como fallido
+	if err != nil {
 		log.Printf("[%s] Error persistiendo: %v", db.nodoID, err)
+		return &pb.AckResponse{
+			Exito:   false,
+			NodoId:  db.nodoID,
+			Mensaje: "Error persistiendo",
+		}, nil
 	}
 	
 	return &pb.AckResponse{
@@ -85,13 +119,26 @@ func (db *DBNode) LeerHistorico(ctx context.Context, in *pb.LeerHistoricoRequest
 	db.ofertasMutex.RLock()
 	defer db.ofertasMutex.RUnlock()
 	
-	ofertas := make([]*pb.OfertaRequest, 0, len(db.ofertas))
-	
-	for _, oferta := range db.ofertas {
-		if in.GetDesdeTimestamp() > 0 && oferta.GetTimestamp() < in.GetDesdeTimestamp() {
-			continue
+	// Entre nodos DB viajan las hermanas; al broker le llega cada oferta
+	// resuelta, lápidas incluidas (las descarta después de juntar las réplicas)
+	var ofertas []*pb.OfertaRequest
+	agregar := func(oferta *pb.OfertaRequest) bool {
+		if !in.GetConHermanas() {
+			oferta = db.resolver(oferta)
 		}
 		ofertas = append(ofertas, oferta)
+		return true
+	}
+	
+	var err error
+	if in.GetDesdeTimestamp() > 0 {
+		err = db.storage.RangoTimestamp(in.GetDesdeTimestamp(), 0, agregar)
+	} else {
+		err = db.storage.Scan(agregar)
+	}
+	if err != nil {
+		log.Printf("[%s] Error leyendo histórico: %v", db.nodoID, err)
+		return nil, status.Errorf(codes.Internal, "error leyendo ofertas: %v", err)
 	}
 	
 	log.Printf("[%s] Devolviendo %d ofertas", db.nodoID, len(ofertas))
@@ -105,20 +152,9 @@ func (db *DBNode) LeerHistorico(ctx context.Context, in *pb.LeerHistoricoRequest
 func (db *DBNode) Sincronizar(ctx context.Context, in *pb.SincronizarRequest) (*pb.SincronizarResponse, error) {
 	log.Printf("[%s] Recibiendo sincronización de %s", db.nodoID, in.GetNodoOrigen())
 	
-	ofertasSincronizadas := 0
-	
-	db.ofertasMutex.Lock()
-	for _, oferta := range in.GetOfertas() {
-		ofertaID := oferta.GetOfertaId()
-		if _, existe := db.ofertas[ofertaID]; !existe {
-			db.ofertas[ofertaID] = oferta
-			ofertasSincronizadas++
-		}
-	}
-	db.ofertasMutex.Unlock()
-	
-	if ofertasSincronizadas > 0 {
-		db.persistirOfertas()
+	ofertasSincronizadas, err := db.fusionarOfertas(in.GetOfertas())
+	if err != nil {
+		log.Printf("[%s] Error persistiendo: %v", db.nodoID, err)
 	}
 	
 	log.Printf("[%s] Sincronizadas %d ofertas nuevas", db.nodoID, ofertasSincronizadas)
@@ -129,97 +165,189 @@ func (db *DBNode) Sincronizar(ctx context.Context, in *pb.SincronizarRequest) (*
 	}, nil
 }
 
-func (db *DBNode) persistirOfertas() error {
+// LeerOferta devuelve la oferta guardada, con su stock y versión actuales.
+func (db *DBNode) LeerOferta(ctx context.Context, in *pb.LeerOfertaRequest) (*pb.LeerOfertaResponse, error) {
+	db.estadoMutex.RLock()
+	activo := db.activo
+	db.estadoMutex.RUnlock()
+	
+	if !activo {
+		return nil, status.Error(codes.Unavailable, "nodo inactivo")
+	}
+	
 	db.ofertasMutex.RLock()
-	defer db.ofertasMutex.RUnlock()
+	registro, existe, err := db.storage.Get(in.GetOfertaId())
+	db.ofertasMutex.RUnlock()
 	
-	file, err := os.Create(db.archivoPersistencia)
 	if err != nil {
-		return err
+		return nil, status.Errorf(codes.Internal, "error leyendo oferta: %v", err)
+	}
+	
+	// Una oferta eliminada no existe, pero se devuelve su lápida: el broker
+	// la necesita para decidir entre las réplicas
+	var oferta *pb.OfertaRequest
+	if existe {
+		oferta = db.resolver(registro)
 	}
-	defer file.Close()
 	
-	encoder := json.NewEncoder(file)
-	return encoder.Encode(db.ofertas)
+	return &pb.LeerOfertaResponse{
+		Existe: existe && !oferta.GetEliminada(),
+		Oferta: oferta,
+		NodoId: db.nodoID,
+	}, nil
 }
 
-func (db *DBNode) cargarOfertas() error {
-	file, err := os.Open(db.archivoPersistencia)
-	if err != nil {
-		if os.IsNotExist(err) {
-			log.Printf("[%s] No hay archivo de persistencia previo", db.nodoID)
-			return nil
-		}
-		return err
+// ActualizarStock es la escritura condicional del stock: solo se aplica si
+// trae una versión mayor que la guardada, o la misma versión y la misma
+// operación (un reintento del broker).
+func (db *DBNode) ActualizarStock(ctx context.Context, in *pb.ActualizarStockRequest) (*pb.ActualizarStockResponse, error) {
+	db.estadoMutex.RLock()
+	activo := db.activo
+	db.estadoMutex.RUnlock()
+	
+	if !activo {
+		return &pb.ActualizarStockResponse{
+			Exito:   false,
+			NodoId:  db.nodoID,
+			Mensaje: "Nodo inactivo",
+		}, nil
 	}
-	defer file.Close()
 	
-	decoder := json.NewDecoder(file)
+	ofertaID := in.GetOfertaId()
 	
 	db.ofertasMutex.Lock()
-	defer db.ofertasMutex.Unlock()
+	registro, existe, err := db.storage.Get(ofertaID)
+	if err != nil || !existe || db.resolver(registro).GetEliminada() {
+		db.ofertasMutex.Unlock()
+		mensaje := "Oferta no existe"
+		if err != nil {
+			log.Printf("[%s] Error leyendo oferta %s: %v", db.nodoID, ofertaID, err)
+			mensaje = "Error leyendo oferta"
+		}
+		return &pb.ActualizarStockResponse{
+			Exito:   false,
+			NodoId:  db.nodoID,
+			Mensaje: mensaje,
+		}, nil
+	}
 	
-	err = decoder.Decode(&db.ofertas)
-	if err != nil && err != io.EOF {
-		return err
+	// Con versiones concurrentes, la resuelta es la de version_stock más alta
+	oferta := db.resolver(registro)
+	version := oferta.GetVersionStock()
+	aplicar := version < in.GetVersion()
+	aceptada := aplicar || (version == in.GetVersion() && oferta.GetOperacionStock() == in.GetOperacionId())
+	if aplicar {
+		// Se guarda una copia: la oferta leída puede estar serializándose
+		// en una respuesta de LeerHistorico
+		actualizada := proto.Clone(oferta).(*pb.OfertaRequest)
+		actualizada.Stock = in.GetStock()
+		actualizada.VersionStock = in.GetVersion()
+		actualizada.OperacionStock = in.GetOperacionId()
+		db.nuevaVersion(actualizada, registro, in.GetRelojVectorial())
+		err = db.storage.Put(actualizada)
+		if err == nil {
+			db.registrarEscritura(registro, actualizada)
+			oferta = actualizada
+		}
+	}
+	resp := &pb.ActualizarStockResponse{
+		Exito:   aceptada,
+		NodoId:  db.nodoID,
+		Stock:   oferta.GetStock(),
+		Version: oferta.GetVersionStock(),
+		Mensaje: "ACK",
 	}
+	db.ofertasMutex.Unlock()
 	
-	log.Printf("[%s] Cargadas %d ofertas desde disco", db.nodoID, len(db.ofertas))
-	return nil
+	if err != nil {
+		log.Printf("[%s] Error persistiendo: %v", db.nodoID, err)
+		resp.Exito = false
+		resp.Mensaje = "Error persistiendo"
+		return resp, nil
+	}
+	
+	if !aceptada {
+		log.Printf("[%s] Stock de %s en versión %d, se rechaza la escritura v%d", db.nodoID, ofertaID, version, in.GetVersion())
+		resp.Mensaje = "Versión en conflicto"
+		return resp, nil
+	}
+	
+	if aplicar {
+		log.Printf("[%s] Stock de %s = %d (v%d)", db.nodoID, ofertaID, in.GetStock(), in.GetVersion())
+	}
+	return resp, nil
 }
 
-func (db *DBNode) conectarAPeers() {
-	db.peersMutex.Lock()
-	defer db.peersMutex.Unlock()
+// fusionarOfertas combina las ofertas de un peer (con sus hermanas) con las
+// locales según sus relojes vectoriales y devuelve cuántas cambiaron. Las
+// versiones concurrentes quedan guardadas como hermanas.
+func (db *DBNode) fusionarOfertas(recibidas []*pb.OfertaRequest) (int, error) {
+	db.ofertasMutex.Lock()
+	defer db.ofertasMutex.Unlock()
 	
-	for i, peerAddr := range db.peers {
-		conn, err := grpc.Dial(peerAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
-		if err != nil {
-			log.Printf("[%s] Error conectando a peer %s: %v", db.nodoID, peerAddr, err)
+	// Una oferta repetida en recibidas se fusiona sobre lo ya fusionado
+	anteriores := make(map[string]*pb.OfertaRequest)
+	fusionadas := make(map[string]*pb.OfertaRequest)
+	var orden []string
+	for _, oferta := range recibidas {
+		id := oferta.GetOfertaId()
+		local, visto := fusionadas[id]
+		if !visto {
+			var err error
+			local, _, err = db.storage.Get(id)
+			if err != nil {
+				return 0, err
+			}
+		}
+		versiones, cambio := fusionarVersiones(versionesDe(local), versionesDe(oferta))
+		if !cambio {
 			continue
 		}
-		
-		db.peerClients[i] = pb.NewDynamoDBClient(conn)
-		log.Printf("[%s] Conectado a peer %s", db.nodoID, peerAddr)
+		if len(versiones) > 1 {
+			log.Printf("[%s] Oferta %s con %d versiones concurrentes", db.nodoID, id, len(versiones))
+		}
+		if !visto {
+			anteriores[id] = local
+			orden = append(orden, id)
+		}
+		fusionadas[id] = db.empaquetar(versiones)
+		if fusionadas[id].GetEliminada() {
+			// Se confirma sobre una copia: empaquetar puede devolver la recibida
+			lapida := proto.Clone(fusionadas[id]).(*pb.OfertaRequest)
+			db.confirmarLapida(lapida)
+			fusionadas[id] = lapida
+		}
 	}
+	
+	nuevas := make([]*pb.OfertaRequest, 0, len(orden))
+	for _, id := range orden {
+		nuevas = append(nuevas, fusionadas[id])
+	}
+	if err := db.storage.Put(nuevas...); err != nil {
+		return 0, err
+	}
+	for _, nueva := range nuevas {
+		db.registrarEscritura(anteriores[nueva.GetOfertaId()], nueva)
+	}
+	return len(nuevas), nil
 }
 
+// sincronizarConPeers trae de cada miembro vivo los cambios desde su marca.
+// Cada rondasAntiEntropia rondas compara además los árboles de Merkle, por si
+// algo no llegó por el camino incremental.
 func (db *DBNode) sincronizarConPeers() {
-	db.ofertasMutex.RLock()
-	ofertas := make([]*pb.OfertaRequest, 0, len(db.ofertas))
-	for _, oferta := range db.ofertas {
-		ofertas = append(ofertas, oferta)
-	}
-	db.ofertasMutex.RUnlock()
-	
-	if len(ofertas) == 0 {
+	if !db.estaActivo() {
 		return
 	}
+	db.rondasSync++
+	completa := db.rondasSync%rondasAntiEntropia == 0
 	
-	db.peersMutex.RLock()
-	defer db.peersMutex.RUnlock()
-	
-	for i, peerClient := range db.peerClients {
-		if peerClient == nil {
-			continue
-		}
-		
-		go func(idx int, client pb.DynamoDBClient) {
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			defer cancel()
-			
-			resp, err := client.Sincronizar(ctx, &pb.SincronizarRequest{
-				NodoOrigen: db.nodoID,
-				Ofertas:    ofertas,
-			})
-			
-			if err != nil {
-				log.Printf("[%s] Error sincronizando con peer %d: %v", db.nodoID, idx, err)
-				return
+	for _, peer := range db.membresia.vivos() {
+		go func(peer miembro) {
+			if err := db.sincronizarConPeer(peer, completa); err != nil {
+				log.Printf("[%s] Error sincronizando con peer %s: %v", db.nodoID, peer.info.GetNodoId(), err)
 			}
-			
-			log.Printf("[%s] Peer %d sincronizó %d ofertas", db.nodoID, idx, resp.GetOfertasSincronizadas())
-		}(i, peerClient)
+		}(peer)
 	}
 }
 
@@ -242,45 +370,22 @@ func (db *DBNode) simularFallo(duracion time.Duration) {
 	db.solicitarSincronizacionDePeers()
 }
 
+// solicitarSincronizacionDePeers se pone al día con todos los peers que
+// respondan, trayendo de cada uno solo los cambios posteriores a su marca.
 func (db *DBNode) solicitarSincronizacionDePeers() {
-	db.peersMutex.RLock()
-	defer db.peersMutex.RUnlock()
-	
-	for i, peerClient := range db.peerClients {
-		if peerClient == nil {
-			continue
-		}
-		
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		
-		resp, err := peerClient.LeerHistorico(ctx, &pb.LeerHistoricoRequest{
-			NodoId:         db.nodoID,
-			DesdeTimestamp: 0,
-		})
-		cancel()
-		
-		if err != nil {
-			log.Printf("[%s] Error solicitando histórico de peer %d: %v", db.nodoID, i, err)
-			continue
-		}
-		
-		db.ofertasMutex.Lock()
-		nuevasOfertas := 0
-		for _, oferta := range resp.GetOfertas() {
-			if _, existe := db.ofertas[oferta.GetOfertaId()]; !existe {
-				db.ofertas[oferta.GetOfertaId()] = oferta
-				nuevasOfertas++
+	var wg sync.WaitGroup
+	for _, peer := range db.membresia.vivos() {
+		wg.Add(1)
+		go func(peer miembro) {
+			defer wg.Done()
+			if err := db.sincronizarConPeer(peer, false); err != nil {
+				log.Printf("[%s] Error resincronizando con peer %s: %v", db.nodoID, peer.info.GetNodoId(), err)
 			}
-		}
-		db.ofertasMutex.Unlock()
-		
-		if nuevasOfertas > 0 {
-			db.persistirOfertas()
-			log.Printf("[%s] Resincronizadas %d ofertas desde peer %d", db.nodoID, nuevasOfertas, i)
-		}
-		
-		break
+		}(peer)
 	}
+	wg.Wait()
+	
+	log.Printf("[%s] Resincronización terminada", db.nodoID)
 }
 
 func main() {
@@ -294,16 +399,26 @@ func main() {
 		puerto = ":50052"  // ← PUERTO DEFAULT PARA BD1
 	}
 	
-	//Leer peers desde una variable de entorno
+	// DIRECCION: cómo llegan los demás nodos a este (defecto: db1:50052, ...)
+	direccion := os.Getenv("DIRECCION")
+	if direccion == "" {
+		direccion = strings.ToLower(nodoID) + puerto
+	}
+	
+	// Semillas para unirse al clúster: SEMILLAS o, como antes, PEERS. Basta
+	// con una; el resto de los miembros se descubre por gossip
     // --- CORRECTED SECTION ---
-	peersStr := os.Getenv("PEERS") // Ej: "10.0.0.2:50053,10.0.0.3:50054"
+	peersStr := os.Getenv("SEMILLAS")
+	if peersStr == "" {
+		peersStr = os.Getenv("PEERS") // Ej: "10.0.0.2:50053,10.0.0.3:50054"
+	}
 	peers := []string{}
 	if peersStr != "" {
 		peers = strings.Split(peersStr, ",") // Use peersStr here
-		log.Printf("[%s] Peers configurados desde ENV: %v", nodoID, peers)
+		log.Printf("[%s] Semillas configuradas desde ENV: %v", nodoID, peers)
 	} else {
         // Fallback to default logic if PEERS env var is not set
-        log.Printf("[%s] Variable PEERS no encontrada, usando defaults...", nodoID)
+        log.Printf("[%s] Variables SEMILLAS y PEERS no encontradas, usando defaults...", nodoID)
 		if nodoID == "DB1" {
 			peers = []string{"db2:50053", "db3:50054"}
 		} else if nodoID == "DB2" {
@@ -314,16 +429,38 @@ func main() {
 	}
     // --- END CORRECTED SECTION ---
 	
-	dbNode := NewDBNode(nodoID, puerto, peers)
+	// MOTOR_ALMACENAMIENTO: memoria (defecto) o bbolt
+	motor := os.Getenv("MOTOR_ALMACENAMIENTO")
+	if motor == "" {
+		motor = motorMemoria
+	}
+	storage, err := abrirStorage(motor, nodoID)
+	if err != nil {
+		log.Fatalf("[%s] Error abriendo almacenamiento: %v", nodoID, err)
+	}
+	if cantidad, err := storage.Cantidad(); err == nil {
+		log.Printf("[%s] Almacenamiento %q con %d ofertas", nodoID, motor, cantidad)
+	}
 	
-	if err := dbNode.cargarOfertas(); err != nil {
-		log.Printf("[%s] Error cargando ofertas: %v", nodoID, err)
+	// RESOLUCION_CONFLICTOS: lww (defecto), menor_precio o mayor_stock
+	resolvedor, err := resolvedorPorNombre(os.Getenv("RESOLUCION_CONFLICTOS"))
+	if err != nil {
+		log.Fatalf("[%s] %v", nodoID, err)
 	}
 	
+	dbNode := NewDBNode(nodoID, puerto, direccion, peers, storage, resolvedor)
+	// Réplicas que alguna vez fueron miembros, para recolectar lápidas
+	if err := dbNode.membresia.cargarReplicas(nodoID + "_replicas.json"); err != nil {
+		log.Fatalf("[%s] Error cargando las réplicas conocidas: %v", nodoID, err)
+	}
+	if err := dbNode.construirMerkle(); err != nil {
+		log.Fatalf("[%s] Error armando el árbol de Merkle: %v", nodoID, err)
+	}
+	
+	go dbNode.bucleMembresia()
+	go dbNode.bucleLapidas()
+	
 	go func() {
-		time.Sleep(3 * time.Second)
-		dbNode.conectarAPeers()
-		
 		ticker := time.NewTicker(30 * time.Second)
 		defer ticker.Stop()
 		
@@ -345,7 +482,22 @@ func main() {
 	
 	log.Printf("[%s] Escuchando en %v", nodoID, lis.Addr())
 	
+	// Al detenerse avisa que sale del clúster, en vez de esperar a que lo
+	// declaren muerto. Con SIGUSR1 se retira para siempre: entrega sus
+	// ofertas y deja de contarse como réplica
+	go func() {
+		senales := make(chan os.Signal, 1)
+		signal.Notify(senales, syscall.SIGINT, syscall.SIGTERM, syscall.SIGUSR1)
+		if <-senales == syscall.SIGUSR1 {
+			dbNode.retirarse()
+		} else {
+			dbNode.membresia.salir(pb.EstadoMiembro_SALIO)
+		}
+		grpcServer.GracefulStop()
+	}()
+	
 	if err := grpcServer.Serve(lis); err != nil {
 		log.Fatalf("[%s] Error sirviendo: %v", nodoID, err)
 	}
+	storage.Close()
 }
\ No newline at end of file
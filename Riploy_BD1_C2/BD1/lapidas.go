@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	pb "riploy_bd1_c2/proto"
+)
+
+// Cada cuánto se buscan lápidas que ya tienen todas las réplicas
+const intervaloLapidas = time.Minute
+
+// Borrar una oferta del almacenamiento no alcanza: la sincronización la
+// traería de vuelta desde otra réplica. Se guarda en cambio una lápida, una
+// versión más de la oferta marcada como eliminada, que se replica y resuelve
+// como cualquier otra. Cada nodo que la guarda se anota en confirmada_por, y
+// la lápida viaja con esa lista; cuando la tienen todas las réplicas ya no
+// hay desde dónde resucitar la oferta y se borra de verdad.
+
+// EliminarOferta escribe la lápida de la oferta. El broker manda el reloj y
+// la versión de stock leídos por quórum, así la lápida reemplaza a todas las
+// versiones que vio.
+func (db *DBNode) EliminarOferta(ctx context.Context, in *pb.EliminarOfertaRequest) (*pb.AckResponse, error) {
+	if !db.estaActivo() {
+		return &pb.AckResponse{
+			Exito:   false,
+			NodoId:  db.nodoID,
+			Mensaje: "Nodo inactivo",
+		}, nil
+	}
+
+	ofertaID := in.GetOfertaId()
+	log.Printf("[%s] Eliminando oferta %s", db.nodoID, ofertaID)
+
+	db.ofertasMutex.Lock()
+	registro, existe, err := db.storage.Get(ofertaID)
+	if err == nil {
+		lapida := &pb.OfertaRequest{
+			OfertaId:     ofertaID,
+			Eliminada:    true,
+			VersionStock: in.GetVersionStock(),
+		}
+		if existe {
+			// La lápida queda en el mismo rango de timestamp que la oferta,
+			// para que las lecturas incrementales del histórico la vean, y
+			// gana a la versión actual aunque haya alguna concurrente
+			actual := db.resolver(registro)
+			lapida.Timestamp = actual.GetTimestamp()
+			if !actual.GetEliminada() && actual.GetVersionStock() >= lapida.GetVersionStock() {
+				lapida.VersionStock = actual.GetVersionStock() + 1
+			}
+		}
+		db.nuevaVersion(lapida, registro, in.GetRelojVectorial())
+		db.confirmarLapida(lapida)
+		if err = db.storage.Put(lapida); err == nil {
+			db.registrarEscritura(registro, lapida)
+		}
+	}
+	db.ofertasMutex.Unlock()
+
+	if err != nil {
+		log.Printf("[%s] Error persistiendo: %v", db.nodoID, err)
+		return &pb.AckResponse{
+			Exito:   false,
+			NodoId:  db.nodoID,
+			Mensaje: "Error persistiendo",
+		}, nil
+	}
+
+	return &pb.AckResponse{
+		Exito:   true,
+		NodoId:  db.nodoID,
+		Mensaje: "ACK",
+	}, nil
+}
+
+// confirmarLapida anota este nodo en la lápida que está por guardar.
+func (db *DBNode) confirmarLapida(registro *pb.OfertaRequest) {
+	if registro.GetEliminada() {
+		registro.ConfirmadaPor = unirNodos(registro.GetConfirmadaPor(), []string{db.nodoID})
+	}
+}
+
+// recolectable indica si el registro es solo una lápida que ya confirmaron
+// todas las réplicas.
+func recolectable(registro *pb.OfertaRequest, replicas []string) bool {
+	if !registro.GetEliminada() || len(registro.GetHermanas()) > 0 {
+		return false
+	}
+	confirmada := make(map[string]bool, len(registro.GetConfirmadaPor()))
+	for _, nodo := range registro.GetConfirmadaPor() {
+		confirmada[nodo] = true
+	}
+	for _, nodo := range replicas {
+		if !confirmada[nodo] {
+			return false
+		}
+	}
+	return true
+}
+
+// recolectarLapidas borra las lápidas que ya tienen todas las réplicas. No se
+// anotan en el registro de cambios: los demás nodos las borran por su cuenta.
+func (db *DBNode) recolectarLapidas() {
+	if !db.estaActivo() {
+		return
+	}
+	replicas := db.membresia.replicas()
+
+	db.ofertasMutex.Lock()
+	defer db.ofertasMutex.Unlock()
+
+	var lapidas []*pb.OfertaRequest
+	err := db.storage.Scan(func(registro *pb.OfertaRequest) bool {
+		if recolectable(registro, replicas) {
+			lapidas = append(lapidas, registro)
+		}
+		return true
+	})
+	if err != nil {
+		log.Printf("[%s] Error buscando lápidas: %v", db.nodoID, err)
+		return
+	}
+	if len(lapidas) == 0 {
+		return
+	}
+
+	ids := make([]string, len(lapidas))
+	for i, lapida := range lapidas {
+		ids[i] = lapida.GetOfertaId()
+	}
+	if err := db.storage.Delete(ids...); err != nil {
+		log.Printf("[%s] Error borrando lápidas: %v", db.nodoID, err)
+		return
+	}
+	for _, lapida := range lapidas {
+		db.merkle.actualizar(lapida, nil)
+	}
+	log.Printf("[%s] Recolectadas %d lápidas (réplicas: %v)", db.nodoID, len(lapidas), replicas)
+}
+
+func (db *DBNode) bucleLapidas() {
+	ticker := time.NewTicker(intervaloLapidas)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		db.recolectarLapidas()
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	pb "riploy_bd1_c2/proto"
+)
+
+func TestNodoRetiradoDejaDeSerReplica(t *testing.T) {
+	archivo := filepath.Join(t.TempDir(), "DB1_replicas.json")
+	m := nuevaMembresia("DB1", "db1:50052", nil)
+	if err := m.cargarReplicas(archivo); err != nil {
+		t.Fatal(err)
+	}
+	m.recibir([]*pb.Miembro{
+		{NodoId: "DB2", Direccion: "db2:50053", Encarnacion: 1},
+		{NodoId: "DB3", Direccion: "db3:50054", Encarnacion: 1},
+	})
+
+	// Detenido puede volver con sus datos: sigue siendo réplica
+	m.recibir([]*pb.Miembro{{NodoId: "DB2", Direccion: "db2:50053", Encarnacion: 1, Estado: pb.EstadoMiembro_SALIO}})
+	m.recibir([]*pb.Miembro{{NodoId: "DB3", Direccion: "db3:50054", Encarnacion: 1, Estado: pb.EstadoMiembro_RETIRADO}})
+	if got, want := m.replicas(), []string{"DB1", "DB2"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("réplicas = %v, se esperaba %v", got, want)
+	}
+
+	// La baja queda en disco
+	reiniciado := nuevaMembresia("DB1", "db1:50052", nil)
+	if err := reiniciado.cargarReplicas(archivo); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := reiniciado.replicas(), []string{"DB1", "DB2"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("réplicas tras reiniciar = %v, se esperaba %v", got, want)
+	}
+
+	// Si vuelve con una encarnación mayor, se cuenta de nuevo
+	m.recibir([]*pb.Miembro{{NodoId: "DB3", Direccion: "db3:50054", Encarnacion: 2}})
+	if got, want := m.replicas(), []string{"DB1", "DB2", "DB3"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("réplicas = %v, se esperaba %v", got, want)
+	}
+}
+
+func estadoDe(m *membresia, nodoID string) pb.EstadoMiembro {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.miembros[nodoID].info.GetEstado()
+}
+
+func TestSospechosoQuedaMuerto(t *testing.T) {
+	m := nuevaMembresia("DB1", "db1:50052", nil)
+	// Nada escucha en esa dirección: el ping falla y no hay ayudantes
+	m.recibir([]*pb.Miembro{{NodoId: "DB2", Direccion: "127.0.0.1:1", Encarnacion: 1}})
+
+	m.sondear()
+	if got := estadoDe(m, "DB2"); got != pb.EstadoMiembro_SOSPECHOSO {
+		t.Fatalf("estado tras el ping fallido = %s, se esperaba SOSPECHOSO", got)
+	}
+
+	// Antes de tiempoSospecha sigue sospechoso
+	m.vencerSospechas()
+	if got := estadoDe(m, "DB2"); got != pb.EstadoMiembro_SOSPECHOSO {
+		t.Fatalf("estado antes de vencer = %s, se esperaba SOSPECHOSO", got)
+	}
+
+	m.mu.Lock()
+	m.miembros["DB2"].sospechosoDesde = time.Now().Add(-tiempoSospecha)
+	m.mu.Unlock()
+	m.vencerSospechas()
+	if got := estadoDe(m, "DB2"); got != pb.EstadoMiembro_MUERTO {
+		t.Fatalf("estado tras vencer = %s, se esperaba MUERTO", got)
+	}
+
+	// Sigue siendo réplica: puede volver con sus datos
+	if got, want := m.replicas(), []string{"DB1", "DB2"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("réplicas = %v, se esperaba %v", got, want)
+	}
+}
+
+func TestSospechosoDesmentido(t *testing.T) {
+	m := nuevaMembresia("DB1", "db1:50052", nil)
+	m.recibir([]*pb.Miembro{{NodoId: "DB2", Direccion: "db2:50053", Encarnacion: 1, Estado: pb.EstadoMiembro_SOSPECHOSO}})
+
+	// El desmentido trae una encarnación mayor y anula la sospecha
+	m.recibir([]*pb.Miembro{{NodoId: "DB2", Direccion: "db2:50053", Encarnacion: 2}})
+	m.mu.Lock()
+	m.miembros["DB2"].sospechosoDesde = time.Now().Add(-tiempoSospecha)
+	m.mu.Unlock()
+	m.vencerSospechas()
+	if got := estadoDe(m, "DB2"); got != pb.EstadoMiembro_VIVO {
+		t.Fatalf("estado = %s, se esperaba VIVO", got)
+	}
+
+	// Y este nodo desmiente lo que digan de él
+	encarnacion := m.yo.GetEncarnacion()
+	m.recibir([]*pb.Miembro{{NodoId: "DB1", Direccion: "db1:50052", Encarnacion: encarnacion, Estado: pb.EstadoMiembro_SOSPECHOSO}})
+	if m.yo.GetEncarnacion() <= encarnacion || m.yo.GetEstado() != pb.EstadoMiembro_VIVO {
+		t.Fatalf("yo = %v, se esperaba VIVO con encarnación mayor a %d", m.yo, encarnacion)
+	}
+}
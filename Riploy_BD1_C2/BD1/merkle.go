@@ -0,0 +1,307 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"log"
+	"time"
+
+	pb "riploy_bd1_c2/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	nivelesMerkle = 8                  // profundidad del árbol
+	rangosMerkle  = 1 << nivelesMerkle // hojas: rangos del hash de oferta_id
+	saltoMerkle   = 4                  // niveles que se bajan por cada ronda de comparación
+)
+
+// arbolMerkle resume las ofertas guardadas por rango de oferta_id. Cada hoja
+// es el XOR de los hashes de los registros de su rango, así una escritura la
+// actualiza sin recorrer el rango; los nodos internos se calculan al pedirlos.
+// Lo protege el ofertasMutex del DBNode, igual que al Storage.
+type arbolMerkle struct {
+	hojas   [rangosMerkle][sha256.Size]byte
+	tamanos [rangosMerkle]int // bytes serializados de cada rango
+}
+
+// rangoDe ubica la oferta en una hoja según el hash de su ID, para que los
+// rangos queden parejos aunque los IDs tengan prefijos comunes.
+func rangoDe(ofertaID string) int {
+	hash := sha256.Sum256([]byte(ofertaID))
+	return int(hash[0])
+}
+
+// hashRegistro cubre el registro completo (hermanas y relojes incluidos): dos
+// nodos con distintas versiones de una oferta difieren en su rango.
+func hashRegistro(registro *pb.OfertaRequest) ([sha256.Size]byte, int) {
+	datos, _ := proto.MarshalOptions{Deterministic: true}.Marshal(registro)
+	return sha256.Sum256(datos), len(datos)
+}
+
+// actualizar reemplaza en el árbol el registro anterior (nil si no había) por el nuevo.
+func (a *arbolMerkle) actualizar(anterior, nuevo *pb.OfertaRequest) {
+	a.aplicar(anterior, -1)
+	a.aplicar(nuevo, 1)
+}
+
+// aplicar agrega (signo 1) o quita (signo -1) un registro de su hoja; como el
+// XOR es su propio inverso, solo cambia la cuenta de bytes.
+func (a *arbolMerkle) aplicar(registro *pb.OfertaRequest, signo int) {
+	if registro == nil {
+		return
+	}
+	rango := rangoDe(registro.GetOfertaId())
+	hash, tamano := hashRegistro(registro)
+	for i := range hash {
+		a.hojas[rango][i] ^= hash[i]
+	}
+	a.tamanos[rango] += signo * tamano
+}
+
+// hashes devuelve los hashes de las posiciones pedidas de un nivel.
+func (a *arbolMerkle) hashes(nivel int, posiciones []int32) ([][]byte, error) {
+	if nivel < 0 || nivel > nivelesMerkle {
+		return nil, fmt.Errorf("nivel %d fuera del árbol (0 a %d)", nivel, nivelesMerkle)
+	}
+
+	actual := a.hojas[:]
+	for n := nivelesMerkle; n > nivel; n-- {
+		superior := make([][sha256.Size]byte, len(actual)/2)
+		for i := range superior {
+			superior[i] = sha256.Sum256(append(actual[2*i][:], actual[2*i+1][:]...))
+		}
+		actual = superior
+	}
+
+	hashes := make([][]byte, len(posiciones))
+	for i, posicion := range posiciones {
+		if posicion < 0 || int(posicion) >= len(actual) {
+			return nil, fmt.Errorf("posición %d fuera del nivel %d", posicion, nivel)
+		}
+		hashes[i] = append([]byte(nil), actual[posicion][:]...)
+	}
+	return hashes, nil
+}
+
+func (a *arbolMerkle) bytesTotales() int {
+	total := 0
+	for _, tamano := range a.tamanos {
+		total += tamano
+	}
+	return total
+}
+
+// construirMerkle arma el árbol con las ofertas que ya están en el Storage.
+func (db *DBNode) construirMerkle() error {
+	db.ofertasMutex.Lock()
+	defer db.ofertasMutex.Unlock()
+
+	db.merkle = &arbolMerkle{}
+	return db.storage.Scan(func(registro *pb.OfertaRequest) bool {
+		db.merkle.actualizar(nil, registro)
+		return true
+	})
+}
+
+func (db *DBNode) HashesMerkle(ctx context.Context, in *pb.HashesMerkleRequest) (*pb.HashesMerkleResponse, error) {
+	db.estadoMutex.RLock()
+	activo := db.activo
+	db.estadoMutex.RUnlock()
+
+	if !activo {
+		return nil, status.Error(codes.Unavailable, "nodo inactivo")
+	}
+
+	db.ofertasMutex.RLock()
+	hashes, err := db.merkle.hashes(int(in.GetNivel()), in.GetPosiciones())
+	db.ofertasMutex.RUnlock()
+
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return &pb.HashesMerkleResponse{
+		Hashes: hashes,
+		NodoId: db.nodoID,
+	}, nil
+}
+
+func (db *DBNode) LeerRangos(ctx context.Context, in *pb.LeerRangosRequest) (*pb.HistoricoResponse, error) {
+	db.estadoMutex.RLock()
+	activo := db.activo
+	db.estadoMutex.RUnlock()
+
+	if !activo {
+		return nil, status.Error(codes.Unavailable, "nodo inactivo")
+	}
+
+	ofertas, err := db.leerRangos(in.GetRangos())
+	if err != nil {
+		log.Printf("[%s] Error leyendo rangos: %v", db.nodoID, err)
+		return nil, status.Errorf(codes.Internal, "error leyendo ofertas: %v", err)
+	}
+
+	log.Printf("[%s] Devolviendo %d ofertas de %d rangos a %s", db.nodoID, len(ofertas), len(in.GetRangos()), in.GetNodoOrigen())
+
+	return &pb.HistoricoResponse{
+		Ofertas: ofertas,
+		NodoId:  db.nodoID,
+	}, nil
+}
+
+// leerRangos devuelve los registros guardados (con sus hermanas) de los rangos pedidos.
+func (db *DBNode) leerRangos(rangos []int32) ([]*pb.OfertaRequest, error) {
+	pedidos := make(map[int]bool, len(rangos))
+	for _, rango := range rangos {
+		pedidos[int(rango)] = true
+	}
+
+	db.ofertasMutex.RLock()
+	defer db.ofertasMutex.RUnlock()
+
+	var ofertas []*pb.OfertaRequest
+	err := db.storage.Scan(func(registro *pb.OfertaRequest) bool {
+		if pedidos[rangoDe(registro.GetOfertaId())] {
+			ofertas = append(ofertas, registro)
+		}
+		return true
+	})
+	return ofertas, err
+}
+
+func (db *DBNode) hashesLocales(nivel int, posiciones []int32) ([][]byte, error) {
+	db.ofertasMutex.RLock()
+	defer db.ofertasMutex.RUnlock()
+	return db.merkle.hashes(nivel, posiciones)
+}
+
+// antiEntropia compara el árbol local con el del peer bajando solo por los
+// subárboles distintos, y después intercambia en ambos sentidos las ofertas
+// de los rangos que no coinciden. Registra los bytes transferidos y la
+// duración frente a lo que costaría enviar el estado completo.
+func (db *DBNode) antiEntropia(peer string, client pb.DynamoDBClient) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	inicio := time.Now()
+	transferidos := 0
+	rondas := 0
+
+	nivel := 0
+	posiciones := []int32{0}
+	for {
+		req := &pb.HashesMerkleRequest{
+			NodoOrigen: db.nodoID,
+			Nivel:      int32(nivel),
+			Posiciones: posiciones,
+		}
+		resp, err := client.HashesMerkle(ctx, req)
+		if err != nil {
+			return err
+		}
+		rondas++
+		transferidos += proto.Size(req) + proto.Size(resp)
+
+		locales, err := db.hashesLocales(nivel, posiciones)
+		if err != nil {
+			return err
+		}
+		if len(resp.GetHashes()) != len(posiciones) {
+			return fmt.Errorf("%s devolvió %d hashes para %d posiciones", peer, len(resp.GetHashes()), len(posiciones))
+		}
+
+		var distintas []int32
+		for i, posicion := range posiciones {
+			if !bytes.Equal(locales[i], resp.GetHashes()[i]) {
+				distintas = append(distintas, posicion)
+			}
+		}
+
+		if len(distintas) == 0 {
+			db.registrarAntiEntropia(peer, 0, 0, 0, rondas, transferidos, time.Since(inicio))
+			return nil
+		}
+		if nivel == nivelesMerkle {
+			posiciones = distintas
+			break
+		}
+
+		// Bajar hasta saltoMerkle niveles por debajo de cada nodo distinto
+		salto := min(saltoMerkle, nivelesMerkle-nivel)
+		posiciones = make([]int32, 0, len(distintas)<<salto)
+		for _, posicion := range distintas {
+			for hijo := int32(0); hijo < 1<<salto; hijo++ {
+				posiciones = append(posiciones, posicion<<salto+hijo)
+			}
+		}
+		nivel += salto
+	}
+
+	// Traer las ofertas del peer en los rangos distintos
+	reqRangos := &pb.LeerRangosRequest{
+		NodoOrigen: db.nodoID,
+		Rangos:     posiciones,
+	}
+	respRangos, err := client.LeerRangos(ctx, reqRangos)
+	if err != nil {
+		return err
+	}
+	transferidos += proto.Size(reqRangos) + proto.Size(respRangos)
+
+	recibidas, err := db.fusionarOfertas(respRangos.GetOfertas())
+	if err != nil {
+		return err
+	}
+
+	// Y enviarle las locales de esos rangos, que ya incluyen lo recibido
+	propias, err := db.leerRangos(posiciones)
+	if err != nil {
+		return err
+	}
+	enviadas := 0
+	if len(propias) > 0 {
+		reqSync := &pb.SincronizarRequest{
+			NodoOrigen: db.nodoID,
+			Ofertas:    propias,
+		}
+		respSync, err := client.Sincronizar(ctx, reqSync)
+		if err != nil {
+			return err
+		}
+		transferidos += proto.Size(reqSync) + proto.Size(respSync)
+		enviadas = int(respSync.GetOfertasSincronizadas())
+	}
+
+	db.registrarAntiEntropia(peer, len(posiciones), recibidas, enviadas, rondas, transferidos, time.Since(inicio))
+	return nil
+}
+
+func (db *DBNode) registrarAntiEntropia(peer string, rangos, recibidas, enviadas, rondas, transferidos int, duracion time.Duration) {
+	db.ofertasMutex.RLock()
+	completo := db.merkle.bytesTotales()
+	db.ofertasMutex.RUnlock()
+
+	if rangos == 0 {
+		log.Printf("[%s] Anti-entropía con %s: en sincronía (rondas: %d, %s en %v; estado completo %s)",
+			db.nodoID, peer, rondas, formatearBytes(transferidos), duracion.Round(time.Millisecond), formatearBytes(completo))
+		return
+	}
+	log.Printf("[%s] Anti-entropía con %s: %d de %d rangos distintos, %d ofertas actualizadas aquí y %d en el peer (rondas: %d, %s en %v; estado completo %s)",
+		db.nodoID, peer, rangos, rangosMerkle, recibidas, enviadas, rondas, formatearBytes(transferidos), duracion.Round(time.Millisecond), formatearBytes(completo))
+}
+
+func formatearBytes(n int) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
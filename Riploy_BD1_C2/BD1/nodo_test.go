@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/grpc"
+	pb "riploy_bd1_c2/proto"
+)
+
+// nodoPrueba arma un DBNode con almacenamiento bbolt en un directorio
+// temporal y las réplicas dadas como conocidas.
+func nodoPrueba(t *testing.T, nodoID string, replicas ...string) *DBNode {
+	t.Helper()
+	storage, err := abrirStorageBbolt(filepath.Join(t.TempDir(), nodoID+".db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { storage.Close() })
+
+	resolvedor, err := resolvedorPorNombre(resolucionUltimaEscritura)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := NewDBNode(nodoID, "0", "", nil, storage, resolvedor)
+	for _, replica := range replicas {
+		db.membresia.conocidos[replica] = true
+	}
+	return db
+}
+
+// leerRegistro devuelve el registro guardado tal cual, con hermanas.
+func leerRegistro(t *testing.T, db *DBNode, ofertaID string) (*pb.OfertaRequest, bool) {
+	t.Helper()
+	registro, existe, err := db.storage.Get(ofertaID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return registro, existe
+}
+
+// clienteLocal atiende las llamadas de anti-entropía con otro DBNode en el
+// mismo proceso, sin red. Anota los rangos que se le piden.
+type clienteLocal struct {
+	pb.DynamoDBClient
+	db      *DBNode
+	pedidos []int32
+}
+
+func (c *clienteLocal) HashesMerkle(ctx context.Context, in *pb.HashesMerkleRequest, _ ...grpc.CallOption) (*pb.HashesMerkleResponse, error) {
+	return c.db.HashesMerkle(ctx, in)
+}
+
+func (c *clienteLocal) LeerRangos(ctx context.Context, in *pb.LeerRangosRequest, _ ...grpc.CallOption) (*pb.HistoricoResponse, error) {
+	c.pedidos = append(c.pedidos, in.GetRangos()...)
+	return c.db.LeerRangos(ctx, in)
+}
+
+func (c *clienteLocal) Sincronizar(ctx context.Context, in *pb.SincronizarRequest, _ ...grpc.CallOption) (*pb.SincronizarResponse, error) {
+	return c.db.Sincronizar(ctx, in)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+
+	pb "riploy_bd1_c2/proto"
+)
+
+// Storage es el motor donde un nodo DB guarda sus ofertas. Las
+// implementaciones son seguras para uso concurrente; las lecturas y
+// escrituras que dependen una de otra (stock, sincronización) las serializa
+// el DBNode con ofertasMutex.
+type Storage interface {
+	// Get devuelve la oferta guardada. La oferta devuelta no se debe
+	// modificar: para cambiarla se guarda una copia con Put.
+	Get(ofertaID string) (*pb.OfertaRequest, bool, error)
+
+	// Put guarda las ofertas reemplazando las que tengan el mismo ID. Cuando
+	// devuelve nil las ofertas ya están en disco.
+	Put(ofertas ...*pb.OfertaRequest) error
+
+	// Delete borra las ofertas (se usa al recolectar lápidas). Los IDs que no
+	// existen se ignoran.
+	Delete(ofertaIDs ...string) error
+
+	// Scan recorre todas las ofertas hasta que fn devuelve false. fn no debe
+	// llamar al mismo Storage.
+	Scan(fn func(*pb.OfertaRequest) bool) error
+
+	// RangoTimestamp recorre las ofertas con desde <= timestamp <= hasta
+	// (hasta = 0 es sin límite superior).
+	RangoTimestamp(desde, hasta int64, fn func(*pb.OfertaRequest) bool) error
+
+	Cantidad() (int, error)
+	Close() error
+}
+
+// Motores disponibles en MOTOR_ALMACENAMIENTO
+const (
+	motorMemoria = "memoria" // mapa en memoria con WAL y snapshots (defecto)
+	motorBbolt   = "bbolt"   // base clave-valor embebida en disco
+)
+
+// abrirStorage abre el motor configurado con los archivos del nodo en el
+// directorio de trabajo.
+func abrirStorage(motor, nodoID string) (Storage, error) {
+	switch motor {
+	case "", motorMemoria:
+		return abrirStorageMemoria(nodoID)
+	case motorBbolt:
+		return abrirStorageBbolt(fmt.Sprintf("%s_ofertas.db", nodoID))
+	default:
+		return nil, fmt.Errorf("motor de almacenamiento desconocido %q (usar %s o %s)", motor, motorMemoria, motorBbolt)
+	}
+}
+
+// enRango indica si el timestamp cae en [desde, hasta] (hasta = 0 es sin límite).
+func enRango(timestamp, desde, hasta int64) bool {
+	return timestamp >= desde && (hasta == 0 || timestamp <= hasta)
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/binary"
+	"time"
+
+	pb "riploy_bd1_c2/proto"
+
+	bolt "go.etcd.io/bbolt"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	bucketOfertas      = []byte("ofertas")       // oferta_id -> OfertaRequest serializada
+	bucketPorTimestamp = []byte("por_timestamp") // timestamp + oferta_id -> vacío
+)
+
+// storageBbolt guarda las ofertas en un archivo bbolt (<nodo>_ofertas.db).
+// Solo se lee del disco lo que se pide, así que el nodo no necesita tener
+// todas las ofertas en memoria ni cargarlas al iniciar. Cada Put es una
+// transacción con fsync.
+type storageBbolt struct {
+	bd *bolt.DB
+}
+
+func abrirStorageBbolt(ruta string) (*storageBbolt, error) {
+	bd, err := bolt.Open(ruta, 0644, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = bd.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(bucketOfertas); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(bucketPorTimestamp)
+		return err
+	})
+	if err != nil {
+		bd.Close()
+		return nil, err
+	}
+	return &storageBbolt{bd: bd}, nil
+}
+
+// claveTimestamp ordena el índice por timestamp (con el bit de signo
+// invertido, para que los negativos queden antes) y luego por oferta_id.
+func claveTimestamp(timestamp int64, ofertaID string) []byte {
+	clave := make([]byte, 8+len(ofertaID))
+	binary.BigEndian.PutUint64(clave, uint64(timestamp)^(1<<63))
+	copy(clave[8:], ofertaID)
+	return clave
+}
+
+func timestampDeClave(clave []byte) int64 {
+	return int64(binary.BigEndian.Uint64(clave[:8]) ^ (1 << 63))
+}
+
+func decodificarOferta(datos []byte) (*pb.OfertaRequest, error) {
+	oferta := &pb.OfertaRequest{}
+	if err := proto.Unmarshal(datos, oferta); err != nil {
+		return nil, err
+	}
+	return oferta, nil
+}
+
+func (s *storageBbolt) Get(ofertaID string) (*pb.OfertaRequest, bool, error) {
+	var oferta *pb.OfertaRequest
+	err := s.bd.View(func(tx *bolt.Tx) error {
+		datos := tx.Bucket(bucketOfertas).Get([]byte(ofertaID))
+		if datos == nil {
+			return nil
+		}
+		var err error
+		oferta, err = decodificarOferta(datos)
+		return err
+	})
+	return oferta, oferta != nil, err
+}
+
+func (s *storageBbolt) Put(ofertas ...*pb.OfertaRequest) error {
+	if len(ofertas) == 0 {
+		return nil
+	}
+	return s.bd.Update(func(tx *bolt.Tx) error {
+		porID := tx.Bucket(bucketOfertas)
+		porTimestamp := tx.Bucket(bucketPorTimestamp)
+
+		for _, oferta := range ofertas {
+			id := []byte(oferta.GetOfertaId())
+			datos, err := proto.Marshal(oferta)
+			if err != nil {
+				return err
+			}
+
+			// Si la oferta cambió de timestamp, sacar la entrada vieja del índice
+			if anterior := porID.Get(id); anterior != nil {
+				vieja, err := decodificarOferta(anterior)
+				if err != nil {
+					return err
+				}
+				if vieja.GetTimestamp() != oferta.GetTimestamp() {
+					if err := porTimestamp.Delete(claveTimestamp(vieja.GetTimestamp(), oferta.GetOfertaId())); err != nil {
+						return err
+					}
+				}
+			}
+
+			if err := porID.Put(id, datos); err != nil {
+				return err
+			}
+			if err := porTimestamp.Put(claveTimestamp(oferta.GetTimestamp(), oferta.GetOfertaId()), nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *storageBbolt) Delete(ofertaIDs ...string) error {
+	if len(ofertaIDs) == 0 {
+		return nil
+	}
+	return s.bd.Update(func(tx *bolt.Tx) error {
+		porID := tx.Bucket(bucketOfertas)
+		porTimestamp := tx.Bucket(bucketPorTimestamp)
+
+		for _, ofertaID := range ofertaIDs {
+			datos := porID.Get([]byte(ofertaID))
+			if datos == nil {
+				continue
+			}
+			oferta, err := decodificarOferta(datos)
+			if err != nil {
+				return err
+			}
+			if err := porTimestamp.Delete(claveTimestamp(oferta.GetTimestamp(), ofertaID)); err != nil {
+				return err
+			}
+			if err := porID.Delete([]byte(ofertaID)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *storageBbolt) Scan(fn func(*pb.OfertaRequest) bool) error {
+	return s.bd.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(bucketOfertas).Cursor()
+		for clave, datos := cursor.First(); clave != nil; clave, datos = cursor.Next() {
+			oferta, err := decodificarOferta(datos)
+			if err != nil {
+				return err
+			}
+			if !fn(oferta) {
+				break
+			}
+		}
+		return nil
+	})
+}
+
+// RangoTimestamp recorre el índice, así que solo lee las ofertas del rango
+// y las entrega ordenadas por timestamp.
+func (s *storageBbolt) RangoTimestamp(desde, hasta int64, fn func(*pb.OfertaRequest) bool) error {
+	return s.bd.View(func(tx *bolt.Tx) error {
+		porID := tx.Bucket(bucketOfertas)
+		cursor := tx.Bucket(bucketPorTimestamp).Cursor()
+
+		for clave, _ := cursor.Seek(claveTimestamp(desde, "")); clave != nil; clave, _ = cursor.Next() {
+			if hasta != 0 && timestampDeClave(clave) > hasta {
+				break
+			}
+			datos := porID.Get(clave[8:])
+			if datos == nil {
+				continue
+			}
+			oferta, err := decodificarOferta(datos)
+			if err != nil {
+				return err
+			}
+			if !fn(oferta) {
+				break
+			}
+		}
+		return nil
+	})
+}
+
+func (s *storageBbolt) Cantidad() (int, error) {
+	cantidad := 0
+	err := s.bd.View(func(tx *bolt.Tx) error {
+		cantidad = tx.Bucket(bucketOfertas).Stats().KeyN
+		return nil
+	})
+	return cantidad, err
+}
+
+func (s *storageBbolt) Close() error {
+	return s.bd.Close()
+}
@@ -19,7 +19,8 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	Ofertas_EnviarOferta_FullMethodName = "/Ofertas/EnviarOferta"
+	Ofertas_EnviarOferta_FullMethodName   = "/Ofertas/EnviarOferta"
+	Ofertas_EliminarOferta_FullMethodName = "/Ofertas/EliminarOferta"
 )
 
 // OfertasClient is the client API for Ofertas service.
@@ -29,6 +30,7 @@ const (
 // Servicio para productores -> broker
 type OfertasClient interface {
 	EnviarOferta(ctx context.Context, in *OfertaRequest, opts ...grpc.CallOption) (*OfertaResponse, error)
+	EliminarOferta(ctx context.Context, in *EliminarOfertaRequest, opts ...grpc.CallOption) (*EliminarOfertaResponse, error)
 }
 
 type ofertasClient struct {
@@ -49,6 +51,16 @@ func (c *ofertasClient) EnviarOferta(ctx context.Context, in *OfertaRequest, opt
 	return out, nil
 }
 
+func (c *ofertasClient) EliminarOferta(ctx context.Context, in *EliminarOfertaRequest, opts ...grpc.CallOption) (*EliminarOfertaResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EliminarOfertaResponse)
+	err := c.cc.Invoke(ctx, Ofertas_EliminarOferta_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // OfertasServer is the server API for Ofertas service.
 // All implementations must embed UnimplementedOfertasServer
 // for forward compatibility.
@@ -56,6 +68,7 @@ func (c *ofertasClient) EnviarOferta(ctx context.Context, in *OfertaRequest, opt
 // Servicio para productores -> broker
 type OfertasServer interface {
 	EnviarOferta(context.Context, *OfertaRequest) (*OfertaResponse, error)
+	EliminarOferta(context.Context, *EliminarOfertaRequest) (*EliminarOfertaResponse, error)
 	mustEmbedUnimplementedOfertasServer()
 }
 
@@ -69,6 +82,9 @@ type UnimplementedOfertasServer struct{}
 func (UnimplementedOfertasServer) EnviarOferta(context.Context, *OfertaRequest) (*OfertaResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method EnviarOferta not implemented")
 }
+func (UnimplementedOfertasServer) EliminarOferta(context.Context, *EliminarOfertaRequest) (*EliminarOfertaResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EliminarOferta not implemented")
+}
 func (UnimplementedOfertasServer) mustEmbedUnimplementedOfertasServer() {}
 func (UnimplementedOfertasServer) testEmbeddedByValue()                 {}
 
@@ -108,6 +124,24 @@ func _Ofertas_EnviarOferta_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Ofertas_EliminarOferta_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EliminarOfertaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OfertasServer).EliminarOferta(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Ofertas_EliminarOferta_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OfertasServer).EliminarOferta(ctx, req.(*EliminarOfertaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Ofertas_ServiceDesc is the grpc.ServiceDesc for Ofertas service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -119,15 +153,29 @@ var Ofertas_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "EnviarOferta",
 			Handler:    _Ofertas_EnviarOferta_Handler,
 		},
+		{
+			MethodName: "EliminarOferta",
+			Handler:    _Ofertas_EliminarOferta_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/ofertas.proto",
 }
 
 const (
-	DynamoDB_GuardarOferta_FullMethodName = "/DynamoDB/GuardarOferta"
-	DynamoDB_LeerHistorico_FullMethodName = "/DynamoDB/LeerHistorico"
-	DynamoDB_Sincronizar_FullMethodName   = "/DynamoDB/Sincronizar"
+	DynamoDB_GuardarOferta_FullMethodName   = "/DynamoDB/GuardarOferta"
+	DynamoDB_LeerHistorico_FullMethodName   = "/DynamoDB/LeerHistorico"
+	DynamoDB_Sincronizar_FullMethodName     = "/DynamoDB/Sincronizar"
+	DynamoDB_LeerOferta_FullMethodName      = "/DynamoDB/LeerOferta"
+	DynamoDB_ActualizarStock_FullMethodName = "/DynamoDB/ActualizarStock"
+	DynamoDB_EliminarOferta_FullMethodName  = "/DynamoDB/EliminarOferta"
+	DynamoDB_HashesMerkle_FullMethodName    = "/DynamoDB/HashesMerkle"
+	DynamoDB_LeerRangos_FullMethodName      = "/DynamoDB/LeerRangos"
+	DynamoDB_LeerCambios_FullMethodName     = "/DynamoDB/LeerCambios"
+	DynamoDB_Ping_FullMethodName            = "/DynamoDB/Ping"
+	DynamoDB_PingIndirecto_FullMethodName   = "/DynamoDB/PingIndirecto"
+	DynamoDB_Unirse_FullMethodName          = "/DynamoDB/Unirse"
+	DynamoDB_Miembros_FullMethodName        = "/DynamoDB/Miembros"
 )
 
 // DynamoDBClient is the client API for DynamoDB service.
@@ -139,6 +187,21 @@ type DynamoDBClient interface {
 	GuardarOferta(ctx context.Context, in *OfertaRequest, opts ...grpc.CallOption) (*AckResponse, error)
 	LeerHistorico(ctx context.Context, in *LeerHistoricoRequest, opts ...grpc.CallOption) (*HistoricoResponse, error)
 	Sincronizar(ctx context.Context, in *SincronizarRequest, opts ...grpc.CallOption) (*SincronizarResponse, error)
+	// Lectura y escritura condicional del stock (compare-and-set por versión)
+	LeerOferta(ctx context.Context, in *LeerOfertaRequest, opts ...grpc.CallOption) (*LeerOfertaResponse, error)
+	ActualizarStock(ctx context.Context, in *ActualizarStockRequest, opts ...grpc.CallOption) (*ActualizarStockResponse, error)
+	// Borrado: escribe una lápida que se replica como cualquier versión
+	EliminarOferta(ctx context.Context, in *EliminarOfertaRequest, opts ...grpc.CallOption) (*AckResponse, error)
+	// Anti-entropía entre nodos DB: hashes del árbol de Merkle y ofertas por rango
+	HashesMerkle(ctx context.Context, in *HashesMerkleRequest, opts ...grpc.CallOption) (*HashesMerkleResponse, error)
+	LeerRangos(ctx context.Context, in *LeerRangosRequest, opts ...grpc.CallOption) (*HistoricoResponse, error)
+	// Sincronización incremental: cambios desde la última secuencia replicada
+	LeerCambios(ctx context.Context, in *LeerCambiosRequest, opts ...grpc.CallOption) (*LeerCambiosResponse, error)
+	// Membresía por gossip (SWIM) entre nodos DB; el broker consulta Miembros
+	Ping(ctx context.Context, in *PingRequest, opts ...grpc.CallOption) (*PingResponse, error)
+	PingIndirecto(ctx context.Context, in *PingIndirectoRequest, opts ...grpc.CallOption) (*PingResponse, error)
+	Unirse(ctx context.Context, in *UnirseRequest, opts ...grpc.CallOption) (*MiembrosResponse, error)
+	Miembros(ctx context.Context, in *MiembrosRequest, opts ...grpc.CallOption) (*MiembrosResponse, error)
 }
 
 type dynamoDBClient struct {
@@ -179,6 +242,106 @@ func (c *dynamoDBClient) Sincronizar(ctx context.Context, in *SincronizarRequest
 	return out, nil
 }
 
+func (c *dynamoDBClient) LeerOferta(ctx context.Context, in *LeerOfertaRequest, opts ...grpc.CallOption) (*LeerOfertaResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LeerOfertaResponse)
+	err := c.cc.Invoke(ctx, DynamoDB_LeerOferta_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dynamoDBClient) ActualizarStock(ctx context.Context, in *ActualizarStockRequest, opts ...grpc.CallOption) (*ActualizarStockResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ActualizarStockResponse)
+	err := c.cc.Invoke(ctx, DynamoDB_ActualizarStock_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dynamoDBClient) EliminarOferta(ctx context.Context, in *EliminarOfertaRequest, opts ...grpc.CallOption) (*AckResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AckResponse)
+	err := c.cc.Invoke(ctx, DynamoDB_EliminarOferta_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dynamoDBClient) HashesMerkle(ctx context.Context, in *HashesMerkleRequest, opts ...grpc.CallOption) (*HashesMerkleResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(HashesMerkleResponse)
+	err := c.cc.Invoke(ctx, DynamoDB_HashesMerkle_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dynamoDBClient) LeerRangos(ctx context.Context, in *LeerRangosRequest, opts ...grpc.CallOption) (*HistoricoResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(HistoricoResponse)
+	err := c.cc.Invoke(ctx, DynamoDB_LeerRangos_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dynamoDBClient) LeerCambios(ctx context.Context, in *LeerCambiosRequest, opts ...grpc.CallOption) (*LeerCambiosResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LeerCambiosResponse)
+	err := c.cc.Invoke(ctx, DynamoDB_LeerCambios_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dynamoDBClient) Ping(ctx context.Context, in *PingRequest, opts ...grpc.CallOption) (*PingResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PingResponse)
+	err := c.cc.Invoke(ctx, DynamoDB_Ping_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dynamoDBClient) PingIndirecto(ctx context.Context, in *PingIndirectoRequest, opts ...grpc.CallOption) (*PingResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PingResponse)
+	err := c.cc.Invoke(ctx, DynamoDB_PingIndirecto_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dynamoDBClient) Unirse(ctx context.Context, in *UnirseRequest, opts ...grpc.CallOption) (*MiembrosResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MiembrosResponse)
+	err := c.cc.Invoke(ctx, DynamoDB_Unirse_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dynamoDBClient) Miembros(ctx context.Context, in *MiembrosRequest, opts ...grpc.CallOption) (*MiembrosResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MiembrosResponse)
+	err := c.cc.Invoke(ctx, DynamoDB_Miembros_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // DynamoDBServer is the server API for DynamoDB service.
 // All implementations must embed UnimplementedDynamoDBServer
 // for forward compatibility.
@@ -188,6 +351,21 @@ type DynamoDBServer interface {
 	GuardarOferta(context.Context, *OfertaRequest) (*AckResponse, error)
 	LeerHistorico(context.Context, *LeerHistoricoRequest) (*HistoricoResponse, error)
 	Sincronizar(context.Context, *SincronizarRequest) (*SincronizarResponse, error)
+	// Lectura y escritura condicional del stock (compare-and-set por versión)
+	LeerOferta(context.Context, *LeerOfertaRequest) (*LeerOfertaResponse, error)
+	ActualizarStock(context.Context, *ActualizarStockRequest) (*ActualizarStockResponse, error)
+	// Borrado: escribe una lápida que se replica como cualquier versión
+	EliminarOferta(context.Context, *EliminarOfertaRequest) (*AckResponse, error)
+	// Anti-entropía entre nodos DB: hashes del árbol de Merkle y ofertas por rango
+	HashesMerkle(context.Context, *HashesMerkleRequest) (*HashesMerkleResponse, error)
+	LeerRangos(context.Context, *LeerRangosRequest) (*HistoricoResponse, error)
+	// Sincronización incremental: cambios desde la última secuencia replicada
+	LeerCambios(context.Context, *LeerCambiosRequest) (*LeerCambiosResponse, error)
+	// Membresía por gossip (SWIM) entre nodos DB; el broker consulta Miembros
+	Ping(context.Context, *PingRequest) (*PingResponse, error)
+	PingIndirecto(context.Context, *PingIndirectoRequest) (*PingResponse, error)
+	Unirse(context.Context, *UnirseRequest) (*MiembrosResponse, error)
+	Miembros(context.Context, *MiembrosRequest) (*MiembrosResponse, error)
 	mustEmbedUnimplementedDynamoDBServer()
 }
 
@@ -207,6 +385,36 @@ func (UnimplementedDynamoDBServer) LeerHistorico(context.Context, *LeerHistorico
 func (UnimplementedDynamoDBServer) Sincronizar(context.Context, *SincronizarRequest) (*SincronizarResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Sincronizar not implemented")
 }
+func (UnimplementedDynamoDBServer) LeerOferta(context.Context, *LeerOfertaRequest) (*LeerOfertaResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LeerOferta not implemented")
+}
+func (UnimplementedDynamoDBServer) ActualizarStock(context.Context, *ActualizarStockRequest) (*ActualizarStockResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ActualizarStock not implemented")
+}
+func (UnimplementedDynamoDBServer) EliminarOferta(context.Context, *EliminarOfertaRequest) (*AckResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EliminarOferta not implemented")
+}
+func (UnimplementedDynamoDBServer) HashesMerkle(context.Context, *HashesMerkleRequest) (*HashesMerkleResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method HashesMerkle not implemented")
+}
+func (UnimplementedDynamoDBServer) LeerRangos(context.Context, *LeerRangosRequest) (*HistoricoResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LeerRangos not implemented")
+}
+func (UnimplementedDynamoDBServer) LeerCambios(context.Context, *LeerCambiosRequest) (*LeerCambiosResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LeerCambios not implemented")
+}
+func (UnimplementedDynamoDBServer) Ping(context.Context, *PingRequest) (*PingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Ping not implemented")
+}
+func (UnimplementedDynamoDBServer) PingIndirecto(context.Context, *PingIndirectoRequest) (*PingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PingIndirecto not implemented")
+}
+func (UnimplementedDynamoDBServer) Unirse(context.Context, *UnirseRequest) (*MiembrosResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Unirse not implemented")
+}
+func (UnimplementedDynamoDBServer) Miembros(context.Context, *MiembrosRequest) (*MiembrosResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Miembros not implemented")
+}
 func (UnimplementedDynamoDBServer) mustEmbedUnimplementedDynamoDBServer() {}
 func (UnimplementedDynamoDBServer) testEmbeddedByValue()                  {}
 
@@ -282,6 +490,186 @@ func _DynamoDB_Sincronizar_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _DynamoDB_LeerOferta_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LeerOfertaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DynamoDBServer).LeerOferta(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DynamoDB_LeerOferta_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DynamoDBServer).LeerOferta(ctx, req.(*LeerOfertaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DynamoDB_ActualizarStock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ActualizarStockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DynamoDBServer).ActualizarStock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DynamoDB_ActualizarStock_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DynamoDBServer).ActualizarStock(ctx, req.(*ActualizarStockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DynamoDB_EliminarOferta_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EliminarOfertaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DynamoDBServer).EliminarOferta(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DynamoDB_EliminarOferta_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DynamoDBServer).EliminarOferta(ctx, req.(*EliminarOfertaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DynamoDB_HashesMerkle_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HashesMerkleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DynamoDBServer).HashesMerkle(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DynamoDB_HashesMerkle_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DynamoDBServer).HashesMerkle(ctx, req.(*HashesMerkleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DynamoDB_LeerRangos_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LeerRangosRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DynamoDBServer).LeerRangos(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DynamoDB_LeerRangos_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DynamoDBServer).LeerRangos(ctx, req.(*LeerRangosRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DynamoDB_LeerCambios_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LeerCambiosRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DynamoDBServer).LeerCambios(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DynamoDB_LeerCambios_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DynamoDBServer).LeerCambios(ctx, req.(*LeerCambiosRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DynamoDB_Ping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DynamoDBServer).Ping(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DynamoDB_Ping_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DynamoDBServer).Ping(ctx, req.(*PingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DynamoDB_PingIndirecto_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PingIndirectoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DynamoDBServer).PingIndirecto(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DynamoDB_PingIndirecto_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DynamoDBServer).PingIndirecto(ctx, req.(*PingIndirectoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DynamoDB_Unirse_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnirseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DynamoDBServer).Unirse(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DynamoDB_Unirse_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DynamoDBServer).Unirse(ctx, req.(*UnirseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DynamoDB_Miembros_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MiembrosRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DynamoDBServer).Miembros(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DynamoDB_Miembros_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DynamoDBServer).Miembros(ctx, req.(*MiembrosRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // DynamoDB_ServiceDesc is the grpc.ServiceDesc for DynamoDB service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -301,6 +689,46 @@ var DynamoDB_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Sincronizar",
 			Handler:    _DynamoDB_Sincronizar_Handler,
 		},
+		{
+			MethodName: "LeerOferta",
+			Handler:    _DynamoDB_LeerOferta_Handler,
+		},
+		{
+			MethodName: "ActualizarStock",
+			Handler:    _DynamoDB_ActualizarStock_Handler,
+		},
+		{
+			MethodName: "EliminarOferta",
+			Handler:    _DynamoDB_EliminarOferta_Handler,
+		},
+		{
+			MethodName: "HashesMerkle",
+			Handler:    _DynamoDB_HashesMerkle_Handler,
+		},
+		{
+			MethodName: "LeerRangos",
+			Handler:    _DynamoDB_LeerRangos_Handler,
+		},
+		{
+			MethodName: "LeerCambios",
+			Handler:    _DynamoDB_LeerCambios_Handler,
+		},
+		{
+			MethodName: "Ping",
+			Handler:    _DynamoDB_Ping_Handler,
+		},
+		{
+			MethodName: "PingIndirecto",
+			Handler:    _DynamoDB_PingIndirecto_Handler,
+		},
+		{
+			MethodName: "Unirse",
+			Handler:    _DynamoDB_Unirse_Handler,
+		},
+		{
+			MethodName: "Miembros",
+			Handler:    _DynamoDB_Miembros_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/ofertas.proto",
@@ -451,7 +879,9 @@ var Consumidor_ServiceDesc = grpc.ServiceDesc{
 }
 
 const (
-	NotificacionesConsumidor_RecibirOferta_FullMethodName = "/NotificacionesConsumidor/RecibirOferta"
+	NotificacionesConsumidor_RecibirOferta_FullMethodName  = "/NotificacionesConsumidor/RecibirOferta"
+	NotificacionesConsumidor_RecibirResumen_FullMethodName = "/NotificacionesConsumidor/RecibirResumen"
+	NotificacionesConsumidor_Verificar_FullMethodName      = "/NotificacionesConsumidor/Verificar"
 )
 
 // NotificacionesConsumidorClient is the client API for NotificacionesConsumidor service.
@@ -461,6 +891,10 @@ const (
 // Servicio para notificaciones push a consumidores
 type NotificacionesConsumidorClient interface {
 	RecibirOferta(ctx context.Context, in *OfertaRequest, opts ...grpc.CallOption) (*AckResponse, error)
+	// Modo resumen: varias ofertas en una sola llamada, de mayor a menor descuento
+	RecibirResumen(ctx context.Context, in *ResumenOfertas, opts ...grpc.CallOption) (*AckResponse, error)
+	// Handshake: el broker comprueba que direccion_grpc llega a este consumidor
+	Verificar(ctx context.Context, in *VerificacionRequest, opts ...grpc.CallOption) (*VerificacionResponse, error)
 }
 
 type notificacionesConsumidorClient struct {
@@ -481,13 +915,37 @@ func (c *notificacionesConsumidorClient) RecibirOferta(ctx context.Context, in *
 	return out, nil
 }
 
-// NotificacionesConsumidorServer is the server API for NotificacionesConsumidor service.
+func (c *notificacionesConsumidorClient) RecibirResumen(ctx context.Context, in *ResumenOfertas, opts ...grpc.CallOption) (*AckResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AckResponse)
+	err := c.cc.Invoke(ctx, NotificacionesConsumidor_RecibirResumen_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notificacionesConsumidorClient) Verificar(ctx context.Context, in *VerificacionRequest, opts ...grpc.CallOption) (*VerificacionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(VerificacionResponse)
+	err := c.cc.Invoke(ctx, NotificacionesConsumidor_Verificar_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// NotificacionesConsumidorServer is the server API for NotificacionesConsumidor service.
 // All implementations must embed UnimplementedNotificacionesConsumidorServer
 // for forward compatibility.
 //
 // Servicio para notificaciones push a consumidores
 type NotificacionesConsumidorServer interface {
 	RecibirOferta(context.Context, *OfertaRequest) (*AckResponse, error)
+	// Modo resumen: varias ofertas en una sola llamada, de mayor a menor descuento
+	RecibirResumen(context.Context, *ResumenOfertas) (*AckResponse, error)
+	// Handshake: el broker comprueba que direccion_grpc llega a este consumidor
+	Verificar(context.Context, *VerificacionRequest) (*VerificacionResponse, error)
 	mustEmbedUnimplementedNotificacionesConsumidorServer()
 }
 
@@ -501,6 +959,12 @@ type UnimplementedNotificacionesConsumidorServer struct{}
 func (UnimplementedNotificacionesConsumidorServer) RecibirOferta(context.Context, *OfertaRequest) (*AckResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method RecibirOferta not implemented")
 }
+func (UnimplementedNotificacionesConsumidorServer) RecibirResumen(context.Context, *ResumenOfertas) (*AckResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RecibirResumen not implemented")
+}
+func (UnimplementedNotificacionesConsumidorServer) Verificar(context.Context, *VerificacionRequest) (*VerificacionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Verificar not implemented")
+}
 func (UnimplementedNotificacionesConsumidorServer) mustEmbedUnimplementedNotificacionesConsumidorServer() {
 }
 func (UnimplementedNotificacionesConsumidorServer) testEmbeddedByValue() {}
@@ -541,6 +1005,42 @@ func _NotificacionesConsumidor_RecibirOferta_Handler(srv interface{}, ctx contex
 	return interceptor(ctx, in, info, handler)
 }
 
+func _NotificacionesConsumidor_RecibirResumen_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResumenOfertas)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotificacionesConsumidorServer).RecibirResumen(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotificacionesConsumidor_RecibirResumen_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotificacionesConsumidorServer).RecibirResumen(ctx, req.(*ResumenOfertas))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotificacionesConsumidor_Verificar_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerificacionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotificacionesConsumidorServer).Verificar(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotificacionesConsumidor_Verificar_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotificacionesConsumidorServer).Verificar(ctx, req.(*VerificacionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // NotificacionesConsumidor_ServiceDesc is the grpc.ServiceDesc for NotificacionesConsumidor service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -552,6 +1052,708 @@ var NotificacionesConsumidor_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "RecibirOferta",
 			Handler:    _NotificacionesConsumidor_RecibirOferta_Handler,
 		},
+		{
+			MethodName: "RecibirResumen",
+			Handler:    _NotificacionesConsumidor_RecibirResumen_Handler,
+		},
+		{
+			MethodName: "Verificar",
+			Handler:    _NotificacionesConsumidor_Verificar_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/ofertas.proto",
+}
+
+const (
+	Compras_ReservarOferta_FullMethodName  = "/Compras/ReservarOferta"
+	Compras_ConfirmarCompra_FullMethodName = "/Compras/ConfirmarCompra"
+)
+
+// ComprasClient is the client API for Compras service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Servicio de reservas y compras sobre el stock de las ofertas
+type ComprasClient interface {
+	ReservarOferta(ctx context.Context, in *ReservarOfertaRequest, opts ...grpc.CallOption) (*ReservarOfertaResponse, error)
+	ConfirmarCompra(ctx context.Context, in *ConfirmarCompraRequest, opts ...grpc.CallOption) (*ConfirmarCompraResponse, error)
+}
+
+type comprasClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewComprasClient(cc grpc.ClientConnInterface) ComprasClient {
+	return &comprasClient{cc}
+}
+
+func (c *comprasClient) ReservarOferta(ctx context.Context, in *ReservarOfertaRequest, opts ...grpc.CallOption) (*ReservarOfertaResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReservarOfertaResponse)
+	err := c.cc.Invoke(ctx, Compras_ReservarOferta_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *comprasClient) ConfirmarCompra(ctx context.Context, in *ConfirmarCompraRequest, opts ...grpc.CallOption) (*ConfirmarCompraResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ConfirmarCompraResponse)
+	err := c.cc.Invoke(ctx, Compras_ConfirmarCompra_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ComprasServer is the server API for Compras service.
+// All implementations must embed UnimplementedComprasServer
+// for forward compatibility.
+//
+// Servicio de reservas y compras sobre el stock de las ofertas
+type ComprasServer interface {
+	ReservarOferta(context.Context, *ReservarOfertaRequest) (*ReservarOfertaResponse, error)
+	ConfirmarCompra(context.Context, *ConfirmarCompraRequest) (*ConfirmarCompraResponse, error)
+	mustEmbedUnimplementedComprasServer()
+}
+
+// UnimplementedComprasServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedComprasServer struct{}
+
+func (UnimplementedComprasServer) ReservarOferta(context.Context, *ReservarOfertaRequest) (*ReservarOfertaResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReservarOferta not implemented")
+}
+func (UnimplementedComprasServer) ConfirmarCompra(context.Context, *ConfirmarCompraRequest) (*ConfirmarCompraResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ConfirmarCompra not implemented")
+}
+func (UnimplementedComprasServer) mustEmbedUnimplementedComprasServer() {}
+func (UnimplementedComprasServer) testEmbeddedByValue()                 {}
+
+// UnsafeComprasServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ComprasServer will
+// result in compilation errors.
+type UnsafeComprasServer interface {
+	mustEmbedUnimplementedComprasServer()
+}
+
+func RegisterComprasServer(s grpc.ServiceRegistrar, srv ComprasServer) {
+	// If the following call pancis, it indicates UnimplementedComprasServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Compras_ServiceDesc, srv)
+}
+
+func _Compras_ReservarOferta_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReservarOfertaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ComprasServer).ReservarOferta(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Compras_ReservarOferta_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ComprasServer).ReservarOferta(ctx, req.(*ReservarOfertaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Compras_ConfirmarCompra_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConfirmarCompraRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ComprasServer).ConfirmarCompra(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Compras_ConfirmarCompra_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ComprasServer).ConfirmarCompra(ctx, req.(*ConfirmarCompraRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Compras_ServiceDesc is the grpc.ServiceDesc for Compras service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Compras_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "Compras",
+	HandlerType: (*ComprasServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ReservarOferta",
+			Handler:    _Compras_ReservarOferta_Handler,
+		},
+		{
+			MethodName: "ConfirmarCompra",
+			Handler:    _Compras_ConfirmarCompra_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/ofertas.proto",
+}
+
+const (
+	Taxonomia_ListarCategorias_FullMethodName   = "/Taxonomia/ListarCategorias"
+	Taxonomia_ResolverCategorias_FullMethodName = "/Taxonomia/ResolverCategorias"
+)
+
+// TaxonomiaClient is the client API for Taxonomia service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Servicio de taxonomía de categorías (lo consultan productores y consumidores)
+type TaxonomiaClient interface {
+	ListarCategorias(ctx context.Context, in *ListarCategoriasRequest, opts ...grpc.CallOption) (*ListarCategoriasResponse, error)
+	// Lleva nombres, alias y subcategorías a su forma canónica, con las mismas
+	// reglas que el broker aplica a ofertas y preferencias
+	ResolverCategorias(ctx context.Context, in *ResolverCategoriasRequest, opts ...grpc.CallOption) (*ResolverCategoriasResponse, error)
+}
+
+type taxonomiaClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTaxonomiaClient(cc grpc.ClientConnInterface) TaxonomiaClient {
+	return &taxonomiaClient{cc}
+}
+
+func (c *taxonomiaClient) ListarCategorias(ctx context.Context, in *ListarCategoriasRequest, opts ...grpc.CallOption) (*ListarCategoriasResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListarCategoriasResponse)
+	err := c.cc.Invoke(ctx, Taxonomia_ListarCategorias_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *taxonomiaClient) ResolverCategorias(ctx context.Context, in *ResolverCategoriasRequest, opts ...grpc.CallOption) (*ResolverCategoriasResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ResolverCategoriasResponse)
+	err := c.cc.Invoke(ctx, Taxonomia_ResolverCategorias_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// TaxonomiaServer is the server API for Taxonomia service.
+// All implementations must embed UnimplementedTaxonomiaServer
+// for forward compatibility.
+//
+// Servicio de taxonomía de categorías (lo consultan productores y consumidores)
+type TaxonomiaServer interface {
+	ListarCategorias(context.Context, *ListarCategoriasRequest) (*ListarCategoriasResponse, error)
+	// Lleva nombres, alias y subcategorías a su forma canónica, con las mismas
+	// reglas que el broker aplica a ofertas y preferencias
+	ResolverCategorias(context.Context, *ResolverCategoriasRequest) (*ResolverCategoriasResponse, error)
+	mustEmbedUnimplementedTaxonomiaServer()
+}
+
+// UnimplementedTaxonomiaServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedTaxonomiaServer struct{}
+
+func (UnimplementedTaxonomiaServer) ListarCategorias(context.Context, *ListarCategoriasRequest) (*ListarCategoriasResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListarCategorias not implemented")
+}
+func (UnimplementedTaxonomiaServer) ResolverCategorias(context.Context, *ResolverCategoriasRequest) (*ResolverCategoriasResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResolverCategorias not implemented")
+}
+func (UnimplementedTaxonomiaServer) mustEmbedUnimplementedTaxonomiaServer() {}
+func (UnimplementedTaxonomiaServer) testEmbeddedByValue()                   {}
+
+// UnsafeTaxonomiaServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TaxonomiaServer will
+// result in compilation errors.
+type UnsafeTaxonomiaServer interface {
+	mustEmbedUnimplementedTaxonomiaServer()
+}
+
+func RegisterTaxonomiaServer(s grpc.ServiceRegistrar, srv TaxonomiaServer) {
+	// If the following call pancis, it indicates UnimplementedTaxonomiaServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Taxonomia_ServiceDesc, srv)
+}
+
+func _Taxonomia_ListarCategorias_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListarCategoriasRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TaxonomiaServer).ListarCategorias(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Taxonomia_ListarCategorias_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TaxonomiaServer).ListarCategorias(ctx, req.(*ListarCategoriasRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Taxonomia_ResolverCategorias_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResolverCategoriasRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TaxonomiaServer).ResolverCategorias(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Taxonomia_ResolverCategorias_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TaxonomiaServer).ResolverCategorias(ctx, req.(*ResolverCategoriasRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Taxonomia_ServiceDesc is the grpc.ServiceDesc for Taxonomia service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Taxonomia_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "Taxonomia",
+	HandlerType: (*TaxonomiaServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListarCategorias",
+			Handler:    _Taxonomia_ListarCategorias_Handler,
+		},
+		{
+			MethodName: "ResolverCategorias",
+			Handler:    _Taxonomia_ResolverCategorias_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/ofertas.proto",
+}
+
+const (
+	CartasMuertas_ListarCartasMuertas_FullMethodName   = "/CartasMuertas/ListarCartasMuertas"
+	CartasMuertas_ObtenerCartaMuerta_FullMethodName    = "/CartasMuertas/ObtenerCartaMuerta"
+	CartasMuertas_ReprocesarCartaMuerta_FullMethodName = "/CartasMuertas/ReprocesarCartaMuerta"
+	CartasMuertas_PurgarCartasMuertas_FullMethodName   = "/CartasMuertas/PurgarCartasMuertas"
+)
+
+// CartasMuertasClient is the client API for CartasMuertas service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Servicio de administración de ofertas rechazadas o no entregadas (dead letters)
+type CartasMuertasClient interface {
+	ListarCartasMuertas(ctx context.Context, in *ListarCartasMuertasRequest, opts ...grpc.CallOption) (*ListarCartasMuertasResponse, error)
+	ObtenerCartaMuerta(ctx context.Context, in *ObtenerCartaMuertaRequest, opts ...grpc.CallOption) (*CartaMuerta, error)
+	ReprocesarCartaMuerta(ctx context.Context, in *ReprocesarCartaMuertaRequest, opts ...grpc.CallOption) (*ReprocesarCartaMuertaResponse, error)
+	PurgarCartasMuertas(ctx context.Context, in *PurgarCartasMuertasRequest, opts ...grpc.CallOption) (*PurgarCartasMuertasResponse, error)
+}
+
+type cartasMuertasClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCartasMuertasClient(cc grpc.ClientConnInterface) CartasMuertasClient {
+	return &cartasMuertasClient{cc}
+}
+
+func (c *cartasMuertasClient) ListarCartasMuertas(ctx context.Context, in *ListarCartasMuertasRequest, opts ...grpc.CallOption) (*ListarCartasMuertasResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListarCartasMuertasResponse)
+	err := c.cc.Invoke(ctx, CartasMuertas_ListarCartasMuertas_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cartasMuertasClient) ObtenerCartaMuerta(ctx context.Context, in *ObtenerCartaMuertaRequest, opts ...grpc.CallOption) (*CartaMuerta, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CartaMuerta)
+	err := c.cc.Invoke(ctx, CartasMuertas_ObtenerCartaMuerta_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cartasMuertasClient) ReprocesarCartaMuerta(ctx context.Context, in *ReprocesarCartaMuertaRequest, opts ...grpc.CallOption) (*ReprocesarCartaMuertaResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReprocesarCartaMuertaResponse)
+	err := c.cc.Invoke(ctx, CartasMuertas_ReprocesarCartaMuerta_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cartasMuertasClient) PurgarCartasMuertas(ctx context.Context, in *PurgarCartasMuertasRequest, opts ...grpc.CallOption) (*PurgarCartasMuertasResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PurgarCartasMuertasResponse)
+	err := c.cc.Invoke(ctx, CartasMuertas_PurgarCartasMuertas_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CartasMuertasServer is the server API for CartasMuertas service.
+// All implementations must embed UnimplementedCartasMuertasServer
+// for forward compatibility.
+//
+// Servicio de administración de ofertas rechazadas o no entregadas (dead letters)
+type CartasMuertasServer interface {
+	ListarCartasMuertas(context.Context, *ListarCartasMuertasRequest) (*ListarCartasMuertasResponse, error)
+	ObtenerCartaMuerta(context.Context, *ObtenerCartaMuertaRequest) (*CartaMuerta, error)
+	ReprocesarCartaMuerta(context.Context, *ReprocesarCartaMuertaRequest) (*ReprocesarCartaMuertaResponse, error)
+	PurgarCartasMuertas(context.Context, *PurgarCartasMuertasRequest) (*PurgarCartasMuertasResponse, error)
+	mustEmbedUnimplementedCartasMuertasServer()
+}
+
+// UnimplementedCartasMuertasServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedCartasMuertasServer struct{}
+
+func (UnimplementedCartasMuertasServer) ListarCartasMuertas(context.Context, *ListarCartasMuertasRequest) (*ListarCartasMuertasResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListarCartasMuertas not implemented")
+}
+func (UnimplementedCartasMuertasServer) ObtenerCartaMuerta(context.Context, *ObtenerCartaMuertaRequest) (*CartaMuerta, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ObtenerCartaMuerta not implemented")
+}
+func (UnimplementedCartasMuertasServer) ReprocesarCartaMuerta(context.Context, *ReprocesarCartaMuertaRequest) (*ReprocesarCartaMuertaResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReprocesarCartaMuerta not implemented")
+}
+func (UnimplementedCartasMuertasServer) PurgarCartasMuertas(context.Context, *PurgarCartasMuertasRequest) (*PurgarCartasMuertasResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PurgarCartasMuertas not implemented")
+}
+func (UnimplementedCartasMuertasServer) mustEmbedUnimplementedCartasMuertasServer() {}
+func (UnimplementedCartasMuertasServer) testEmbeddedByValue()                       {}
+
+// UnsafeCartasMuertasServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CartasMuertasServer will
+// result in compilation errors.
+type UnsafeCartasMuertasServer interface {
+	mustEmbedUnimplementedCartasMuertasServer()
+}
+
+func RegisterCartasMuertasServer(s grpc.ServiceRegistrar, srv CartasMuertasServer) {
+	// If the following call pancis, it indicates UnimplementedCartasMuertasServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&CartasMuertas_ServiceDesc, srv)
+}
+
+func _CartasMuertas_ListarCartasMuertas_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListarCartasMuertasRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CartasMuertasServer).ListarCartasMuertas(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CartasMuertas_ListarCartasMuertas_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CartasMuertasServer).ListarCartasMuertas(ctx, req.(*ListarCartasMuertasRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CartasMuertas_ObtenerCartaMuerta_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ObtenerCartaMuertaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CartasMuertasServer).ObtenerCartaMuerta(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CartasMuertas_ObtenerCartaMuerta_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CartasMuertasServer).ObtenerCartaMuerta(ctx, req.(*ObtenerCartaMuertaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CartasMuertas_ReprocesarCartaMuerta_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReprocesarCartaMuertaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CartasMuertasServer).ReprocesarCartaMuerta(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CartasMuertas_ReprocesarCartaMuerta_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CartasMuertasServer).ReprocesarCartaMuerta(ctx, req.(*ReprocesarCartaMuertaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CartasMuertas_PurgarCartasMuertas_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PurgarCartasMuertasRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CartasMuertasServer).PurgarCartasMuertas(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CartasMuertas_PurgarCartasMuertas_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CartasMuertasServer).PurgarCartasMuertas(ctx, req.(*PurgarCartasMuertasRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// CartasMuertas_ServiceDesc is the grpc.ServiceDesc for CartasMuertas service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var CartasMuertas_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "CartasMuertas",
+	HandlerType: (*CartasMuertasServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListarCartasMuertas",
+			Handler:    _CartasMuertas_ListarCartasMuertas_Handler,
+		},
+		{
+			MethodName: "ObtenerCartaMuerta",
+			Handler:    _CartasMuertas_ObtenerCartaMuerta_Handler,
+		},
+		{
+			MethodName: "ReprocesarCartaMuerta",
+			Handler:    _CartasMuertas_ReprocesarCartaMuerta_Handler,
+		},
+		{
+			MethodName: "PurgarCartasMuertas",
+			Handler:    _CartasMuertas_PurgarCartasMuertas_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/ofertas.proto",
+}
+
+const (
+	Raft_SolicitarVoto_FullMethodName    = "/Raft/SolicitarVoto"
+	Raft_AgregarEntradas_FullMethodName  = "/Raft/AgregarEntradas"
+	Raft_InstalarSnapshot_FullMethodName = "/Raft/InstalarSnapshot"
+)
+
+// RaftClient is the client API for Raft service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Servicio de consenso Raft entre réplicas del broker
+type RaftClient interface {
+	SolicitarVoto(ctx context.Context, in *SolicitarVotoRequest, opts ...grpc.CallOption) (*SolicitarVotoResponse, error)
+	AgregarEntradas(ctx context.Context, in *AgregarEntradasRequest, opts ...grpc.CallOption) (*AgregarEntradasResponse, error)
+	InstalarSnapshot(ctx context.Context, in *InstalarSnapshotRequest, opts ...grpc.CallOption) (*InstalarSnapshotResponse, error)
+}
+
+type raftClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRaftClient(cc grpc.ClientConnInterface) RaftClient {
+	return &raftClient{cc}
+}
+
+func (c *raftClient) SolicitarVoto(ctx context.Context, in *SolicitarVotoRequest, opts ...grpc.CallOption) (*SolicitarVotoResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SolicitarVotoResponse)
+	err := c.cc.Invoke(ctx, Raft_SolicitarVoto_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *raftClient) AgregarEntradas(ctx context.Context, in *AgregarEntradasRequest, opts ...grpc.CallOption) (*AgregarEntradasResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AgregarEntradasResponse)
+	err := c.cc.Invoke(ctx, Raft_AgregarEntradas_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *raftClient) InstalarSnapshot(ctx context.Context, in *InstalarSnapshotRequest, opts ...grpc.CallOption) (*InstalarSnapshotResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(InstalarSnapshotResponse)
+	err := c.cc.Invoke(ctx, Raft_InstalarSnapshot_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RaftServer is the server API for Raft service.
+// All implementations must embed UnimplementedRaftServer
+// for forward compatibility.
+//
+// Servicio de consenso Raft entre réplicas del broker
+type RaftServer interface {
+	SolicitarVoto(context.Context, *SolicitarVotoRequest) (*SolicitarVotoResponse, error)
+	AgregarEntradas(context.Context, *AgregarEntradasRequest) (*AgregarEntradasResponse, error)
+	InstalarSnapshot(context.Context, *InstalarSnapshotRequest) (*InstalarSnapshotResponse, error)
+	mustEmbedUnimplementedRaftServer()
+}
+
+// UnimplementedRaftServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedRaftServer struct{}
+
+func (UnimplementedRaftServer) SolicitarVoto(context.Context, *SolicitarVotoRequest) (*SolicitarVotoResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SolicitarVoto not implemented")
+}
+func (UnimplementedRaftServer) AgregarEntradas(context.Context, *AgregarEntradasRequest) (*AgregarEntradasResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AgregarEntradas not implemented")
+}
+func (UnimplementedRaftServer) InstalarSnapshot(context.Context, *InstalarSnapshotRequest) (*InstalarSnapshotResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method InstalarSnapshot not implemented")
+}
+func (UnimplementedRaftServer) mustEmbedUnimplementedRaftServer() {}
+func (UnimplementedRaftServer) testEmbeddedByValue()              {}
+
+// UnsafeRaftServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RaftServer will
+// result in compilation errors.
+type UnsafeRaftServer interface {
+	mustEmbedUnimplementedRaftServer()
+}
+
+func RegisterRaftServer(s grpc.ServiceRegistrar, srv RaftServer) {
+	// If the following call pancis, it indicates UnimplementedRaftServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Raft_ServiceDesc, srv)
+}
+
+func _Raft_SolicitarVoto_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SolicitarVotoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RaftServer).SolicitarVoto(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Raft_SolicitarVoto_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RaftServer).SolicitarVoto(ctx, req.(*SolicitarVotoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Raft_AgregarEntradas_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AgregarEntradasRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RaftServer).AgregarEntradas(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Raft_AgregarEntradas_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RaftServer).AgregarEntradas(ctx, req.(*AgregarEntradasRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Raft_InstalarSnapshot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InstalarSnapshotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RaftServer).InstalarSnapshot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Raft_InstalarSnapshot_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RaftServer).InstalarSnapshot(ctx, req.(*InstalarSnapshotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Raft_ServiceDesc is the grpc.ServiceDesc for Raft service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Raft_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "Raft",
+	HandlerType: (*RaftServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SolicitarVoto",
+			Handler:    _Raft_SolicitarVoto_Handler,
+		},
+		{
+			MethodName: "AgregarEntradas",
+			Handler:    _Raft_AgregarEntradas_Handler,
+		},
+		{
+			MethodName: "InstalarSnapshot",
+			Handler:    _Raft_InstalarSnapshot_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/ofertas.proto",
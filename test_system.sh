@@ -158,13 +158,13 @@ test_status "Consumidor H4 generó archivo CSV"
 echo ""
 echo "10. Verificando persistencia en nodos DB..."
 
-docker exec cyberday_db1 test -f /data/DB1_ofertas.json
+docker exec cyberday_db1 test -f /data/DB1_ofertas.snap
 test_status "DB1 persistió ofertas a disco"
 
-docker exec cyberday_db2 test -f /data/DB2_ofertas.json
+docker exec cyberday_db2 test -f /data/DB2_ofertas.snap
 test_status "DB2 persistió ofertas a disco"
 
-docker exec cyberday_db3 test -f /data/DB3_ofertas.json
+docker exec cyberday_db3 test -f /data/DB3_ofertas.snap
 test_status "DB3 persistió ofertas a disco"
 
 echo ""